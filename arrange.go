@@ -14,13 +14,21 @@ import (
 const fps = 60.0
 
 func animate(animate blockchan, stop stopchan) {
-	b := <-animate
+	var b *blockArrange
+	select {
+	case b = <-animate:
+	case <-stop:
+		return
+	}
 	n := b.block.node
 	converged := make(chan bool, 1)
 	for {
 		next := time.After(time.Second / fps)
 		select {
 		case DoChan(n) <- func() {
+			if f, ok := n.(*funcNode); ok {
+				f.laidOut = true
+			}
 			c := CenterInParent(n)
 			converged <- b.animate()
 			ResizeToFit(n, 0)
@@ -63,12 +71,18 @@ func (n *nodeArrange) animate() bool {
 	n.arrange()
 	for _, b := range n.blocks {
 		b.block.Move(Pos(b))
+		if t := n.caseTypes[b.block]; t != nil {
+			t.Move(caseTypeRect(RectInParent(b.block), t).Min)
+		}
 	}
 	n.node.SetRect(Rect(n))
 	for _, p := range n.ports {
 		p.port.Move(Pos(p))
 	}
 
+	if n.block.block.fixed[n.node] {
+		return converged
+	}
 	pos := Pos(n.node)
 	d := Pos(n).Sub(pos)
 	if d.Len() > .1 {
@@ -108,37 +122,89 @@ func (n *nodeArrange) arrange() {
 	case *selectNode:
 		seqIn := n.ports[0]
 		seqOut := n.ports[len(n.ports)-1]
-		if len(n.blocks) > 0 {
-			x := 0.0
-			i := 1
-			for j, b := range n.blocks {
-				if j > 0 {
-					x += portSize + (Width(n.blocks[j-1])+Width(b))/2
-				}
-				if !n.defaultCase[b.block] {
-					ch := n.ports[i]
+		x := 0.0
+		i := 1
+		for j, b := range n.blocks {
+			if j > 0 {
+				x += portSize + (Width(n.blocks[j-1])+Width(b))/2
+			}
+			if !n.defaultCase[b.block] {
+				ch := n.ports[i]
+				i++
+				if n.sendCase[b.block] {
+					elem := n.ports[i]
 					i++
-					if n.sendCase[b.block] {
-						elem := n.ports[i]
-						i++
-						MoveCenter(ch, Pt(x-portSize/2, portSize))
-						MoveCenter(elem, Pt(x+portSize/2, portSize))
-					} else {
-						MoveCenter(ch, Pt(x, portSize))
-					}
+					MoveCenter(ch, Pt(x-portSize/2, portSize))
+					MoveCenter(elem, Pt(x+portSize/2, portSize))
+				} else {
+					MoveCenter(ch, Pt(x, portSize))
 				}
-				b.Move(Pt(x-Width(b)/2, -Height(b)-portSize))
 			}
-			MoveCenter(seqIn, Pt(0, -portSize))
-			MoveCenter(seqOut, Pt(0, -Height(n.blocks[0])-portSize))
-		} else {
-			r := RectInParent(node.name)
-			x := r.Center().X
-			MoveCenter(seqIn, Pt(x, r.Max.Y))
-			MoveCenter(seqOut, Pt(x, r.Min.Y))
+			b.Move(Pt(x-Width(b)/2, -Height(b)-portSize))
 		}
+		n.arrangeSeqPorts(seqIn, seqOut, RectInParent(node.name))
 		ResizeToFit(n, 0)
 		n.SetRect(Rect(n).Union(RectInParent(node.name)))
+	case *switchNode:
+		seqIn, tag := n.ports[0], n.ports[1]
+		seqOut := n.ports[len(n.ports)-1]
+		r := RectInParent(node.name)
+		MoveCenter(tag, Pt(r.Center().X, portSize))
+		x := 0.0
+		i := 2
+		for j, b := range n.blocks {
+			if j > 0 {
+				x += portSize + (Width(n.blocks[j-1])+Width(b))/2
+			}
+			nvals := n.caseValues[b.block]
+			for k := 0; k < nvals; k++ {
+				MoveCenter(n.ports[i], Pt(x+(float64(k)-float64(nvals-1)/2)*portSize, portSize))
+				i++
+			}
+			b.Move(Pt(x-Width(b)/2, -Height(b)-portSize))
+		}
+		n.arrangeSeqPorts(seqIn, seqOut, r)
+		ResizeToFit(n, 0)
+		n.SetRect(Rect(n).Union(r))
+	case *typeSwitchNode:
+		seqIn, input := n.ports[0], n.ports[1]
+		seqOut := n.ports[len(n.ports)-1]
+		r := RectInParent(node.name)
+		MoveCenter(input, Pt(r.Center().X, portSize))
+		width := func(b *blockArrange) float64 {
+			if t := n.caseTypes[b.block]; t != nil {
+				return math.Max(Width(b), Width(t))
+			}
+			return Width(b)
+		}
+		x := 0.0
+		for j, b := range n.blocks {
+			if j > 0 {
+				x += portSize + (width(n.blocks[j-1])+width(b))/2
+			}
+			b.Move(Pt(x-Width(b)/2, -Height(b)-portSize))
+		}
+		n.arrangeSeqPorts(seqIn, seqOut, r)
+		ResizeToFit(n, 0)
+		rect := Rect(n).Union(r)
+		for _, b := range n.blocks {
+			if t := n.caseTypes[b.block]; t != nil {
+				rect = rect.Union(caseTypeRect(RectInParent(b), t))
+			}
+		}
+		n.SetRect(rect)
+	}
+}
+
+// arrangeSeqPorts places the sequencing ports of a node whose name is displayed in the rect name, either at the first of its blocks or, if it has none, at the name.
+func (n *nodeArrange) arrangeSeqPorts(seqIn, seqOut *portArrange, name Rectangle) {
+	if len(n.blocks) > 0 {
+		MoveCenter(seqIn, Pt(0, -portSize))
+		MoveCenter(seqOut, Pt(0, -Height(n.blocks[0])-portSize))
+	} else {
+		x := name.Center().X
+		MoveCenter(seqIn, Pt(x, name.Max.Y))
+		MoveCenter(seqOut, Pt(x, name.Min.Y))
 	}
 }
 
@@ -254,7 +320,7 @@ func arrange(arrange, childArranged, arranged blockchan, stop stopchan) {
 			srcNode := c.src.node.in(b)
 			dstNode := c.dst.node.in(b)
 			d := c.dst.centerIn(b).Sub(c.src.centerIn(b))
-			if c.conn.feedback {
+			if c.feedback {
 				d.Y = -d.Y
 				d.Y -= connLen + Height(srcNode) + Height(dstNode)
 			} else {
@@ -269,7 +335,7 @@ func arrange(arrange, childArranged, arranged blockchan, stop stopchan) {
 			if d.Y > 0 {
 				d.Y *= d.Y * d.Y
 			}
-			if c.conn.feedback {
+			if c.feedback {
 				d.Y = -d.Y
 			}
 			d = d.Mul(connLenCoef)
@@ -301,6 +367,9 @@ func arrange(arrange, childArranged, arranged blockchan, stop stopchan) {
 
 		converged := true
 		for _, n := range b.nodes {
+			if n.fixed {
+				continue
+			}
 			dx := rprop(&n.g_.X, &n.g.X, &n.step.X)
 			dy := rprop(&n.g_.Y, &n.g.Y, &n.step.Y)
 			d := Pt(dx, dy)
@@ -482,15 +551,21 @@ func (b *blockArrange) uncross() {
 		d1 = d.Mul(d1.Len()).Sub(d1)
 		d2 = d.Mul(d2.Len()).Sub(d2)
 		if dst1 == dst2 || (src1 != src2 && rand.Float64() < .5) {
-			src1.Move(Pos(src1).Sub(d1))
-			src2.Move(Pos(src2).Sub(d2))
+			src1.nudge(d1.Mul(-1))
+			src2.nudge(d2.Mul(-1))
 		} else {
-			dst1.Move(Pos(dst1).Add(d1))
-			dst2.Move(Pos(dst2).Add(d2))
+			dst1.nudge(d1)
+			dst2.nudge(d2)
 		}
 	})
 }
 
+func (n *nodeArrange) nudge(d Point) {
+	if !n.fixed {
+		n.Move(Pos(n).Add(d))
+	}
+}
+
 type blockArrange struct {
 	*ViewBase
 	block *block
@@ -507,7 +582,10 @@ type nodeArrange struct {
 	blocks []*blockArrange
 
 	hasConns              bool
+	fixed                 bool
 	defaultCase, sendCase map[*block]bool
+	caseValues            map[*block]int
+	caseTypes             map[*block]*typeView
 
 	g, g_, step Point
 
@@ -524,6 +602,7 @@ type connArrange struct {
 	*ViewBase
 	conn     *connection
 	src, dst *portArrange
+	feedback bool
 	hidden   bool
 }
 
@@ -532,13 +611,13 @@ func newBlockArrange(block *block, n *nodeArrange, ports portmap) *blockArrange
 	b.ViewBase = NewView(b)
 	b.Move(Pos(block))
 	b.SetRect(Rect(block))
-	for n := range block.nodes {
+	for _, n := range block.nodes() {
 		n := newNodeArrange(n, b, ports)
 		b.nodes = append(b.nodes, n)
 		b.Add(n)
 	}
-	for c := range block.conns {
-		if c.src == nil || c.dst == nil {
+	for _, c := range block.conns() {
+		if c.src() == nil || c.dst() == nil {
 			continue
 		}
 		c := newConnArrange(c, ports)
@@ -553,11 +632,12 @@ func newNodeArrange(node node, b *blockArrange, ports portmap) *nodeArrange {
 	n.ViewBase = NewView(n)
 	n.Move(Pos(node))
 	n.SetRect(Rect(node))
+	n.fixed = b.block.fixed[node]
 	for _, port := range append(node.inputs(), node.outputs()...) {
 		p := newPortArrange(port, n)
 		n.ports = append(n.ports, p)
 		n.Add(p)
-		if len(port.conns) > 0 {
+		if len(port.conns()) > 0 {
 			n.hasConns = true
 		}
 		ports[port] = p
@@ -587,6 +667,22 @@ func newNodeArrange(node node, b *blockArrange, ports portmap) *nodeArrange {
 			n.defaultCase[c.blk] = c.ch == nil
 			n.sendCase[c.blk] = c.send
 		}
+	case *switchNode:
+		n.caseValues = map[*block]int{}
+		for _, c := range node.cases {
+			b := newBlockArrange(c.blk, n, ports)
+			n.blocks = append(n.blocks, b)
+			n.Add(b)
+			n.caseValues[c.blk] = len(c.vals)
+		}
+	case *typeSwitchNode:
+		n.caseTypes = map[*block]*typeView{}
+		for _, c := range node.cases {
+			b := newBlockArrange(c.blk, n, ports)
+			n.blocks = append(n.blocks, b)
+			n.Add(b)
+			n.caseTypes[c.blk] = c.typ
+		}
 	}
 	n.step = Pt(1, 1)
 	return n
@@ -603,8 +699,9 @@ func newPortArrange(port *port, n *nodeArrange) *portArrange {
 func newConnArrange(conn *connection, ports portmap) *connArrange {
 	c := &connArrange{conn: conn}
 	c.ViewBase = NewView(c)
-	c.src = ports[conn.src]
-	c.dst = ports[conn.dst]
+	c.src = ports[conn.src()]
+	c.dst = ports[conn.dst()]
+	c.feedback = conn.model.Feedback
 	c.hidden = conn.hidden
 	return c
 }
@@ -647,8 +744,11 @@ func (n *nodeArrange) copy(b *blockArrange, ports portmap) *nodeArrange {
 		n2.Add(b)
 	}
 	n2.hasConns = n.hasConns
+	n2.fixed = n.fixed
 	n2.defaultCase = n.defaultCase
 	n2.sendCase = n.sendCase
+	n2.caseValues = n.caseValues
+	n2.caseTypes = n.caseTypes
 	n2.step = Pt(1, 1)
 	return n2
 }
@@ -662,7 +762,7 @@ func (p *portArrange) copy(n *nodeArrange) *portArrange {
 }
 
 func (c *connArrange) copy(ports portmap) *connArrange {
-	c2 := &connArrange{conn: c.conn, hidden: c.hidden}
+	c2 := &connArrange{conn: c.conn, feedback: c.feedback, hidden: c.hidden}
 	c2.ViewBase = NewView(c2)
 	c2.src = ports[c.src.port]
 	c2.dst = ports[c.dst.port]
@@ -4,18 +4,18 @@ package audio
 
 func Add(x2 Audio, x22 Audio) (x Audio) {
 	var v Audio
-	var v2 Audio
+	var v2 int
 	var v3 Audio
 	var v4 Audio
-	var v5 int
-	v2 = x2
-	v3 = x2
-	v4 = x22
-	x222 := len(v2)
-	v5 = x222
-	x3 := make(Audio, v5)
-	v = x3
-	x4 := v.Add(v3, v4)
+	var v5 Audio
+	v = x2
+	v4 = x2
+	v5 = x22
+	x222 := len(v)
+	v2 = x222
+	x3 := make(Audio, v2)
+	v3 = x3
+	x4 := v3.Add(v4, v5)
 	x = x4
 	return
 }
@@ -12,4 +12,5 @@ func (x *AttackReleaseEnv) Release() () {
 	const x3 = true
 	v3 = x3
 	*v2 = v3
+	return
 }
@@ -7,54 +7,55 @@ import (
 )
 
 func (x *AttackReleaseEnv) SetAttackTime(x2 float64) () {
-	var v float64
-	var v2 *float64
-	var v3 *AttackReleaseEnv
-	var v4 *AttackReleaseEnv
+	var v *AttackReleaseEnv
+	var v2 *AttackReleaseEnv
+	var v3 *Params
+	var v4 float64
 	var v5 float64
-	var v6 *AttackReleaseEnv
-	var v7 float64
+	var v6 float64
+	var v7 bool
 	var v8 float64
-	var v9 float64
-	var v10 bool
-	var v11 *float64
-	var v12 *Params
+	var v9 *float64
+	var v10 float64
+	var v11 *AttackReleaseEnv
+	var v12 *float64
 	var v13 float64
-	v4 = x//env
-	v7 = x2
-	v = x2
-	v3 = x//env
-	v6 = x//env
-	x22 := &v4.up
-	v2 = x22
-	x3 := &v6.Params
-	v12 = x3
-	x4 := &v12.SampleRate
-	v13 = *x4
-	x5 := v13 * v7
+	v = x//env
+	v11 = x//env
+	v13 = x2
+	v2 = x//env
+	v5 = x2
+	x22 := &v.up
+	v9 = x22
+	x3 := &v2.Params
+	v3 = x3
+	x4 := &v3.SampleRate
+	v4 = *x4
+	x5 := v4 * v5
+	v6 = x5
 	v8 = x5
-	v9 = x5
 	var v14 float64
-	x6 := v9 != v14
-	v10 = x6
-	if v10 {
+	x6 := v6 != v14
+	v7 = x6
+	if v7 {
 		var v15 float64
 		var v16 float64
 		var v17 float64
 		const x7 = 0.1
-		v17 = x7
+		v16 = x7
 		const x8 = 1
 		v15 = x8
 		x9 := v15 / v8
-		v16 = x9
-		x10 := math.Pow(v17, v16)
-		v5 = x10
+		v17 = x9
+		x10 := math.Pow(v16, v17)
+		v10 = x10
 	} else  {
 		const x11 = 1
-		v5 = x11
+		v10 = x11
 	}
-	*v2 = v5
-	x12 := &v3.attackTime
-	v11 = x12
-	*v11 = v
+	*v9 = v10
+	x12 := &v11.attackTime
+	v12 = x12
+	*v12 = v13
+	return
 }
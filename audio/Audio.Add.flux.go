@@ -7,27 +7,27 @@ func (x2 Audio) Add(x3 Audio, x4 Audio) (x Audio) {
 	var v2 Audio
 	var v3 Audio
 	v = x2
-	x = x2
 	v2 = x3
 	v3 = x4
+	x = x2
 	for k := range v {
 		var v4 = &v[k]
-		var v5 *float64
-		var v6 float64
+		var v5 int
+		var v6 int
 		var v7 float64
 		var v8 float64
-		var v9 int
-		var v10 int
-		v10 = k
-		v5 = v4
-		v9 = k
-		x22 := &v2[v9]
-		v6 = *x22
-		x32 := &v3[v10]
-		v7 = *x32
-		x42 := v6 + v7
-		v8 = x42
-		*v5 = v8
+		var v9 *float64
+		var v10 float64
+		v5 = k
+		v6 = k
+		v9 = v4
+		x22 := &v2[v5]
+		v7 = *x22
+		x32 := &v3[v6]
+		v8 = *x32
+		x42 := v7 + v8
+		v10 = x42
+		*v9 = v10
 	}
 	return
 }
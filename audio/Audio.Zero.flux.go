@@ -12,4 +12,5 @@ func (x Audio) Zero() () {
 		var v4 float64
 		*v3 = v4
 	}
+	return
 }
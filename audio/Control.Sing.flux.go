@@ -3,156 +3,156 @@
 package audio
 
 func (x *Control) Sing() (x2 Audio, done bool) {
-	var v *Params
-	var v2 int
-	var v3 float64
+	var v *Control
+	var v2 *Control
+	var v3 *Params
 	var v4 int
 	var v5 *Params
-	var v6 int
-	var v7 int
-	var v8 []float64
-	var v9 []ControlPeriod
-	var v10 []ControlPeriod
-	var v11 int
-	var v12 Audio
-	var v13 float64
-	var v14 float64
-	var v15 int
+	var v6 float64
+	var v7 float64
+	var v8 float64
+	var v9 float64
+	var v10 *Control
+	var v11 *Control
+	var v12 []ControlPeriod
+	var v13 *Control
+	var v14 Audio
+	var v15 *Control
 	var v16 int
 	var v17 int
 	var v18 int
 	var v19 int
-	var v20 *Control
-	var v21 *Control
-	var v22 float64
+	var v20 []ControlPeriod
+	var v21 int
+	var v22 []float64
 	var v23 int
-	var v24 int
-	var v25 int
-	var v26 *Control
-	var v27 *Control
-	var v28 *float64
-	var v29 float64
-	var v30 *Control
-	var v31 Audio
-	var v32 *int
-	var v33 *Control
-	var v34 float64
-	var v35 float64
+	var v24 float64
+	var v25 float64
+	var v26 int
+	var v27 int
+	var v28 int
+	var v29 Audio
+	var v30 int
+	var v31 *float64
+	var v32 float64
+	var v33 int
+	var v34 int
+	var v35 *int
 	var v36 int
-	v30 = x
-	v33 = x
-	v21 = x
-	v26 = x
-	v27 = x
-	v20 = x
-	x22 := &v30.t
-	v28 = x22//t
-	v13 = *x22//t
-	v22 = *x22
-	x3 := &v21.Params
+	v = x
+	v10 = x
+	v11 = x
+	v13 = x
+	v15 = x
+	v2 = x
+	x22 := &v.t
+	v24 = *x22//t
+	v31 = x22//t
+	v8 = *x22
+	x3 := &v2.Params
+	v3 = x3
 	v5 = x3
-	v = x3
-	x4 := &v.BufferSize
-	v2 = *x4
-	x5 := (float64)(v2)
-	v29 = x5
+	x4 := &v3.BufferSize
+	v4 = *x4
+	x5 := (float64)(v4)
+	v6 = x5
 	x6 := &v5.SampleRate
-	v14 = *x6
-	v3 = *x6
-	x7 := v29 / v3
-	v34 = x7
-	x8 := v22 + v34
-	v35 = x8
-	x9 := &v20.i
-	v11 = *x9//i
-	v19 = *x9//i
-	v32 = x9//i
-	v4 = *x9//i
-	v6 = *x9//i
-	v18 = *x9//i
-	x10 := &v27.Periods
-	v9 = *x10
-	v10 = *x10
-	x11 := len(v10)
-	v24 = x11
-	v36 = x11
-	x12 := &v26.Out
+	v25 = *x6
+	v7 = *x6
+	x7 := v6 / v7
+	v9 = x7
+	x8 := v8 + v9
+	v32 = x8
+	x9 := &v10.i
+	v16 = *x9//i
+	v21 = *x9//i
+	v23 = *x9//i
+	v28 = *x9//i
+	v35 = x9//i
+	v36 = *x9//i
+	x10 := &v11.Periods
+	v12 = *x10
+	v20 = *x10
+	x11 := len(v12)
+	v17 = x11
+	v34 = x11
+	x12 := &v13.Out
+	v14 = *x12
+	v29 = *x12
 	x2 = *x12
-	v31 = *x12
-	v12 = *x12
-	x13 := len(v31)
-	v16 = x13//max
-	v17 = x13//max
-	v15 = x13//max
-	x14 := &v33.elapsed
-	v8 = *x14
+	x13 := len(v14)
+	v19 = x13//max
+	v26 = x13//max
+	v27 = x13//max
+	x14 := &v15.elapsed
+	v22 = *x14
 	for {
 		var v37 bool
 		var v38 bool
-		var v39 int
-		var v40 int
-		var v41 int
+		var v39 bool
+		var v40 float64
+		var v41 float64
 		var v42 float64
-		var v43 bool
-		var v44 float64
+		var v43 int
+		var v44 bool
 		var v45 int
-		var v46 Audio
-		var v47 bool
-		var v48 ControlPeriod
-		var v49 float64
-		x15 := v6 >= v36
+		var v46 int
+		var v47 ControlPeriod
+		var v48 Audio
+		var v49 int
+		x15 := v16 >= v17
 		v37 = x15
-		x16 := v23 >= v17
-		v47 = x16
-		x17 := v37 || v47
-		v38 = x17
-		if v38 {
+		x16 := v18 >= v19
+		v38 = x16
+		x17 := v37 || v38
+		v39 = x17
+		if v39 {
 			break
 		}//;0
-		x18 := &v9[v18]//0;
-		v48 = *x18
-		x19 := &v8[v11]//0;
-		v42 = *x19
-		x20 := v42 - v13
-		v44 = x20
-		x21 := v44 * v14
-		v49 = x21
-		x222 := (int)(v49)
-		v39 = x222
-		v40 = x222
-		x23 := v39 > v15
-		v43 = x23
-		if v43 {
+		x18 := &v20[v21]//0;
+		v47 = *x18
+		x19 := &v22[v23]//0;
+		v40 = *x19
+		x20 := v40 - v24
+		v41 = x20
+		x21 := v41 * v25
+		v42 = x21
+		x222 := (int)(v42)
+		v43 = x222
+		v45 = x222
+		x23 := v43 > v26
+		v44 = x23
+		if v44 {
 			var v50 int
-			x24 := v16 + v50
-			v40 = x24
+			x24 := v27 + v50
+			v45 = x24
 		} else  {
 			var v51 int
 			const x25 = 1
 			v51 = x25
-			x26 := v19 + v51
-			v19 = x26//ii
-			v18 = x26//ii
-			v25 = x26
-			v4 = x26//ii
-			v11 = x26//ii
-			v6 = x26//ii
+			x26 := v28 + v51
+			v16 = x26//ii
+			v21 = x26//ii
+			v23 = x26//ii
+			v28 = x26//ii
+			v33 = x26
+			v36 = x26//ii
 		}
 		var v52 int
-		x27 := v40 + v52
-		v45 = x27
-		v23 = x27//n
-		v41 = x27
-		x28 := v12[v7:v45]
-		v46 = x28
-		v48.Control(v46)
+		x27 := v45 + v52
+		v18 = x27//n
+		v46 = x27
+		v49 = x27
+		x28 := v29[v30:v46]
+		v48 = x28
+		v47.Control(v48)
 		var v53 int
-		x29 := v41 + v53
-		v7 = x29
+		x29 := v49 + v53
+		v30 = x29
 	}//;1
-	*v28 = v35//1;
-	x30 := v25 == v24
+	*v31 = v32//1;
+	x30 := v33 == v34
 	done = x30
-	*v32 = v4
+	*v35 = v36
 	return
 }
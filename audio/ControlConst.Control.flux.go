@@ -3,15 +3,15 @@
 package audio
 
 func (x *ControlConst) Control(a Audio) () {
-	var v Audio
-	var v2 *ControlConst
+	var v *ControlConst
+	var v2 Audio
 	var v3 float64
-	v2 = x
-	v = a
-	x2 := &v2.Value
+	v = x
+	v2 = a
+	x2 := &v.Value
 	v3 = *x2
-	for i := range v {
-		var v4 = &v[i]
+	for i := range v2 {
+		var v4 = &v2[i]
 		var v5 *float64
 		v5 = v4
 		*v5 = v3
@@ -3,33 +3,33 @@
 package audio
 
 func (x *ControlLine) Control(a Audio) () {
-	var v float64
-	var v2 *float64
-	var v3 *ControlLine
-	var v4 Audio
+	var v *ControlLine
+	var v2 *ControlLine
+	var v3 Audio
+	var v4 float64
 	var v5 float64
 	var v6 float64
-	var v7 float64
-	var v8 *ControlLine
-	v8 = x
-	v3 = x
-	v4 = a
-	x2 := &v8.x
-	v = *x2
-	v2 = x2
-	v6 = *x2
-	x3 := &v3.step
-	v5 = *x3
-	for i := range v4 {
-		var v9 = &v4[i]
+	var v7 *float64
+	var v8 float64
+	v = x
+	v2 = x
+	v3 = a
+	x2 := &v.x
+	v4 = *x2
+	v5 = *x2
+	v7 = x2
+	x3 := &v2.step
+	v6 = *x3
+	for i := range v3 {
+		var v9 = &v3[i]
 		var v10 *float64
 		v10 = v9
-		*v10 = v
-		x4 := v6 + v5
-		v7 = x4
-		v6 = x4
-		v = x4
+		*v10 = v4
+		x4 := v5 + v6
+		v4 = x4
+		v5 = x4
+		v8 = x4
 	}
-	*v2 = v7
+	*v7 = v8
 	return
 }
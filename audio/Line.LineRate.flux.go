@@ -4,42 +4,42 @@ package audio
 
 func (x2 *Line) LineRate(rate float64) (x Audio) {
 	var v *Line
-	var v2 *Params
-	var v3 float64
-	var v4 *Line
-	var v5 *Line
-	var v6 float64
-	var v7 Audio
+	var v2 *Line
+	var v3 *Params
+	var v4 float64
+	var v5 float64
+	var v6 Audio
+	var v7 *Line
 	var v8 float64
-	v4 = x2
 	v = x2
-	v5 = x2
-	v6 = rate
-	x22 := &v4.Out
-	v7 = *x22
+	v2 = x2
+	v4 = rate
+	v7 = x2
+	x22 := &v.Out
+	v6 = *x22
 	x = *x22
-	x3 := &v.Params
-	v2 = x3
-	x4 := &v2.SampleRate
-	v3 = *x4
-	x5 := v6 / v3
+	x3 := &v2.Params
+	v3 = x3
+	x4 := &v3.SampleRate
+	v5 = *x4
+	x5 := v4 / v5
 	v8 = x5
-	for i := range v7 {
-		var v9 = &v7[i]
-		var v10 *float64
-		var v11 float64
-		var v12 *float64
-		var v13 float64
+	for i := range v6 {
+		var v9 = &v6[i]
+		var v10 float64
+		var v11 *float64
+		var v12 float64
+		var v13 *float64
 		var v14 float64
-		v10 = v9
-		x6 := &v5.x
-		v11 = *x6
-		v12 = x6
-		x7 := v11 + v8
-		v13 = x7
+		v11 = v9
+		x6 := &v7.x
+		v10 = *x6
+		v13 = x6
+		x7 := v10 + v8
+		v12 = x7
 		v14 = x7
-		*v10 = v13
-		*v12 = v14
+		*v11 = v12
+		*v13 = v14
 	}
 	return
 }
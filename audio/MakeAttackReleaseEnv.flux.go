@@ -5,9 +5,9 @@ package audio
 func MakeAttackReleaseEnv(attackTime float64, releaseTime float64) (x AttackReleaseEnv) {
 	var v float64
 	var v2 float64
-	v2 = attackTime
-	v = releaseTime
-	x2 := AttackReleaseEnv{attackTime: v2, releaseTime: v}
+	v = attackTime
+	v2 = releaseTime
+	x2 := AttackReleaseEnv{attackTime: v, releaseTime: v2}
 	x = x2
 	return
 }
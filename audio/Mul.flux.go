@@ -4,36 +4,36 @@ package audio
 
 func Mul(x2 Audio, x22 Audio) (x Audio) {
 	var v Audio
-	var v2 Audio
+	var v2 int
 	var v3 Audio
-	var v4 int
+	var v4 Audio
 	var v5 Audio
 	v = x2
-	v2 = x2
-	v3 = x22
+	v4 = x2
+	v5 = x22
 	x222 := len(v)
-	v4 = x222
-	x3 := make(Audio, v4)
-	v5 = x3
+	v2 = x222
+	x3 := make(Audio, v2)
+	v3 = x3
 	x = x3
-	for k := range v5 {
-		var v6 = &v5[k]
+	for k := range v3 {
+		var v6 = &v3[k]
 		var v7 int
 		var v8 int
-		var v9 *float64
+		var v9 float64
 		var v10 float64
-		var v11 float64
+		var v11 *float64
 		var v12 float64
-		v9 = v6
+		v11 = v6
 		v7 = k
 		v8 = k
-		x4 := &v2[v7]
-		v10 = *x4
-		x5 := &v3[v8]
-		v11 = *x5
-		x6 := v10 * v11
+		x4 := &v4[v7]
+		v9 = *x4
+		x5 := &v5[v8]
+		v10 = *x5
+		x6 := v9 * v10
 		v12 = x6
-		*v9 = v12
+		*v11 = v12
 	}
 	return
 }
@@ -7,55 +7,55 @@ import (
 )
 
 func (x2 *MultiVoice) Sing() (x Audio, done bool) {
-	var v Audio
-	var v2 map[Voice]struct{}
-	var v3 *MultiVoice
-	var v4 *MultiVoice
+	var v *MultiVoice
+	var v2 *MultiVoice
+	var v3 map[Voice]struct{}
+	var v4 int
 	var v5 Audio
-	var v6 Audio
+	var v6 *MultiVoice
 	var v7 *sync.Mutex
-	var v8 *MultiVoice
-	var v9 *sync.Mutex
-	var v10 map[Voice]struct{}
+	var v8 map[Voice]struct{}
+	var v9 Audio
+	var v10 Audio
 	var v11 map[Voice]struct{}
-	var v12 int
-	v8 = x2
-	v3 = x2
-	v4 = x2
-	x22 := &v8.Out
-	x = *x22
+	var v12 *sync.Mutex
+	v = x2
+	v2 = x2
+	v6 = x2
+	x22 := &v.Out
+	v10 = *x22
 	v5 = *x22
-	v6 = *x22
-	v = *x22
-	x3 := &v4.voices
-	v10 = *x3
-	v2 = *x3
+	v9 = *x22
+	x = *x22
+	x3 := &v2.voices
 	v11 = *x3
-	x4 := len(v10)
-	v12 = x4
+	v3 = *x3
+	v8 = *x3
+	x4 := len(v3)
+	v4 = x4
 	var v13 int
-	x5 := v12 == v13
+	x5 := v4 == v13
 	done = x5
 	v5.Zero()//;0
-	x6 := &v3.mu
+	x6 := &v6.mu
+	v12 = x6
 	v7 = x6
-	v9 = x6
 	v7.Lock()//;1
-	for k := range v11 {
+	for k := range v8 {
 		var v14 Voice
 		var v15 Audio
 		var v16 bool
 		var v17 Voice
-		v17 = k
 		v14 = k
+		v17 = k
 		x7, done2 := v14.Sing()
 		v15 = x7
 		v16 = done2
-		v6.Add(v, v15)
+		v9.Add(v10, v15)
 		if v16 {
-			delete(v2, v17)
+			delete(v11, v17)
 		}
 	}//0,1;2
-	v9.Unlock()//2;
+	v12.Unlock()//2;
 	return
 }
@@ -8,20 +8,21 @@ import (
 
 func (x *MultiVoice) StopVoice(v Voice) () {
 	var v2 *MultiVoice
-	var v3 Voice
-	var v4 map[Voice]struct{}
-	var v5 *sync.Mutex
-	var v6 *sync.Mutex
-	var v7 *MultiVoice
-	v7 = x
+	var v3 *MultiVoice
+	var v4 *sync.Mutex
+	var v5 map[Voice]struct{}
+	var v6 Voice
+	var v7 *sync.Mutex
 	v2 = x
-	v3 = v
+	v3 = x
+	v6 = v
 	x2 := &v2.voices
-	v4 = *x2
-	x3 := &v7.mu
-	v5 = x3
-	v6 = x3
-	v5.Lock()//;0
-	delete(v4, v3)//0;1
-	v6.Unlock()//1;
+	v5 = *x2
+	x3 := &v3.mu
+	v4 = x3
+	v7 = x3
+	v4.Lock()//;0
+	delete(v5, v6)//0;1
+	v7.Unlock()//1;
+	return
 }
@@ -4,36 +4,36 @@ package audio
 
 func NewControl(x []ControlPeriod) (c Control) {
 	var v []ControlPeriod
-	var v2 []ControlPeriod
-	var v3 int
-	var v4 []float64
+	var v2 int
+	var v3 []ControlPeriod
+	var v4 float64
 	var v5 []float64
-	var v6 float64
-	var v7 []ControlPeriod
-	v2 = x
-	v7 = x
+	var v6 []ControlPeriod
+	var v7 []float64
 	v = x
+	v3 = x
+	v6 = x
 	x2 := len(v)
-	v3 = x2
-	x3 := make([]float64, v3)
-	v4 = x3
+	v2 = x2
+	x3 := make([]float64, v2)
 	v5 = x3
-	for k := range v7 {
-		var v8 = &v7[k]
-		var v9 float64
+	v7 = x3
+	for k := range v3 {
+		var v8 = &v3[k]
+		var v9 ControlPeriod
 		var v10 float64
 		var v11 int
-		var v12 ControlPeriod
+		var v12 float64
 		v11 = k
-		v12 = *v8
-		x4 := v12.Duration()
-		v9 = x4
-		x5 := v9 + v6
-		v10 = x5
-		v6 = x5
-		v5[v11] = v10
+		v9 = *v8
+		x4 := v9.Duration()
+		v10 = x4
+		x5 := v10 + v4
+		v12 = x5
+		v4 = x5
+		v5[v11] = v12
 	}
-	x6 := Control{Periods: v2, elapsed: v4}
+	x6 := Control{Periods: v6, elapsed: v7}
 	c = x6
 	return
 }
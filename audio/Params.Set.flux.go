@@ -7,176 +7,176 @@ import (
 )
 
 func (p *Params) Set(x interface{}) () {
-	var v map[interface{}]struct{}
-	var v2 func(x6 reflect.Value)
-	var v3 interface{}
-	var v4 interface{}
-	var v5 Params
-	var v6 map[interface{}]struct{}
-	var v7 map[interface{}]struct{}
-	var v8 bool
-	var v9 interface{}
-	var v10 Params
-	var v11 interface{}
-	var v12 Params
-	var v13 bool
+	var v Params
+	var v2 map[interface{}]struct{}
+	var v3 bool
+	var v4 map[interface{}]struct{}
+	var v5 interface{}
+	var v6 Params
+	var v7 bool
+	var v8 interface{}
+	var v9 map[interface{}]struct{}
+	var v10 interface{}
+	var v11 Params
+	var v12 interface{}
+	var v13 func(x6 reflect.Value)
 	var v14 func(x6 reflect.Value)
-	var v15 Params
-	var v16 interface{}
-	v15 = *p//p
-	v5 = *p
-	v12 = *p//p
-	v9 = x//x
-	v11 = x//x
-	v3 = x//x
-	v10 = *p//p
-	v16 = x//x
-	v4 = x//x
-	x2 := v5.visited
-	v = x2
-	v6 = x2
-	v7 = x2
-	x3 := v7 == nil
-	v13 = x3
-	if v13 {
+	var v15 interface{}
+	var v16 Params
+	v = *p
+	v10 = x//x
+	v11 = *p//p
+	v12 = x//x
+	v15 = x//x
+	v16 = *p//p
+	v5 = x//x
+	v6 = *p//p
+	v8 = x//x
+	x2 := v.visited
+	v2 = x2
+	v4 = x2
+	v9 = x2
+	x3 := v2 == nil
+	v3 = x3
+	if v3 {
 		x4 := map[interface{}]struct{}{}
-		v = x4
-		v6 = x4
+		v4 = x4
+		v9 = x4
 	}
-	_, ok := v[v4]
-	v8 = ok
+	_, ok := v4[v5]
+	v7 = ok
 	x5 := func (x6 reflect.Value) () {
 		var v17 reflect.Value
 		var v18 reflect.Value
 		var v19 reflect.Kind
-		var v20 bool
-		var v21 reflect.Value
+		var v20 reflect.Kind
+		var v21 reflect.Kind
 		var v22 reflect.Kind
 		var v23 bool
 		var v24 bool
 		var v25 bool
 		var v26 bool
-		var v27 reflect.Value
+		var v27 bool
 		var v28 reflect.Value
-		var v29 bool
-		var v30 reflect.Kind
-		var v31 reflect.Kind
+		var v29 reflect.Value
+		var v30 bool
+		var v31 reflect.Value
+		v17 = x6
 		v18 = x6
 		v28 = x6
-		v17 = x6
-		v27 = x6
-		v21 = x6
+		v29 = x6
+		v31 = x6
 		x62 := v17.CanAddr()
-		v29 = x62
-		x7 := v28.Kind()
-		v30 = x7
-		v22 = x7
+		v25 = x62
+		x7 := v18.Kind()
+		v19 = x7
+		v21 = x7
 		const x8 = reflect.Ptr
-		v19 = x8
-		x9 := v30 != v19
+		v20 = x8
+		x9 := v19 != v20
 		v23 = x9
 		const x10 = reflect.Interface
-		v31 = x10
-		x11 := v22 != v31
+		v22 = x10
+		x11 := v21 != v22
 		v24 = x11
 		x12 := v23 && v24
-		v25 = x12
-		x13 := v29 && v25
-		v20 = x13
-		if v20 {
-			x14 := v27.Addr()
-			v18 = x14
-			v21 = x14
+		v26 = x12
+		x13 := v25 && v26
+		v27 = x13
+		if v27 {
+			x14 := v28.Addr()
+			v29 = x14
+			v31 = x14
 		}
-		x15 := v21.CanInterface()
-		v26 = x15
-		if v26 {
+		x15 := v29.CanInterface()
+		v30 = x15
+		if v30 {
 			var v32 interface{}
-			i := v18.Interface()
+			i := v31.Interface()
 			v32 = i
-			v10.Set(v32)
+			v6.Set(v32)
 		}
 		return
 	}
-	v2 = x5//f
+	v13 = x5//f
 	v14 = x5//f
-	if v8 {
+	if v7 {
 	} else  {
 		var v33 bool
-		var v34 *Params
+		var v34 AudioParamSetter
 		var v35 bool
-		var v36 AudioParamSetter
-		x16, ok2 := v9.(AudioParamSetter)
-		v36 = x16
+		var v36 *Params
+		x16, ok2 := v8.(AudioParamSetter)
 		v33 = ok2
+		v34 = x16
 		var v37 struct{}
-		v6[v16] = v37//;0
+		v9[v10] = v37//;0
 		if v33 {
-			v36.SetAudioParams(v15)//0;
+			v34.SetAudioParams(v11)//0;
 		} else  {
-			var v38 reflect.Kind
-			var v39 reflect.Kind
-			var v40 bool
+			var v38 reflect.Value
+			var v39 reflect.Value
+			var v40 reflect.Kind
 			var v41 reflect.Kind
-			var v42 reflect.Value
+			var v42 bool
 			var v43 reflect.Value
-			var v44 reflect.Kind
-			var v45 reflect.Value
-			var v46 bool
-			var v47 reflect.Value
+			var v44 reflect.Value
+			var v45 reflect.Kind
+			var v46 reflect.Kind
+			var v47 bool
 			var v48 reflect.Value
 			var v49 reflect.Value
-			x17 := reflect.ValueOf(v11)
-			v42 = x17
-			x18 := reflect.Indirect(v42)
-			v47 = x18
-			v48 = x18
+			x17 := reflect.ValueOf(v12)
+			v38 = x17
+			x18 := reflect.Indirect(v38)
+			v39 = x18
 			v43 = x18
+			v44 = x18
+			v48 = x18
 			v49 = x18
-			v45 = x18
-			x19 := v43.Kind()
-			v44 = x19
-			v38 = x19
+			x19 := v39.Kind()
+			v40 = x19
+			v45 = x19
 			const x20 = reflect.Struct
 			v41 = x20
-			x21 := v44 == v41
-			v46 = x21
-			if v46 {
+			x21 := v40 == v41
+			v42 = x21
+			if v42 {
 				var v50 int
-				x22 := v49.NumField()
+				x22 := v43.NumField()
 				v50 = x22
 				for k := int(0); k < v50; k++ {
 					var v51 int
 					var v52 reflect.Value
 					v51 = k
-					x23 := v45.Field(v51)
+					x23 := v44.Field(v51)
 					v52 = x23
-					v2(v52)
+					v13(v52)
 				}
 			}
 			const x24 = reflect.Slice
-			v39 = x24
-			x25 := v38 == v39
-			v40 = x25
-			if v40 {
+			v46 = x24
+			x25 := v45 == v46
+			v47 = x25
+			if v47 {
 				var v53 int
-				x26 := v47.Len()
+				x26 := v48.Len()
 				v53 = x26
 				for k2 := int(0); k2 < v53; k2++ {
 					var v54 int
 					var v55 reflect.Value
 					v54 = k2
-					x27 := v48.Index(v54)
+					x27 := v49.Index(v54)
 					v55 = x27
 					v14(v55)
 				}
 			}
 		}
-		x28, ok3 := v3.(*Params)
-		v34 = x28
+		x28, ok3 := v15.(*Params)
 		v35 = ok3
+		v36 = x28
 		if v35 {
-			*v34 = v12
+			*v36 = v16
 		}
 	}
 	return
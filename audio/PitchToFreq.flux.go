@@ -15,12 +15,12 @@ func PitchToFreq(pitch float64) (freq float64) {
 	var v6 float64
 	v = pitch
 	const x = 2
-	v2 = x
+	v3 = x
 	const x2 = 12
-	v3 = x2
-	x3 := v / v3
+	v2 = x2
+	x3 := v / v2
 	v4 = x3
-	x4 := math.Pow(v2, v4)
+	x4 := math.Pow(v3, v4)
 	v5 = x4
 	const x5 = 512
 	v6 = x5
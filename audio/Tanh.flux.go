@@ -8,29 +8,29 @@ import (
 
 func Tanh(x2 Audio) (x Audio) {
 	var v Audio
-	var v2 Audio
+	var v2 int
 	var v3 Audio
-	var v4 int
-	v2 = x2
-	v3 = x2
-	x22 := len(v2)
-	v4 = x22
-	x3 := make(Audio, v4)
+	var v4 Audio
+	v = x2
+	v4 = x2
+	x22 := len(v)
+	v2 = x22
+	x3 := make(Audio, v2)
+	v3 = x3
 	x = x3
-	v = x3
-	for k := range v {
-		var v5 = &v[k]
-		var v6 *float64
-		var v7 int
-		var v8 float64
+	for k := range v3 {
+		var v5 = &v3[k]
+		var v6 int
+		var v7 float64
+		var v8 *float64
 		var v9 float64
-		v7 = k
-		v6 = v5
-		x4 := &v3[v7]
-		v8 = *x4
-		x5 := math.Tanh(v8)
+		v6 = k
+		v8 = v5
+		x4 := &v4[v6]
+		v7 = *x4
+		x5 := math.Tanh(v7)
 		v9 = x5
-		*v6 = v9
+		*v8 = v9
 	}
 	return
 }
@@ -8,75 +8,77 @@ import (
 )
 
 func Play(x audio.Voice) () {
-	var v int
-	var v2 *audio.Params
-	var v3 interface{}
-	var v4 *portaudio.Stream
-	var v5 *portaudio.Stream
+	var v float64
+	var v2 int
+	var v3 *audio.Params
+	var v4 interface{}
+	var v5 audio.Voice
 	var v6 chan struct{}
-	var v7 chan struct{}
-	var v8 interface{}
-	var v9 float64
-	var v10 float64
-	var v11 int
-	var v12 audio.Voice
-	var v13 int
-	v12 = x
-	v8 = x
+	var v7 int
+	var v8 float64
+	var v9 int
+	var v10 interface{}
+	var v11 *portaudio.Stream
+	var v12 chan struct{}
+	var v13 *portaudio.Stream
+	v4 = x
+	v5 = x
 	portaudio.Initialize()//;0
 	defer portaudio.Terminate()//0;
 	var v14 int
 	x2 := make(chan struct{}, v14)
+	v12 = x2
 	v6 = x2
-	v7 = x2
 	const x3 = 96000
-	v9 = x3
-	v10 = x3
+	v = x3
+	v8 = x3
 	const x4 = 64
-	v13 = x4
-	v = x4
-	x5 := &audio.Params{SampleRate: v10, BufferSize: v13}
-	v2 = x5
-	v2.Set(v8)//;1
+	v2 = x4
+	v9 = x4
+	x5 := &audio.Params{SampleRate: v, BufferSize: v2}
+	v3 = x5
+	v3.Set(v4)//;1
 	const x6 = 1
-	v11 = x6
+	v7 = x6
 	x7 := func (in [][]float32, out [][]float32) () {
-		var v15 []float32
-		var v16 [][]float32
-		var v17 bool
-		var v18 audio.Audio
-		v16 = out
-		x8, done := v12.Sing()
-		v18 = x8
-		v17 = done
+		var v15 [][]float32
+		var v16 audio.Audio
+		var v17 []float32
+		var v18 bool
+		v15 = out
+		x8, done := v5.Sing()
+		v16 = x8
+		v18 = done
 		var v19 int
-		x9 := &v16[v19]
-		v15 = *x9
-		for k := range v18 {
-			var v20 = &v18[k]
-			var v21 int
-			var v22 float64
+		x9 := &v15[v19]
+		v17 = *x9
+		for k := range v16 {
+			var v20 = &v16[k]
+			var v21 float64
+			var v22 int
 			var v23 float32
-			v21 = k
-			v22 = *v20
-			x10 := (float32)(v22)
+			v21 = *v20
+			v22 = k
+			x10 := (float32)(v21)
 			v23 = x10
-			v15[v21] = v23
+			v17[v22] = v23
 		}
-		if v17 {
+		if v18 {
 			var v24 struct{}
 			select {
-			case v7 <- v24:
+			case v6 <- v24:
 			default:
 			}
 		}
+		return
 	}
-	v3 = x7
+	v10 = x7
 	var v25 int
-	x11, _ := portaudio.OpenDefaultStream(v25, v11, v9, v, v3)//0;
-	v4 = x11
-	v5 = x11
-	v4.Start()//1;2
-	<-v6//2;3
-	v5.Stop()//3;
+	x11, _ := portaudio.OpenDefaultStream(v25, v7, v8, v9, v10)//0;
+	v11 = x11
+	v13 = x11
+	v11.Start()//1;2
+	<-v12//2;3
+	v13.Stop()//3;
+	return
 }
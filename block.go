@@ -6,9 +6,8 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
-	. "github.com/gordonklaus/util"
-	"fmt"
 	"go/token"
 	"math/rand"
 )
@@ -17,10 +16,12 @@ const blockRadius = 8
 
 type block struct {
 	*ViewBase
+	model   *graph.Block
 	node    node
-	nodes   map[node]bool
-	conns   map[*connection]bool
+	fixed   map[node]bool // nodes placed by the user or loaded with a position; the arranger leaves them where they are
 	focused bool
+	band    Rectangle // the rubber band selecting nodes, while banding
+	banding bool
 
 	arrange, childArranged blockchan
 	stop                   stopchan
@@ -29,9 +30,10 @@ type block struct {
 func newBlock(n node, arranged blockchan) *block {
 	b := &block{}
 	b.ViewBase = NewView(b)
+	b.model = graph.NewBlock(n.graphNode())
+	b.model.Data = b
 	b.node = n
-	b.nodes = map[node]bool{}
-	b.conns = map[*connection]bool{}
+	b.fixed = map[node]bool{}
 
 	b.arrange = make(blockchan)
 	b.childArranged = make(blockchan)
@@ -45,9 +47,33 @@ func newBlock(n node, arranged blockchan) *block {
 
 func (b *block) close() {
 	close(b.stop)
-	for n := range b.nodes {
+	for _, n := range b.nodes() {
 		b.removeNode(n)
 	}
+	b.model.Close()
+}
+
+func blockView(b *graph.Block) *block {
+	if b == nil {
+		return nil
+	}
+	return b.Data.(*block)
+}
+
+func (b block) nodes() (nodes []node) {
+	for _, n := range b.model.Nodes {
+		nodes = append(nodes, n.Data.(node))
+	}
+	return
+}
+
+func (b block) conns() []*connection { return connViews(b.model.Conns) }
+
+func connViews(conns []*graph.Connection) (views []*connection) {
+	for _, c := range conns {
+		views = append(views, c.Data.(*connection))
+	}
+	return
 }
 
 func (b *block) outer() *block { return b.node.block() }
@@ -71,18 +97,16 @@ func func_(n node) *funcNode {
 }
 
 func (b *block) addNode(n node) {
-	if !b.nodes[n] {
-		b.Add(n)
-		n.Move(Pt(rand.NormFloat64(), rand.NormFloat64()))
-		b.nodes[n] = true
-		n.setBlock(b)
+	if n.block() != b {
+		edited(b.node)
+		b.model.AddNode(n.graphNode())
 		switch n := n.(type) {
 		case *callNode:
 			if n.obj != nil && !isMethod(n.obj) {
 				b.func_().addPkgRef(n.obj)
 			}
-		case *compositeLiteralNode:
-			// handled in compositeLiteralNode.setType
+		case *compositeLiteralNode, *structPackNode:
+			// handled in setType
 		case *valueNode:
 			switch obj := n.obj.(type) {
 			case *types.Const, *types.Var:
@@ -93,17 +117,15 @@ func (b *block) addNode(n node) {
 				}
 			}
 		}
-		rearrange(b)
 	}
 }
 
 func (b *block) removeNode(n node) {
-	if b.nodes[n] {
+	if n.block() == b {
+		edited(b.node)
 		for _, c := range append(n.inConns(), n.outConns()...) {
-			c.blk.removeConn(c)
+			c.block().removeConn(c)
 		}
-		b.Remove(n)
-		delete(b.nodes, n)
 		switch n := n.(type) {
 		case *callNode:
 			if n.obj != nil && !isMethod(n.obj) {
@@ -113,6 +135,10 @@ func (b *block) removeNode(n node) {
 			if t := *n.typ.typ; t != nil {
 				b.func_().subPkgRef(t)
 			}
+		case *structPackNode:
+			if t := *n.typ.typ; t != nil {
+				b.func_().subPkgRef(t)
+			}
 		case *valueNode:
 			switch obj := n.obj.(type) {
 			case *types.Const, *types.Var:
@@ -130,33 +156,105 @@ func (b *block) removeNode(n node) {
 			for _, c := range n.cases {
 				c.blk.close()
 			}
+		case *switchNode:
+			for _, c := range n.cases {
+				c.blk.close()
+			}
+		case *typeSwitchNode:
+			for _, c := range n.cases {
+				c.blk.close()
+			}
 		case *loopNode:
 			n.loopblk.close()
 		case *funcNode:
 			n.funcblk.close()
 		}
-		rearrange(b)
+		b.model.RemoveNode(n.graphNode())
+	}
+}
+
+// moveNodes moves nodes, which must all belong to one block, to b, keeping those of their connections that are still valid.
+// The nodes may come from another func, provided that none of their connections lead to nodes left behind.
+func (b *block) moveNodes(nodes []node) {
+	if len(nodes) == 0 {
+		return
+	}
+	edited(b.node)
+	old := nodes[0].block()
+	f, g := old.func_(), b.func_()
+	models := []*graph.Node{}
+	for _, n := range nodes {
+		if f != g {
+			ns := []node{n}
+			for _, b := range n.graphNode().Blocks {
+				ns = append(ns, b.Data.(*block).allNodes()...)
+			}
+			for _, n := range ns {
+				for _, x := range pkgRefs(n) {
+					f.subPkgRef(x)
+					g.addPkgRef(x)
+				}
+			}
+		}
+		models = append(models, n.graphNode())
+	}
+	b.model.MoveNodes(models)
+	done := map[*connection]bool{}
+	for _, n := range nodes {
+		for _, c := range append(n.inConns(), n.outConns()...) {
+			if done[c] {
+				continue
+			}
+			done[c] = true
+			if c.connectable(c.src(), c.dst()) {
+				c.reform()
+			} else {
+				c.block().removeConn(c)
+			}
+		}
 	}
 }
 
-func (b *block) addConn(c *connection) {
-	if c.blk != nil {
-		delete(c.blk.conns, c)
-		c.blk.Remove(c)
-		rearrange(c.blk)
+func (b *block) removeConn(c *connection) {
+	c.disconnect()
+	if b := c.block(); b != nil { // disconnect might change c's block, and c may already have been removed
+		b.model.RemoveConn(c.model)
+	}
+}
+
+// NodeAdded shows the view of n, which was added to b's model.
+func (b *block) NodeAdded(m *graph.Node) {
+	n := m.Data.(node)
+	b.Add(n)
+	n.Move(Pt(rand.NormFloat64(), rand.NormFloat64()))
+	if n, ok := n.(*returnNode); ok {
+		n.syncResults()
 	}
-	c.blk = b
+	rearrange(b)
+}
+
+// NodeRemoved hides the view of n, which was removed from b's model.
+func (b *block) NodeRemoved(m *graph.Node) {
+	n := m.Data.(node)
+	b.Remove(n)
+	delete(b.fixed, n)
+	if f := b.func_(); f != nil {
+		delete(f.selected, n)
+	}
+	rearrange(b)
+}
+
+// ConnAdded shows the view of c, which was added to b's model.
+func (b *block) ConnAdded(m *graph.Connection) {
+	c := m.Data.(*connection)
 	b.Add(c)
 	Lower(c)
-	b.conns[c] = true
 	rearrange(b)
 }
 
-func (b *block) removeConn(c *connection) {
-	c.disconnect()
-	b = c.blk //disconnect might change c.blk
-	delete(b.conns, c)
-	b.Remove(c)
+// ConnRemoved hides the view of c, which was removed from b's model.
+func (b *block) ConnRemoved(m *graph.Connection) {
+	b.Remove(m.Data.(*connection))
 	rearrange(b)
 }
 
@@ -164,7 +262,7 @@ func (b *block) walk(bf func(*block), nf func(node), cf func(*connection)) {
 	if bf != nil {
 		bf(b)
 	}
-	for n := range b.nodes {
+	for _, n := range b.nodes() {
 		if nf != nil {
 			nf(n)
 		}
@@ -177,6 +275,14 @@ func (b *block) walk(bf func(*block), nf func(node), cf func(*connection)) {
 			for _, c := range n.cases {
 				c.blk.walk(bf, nf, cf)
 			}
+		case *switchNode:
+			for _, c := range n.cases {
+				c.blk.walk(bf, nf, cf)
+			}
+		case *typeSwitchNode:
+			for _, c := range n.cases {
+				c.blk.walk(bf, nf, cf)
+			}
 		case *loopNode:
 			n.loopblk.walk(bf, nf, cf)
 		case *funcNode:
@@ -184,137 +290,50 @@ func (b *block) walk(bf func(*block), nf func(node), cf func(*connection)) {
 		}
 	}
 	if cf != nil {
-		for c := range b.conns {
+		for _, c := range b.conns() {
 			cf(c)
 		}
 	}
 }
 
-func (b *block) allNodes() (nodes []node) {
-	b.walk(nil, func(n node) {
-		nodes = append(nodes, n)
-	}, nil)
-	return
-}
-
-func (b block) inConns() (conns []*connection) {
-	for n := range b.nodes {
-		for _, c := range n.inConns() {
-			if !b.conns[c] {
-				conns = append(conns, c)
-			}
-		}
-	}
-	return
-}
-
-func (b block) outConns() (conns []*connection) {
-	for n := range b.nodes {
-		for _, c := range n.outConns() {
-			if !b.conns[c] {
-				conns = append(conns, c)
-			}
-		}
+// walkInOrder calls f for each node in b and its nested blocks, visiting the nodes of each block in execution order followed by those that have none (the outputs node and any nodes in a cycle).
+// Unlike walk, the order is the same each time a func is read, so it can be used to identify nodes in the written file.
+func (b *block) walkInOrder(f func(node)) {
+	order, _ := b.nodeOrder()
+	ordered := map[node]bool{}
+	for _, n := range order {
+		ordered[n] = true
 	}
-	return
-}
-
-func (b *block) nodeOrder() []node {
-	order := []node{}
-	var inputsNode *portsNode
-
-	visited := Set{}
-	var insertInOrder func(n node, visitedThisCall Set)
-	insertInOrder = func(n node, visitedThisCall Set) {
-		if visitedThisCall[n] {
-			fmt.Println("cyclic")
-			return
-		}
-		visitedThisCall[n] = true
-
-		if !visited[n] {
-			visited[n] = true
-			for _, src := range srcsInBlock(n) {
-				insertInOrder(src, visitedThisCall.Copy())
-			}
-			if pn, ok := n.(*portsNode); ok {
-				if !pn.out {
-					inputsNode = pn
-				}
-			} else {
-				order = append(order, n)
-			}
+	for _, n := range b.model.Nodes {
+		if n := n.Data.(node); !ordered[n] {
+			order = append(order, n)
 		}
 	}
-
-	endNodes := []node{}
-	for n := range b.nodes {
-		if len(dstsInBlock(n)) == 0 {
-			endNodes = append(endNodes, n)
+	for _, n := range order {
+		f(n)
+		for _, b := range n.graphNode().Blocks {
+			b.Data.(*block).walkInOrder(f)
 		}
 	}
-	if len(endNodes) == 0 && len(b.nodes) > 0 {
-		fmt.Println("cyclic")
-	}
-
-	for _, n := range endNodes {
-		insertInOrder(n, Set{})
-	}
-	if inputsNode != nil {
-		order = append([]node{inputsNode}, order...)
-	}
-	return order
 }
 
-func srcsInBlock(n node) (srcs []node) {
-	b := n.block()
-	for _, c := range n.inConns() {
-		if c.feedback || c.src == nil {
-			continue
-		}
-		if src := b.find(c.src.node); src != nil {
-			srcs = append(srcs, src)
-		}
-	}
-	for _, c := range n.outConns() {
-		if !c.feedback || c.dst == nil {
-			continue
-		}
-		if dst := b.find(c.dst.node); dst != nil && dst != n {
-			srcs = append(srcs, dst)
-		}
-	}
+func (b *block) allNodes() (nodes []node) {
+	b.walk(nil, func(n node) {
+		nodes = append(nodes, n)
+	}, nil)
 	return
 }
 
-func dstsInBlock(n node) (dsts []node) {
-	b := n.block()
-	for _, c := range n.outConns() {
-		if c.feedback || c.dst == nil {
-			continue
-		}
-		if dst := b.find(c.dst.node); dst != nil {
-			dsts = append(dsts, dst)
-		}
-	}
-	for _, c := range n.inConns() {
-		if !c.feedback || c.src == nil {
-			continue
-		}
-		if src := b.find(c.src.node); src != nil && src != n {
-			dsts = append(dsts, src)
-		}
-	}
-	return
-}
+func (b block) inConns() []*connection  { return connViews(b.model.InConns()) }
+func (b block) outConns() []*connection { return connViews(b.model.OutConns()) }
 
-func (b *block) find(n node) node {
-	for b2 := n.block(); b2 != nil; n, b2 = b2.node, b2.outer() {
-		if b2 == b {
-			return n
-		}
+// nodeOrder returns the nodes of b in execution order.  cyclic reports whether the connections between them form a cycle, in which case the order is incomplete.
+func (b *block) nodeOrder() (order []node, cyclic bool) {
+	nodes, cyclic := b.model.NodeOrder()
+	for _, n := range nodes {
+		order = append(order, n.Data.(node))
 	}
-	return nil
+	return
 }
 
 func nearestView(parent View, views []View, p Point, dirKey int) (nearest View) {
@@ -356,7 +375,7 @@ func (b *block) focusNearestView(viewOrPoint interface{}, dirKey int) {
 }
 
 func (b *block) focus() {
-	if len(b.nodes) == 0 {
+	if len(b.nodes()) == 0 {
 		SetKeyFocus(b)
 	} else {
 		b.focusNearestView(Center(b).Add(Pt(0, Height(b)/2)), KeyDown)
@@ -364,7 +383,7 @@ func (b *block) focus() {
 }
 
 func (b *block) TookKeyFocus() {
-	for n := range b.nodes {
+	for _, n := range b.nodes() {
 		SetKeyFocus(n)
 		return
 	}
@@ -379,15 +398,75 @@ func (b *block) LostKeyFocus() {
 }
 
 func (b *block) KeyPress(event KeyEvent) {
+	f := b.func_()
+	if event.Command {
+		switch event.Key {
+		case KeyC, KeyX:
+			if c := copyNodes(b.selectionOrFocus()); c != nil {
+				clipboard = c
+				if event.Key == KeyX {
+					for _, n := range b.selectionOrFocus() {
+						b.removeNode(n)
+					}
+					c.cut = true
+					SetKeyFocus(b)
+				}
+			}
+			return
+		case KeyV:
+			if clipboard != nil {
+				f.clearSelection()
+				nodes := b.paste(clipboard)
+				for _, n := range nodes {
+					b.selectNode(n)
+				}
+				if len(nodes) > 0 {
+					SetKeyFocus(nodes[0])
+				}
+			}
+			return
+		case KeyE:
+			if nodes := b.selectionOrFocus(); len(nodes) > 0 {
+				b.editExtractFunc(nodes)
+			}
+			return
+		case KeyI:
+			if n, ok := KeyFocus(b).(*callNode); ok && n.block() == b {
+				f.clearSelection()
+				nodes := b.inline(n)
+				for _, n := range nodes {
+					b.selectNode(n)
+				}
+				if len(nodes) > 0 {
+					SetKeyFocus(nodes[0])
+				}
+			}
+			return
+		}
+	}
 	switch k := event.Key; k {
 	case KeyLeft, KeyRight, KeyUp, KeyDown:
-		if event.Alt && !event.Shift {
+		if event.Shift && !event.Alt {
+			if n, ok := KeyFocus(b).(node); ok && n.block() == b {
+				b.selectNode(n)
+				views := []View{}
+				for _, n := range b.nodes() {
+					if _, ok := n.(*portsNode); !ok {
+						views = append(views, n)
+					}
+				}
+				if n, ok := nearestView(b, views, Map(ZP, n, b), k).(node); ok {
+					b.selectNode(n)
+					SetKeyFocus(n)
+				}
+			}
+		} else if event.Alt && !event.Shift {
 			b.focusNearestView(KeyFocus(b), k)
 		} else if n, ok := KeyFocus(b).(node); ok {
 			focseq := event.Alt && event.Shift
 			if k == KeyUp {
 				seq := seqIn(n)
-				if num := len(ins(n)); seq != nil && (focseq || num == 0 && len(seq.conns) > 0) {
+				if num := len(ins(n)); seq != nil && (focseq || num == 0 && len(seq.conns()) > 0) {
 					SetKeyFocus(seq)
 				} else if num > 0 {
 					SetKeyFocus(ins(n)[(num-1)/2])
@@ -395,7 +474,7 @@ func (b *block) KeyPress(event KeyEvent) {
 			}
 			if k == KeyDown {
 				seq := seqOut(n)
-				if num := len(outs(n)); seq != nil && (focseq || num == 0 && len(seq.conns) > 0) {
+				if num := len(outs(n)); seq != nil && (focseq || num == 0 && len(seq.conns()) > 0) {
 					SetKeyFocus(seq)
 				} else if num > 0 {
 					SetKeyFocus(outs(n)[(num-1)/2])
@@ -409,6 +488,13 @@ func (b *block) KeyPress(event KeyEvent) {
 			b.ViewBase.KeyPress(event)
 		}
 	case KeyBackspace, KeyDelete:
+		if nodes := b.selection(); len(nodes) > 0 {
+			for _, n := range nodes {
+				b.removeNode(n)
+			}
+			SetKeyFocus(b)
+			return
+		}
 		switch v := KeyFocus(b).(type) {
 		case *block:
 			SetKeyFocus(v.node)
@@ -417,16 +503,18 @@ func (b *block) KeyPress(event KeyEvent) {
 			foc := View(b)
 			in, out := v.inConns(), v.outConns()
 			if len(in) > 0 {
-				foc = in[len(in)-1].src.node
+				foc = in[len(in)-1].src().node
 			}
 			if (len(in) == 0 || k == KeyDelete) && len(out) > 0 {
-				foc = out[len(out)-1].dst.node
+				foc = out[len(out)-1].dst().node
 			}
 			b.removeNode(v)
 			SetKeyFocus(foc)
 		}
 	case KeyEscape:
-		if f, ok := b.node.(*funcNode); ok && !f.literal {
+		if len(f.selected) > 0 {
+			f.clearSelection()
+		} else if f, ok := b.node.(*funcNode); ok && !f.literal {
 			f.Close()
 		} else if f, ok := b.node.(focuserFrom); ok {
 			f.focusFrom(b)
@@ -439,8 +527,8 @@ func (b *block) KeyPress(event KeyEvent) {
 			default:
 				browser := newBrowser(browserOptions{enterTypes: true, canFuncAsVal: true}, b)
 				b.Add(browser)
-				w := window(b)
-				browser.Move(Map(Center(w), w, b))
+				p := paneOf(b)
+				browser.Move(Map(Center(p), p, b))
 				browser.accepted = func(obj types.Object) {
 					browser.Close()
 					b.newNode(obj, browser.funcAsVal, "")
@@ -490,8 +578,10 @@ func (b *block) newNode(obj types.Object, funcAsVal bool, godefer string) node {
 	switch obj := obj.(type) {
 	case special:
 		switch obj.Name {
-		case "break", "continue", "return":
+		case "break", "continue":
 			n = newBranchNode(obj.Name)
+		case "return":
+			n = newReturnNode()
 		case "call":
 			n = newCallNode(nil, currentPkg, godefer)
 		case "convert":
@@ -519,10 +609,20 @@ func (b *block) newNode(obj types.Object, funcAsVal bool, godefer string) node {
 			n = i
 		case "loop":
 			n = newLoopNode(b.childArranged)
+		case "pack":
+			n = newStructPackNode(currentPkg)
 		case "select":
 			n = newSelectNode(b.childArranged)
+		case "switch":
+			s := newSwitchNode(b.childArranged)
+			s.newCase()
+			n = s
 		case "typeAssert":
 			n = newTypeAssertNode(currentPkg)
+		case "typeSwitch":
+			n = newTypeSwitchNode(currentPkg, b.childArranged)
+		case "unpack":
+			n = newStructUnpackNode()
 		}
 	case *types.Func, *types.Builtin:
 		if obj.GetName() == "[]" {
@@ -560,29 +660,74 @@ func (b *block) newNode(obj types.Object, funcAsVal bool, godefer string) node {
 	return n
 }
 
-func (b *block) Paint() {
+// Mouse drags a rubber band over the nodes of b to select them, or opens b's context menu on a right click.
+func (b *block) Mouse(m MouseEvent) {
+	if m.Button == MouseButtonRight {
+		if m.Press {
+			SetKeyFocus(b)
+			openContextMenu(b, m.Pos)
+		}
+		return
+	}
+	switch {
+	case m.Press:
+		b.band = Rectangle{m.Pos, m.Pos}
+		b.banding = true
+	case m.Drag:
+		b.band.Max = m.Pos
+	case m.Release:
+		b.band.Max = m.Pos
+		b.banding = false
+		f := b.func_()
+		f.clearSelection()
+		var first node
+		b.walkInOrder(func(n node) {
+			if n.block() == b && RectInParent(n).Overlaps(b.band.Canon()) {
+				b.selectNode(n)
+				if first == nil && f.selected[n] {
+					first = n
+				}
+			}
+		})
+		if first != nil {
+			SetKeyFocus(first)
+		}
+	}
+	Repaint(b)
+}
+
+func (b *block) Paint(cv Canvas) {
+	cv.SetColor(selectionColor)
+	for n := range b.func_().selected {
+		if n.block() == b {
+			cv.FillRect(RectInParent(n))
+		}
+	}
+	if b.banding {
+		cv.DrawRect(b.band.Canon())
+	}
 	if b.focused {
-		SetPointSize(2 * portSize)
-		SetColor(focusColor)
-		DrawPoint(Center(b))
+		cv.SetPointSize(2 * portSize)
+		cv.SetColor(focusColor)
+		cv.DrawPoint(Center(b))
 	}
 	{
-		SetColor(lineColor)
-		SetLineWidth(1.5)
+		cv.SetColor(lineColor)
+		cv.SetLineWidth(1.5)
 		rect := Rect(b)
 		l, r, b, t := rect.Min.X, rect.Max.X, rect.Min.Y, rect.Max.Y
 		lb, bl := Pt(l, b+blockRadius), Pt(l+blockRadius, b)
 		rb, br := Pt(r, b+blockRadius), Pt(r-blockRadius, b)
 		rt, tr := Pt(r, t-blockRadius), Pt(r-blockRadius, t)
 		lt, tl := Pt(l, t-blockRadius), Pt(l+blockRadius, t)
-		DrawLine(bl, br)
-		DrawBezier(br, Pt(r, b), rb)
-		DrawLine(rb, rt)
-		DrawBezier(rt, Pt(r, t), tr)
-		DrawLine(tr, tl)
-		DrawBezier(tl, Pt(l, t), lt)
-		DrawLine(lt, lb)
-		DrawBezier(lb, Pt(l, b), bl)
+		cv.DrawLine(bl, br)
+		cv.DrawBezier(br, Pt(r, b), rb)
+		cv.DrawLine(rb, rt)
+		cv.DrawBezier(rt, Pt(r, t), tr)
+		cv.DrawLine(tr, tl)
+		cv.DrawBezier(tl, Pt(l, t), lt)
+		cv.DrawLine(lt, lb)
+		cv.DrawBezier(lb, Pt(l, b), bl)
 	}
 }
 
@@ -597,18 +742,23 @@ func newOutputsNode() *portsNode { return newPortsNode(true) }
 func newPortsNode(out bool) *portsNode {
 	n := &portsNode{out: out}
 	n.nodeBase = newNodeBase(n)
+	n.model.Kind = graph.Inputs
+	if out {
+		n.model.Kind = graph.Outputs
+	}
 	return n
 }
 
 func (n *portsNode) removePort(p *port) {
 	if n.editable {
-		f := n.blk.node.(*funcNode)
+		edited(n)
+		f := n.block().node.(*funcNode)
 		sig := f.sig()
 
-		ports := n.ins
+		ports := n.inputs()
 		vars := &sig.Results
 		if p.out {
-			ports = n.outs
+			ports = n.outputs()
 			if sig.Recv != nil { // don't remove receiver
 				ports = ports[1:]
 			}
@@ -617,7 +767,7 @@ func (n *portsNode) removePort(p *port) {
 
 		for i, q := range ports {
 			if q == p {
-				n.blk.func_().subPkgRef((*vars)[i].Type)
+				n.block().func_().subPkgRef((*vars)[i].Type)
 				*vars = append((*vars)[:i], (*vars)[i+1:]...)
 				n.removePortBase(p)
 				if i == len(*vars) {
@@ -626,6 +776,9 @@ func (n *portsNode) removePort(p *port) {
 				if f.obj == nil {
 					f.output.setType(sig)
 				}
+				if !p.out {
+					f.resultsChanged()
+				}
 				break
 			}
 		}
@@ -633,31 +786,35 @@ func (n *portsNode) removePort(p *port) {
 }
 
 func (n *portsNode) KeyPress(event KeyEvent) {
-	if f, ok := n.blk.node.(*funcNode); ok && f.literal && event.Key == KeyDown && n.out {
+	if f, ok := n.block().node.(*funcNode); ok && f.literal && event.Key == KeyDown && n.out {
 		SetKeyFocus(f)
-	} else if l, ok := n.blk.node.(*loopNode); ok && event.Key == KeyUp {
+	} else if l, ok := n.block().node.(*loopNode); ok && event.Key == KeyUp {
 		SetKeyFocus(l)
-	} else if s, ok := n.blk.node.(*selectNode); ok && event.Key == KeyUp {
+	} else if s, ok := n.block().node.(*selectNode); ok && event.Key == KeyUp {
 		s.focusFrom(n)
+	} else if s, ok := n.block().node.(*typeSwitchNode); ok && event.Key == KeyUp {
+		s.focusFrom(n)
+	} else if f, ok := n.block().node.(*funcNode); ok && f.tparams != nil && n.editable && !n.out && event.Text == "[" {
+		f.tparams.edit()
 	} else if n.editable && event.Text == "," {
-		f := n.blk.node.(*funcNode)
+		f := n.block().node.(*funcNode)
 		sig := f.sig()
 
 		newPort := newOutput
-		ports := &n.outs
+		ports := n.outputs()
 		vars := &sig.Params
 		if n.out {
 			newPort = newInput
-			ports = &n.ins
+			ports = n.inputs()
 			vars = &sig.Results
 		}
 
-		v := types.NewVar(0, n.blk.func_().pkg(), "", nil)
+		v := types.NewVar(0, n.block().func_().pkg(), "", nil)
 		p := newPort(n, v)
 
-		i := len(*ports)
+		i := len(ports)
 		if focus, ok := KeyFocus(n).(*port); ok {
-			for j, p := range *ports {
+			for j, p := range ports {
 				if p == focus {
 					i = j
 					break
@@ -669,7 +826,7 @@ func (n *portsNode) KeyPress(event KeyEvent) {
 		}
 
 		n.Add(p)
-		*ports = append((*ports)[:i], append([]*port{p}, (*ports)[i:]...)...)
+		n.model.InsertPort(p.model, i)
 		n.reform()
 		Show(p.valView)
 		p.valView.edit(func() {
@@ -681,7 +838,7 @@ func (n *portsNode) KeyPress(event KeyEvent) {
 				if i == len(*vars)-1 {
 					sig.IsVariadic = false
 				}
-				n.blk.func_().addPkgRef(v.Type)
+				n.block().func_().addPkgRef(v.Type)
 				SetKeyFocus(p)
 			} else {
 				n.removePortBase(p)
@@ -689,13 +846,16 @@ func (n *portsNode) KeyPress(event KeyEvent) {
 			if f.obj == nil {
 				f.output.setType(sig)
 			}
+			if n.out {
+				f.resultsChanged()
+			}
 		})
 	} else if n.editable && !n.out && event.Key == KeyPeriod && event.Ctrl {
-		f := n.blk.node.(*funcNode)
+		f := n.block().node.(*funcNode)
 		sig := f.sig()
-		len := len(n.outs)
+		len := len(n.outputs())
 		if len > 0 && (sig.Recv == nil || len > 1) {
-			p := n.outs[len-1]
+			p := n.outputs()[len-1]
 			if KeyFocus(n) != p {
 				return
 			}
@@ -715,14 +875,14 @@ func (n *portsNode) KeyPress(event KeyEvent) {
 	}
 }
 
-func (n *portsNode) Paint() {
-	SetColor(lineColor)
-	SetLineWidth(3)
-	DrawLine(Pt(-portSize/4, 0), Pt(portSize/4, 0))
-	n.nodeBase.Paint()
+func (n *portsNode) Paint(cv Canvas) {
+	cv.SetColor(lineColor)
+	cv.SetLineWidth(3)
+	cv.DrawLine(Pt(-portSize/4, 0), Pt(portSize/4, 0))
+	n.nodeBase.Paint(cv)
 	if n.focused {
-		SetPointSize(2 * portSize)
-		SetColor(focusColor)
-		DrawPoint(ZP)
+		cv.SetPointSize(2 * portSize)
+		cv.SetColor(focusColor)
+		cv.DrawPoint(ZP)
 	}
 }
@@ -26,6 +26,7 @@ type browser struct {
 	typ        types.Type // non-nil if this is a selection browser
 	currentPkg *types.Package
 	imports    []*types.Package
+	tparams    []*types.TypeName // the type parameters in scope
 	finished   bool
 	accepted   func(types.Object)
 	canceled   func()
@@ -61,11 +62,13 @@ loop:
 			f := v.func_()
 			b.currentPkg = f.pkg()
 			b.imports = f.imports()
+			b.tparams = typeParams(f.sig())
 			break loop
-		case *fluxWindow:
-			t := v.browser.currentObj().(*types.TypeName)
+		case *pane:
+			t := v.obj.(*types.TypeName)
 			b.currentPkg = t.Pkg
 			b.imports = imports(t)
+			b.tparams = t.Type.(*types.Named).TypeParams
 			break loop
 		}
 	}
@@ -80,7 +83,7 @@ loop:
 	b.Add(b.typeView)
 
 	b.pkgName = NewText("")
-	b.pkgName.SetBackgroundColor(Color{0, 0, 0, .7})
+	b.pkgName.SetBackgroundColor(labelColor)
 	b.Add(b.pkgName)
 
 	b.clearText()
@@ -218,7 +221,7 @@ func (b *browser) refresh() {
 	for i, obj := range b.objs {
 		l := NewText(obj.GetName())
 		l.SetTextColor(color(obj, false, b.funcAsVal))
-		l.SetBackgroundColor(Color{0, 0, 0, .7})
+		l.SetBackgroundColor(labelColor)
 		b.Add(l)
 		b.objTexts = append(b.objTexts, l)
 		l.Move(Pt(xOffset, float64(n-i-1)*Height(l)))
@@ -245,7 +248,7 @@ func (b *browser) refresh() {
 		b.text.SetTextColor(color(cur, true, b.funcAsVal))
 		if b.currentPkg == nil && len(b.path) > 0 {
 			if p, ok := b.path[0].(*pkgObject); ok {
-				b.typeView.currentPkg = pkgs[p.importPath]
+				b.typeView.currentPkg = loadedPackage(p.importPath)
 			}
 		}
 		switch cur := cur.(type) {
@@ -267,6 +270,9 @@ func (b *browser) refresh() {
 	}
 
 	Pan(b, Pt(0, yOffset))
+	if KeyFocus(b) == b {
+		announce(b)
+	}
 }
 
 var pkgObjects = map[string]*pkgObject{}
@@ -281,18 +287,21 @@ func (b browser) filteredObjs() (objs objects) {
 		}
 	}
 
-	addSubPkgs := func(importPath string) {
+	addSubPkgs := func(importPath string, dirs []string) {
 		seen := map[string]bool{}
-		for _, srcDir := range build.Default.SrcDirs() {
-			files, err := ioutil.ReadDir(filepath.Join(srcDir, importPath))
+		for _, dir := range dirs {
+			files, err := ioutil.ReadDir(dir)
 			if err != nil {
 				continue
 			}
 			for _, f := range files {
 				name := filepath.Base(f.Name())
-				if !f.IsDir() || !unicode.IsLetter([]rune(name)[0]) || name == "testdata" || seen[name] {
+				if !f.IsDir() || !unicode.IsLetter([]rune(name)[0]) || name == "testdata" || name == "vendor" || seen[name] {
 					continue
 				}
+				if _, err := os.Stat(filepath.Join(dir, name, "go.mod")); err == nil {
+					continue // another module, listed at the root if it is required
+				}
 				if _, ok := b.newObj.(*pkgObject); ok && name == b.oldName {
 					// when editing a package path, it will be added in filteredObjs as newObj, so don't add it here
 					continue
@@ -300,15 +309,7 @@ func (b browser) filteredObjs() (objs objects) {
 				seen[name] = true
 
 				importPath := path.Join(importPath, name)
-				pkgObj, ok := pkgObjects[importPath]
-				if !ok {
-					if pkg, err := build.Import(importPath, "", build.AllowBinary); err == nil {
-						name = pkg.Name
-					}
-					pkgObj = &pkgObject{nil, path.Base(importPath), srcDir, importPath, name}
-					pkgObjects[importPath] = pkgObj
-				}
-				add(pkgObj)
+				add(newPkgObject(path.Base(importPath), filepath.Join(dir, name), importPath))
 			}
 		}
 	}
@@ -328,17 +329,12 @@ func (b browser) filteredObjs() (objs objects) {
 	} else if len(b.path) > 0 {
 		switch obj := b.path[0].(type) {
 		case *pkgObject:
-			if pkg, err := getPackage(obj.importPath); err == nil {
+			if pkg, ok := b.getPackage(obj); ok {
 				for _, obj := range pkg.Scope().Objects {
 					add(obj)
 				}
-			} else {
-				if _, ok := err.(*build.NoGoError); !ok {
-					fmt.Println(err)
-				}
-				pkgs[obj.importPath] = types.NewPackage(obj.importPath, obj.pkgName, types.NewScope(types.Universe))
 			}
-			addSubPkgs(obj.importPath)
+			addSubPkgs(obj.importPath, obj.dirs())
 		case *types.TypeName:
 			for _, m := range intuitiveMethodSet(obj.Type) {
 				if types.IsIdentical(m.Obj.(*types.Func).Type.(*types.Signature).Recv.Type, m.Recv) {
@@ -351,7 +347,7 @@ func (b browser) filteredObjs() (objs objects) {
 			}
 		}
 	} else {
-		for _, name := range []string{"break", "call", "continue", "convert", "defer", "func", "go", "if", "loop", "return", "select", "typeAssert"} {
+		for _, name := range []string{"break", "call", "continue", "convert", "defer", "func", "go", "if", "loop", "pack", "return", "select", "switch", "typeAssert", "typeSwitch", "unpack"} {
 			add(special{newVar(name, nil)})
 		}
 		for _, name := range []string{"=", "*"} {
@@ -366,9 +362,12 @@ func (b browser) filteredObjs() (objs objects) {
 				add(obj)
 			}
 		}
+		for _, tn := range b.tparams {
+			add(tn)
+		}
 		for _, obj := range types.Universe.Objects {
 			switch obj.GetName() {
-			case "nil", "print", "println":
+			case "nil", "print", "println", "clear", "min", "max": // TODO: nodes for clear, min, and max
 				continue
 			}
 			add(obj)
@@ -379,15 +378,49 @@ func (b browser) filteredObjs() (objs objects) {
 		for _, t := range []*types.TypeName{protoPointer, protoArray, protoSlice, protoMap, protoChan, protoFunc, protoInterface, protoStruct} {
 			add(t)
 		}
-		addSubPkgs("")
+		if mainModule == nil {
+			addSubPkgs("", build.Default.SrcDirs())
+		} else {
+			// the standard library, the main module, and the modules it requires directly
+			addSubPkgs("", []string{filepath.Join(build.Default.GOROOT, "src")})
+			add(newPkgObject(mainModule.path, mainModule.dir, mainModule.path))
+			for _, r := range mainModule.requires {
+				if dir, ok := mainModule.moduleDir(r.path); ok && !r.indirect {
+					add(newPkgObject(r.path, dir, r.path))
+				}
+			}
+		}
 	}
 
 	sort.Sort(objs)
 	return
 }
 
-func isFluxObj(obj types.Object) bool {
-	return fluxObjs[obj]
+// getPackage returns the package of p, if it is loaded.  Otherwise, so as not to keep the user waiting, it loads the package in the background and refreshes b when it is done.
+// A package that can't be loaded is replaced with an empty one.
+func (b *browser) getPackage(p *pkgObject) (*types.Package, bool) {
+	loaded := func(pkg *types.Package, err error) {
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); !ok {
+				fmt.Println(err)
+			}
+			addPackage(types.NewPackage(p.importPath, p.pkgName, types.NewScope(types.Universe)))
+		}
+	}
+	if !InWindow(b) {
+		pkg, err := getPackage(p.importPath)
+		loaded(pkg, err)
+		return loadedPackage(p.importPath), true
+	}
+	do := DoChan(b)
+	return getPackageLater(p.importPath, func(pkg *types.Package, err error) {
+		do <- func() {
+			loaded(pkg, err)
+			if len(b.path) > 0 && b.path[0] == p {
+				b.refresh()
+			}
+		}
+	})
 }
 
 func isType(obj types.Object) bool {
@@ -422,6 +455,21 @@ func isCompositeType(obj types.Object) bool {
 	return false
 }
 
+func isStructOrPtrType(obj types.Object) bool {
+	return isStructType(obj) || obj == protoPointer
+}
+
+func isStructType(obj types.Object) bool {
+	if obj == protoStruct {
+		return true
+	}
+	if obj, ok := obj.(*types.TypeName); ok {
+		_, ok := underlying(obj.GetType()).(*types.Struct)
+		return ok
+	}
+	return false
+}
+
 func isMakeableType(obj types.Object) bool {
 	switch obj {
 	case protoSlice, protoMap, protoChan:
@@ -516,7 +564,7 @@ func (b *browser) KeyPress(event KeyEvent) {
 			b.newObj = obj
 			b.oldName = obj.GetName()
 			if p, ok := obj.(*pkgObject); ok {
-				delete(pkgs, p.importPath)
+				forgetPackage(p.importPath)
 				delete(pkgObjects, p.importPath)
 			} else {
 				if objs := obj.GetPkg().Scope().Objects; objs[obj.GetName()] == obj {
@@ -545,11 +593,14 @@ func (b *browser) KeyPress(event KeyEvent) {
 				oldImportPath := p.importPath
 				if len(b.path) > 0 {
 					parent := b.path[0].(*pkgObject)
-					p.srcDir = parent.srcDir
+					p.dir = filepath.Join(parent.dir, p.name)
 					p.importPath = path.Join(parent.importPath, p.name)
+				} else if mainModule != nil {
+					p.dir = filepath.Join(mainModule.dir, p.name)
+					p.importPath = path.Join(mainModule.path, p.name)
 				} else {
 					dirs := build.Default.SrcDirs()
-					p.srcDir = dirs[len(dirs)-1]
+					p.dir = filepath.Join(dirs[len(dirs)-1], p.name)
 					p.importPath = p.name
 				}
 				pkgObjects[p.importPath] = p
@@ -562,9 +613,8 @@ func (b *browser) KeyPress(event KeyEvent) {
 					return
 				}
 				p.pkgName = p.name
-				path := p.fullPath()
-				if err := os.Mkdir(path, 0777); err != nil {
-					fmt.Printf("error creating %s: %s\n", path, err)
+				if err := os.Mkdir(p.dir, 0777); err != nil {
+					fmt.Printf("error creating %s: %s\n", p.dir, err)
 					b.clearText()
 					return
 				}
@@ -573,7 +623,7 @@ func (b *browser) KeyPress(event KeyEvent) {
 					recv := b.path[0].(*types.TypeName).Type.(*types.Named)
 					recv.Methods = append(recv.Methods, obj.(*types.Func))
 				} else {
-					pkgs[b.path[0].(*pkgObject).importPath].Scope().Insert(obj)
+					loadedPackage(b.path[0].(*pkgObject).importPath).Scope().Insert(obj)
 				}
 				if b.oldName != "" {
 					newName := obj.GetName()
@@ -607,6 +657,7 @@ func (b *browser) KeyPress(event KeyEvent) {
 							fmt.Println("error renaming files: ", err)
 						}
 					}
+					packageWritten(obj.GetPkg().Path)
 					b.oldName = ""
 					b.clearText()
 					return
@@ -640,7 +691,7 @@ func (b *browser) KeyPress(event KeyEvent) {
 				}
 				t := NewText(obj.GetName() + sep)
 				t.SetTextColor(color(obj, true, b.funcAsVal))
-				t.SetBackgroundColor(Color{0, 0, 0, .7})
+				t.SetBackgroundColor(labelColor)
 				b.Add(t)
 				x := 0.0
 				if t, ok := b.lastPathText(); ok {
@@ -661,7 +712,7 @@ func (b *browser) KeyPress(event KeyEvent) {
 					recv := b.path[0].(*types.TypeName).Type.(*types.Named)
 					recv.Methods = append(recv.Methods, b.newObj.(*types.Func))
 				} else {
-					pkgs[b.path[0].(*pkgObject).importPath].Scope().Insert(b.newObj)
+					loadedPackage(b.path[0].(*pkgObject).importPath).Scope().Insert(b.newObj)
 				}
 			} else if b.i < len(b.objs)-1 {
 				b.i++
@@ -688,7 +739,7 @@ func (b *browser) KeyPress(event KeyEvent) {
 			}
 		}
 	default:
-		if event.Command && (event.Key == KeyN || event.Key == KeyW || event.Key == KeyQ) {
+		if event.Command && (event.Key == KeyN || event.Key == KeyW || event.Key == KeyQ || event.Key == KeyD || event.Key == KeyK || event.Key == KeyT || event.Key == KeySlash || event.Key == KeyLeftBracket || event.Key == KeyRightBracket) {
 			b.ViewBase.KeyPress(event)
 			return
 		}
@@ -704,8 +755,8 @@ func (b *browser) KeyPress(event KeyEvent) {
 		if len(b.path) > 0 {
 			switch obj := b.path[0].(type) {
 			case *pkgObject:
-				makeInPkg = true
-				pkg = pkgs[obj.importPath]
+				pkg = loadedPackage(obj.importPath)
+				makeInPkg = pkg != nil // not while it is loading
 			case *types.TypeName:
 				recv = obj
 				pkg = obj.Pkg
@@ -770,7 +821,7 @@ func (b *browser) unique(name string) bool {
 		}
 		return true
 	}
-	return pkgs[b.path[0].(*pkgObject).importPath].Scope().LookupParent(name) == nil
+	return loadedPackage(b.path[0].(*pkgObject).importPath).Scope().LookupParent(name) == nil
 }
 
 func (b *browser) KeyRelease(event KeyEvent) {
@@ -787,17 +838,17 @@ func deleteObj(obj types.Object) bool {
 				deleteObj(obj)
 			}
 		}
-		dir := p.fullPath()
+		dir := p.dir
 		os.Remove(filepath.Join(dir, "package.flux.go"))
 		os.Remove(filepath.Join(dir, ".DS_Store"))
 		if files, err := ioutil.ReadDir(dir); err != nil || len(files) > 0 || trash.Trash(dir) != nil {
 			return false
 		}
 		delete(pkgObjects, p.importPath)
-		delete(pkgs, p.importPath)
+		forgetPackage(p.importPath)
 		return true
 	}
-	if !fluxObjs[obj] {
+	if !isFluxObj(obj) {
 		return false
 	}
 	if t, ok := obj.(*types.TypeName); ok {
@@ -824,11 +875,12 @@ func deleteObj(obj types.Object) bool {
 			}
 		}
 	}
-	delete(fluxObjs, obj)
+	setFluxObj(obj, false)
+	packageWritten(obj.GetPkg().Path)
 	return true
 }
 
-func (b *browser) Paint() {
+func (b *browser) Paint(cv Canvas) {
 	rect := ZR
 	if b.newObj == nil && len(b.objTexts) > 0 {
 		cur := b.objTexts[b.i]
@@ -837,18 +889,40 @@ func (b *browser) Paint() {
 		rect = RectInParent(b.text)
 		rect.Min.X = 0
 	}
-	SetColor(Color{1, 1, 1, .7})
-	FillRect(rect)
+	cv.SetColor(currentItemColor)
+	cv.FillRect(rect)
 }
 
 type pkgObject struct {
 	types.Object
-	name                        string // the final path element; display name
-	srcDir, importPath, pkgName string
+	name                     string // the final path element, or the module path at the root of a module; display name
+	dir, importPath, pkgName string
+}
+
+// newPkgObject returns the pkgObject for the package in dir, reusing the one already made for importPath.
+func newPkgObject(name, dir, importPath string) *pkgObject {
+	p, ok := pkgObjects[importPath]
+	if !ok {
+		pkgName := path.Base(importPath)
+		if pkg, err := importPkg(importPath, build.AllowBinary); err == nil {
+			pkgName = pkg.Name
+		}
+		p = &pkgObject{nil, name, dir, importPath, pkgName}
+		pkgObjects[importPath] = p
+	}
+	return p
 }
 
-func (p pkgObject) fullPath() string {
-	return filepath.Join(p.srcDir, p.importPath)
+// dirs returns the directories holding the packages below p:  p's own in a module, or else the one at p's import path in each of GOROOT and GOPATH.
+func (p pkgObject) dirs() []string {
+	if mainModule != nil {
+		return []string{p.dir}
+	}
+	dirs := []string{}
+	for _, srcDir := range build.Default.SrcDirs() {
+		dirs = append(dirs, filepath.Join(srcDir, filepath.FromSlash(p.importPath)))
+	}
+	return dirs
 }
 
 func (p pkgObject) GetName() string        { return p.name }
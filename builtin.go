@@ -109,13 +109,13 @@ func newComplexNode() *complexNode {
 
 func (n *complexNode) connectable(t types.Type, dst *port) bool {
 	b, ok := underlying(t).(*types.Basic)
-	return ok && b.Info&types.IsFloat != 0 && assignableToAll(t, n.ins...)
+	return ok && b.Info&types.IsFloat != 0 && assignableToAll(t, n.inputs()...)
 }
 
 func (n *complexNode) connsChanged() {
-	t := untypedToTyped(inputType(n.ins...))
-	n.ins[0].setType(t)
-	n.ins[1].setType(t)
+	t := untypedToTyped(inputType(n.inputs()...))
+	n.inputs()[0].setType(t)
+	n.inputs()[1].setType(t)
 	if t != nil {
 		if underlying(t).(*types.Basic).Kind == types.Float32 {
 			t = types.Typ[types.Complex64]
@@ -123,7 +123,7 @@ func (n *complexNode) connsChanged() {
 			t = types.Typ[types.Complex128]
 		}
 	}
-	n.outs[0].setType(t)
+	n.outputs()[0].setType(t)
 }
 
 type deleteNode struct {
@@ -219,19 +219,19 @@ func (n *makeNode) editType() {
 		if t := *n.typ.typ; t != nil {
 			n.setType(t)
 		} else {
-			n.blk.removeNode(n)
-			SetKeyFocus(n.blk)
+			n.block().removeNode(n)
+			SetKeyFocus(n.block())
 		}
 	})
 }
 
 func (n *makeNode) setType(t types.Type) {
 	n.typ.setType(t)
-	n.outs[0].setType(t)
+	n.outputs()[0].setType(t)
 	if t != nil {
-		n.blk.func_().addPkgRef(t)
+		n.block().func_().addPkgRef(t)
 		if nt, ok := t.(*types.Named); ok {
-			t = nt.UnderlyingT
+			t = nt.Underlying()
 		}
 		n.newInput(newVar("len", types.Typ[types.Int]))
 		if _, ok := t.(*types.Slice); ok {
@@ -263,17 +263,17 @@ func (n *newNode) editType() {
 		if t := *n.typ.typ; t != nil {
 			n.setType(t)
 		} else {
-			n.blk.removeNode(n)
-			SetKeyFocus(n.blk)
+			n.block().removeNode(n)
+			SetKeyFocus(n.block())
 		}
 	})
 }
 
 func (n *newNode) setType(t types.Type) {
 	n.typ.setType(t)
-	n.outs[0].setType(n.outs[0].obj.Type)
+	n.outputs()[0].setType(n.outputs()[0].obj.Type)
 	if t != nil {
-		n.blk.func_().addPkgRef(t)
+		n.block().func_().addPkgRef(t)
 		n.reform()
 		SetKeyFocus(n)
 	}
@@ -11,7 +11,8 @@ import (
 
 type callNode struct {
 	*nodeBase
-	obj types.Object
+	obj   types.Object
+	targs []types.Type // the type arguments of a generic func, once they are all inferred from the inputs; otherwise nil
 }
 
 func newCallNode(obj types.Object, currentPkg *types.Package, godefer string) node {
@@ -83,6 +84,14 @@ func newCallNode(obj types.Object, currentPkg *types.Package, godefer string) no
 }
 
 func (n *callNode) connectable(t types.Type, dst *port) bool {
+	if n.generic() {
+		targs, ok := n.infer(dst, t)
+		sig := n.obj.GetType().(*types.Signature)
+		if !ok || types.Verify(typeParams(sig), targs) >= 0 {
+			return false
+		}
+		return assignable(t, paramType(instantiate(sig, targs), portIndex(ins(n), dst), dst.valView.ellipsis))
+	}
 	f := ins(n)[0]
 	if n.obj == nil && dst == f {
 		_, ok := underlying(t).(*types.Signature)
@@ -95,6 +104,164 @@ func (n *callNode) connectable(t types.Type, dst *port) bool {
 	return assignable(t, dst.obj.Type)
 }
 
+// generic reports whether n calls a generic func, or a method of a generic type.
+func (n *callNode) generic() bool {
+	return n.obj != nil && typeParams(n.obj.GetType().(*types.Signature)) != nil
+}
+
+// sig returns the signature of the called func, instantiated if it is generic and its type arguments are known.
+func (n *callNode) sig() *types.Signature {
+	if n.obj != nil {
+		sig := n.obj.GetType().(*types.Signature)
+		if n.targs != nil {
+			sig = instantiate(sig, n.targs)
+		}
+		return sig
+	}
+	if t := inputType(ins(n)[0]); t != nil {
+		return underlying(t).(*types.Signature)
+	}
+	return nil
+}
+
+// infer infers the type arguments of a generic func from the types of the connected inputs, as if t were connected to dst.
+func (n *callNode) infer(dst *port, t types.Type) ([]types.Type, bool) {
+	sig := n.obj.GetType().(*types.Signature)
+	ins := ins(n)
+	params := make([]types.Type, len(ins))
+	args := make([]types.Type, len(ins))
+	for i, in := range ins {
+		params[i] = paramType(sig, i, in.valView.ellipsis)
+		if in == dst {
+			args[i] = t
+			continue
+		}
+		// not inputType, which asks whether the type is connectable and so would come back here
+		for _, c := range in.conns() {
+			if c.src() != nil {
+				args[i] = c.src().obj.Type
+				break
+			}
+		}
+	}
+	return types.Infer(typeParams(sig), params, args)
+}
+
+// inferTypeArgs infers the type arguments of a generic func from the types of the connected inputs and updates the port types accordingly.
+func (n *callNode) inferTypeArgs() {
+	targs, ok := n.infer(nil, nil)
+	if !ok || types.Verify(typeParams(n.obj.GetType().(*types.Signature)), targs) >= 0 {
+		targs = nil
+	}
+	for _, t := range targs {
+		if t == nil {
+			targs = nil
+			break
+		}
+	}
+	n.setTypeArgs(targs)
+}
+
+func (n *callNode) setTypeArgs(targs []types.Type) {
+	if identicalTypes(n.targs, targs) {
+		return
+	}
+	n.targs = targs
+	sig := n.sig()
+	for i, in := range ins(n) {
+		if t := paramType(sig, i, in.valView.ellipsis); t != nil && !in.bad {
+			in.setType(t)
+		}
+	}
+	for i, out := range outs(n) {
+		if i < len(sig.Results) && !out.bad {
+			out.setType(sig.Results[i].Type)
+		}
+	}
+}
+
+func (n *callNode) newInput(v *types.Var) *port {
+	if n.generic() && v != nil {
+		v = newVar(v.Name, v.Type) // port types change as type arguments are inferred; don't change the signature along with them
+	}
+	p := n.nodeBase.newInput(v)
+	if n.generic() {
+		p.connsChanged = n.inferTypeArgs
+	}
+	return p
+}
+
+func (n *callNode) newOutput(v *types.Var) *port {
+	if n.generic() && v != nil {
+		v = newVar(v.Name, v.Type)
+	}
+	return n.nodeBase.newOutput(v)
+}
+
+// typeParams returns the type parameters of a generic func of type sig or, for a method, of its generic receiver base type.
+func typeParams(sig *types.Signature) []*types.TypeName {
+	if sig.TypeParams != nil {
+		return sig.TypeParams
+	}
+	if sig.Recv != nil {
+		t, _ := indirect(sig.Recv.Type)
+		if t, ok := t.(*types.Named); ok && t.Orig == nil {
+			return t.TypeParams
+		}
+	}
+	return nil
+}
+
+// instantiate returns the signature of the generic func (or method of a generic type) of type sig for the type arguments targs.
+// Type parameters with nil type arguments are left in place.
+func instantiate(sig *types.Signature, targs []types.Type) *types.Signature {
+	s := *sig
+	s.TypeParams = typeParams(sig)
+	return types.Instantiate(&s, targs).(*types.Signature)
+}
+
+// paramType returns the type of the i'th input of a call of a func of type sig, or nil if there is no such parameter.
+func paramType(sig *types.Signature, i int, ellipsis bool) types.Type {
+	if sig.Recv != nil {
+		if i == 0 {
+			return sig.Recv.Type
+		}
+		i--
+	}
+	if n := len(sig.Params); sig.IsVariadic && i >= n-1 {
+		t := sig.Params[n-1].Type
+		if ellipsis {
+			return t
+		}
+		return t.(*types.Slice).Elem
+	}
+	if i < len(sig.Params) {
+		return sig.Params[i].Type
+	}
+	return nil
+}
+
+func identicalTypes(x, y []types.Type) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !types.IsIdentical(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func portIndex(ports []*port, p *port) int {
+	for i, q := range ports {
+		if q == p {
+			return i
+		}
+	}
+	return -1
+}
+
 func (n *callNode) addPorts(sig *types.Signature) {
 	if sig.Recv != nil {
 		n.newInput(sig.Recv)
@@ -155,12 +322,7 @@ func (n *callNode) removePort(p *port) {
 
 // returns index of first variadic port and its var
 func (n *callNode) variadic() (int, *types.Var) {
-	var sig *types.Signature
-	if n.obj != nil {
-		sig = n.obj.GetType().(*types.Signature)
-	} else if t := inputType(ins(n)[0]); t != nil {
-		sig = underlying(t).(*types.Signature)
-	}
+	sig := n.sig()
 	if sig == nil || !sig.IsVariadic {
 		return -1, nil
 	}
@@ -178,16 +340,16 @@ func (n *callNode) variadic() (int, *types.Var) {
 func (n *callNode) ellipsis() bool {
 	i, v := n.variadic()
 	ins := ins(n)
-	return v != nil && i == len(ins)-1 && ins[i].obj == v
+	return v != nil && i == len(ins)-1 && ins[i].valView.ellipsis
 }
 
-func (n *callNode) Paint() {
-	n.nodeBase.Paint()
+func (n *callNode) Paint(cv Canvas) {
+	n.nodeBase.Paint(cv)
 	if n.obj != nil && unknown(n.obj) {
-		SetColor(Color{1, 0, 0, 1})
-		SetLineWidth(3)
+		cv.SetColor(errorColor)
+		cv.SetLineWidth(3)
 		r := RectInParent(n.text)
-		DrawLine(r.Min, r.Max)
-		DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
+		cv.DrawLine(r.Min, r.Max)
+		cv.DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
 	}
 }
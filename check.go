@@ -0,0 +1,256 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/types"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// check implements the "flux check" command, which loads and rewrites the Flux funcs in the given packages without opening a window.
+// Problems are printed to stdout.  The returned exit status is nonzero if there were any.
+func check(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result back to the source file instead of only checking it")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: flux check [-w] [packages]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	status := 0
+	paths, err := matchPackages(patterns)
+	if err != nil {
+		fmt.Println(err)
+		status = 1
+	}
+	for _, path := range paths {
+		pkg, err := getPackage(path)
+		if err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			status = 1
+			continue
+		}
+		for _, obj := range pkgFluxFuncs(pkg) {
+			problems := checkFunc(obj, *write)
+			for _, p := range problems {
+				fmt.Printf("%s: %s\n", fluxPath(obj), p)
+			}
+			if len(problems) > 0 {
+				status = 1
+			}
+		}
+	}
+	return status
+}
+
+// matchPackages returns the import paths of the packages matching patterns.  A pattern is an import path or a directory (absolute or beginning with "." or ".."), optionally ending with "/..." to match all packages below it.
+func matchPackages(patterns []string) (paths []string, err error) {
+	seen := map[string]bool{}
+	add := func(dir string) {
+		p, err := importDir(dir, build.FindOnly)
+		if err != nil || p.ImportPath == "." || seen[p.ImportPath] {
+			return
+		}
+		seen[p.ImportPath] = true
+		paths = append(paths, p.ImportPath)
+	}
+	for _, pattern := range patterns {
+		recursive := strings.HasSuffix(pattern, "/...")
+		pattern = strings.TrimSuffix(pattern, "/...")
+		dir := pattern
+		if !build.IsLocalImport(pattern) && !filepath.IsAbs(pattern) {
+			p, err2 := importPkg(pattern, build.FindOnly)
+			if err2 != nil {
+				err = err2
+				continue
+			}
+			dir = p.Dir
+		} else if abs, err2 := filepath.Abs(dir); err2 == nil { // go/build only finds the import path of an absolute dir
+			dir = abs
+		}
+		if !recursive {
+			if _, err2 := build.ImportDir(dir, 0); err2 != nil {
+				err = err2
+				continue
+			}
+			add(dir)
+			continue
+		}
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return nil
+			}
+			if name := info.Name(); path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil && path != dir {
+				return filepath.SkipDir // another module
+			}
+			if _, err := build.ImportDir(path, 0); err == nil {
+				add(path)
+			}
+			return nil
+		})
+	}
+	return
+}
+
+// pkgFluxFuncs returns pkg's funcs and methods that are stored in Flux files, sorted by file name.
+func pkgFluxFuncs(pkg *types.Package) (objs []types.Object) {
+	pkgsMu.Lock()
+	for obj := range fluxObjs {
+		if _, ok := obj.(*types.Func); ok && obj.GetPkg() == pkg {
+			objs = append(objs, obj)
+		}
+	}
+	pkgsMu.Unlock()
+	sort.Sort(objsByPath(objs))
+	return
+}
+
+type objsByPath []types.Object
+
+func (o objsByPath) Len() int           { return len(o) }
+func (o objsByPath) Less(i, j int) bool { return fluxPath(o[i]) < fluxPath(o[j]) }
+func (o objsByPath) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+
+// checkFunc loads the func obj, reports any problems found in it, and writes it back out, to its file if write is true.
+func checkFunc(obj types.Object, write bool) (problems []string) {
+	path := fluxPath(obj)
+	defer func() {
+		if err := recover(); err != nil {
+			problems = append(problems, fmt.Sprintf("panic: %v", err))
+		}
+	}()
+
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, nil); err != nil {
+		return []string{err.Error()}
+	}
+	problems = checkBlock(f.funcblk)
+
+	buf := &bytes.Buffer{}
+	problems = append(problems, writeFunc(buf, f)...)
+	if _, err := parser.ParseFile(token.NewFileSet(), path, buf.Bytes(), 0); err != nil {
+		problems = append(problems, "writer produced invalid Go: "+err.Error())
+		return
+	}
+	if write {
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0666); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return
+}
+
+func checkBlock(b *block) (problems []string) {
+	b.walk(nil, func(n node) {
+		switch n := n.(type) {
+		case *callNode:
+			if n.obj != nil && unknown(n.obj) {
+				problems = append(problems, "unknown object "+n.obj.GetName())
+			}
+		case *valueNode:
+			if n.obj != nil && unknown(n.obj) {
+				problems = append(problems, "unknown object "+n.obj.GetName())
+			}
+		}
+		for _, p := range append(n.inputs(), n.outputs()...) {
+			if p.bad {
+				problems = append(problems, fmt.Sprintf("bad port %s of %s", describePort(p), describeNode(n)))
+			}
+			if p.obj.Type != seqType {
+				walkType(p.obj.Type, func(t *types.Named) {
+					if unknownType(t) {
+						problems = append(problems, fmt.Sprintf("unknown type %s at port %s of %s", t.Obj.Name, describePort(p), describeNode(n)))
+					}
+				})
+			}
+		}
+	}, func(c *connection) {
+		if c.bad {
+			problems = append(problems, fmt.Sprintf("bad connection from %s of %s to %s of %s", describePort(c.src()), describeNode(c.src().node), describePort(c.dst()), describeNode(c.dst().node)))
+		}
+	})
+	return
+}
+
+// unknownType reports whether t was named in a Flux file but could not be resolved by the reader.
+func unknownType(t *types.Named) bool {
+	return t.UnderlyingT == types.Typ[types.Invalid]
+}
+
+func describeBlock(b *block) string {
+	if b.node.block() == nil {
+		return "func block"
+	}
+	return "block of " + describeNode(b.node)
+}
+
+func describeNode(n node) string {
+	switch n := n.(type) {
+	case *portsNode:
+		if n.out {
+			return "outputs"
+		}
+		return "inputs"
+	case *ifNode:
+		return "if"
+	case *loopNode:
+		return "loop"
+	case *selectNode:
+		return "select"
+	case *switchNode:
+		return "switch"
+	case *typeSwitchNode:
+		return "typeSwitch"
+	case *funcNode:
+		return "func literal"
+	case interface {
+		nodeText() string
+	}:
+		if s := n.nodeText(); s != "" {
+			return s
+		}
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*main.")
+}
+
+func describePort(p *port) string {
+	if p == nil {
+		return "nothing"
+	}
+	if p.obj.Name != "" {
+		return p.obj.Name
+	}
+	ports := ins(p.node)
+	if p.out {
+		ports = outs(p.node)
+	}
+	for i, q := range ports {
+		if q == p {
+			return fmt.Sprintf("#%d", i+1)
+		}
+	}
+	return "seq"
+}
@@ -1,36 +1,285 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"github.com/gordonklaus/flux/go/types"
 	. "github.com/gordonklaus/flux/gui"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 )
 
 var (
-	lineColor  = Color{.5, .5, .5, 1}
-	focusColor = Color{1, 1, 1, .5}
-	noColor    = Color{}
+	backgroundColor           = Color{0, 0, 0, 1}
+	textColor                 = Color{1, 1, 1, 1}
+	textBackgroundColor       = Color{0, 0, 0, 1}
+	textSelectionColor        = Color{.3, .5, 1, .5}
+	lineColor                 = Color{.5, .5, .5, 1}
+	focusColor                = Color{1, 1, 1, .5}
+	highlightColor            = Color{1, 1, 1, .2}
+	selectionColor            = Color{.5, .7, 1, .3}
+	editingColor              = Color{1, .5, 0, .5}
+	errorColor                = Color{1, 0, 0, 1}
+	panelColor                = Color{0, 0, 0, .8}
+	labelColor                = Color{0, 0, 0, .7}
+	currentItemColor          = Color{1, 1, 1, .7}
+	viewportColor             = Color{1, 1, 1, .8}
+	typeFocusColor            = Color{.25, .25, .25, 1}
+	unexportedColor           = Color{.3, .3, .3, 1}
+	unexportedBackgroundColor = Color{0, 0, 0, .3}
+	packageColor              = Color{1, 1, 1, 1}
+	typeColor                 = Color{.6, 1, .6, 1}
+	funcColor                 = Color{1, .6, .6, 1}
+	varColor                  = Color{.6, .6, 1, 1}
+	specialColor              = Color{1, 1, .6, 1}
+	noColor                   = Color{}
+
+	typeColors bool // whether to color each connection by the type it carries
 )
 
 func color(obj types.Object, bright, funcAsVal bool) Color {
-	alpha := .7
-	if bright {
-		alpha = 1
-	}
+	c := Color{}
 	switch obj.(type) {
 	case special:
-		return Color{1, 1, .6, alpha}
+		c = specialColor
 	case *pkgObject:
-		return Color{1, 1, 1, alpha}
+		c = packageColor
 	case *types.TypeName:
-		return Color{.6, 1, .6, alpha}
+		c = typeColor
 	case *types.Func, *types.Builtin:
 		if funcAsVal && obj.GetPkg() != nil { //Pkg==nil == builtin
 			return color(&types.Var{}, bright, funcAsVal)
 		}
-		return Color{1, .6, .6, alpha}
+		c = funcColor
 	case *types.Var, *types.Const, field:
-		return Color{.6, .6, 1, alpha}
+		c = varColor
+	default:
+		panic(fmt.Sprintf("unknown object type %T", obj))
+	}
+	if !bright {
+		c.A *= .7
+	}
+	return c
+}
+
+// connectionColor returns the color of connections carrying values of type t:  the line color, or, when coloring by type, a hue hashed from t, which is the same wherever t appears.
+func connectionColor(t types.Type) Color {
+	if !typeColors || t == nil || t == seqType {
+		return lineColor
+	}
+	h := fnv.New32a()
+	io.WriteString(h, types.TypeString(nil, t))
+	hue := float64(h.Sum32()%360) / 60
+	x := 1 - math.Abs(math.Mod(hue, 2)-1)
+	var r, g, b float64
+	switch int(hue) {
+	case 0:
+		r, g, b = 1, x, 0
+	case 1:
+		r, g, b = x, 1, 0
+	case 2:
+		r, g, b = 0, 1, x
+	case 3:
+		r, g, b = 0, x, 1
+	case 4:
+		r, g, b = x, 0, 1
+	case 5:
+		r, g, b = 1, 0, x
+	}
+	// Mix the hue with the line color, so that it keeps to the brightness of the theme.
+	const k = .7
+	l := lineColor
+	return Color{l.R + k*(r-l.R), l.G + k*(g-l.G), l.B + k*(b-l.B), l.A}
+}
+
+// themeColors lists the colors set by a theme, by the names used in theme files.
+var themeColors = []struct {
+	name string
+	c    *Color
+}{
+	{"background", &backgroundColor},
+	{"text", &textColor},
+	{"textBackground", &textBackgroundColor},
+	{"textSelection", &textSelectionColor},
+	{"line", &lineColor},
+	{"focus", &focusColor},
+	{"highlight", &highlightColor},
+	{"selection", &selectionColor},
+	{"editing", &editingColor},
+	{"error", &errorColor},
+	{"panel", &panelColor},
+	{"label", &labelColor},
+	{"currentItem", &currentItemColor},
+	{"viewport", &viewportColor},
+	{"typeFocus", &typeFocusColor},
+	{"unexported", &unexportedColor},
+	{"unexportedBackground", &unexportedBackgroundColor},
+	{"package", &packageColor},
+	{"type", &typeColor},
+	{"func", &funcColor},
+	{"var", &varColor},
+	{"special", &specialColor},
+}
+
+// themes are the built-in themes, in the format of a theme file.  The dark theme, whose colors are those above, is the default.
+var themes = map[string]string{
+	"light": `
+background = #ffffff
+text = #000000
+textBackground = #ffffff
+textSelection = #4c80ff66
+line = #737373
+focus = #00000066
+highlight = #00000026
+selection = #3366ff4c
+editing = #ff800099
+error = #cc0000
+panel = #ffffffe6
+label = #ffffffcc
+currentItem = #00000026
+viewport = #000000cc
+typeFocus = #d9d9d9
+unexported = #999999
+unexportedBackground = #ffffff4c
+package = #000000
+type = #008000
+func = #b30000
+var = #0000cc
+special = #8c7300
+`,
+	"high-contrast": `
+background = #000000
+text = #ffffff
+textBackground = #000000
+textSelection = #0080ffb3
+line = #ffffff
+focus = #ffff00cc
+highlight = #ffff0059
+selection = #0099ff80
+editing = #ff8000e6
+error = #ff0000
+panel = #000000
+label = #000000
+currentItem = #ffff0080
+viewport = #ffff00
+typeFocus = #595959
+unexported = #b3b3b3
+unexportedBackground = #000000
+package = #ffffff
+type = #4dff4d
+func = #ff6666
+var = #80b3ff
+special = #ffff00
+`,
+}
+
+func init() {
+	buf := &bytes.Buffer{}
+	writeTheme(buf)
+	themes["dark"] = buf.String()
+}
+
+// loadTheme sets the colors from the built-in theme with the given name or, failing that, from the theme file at that path, starting from the dark theme.
+func loadTheme(name string) error {
+	if err := readTheme(strings.NewReader(themes["dark"])); err != nil {
+		return err
+	}
+	if t, ok := themes[name]; ok {
+		return readTheme(strings.NewReader(t))
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := readTheme(f); err != nil {
+		return fmt.Errorf("%s:%v", name, err)
+	}
+	return nil
+}
+
+// readTheme sets the colors listed in r, one per line in the form "name = #rrggbb" or "name = #rrggbbaa" as written by writeTheme.  Blank lines and lines starting with # are ignored.
+func readTheme(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return fmt.Errorf("%d: want \"name = #rrggbb\"", line)
+		}
+		name, value := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+		c := findThemeColor(name)
+		if c == nil {
+			return fmt.Errorf("%d: unknown color %s", line, name)
+		}
+		col, ok := parseColor(value)
+		if !ok {
+			return fmt.Errorf("%d: bad color %q", line, value)
+		}
+		*c = col
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	SetDefaultTextColors(textColor, textBackgroundColor, textSelectionColor)
+	return nil
+}
+
+func findThemeColor(name string) *Color {
+	for _, c := range themeColors {
+		if c.name == name {
+			return c.c
+		}
+	}
+	return nil
+}
+
+// writeTheme lists the current colors in the form read by readTheme.
+func writeTheme(w io.Writer) {
+	for _, c := range themeColors {
+		fmt.Fprintf(w, "%s = %s\n", c.name, hexColor(*c.c))
+	}
+}
+
+// parseColor parses a color written as "#rrggbb" or "#rrggbbaa".
+func parseColor(s string) (Color, bool) {
+	if len(s) != 7 && len(s) != 9 || s[0] != '#' {
+		return Color{}, false
+	}
+	x, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	if len(s) == 7 {
+		x = x<<8 | 0xff
+	}
+	return Color{float64(x>>24) / 255, float64(x>>16&0xff) / 255, float64(x>>8&0xff) / 255, float64(x&0xff) / 255}, true
+}
+
+func hexColor(c Color) string {
+	b := func(x float64) uint8 { return uint8(math.Max(0, math.Min(255, x*255+.5))) }
+	if c.A == 1 {
+		return fmt.Sprintf("#%02x%02x%02x", b(c.R), b(c.G), b(c.B))
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", b(c.R), b(c.G), b(c.B), b(c.A))
+}
+
+// themeCmd prints the colors of the named theme, or of the dark theme, for use as a starting point for a theme file.
+func themeCmd(args []string) int {
+	if len(args) > 0 {
+		if err := loadTheme(args[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
 	}
-	panic(fmt.Sprintf("unknown object type %T", obj))
+	writeTheme(os.Stdout)
+	return 0
 }
@@ -0,0 +1,76 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/types"
+	"strings"
+	"testing"
+)
+
+// TestTheme loads each built-in theme, writes it and reads it back, and checks that mistakes in a theme file are reported.
+func TestTheme(t *testing.T) {
+	defer loadTheme("dark")
+
+	for name := range themes {
+		if err := loadTheme(name); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		buf := &bytes.Buffer{}
+		writeTheme(buf)
+		want := buf.String()
+		if err := readTheme(strings.NewReader(want)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		buf.Reset()
+		writeTheme(buf)
+		if got := buf.String(); got != want {
+			t.Errorf("%s: read back as\n%s\nwant\n%s", name, got, want)
+		}
+	}
+
+	loadTheme("light")
+	if hexColor(backgroundColor) != "#ffffff" || hexColor(textColor) != "#000000" {
+		t.Errorf("light theme has background %s and text %s", hexColor(backgroundColor), hexColor(textColor))
+	}
+
+	for _, test := range []struct{ theme, err string }{
+		{"line = #123456", ""},
+		{"# comment\n\nfocus = #12345678", ""},
+		{"line = #12345", "1: bad color \"#12345\""},
+		{"line = 123456", "1: bad color \"123456\""},
+		{"\nlines = #123456", "2: unknown color lines"},
+		{"line #123456", "1: want \"name = #rrggbb\""},
+	} {
+		err := readTheme(strings.NewReader(test.theme))
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("reading %q: got error %v, want %q", test.theme, err, test.err)
+		}
+	}
+}
+
+// TestConnectionColor checks that connections are colored by type only when asked to, and that the same type always gets the same color.
+func TestConnectionColor(t *testing.T) {
+	defer func() { typeColors = false }()
+
+	str := types.Typ[types.String]
+	if c := connectionColor(str); c != lineColor {
+		t.Errorf("without type colors, a string connection is %v, want %v", c, lineColor)
+	}
+	typeColors = true
+	if c := connectionColor(str); c == lineColor {
+		t.Errorf("with type colors, a string connection has the line color")
+	}
+	if c := connectionColor(seqType); c != lineColor {
+		t.Errorf("with type colors, a sequencing connection is %v, want %v", c, lineColor)
+	}
+	if c1, c2 := connectionColor(&types.Slice{Elem: str}), connectionColor(&types.Slice{Elem: str}); c1 != c2 {
+		t.Errorf("two []string connections are %v and %v", c1, c2)
+	}
+	if c1, c2 := connectionColor(str), connectionColor(types.Typ[types.Int]); c1 == c2 {
+		t.Errorf("string and int connections are both %v", c1)
+	}
+}
@@ -6,8 +6,8 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
-	. "github.com/chsc/gogl/gl21"
 	"math"
 	"sort"
 	"strings"
@@ -16,133 +16,33 @@ import (
 type connection struct {
 	*ViewBase
 	AggregateMouser
-	blk      *block
-	src      *port
-	dst      *port
-	feedback bool
+	model *graph.Connection
 
 	focused, focusSrc bool
 	srcPt             Point
 	dstPt             Point
 	hidden            bool
 
-	savedPort *port
-	editing   bool
+	savedPort   *port
+	editing     bool
+	highlighted []*port // the ports to which the end being edited can be moved
 
 	bad, wasBad bool
 }
 
 func newConnection() *connection {
 	c := &connection{}
+	c.model = &graph.Connection{Data: c}
 	c.ViewBase = NewView(c)
 	c.AggregateMouser = AggregateMouser{NewClickFocuser(c)}
 	return c
 }
 
 func (c *connection) connectable(src, dst *port) bool {
-	// Quietly disconnect c for the duration of this call to more accurately simulate the proposed scenario in which
-	// it has the new src and dst.  In particular, assignableToAll must ignore the current state of this connection when
-	// reconnecting a generic input.
-	p, q := c.src, c.dst
-	c.src, c.dst = nil, nil
-	defer func() { c.src, c.dst = p, q }()
-
-	if src.out == dst.out {
-		return false
-	}
-	for _, c := range src.conns {
-		if c.dst == dst {
-			return false
-		}
-	}
-
-	t := src.obj.Type
-	u := dst.obj.Type
-	if t == nil {
-		return false
-	}
-	if (t == seqType) != (u == seqType) {
-		return false
-	}
-	if t == seqType {
-		return src.node.block() == dst.node.block() && src.node != dst.node && !precedes(src.node, dst.node) && !precedes(dst.node, src.node)
-	}
-
-	f := func(t types.Type) bool { return assignable(t, u) }
-	if n, ok := dst.node.(connectable); ok {
-		f = func(t types.Type) bool { return n.connectable(t, dst) && assignableToAll(t, dst) }
-	}
-	if !maybeIndirect(t, f) {
-		return false
-	}
-
-	// TODO: recursive func literals
-	// if f, ok := src.node.(*funcNode); ok && parentOrSelfInBlock(dst.node, f.funcblk) != nil {
-	// 	return true
-	// }
-
-	n1, n2 := src.node, dst.node
-	for b := n1.block(); ; n1, b = b.node, b.outer() {
-		if n := b.find(n2); n != nil {
-			n2 = n
-			break
-		}
-	}
-	if c.feedback {
-		for b := n1.block(); ; b = b.outer() {
-			if b == nil {
-				return false
-			}
-			if _, ok := b.node.(*loopNode); ok {
-				break
-			}
-		}
-		n1, n2 = n2, n1
-	} else if n1 == n2 {
-		return false
-	}
-	return !precedes(n2, n1)
+	return c.model.Connectable(src.model, dst.model)
 }
 
-func precedes(n1, n2 node) bool {
-	for _, dst := range dstsInBlock(n1) {
-		if dst == n2 || precedes(dst, n2) {
-			return true
-		}
-	}
-	return false
-}
-
-func assignable(t, u types.Type) bool {
-	if t == nil || u == nil {
-		return false
-	}
-	if t, ok := t.(*types.Basic); ok && t.Info&types.IsUntyped != 0 {
-		// TODO: consider representability of const values
-		switch u := underlying(u).(type) {
-		case *types.Interface:
-			return u.Empty()
-		case *types.Basic:
-			int := t.Info&types.IsInteger != 0
-			float := t.Info&types.IsFloat != 0
-			complex := t.Info&types.IsComplex != 0
-			switch {
-			case u.Info&types.IsBoolean != 0:
-				return t.Info&types.IsBoolean != 0
-			case u.Info&types.IsInteger != 0:
-				return int
-			case u.Info&types.IsFloat != 0:
-				return int || float
-			case u.Info&types.IsComplex != 0:
-				return int || float || complex
-			case u.Info&types.IsString != 0:
-				return t.Info&types.IsString != 0
-			}
-		}
-		return false
-	}
-	return types.IsAssignableTo(t, u)
-}
+func assignable(t, u types.Type) bool { return graph.Assignable(t, u) }
 
 type connectable interface {
 	connectable(t types.Type, dst *port) bool
@@ -151,140 +51,104 @@ type connectable interface {
 // Returns true if t is assignable to or from all of the source types (or their indirections).
 // Does not indirect t as connectable (via which this is meant to be called) already does so.
 func assignableToAll(t types.Type, ins ...*port) bool {
-	for _, p := range ins {
-		for _, c := range p.conns {
-			if c.src != nil {
-				if !maybeIndirect(c.src.obj.Type, func(t2 types.Type) bool { return assignable(t, t2) || assignable(t2, t) }) {
-					return false
-				}
-			}
-		}
-	}
-	return true
+	return graph.AssignableToAll(t, portModels(ins)...)
 }
 
 // Returns one of the source types (or its indirection) to which all of the others (or their indirections) are assignable.
-func inputType(ins ...*port) (T types.Type) {
-	assign := func(t, u types.Type) bool {
-		if T == nil || assignable(t, u) {
-			T = u
-			return true
-		}
-		return false
-	}
-	for _, p := range ins {
-		for _, c := range p.conns {
-			if c.src != nil {
-				maybeIndirect(T, func(t types.Type) bool {
-					return maybeIndirect(c.src.obj.Type, func(u types.Type) bool {
-						return assign(t, u) || assign(u, t)
-					})
-				})
-			}
-		}
-	}
+func inputType(ins ...*port) types.Type {
+	return graph.InputType(portModels(ins)...)
+}
 
-	if T != nil && !ins[0].node.(connectable).connectable(T, ins[0]) {
-		T, _ = indirect(T)
+func portModels(ports []*port) (models []*graph.Port) {
+	for _, p := range ports {
+		models = append(models, p.model)
 	}
 	return
 }
 
-func maybeIndirect(t types.Type, f func(t types.Type) bool) bool {
-	p, ok := indirect(t)
-	return f(t) || ok && f(p)
-}
+func (c connection) block() *block { return blockView(c.model.Block) }
+func (c connection) src() *port    { return portView(c.model.Src) }
+func (c connection) dst() *port    { return portView(c.model.Dst) }
 
-func (c connection) connected() bool { return c.src != nil && c.dst != nil }
+func (c connection) connected() bool { return c.src() != nil && c.dst() != nil }
 func (c *connection) disconnect() {
 	c.setSrc(nil)
 	c.setDst(nil)
 }
 
 func (c *connection) setSrc(src *port) {
+	c.edited(src)
+	old := c.src()
 	txt := ""
-	if c.src != nil {
-		c.src.disconnect(c)
-		txt = c.src.conntxt.Text()
-		c.updateSrcTxt("")
+	if old != nil {
+		txt = old.conntxt.Text()
 	}
-	if c.bad && src != nil && c.connectable(src, c.dst) {
+	if c.bad && src != nil && c.connectable(src, c.dst()) {
 		c.bad = false
 	}
-	c.src = src
-	c.reblock()
+	var m *graph.Port
 	if src != nil {
-		src.connect(c)
-		c.updateSrcTxt(txt)
+		m = src.model
+	}
+	c.model.SetSrc(m)
+	if old != nil {
+		old.updateSrcTxt("")
 	}
-	if c.dst != nil {
-		c.dst.connsChanged()
+	if src != nil {
+		src.updateSrcTxt(txt)
+	}
+	if c.dst() != nil {
+		c.dst().connsChanged()
 	}
 	c.reform()
 }
 
 func (c *connection) setDst(dst *port) {
-	if c.dst != nil {
-		c.dst.disconnect(c)
-		c.dst.connsChanged()
-		c.updateDstTxt()
-	}
-	if c.bad && dst != nil && c.connectable(c.src, dst) {
+	c.edited(dst)
+	old := c.dst()
+	if c.bad && dst != nil && c.connectable(c.src(), dst) {
 		c.bad = false
 	}
-	c.dst = dst
-	c.reblock()
+	var m *graph.Port
 	if dst != nil {
-		dst.connect(c)
-		c.dst.connsChanged()
-		c.updateDstTxt()
+		m = dst.model
+	}
+	c.model.SetDst(m)
+	if old != nil {
+		old.connsChanged()
+		old.updateDstTxt()
+	}
+	if dst != nil {
+		dst.connsChanged()
+		dst.updateDstTxt()
 	}
 	c.reform()
 }
 
-func (c *connection) reblock() {
-	var newblk *block
-	switch {
-	case c.src == nil && c.dst == nil:
-		return
-	case c.src == nil:
-		newblk = c.dst.node.block()
-	case c.dst == nil:
-		newblk = c.src.node.block()
-	default:
-		for b := c.src.node.block(); ; b = b.outer() {
-			if b.find(c.dst.node) != nil {
-				newblk = b
-				break
-			}
-		}
-		if c.feedback {
-			for {
-				n := newblk.node
-				newblk = n.block()
-				if _, ok := n.(*loopNode); ok {
-					break
-				}
-			}
+// edited records the state of c's func before a change to c, which is being connected to p (or nil).
+func (c *connection) edited(p *port) {
+	for _, p := range []*port{p, c.src(), c.dst()} {
+		if p != nil {
+			edited(p.node)
+			return
 		}
 	}
-	newblk.addConn(c)
 }
 
 func (c *connection) reform() {
 	unconnectedOffset := Pt(0, -32)
-	if c.feedback {
+	if c.model.Feedback {
 		unconnectedOffset.Y = 96
 	}
-	if c.src != nil {
-		c.srcPt = Map(Center(c.src), c.src, c.blk)
+	if c.src() != nil {
+		c.srcPt = Map(Center(c.src()), c.src(), c.block())
 	}
-	if c.dst != nil {
-		c.dstPt = Map(Center(c.dst), c.dst, c.blk)
+	if c.dst() != nil {
+		c.dstPt = Map(Center(c.dst()), c.dst(), c.block())
 	} else {
 		c.dstPt = c.srcPt.Add(unconnectedOffset)
 	}
-	if c.src == nil {
+	if c.src() == nil {
 		c.srcPt = c.dstPt.Sub(unconnectedOffset)
 	}
 
@@ -317,15 +181,47 @@ func (c *connection) startEditing() {
 		if c.hidden {
 			return // TODO: alternately, edit all hidden connections simultaneously?
 		}
-		c.savedPort = c.src
+		c.savedPort = c.src()
 	} else {
-		c.savedPort = c.dst
+		c.savedPort = c.dst()
 	}
 	c.wasBad = c.bad
+	c.edited(nil)
 	c.editing = true
+	c.highlighted = c.candidates()
+	for _, p := range c.highlighted {
+		p.setHighlighted(true)
+	}
 	c.reform()
 }
 
+// candidates returns the ports to which the focused end of c can be moved, including the one it is connected to.
+func (c *connection) candidates() (ports []*port) {
+	p1 := c.src()
+	if c.focusSrc {
+		p1 = c.dst()
+	}
+	if p1 == nil {
+		return nil
+	}
+	for _, n := range c.block().outermost().allNodes() {
+		p := n.inputs()
+		if c.focusSrc {
+			p = n.outputs()
+		}
+		for _, p2 := range p {
+			src, dst := p1, p2
+			if c.focusSrc {
+				src, dst = dst, src
+			}
+			if src == c.src() && dst == c.dst() || c.connectable(src, dst) {
+				ports = append(ports, p2)
+			}
+		}
+	}
+	return
+}
+
 func (c *connection) cancelEditing() {
 	if c.editing {
 		if c.focusSrc {
@@ -341,14 +237,18 @@ func (c *connection) cancelEditing() {
 func (c *connection) stopEditing() {
 	if c.editing {
 		c.editing = false
+		for _, p := range c.highlighted {
+			p.setHighlighted(false)
+		}
+		c.highlighted = nil
 		if c.connected() {
 			c.reform()
 		} else {
-			p := c.src
+			p := c.src()
 			if c.focusSrc {
-				p = c.dst
+				p = c.dst()
 			}
-			c.blk.removeConn(c)
+			c.block().removeConn(c)
 			SetKeyFocus(p)
 		}
 	}
@@ -356,21 +256,21 @@ func (c *connection) stopEditing() {
 
 func (c *connection) toggleHidden() {
 	c.hidden = !c.hidden
-	rearrange(c.blk)
+	rearrange(c.block())
 	if c.hidden {
-		if srctxt := c.src.conntxt; srctxt.Text() == "" {
+		if srctxt := c.src().conntxt; srctxt.Text() == "" {
 			srctxt.TextChanged = func(string) {
-				for _, c := range c.src.conns {
+				for _, c := range c.src().conns() {
 					if c.hidden {
-						c.updateDstTxt()
+						c.dst().updateDstTxt()
 					}
 				}
 			}
 			srctxt.Accept = func(name string) {
 				names := map[string]bool{"": true}
-				c.blk.outermost().walk(nil, nil, func(conn *connection) {
-					if conn.src != c.src {
-						names[conn.src.conntxt.Text()] = true
+				c.block().outermost().walk(nil, nil, func(conn *connection) {
+					if conn.src() != c.src() {
+						names[conn.src().conntxt.Text()] = true
 					}
 				})
 				if names[name] {
@@ -388,34 +288,36 @@ func (c *connection) toggleHidden() {
 			SetKeyFocus(srctxt)
 		}
 	}
-	c.updateSrcTxt("")
-	c.updateDstTxt()
+	c.src().updateSrcTxt("")
+	c.dst().updateDstTxt()
 }
 
-func (c *connection) updateSrcTxt(txt string) {
+// updateSrcTxt shows, at the output p, the name of its hidden connections, if any; txt names a new source's connections unless they already have a name.
+func (p *port) updateSrcTxt(txt string) {
 	anyHidden := false
-	for _, c := range c.src.conns {
+	for _, c := range p.conns() {
 		if c.hidden {
 			anyHidden = true
 		}
 	}
-	srctxt := c.src.conntxt
+	srctxt := p.conntxt
 	if !anyHidden {
 		srctxt.SetText("")
-	} else if txt != "" {
+	} else if txt != "" && srctxt.Text() == "" { // don't rename the existing named connections of a new source
 		srctxt.SetText(txt)
 	}
 	srctxt.Move(Pt(-Width(srctxt)/2, -Height(srctxt)))
 }
 
-func (c *connection) updateDstTxt() {
-	if c.dst == nil {
+// updateDstTxt shows, at the input p, the names of its hidden connections.
+func (p *port) updateDstTxt() {
+	if p == nil {
 		return
 	}
 	nameset := map[string]bool{}
-	for _, c := range c.dst.conns {
+	for _, c := range p.conns() {
 		if c.hidden {
-			nameset[c.src.conntxt.Text()] = true
+			nameset[c.src().conntxt.Text()] = true
 		}
 	}
 	names := []string{}
@@ -423,7 +325,7 @@ func (c *connection) updateDstTxt() {
 		names = append(names, n)
 	}
 	sort.StringSlice(names).Sort()
-	dsttxt := c.dst.conntxt
+	dsttxt := p.conntxt
 	dsttxt.SetText(strings.Join(names, ","))
 	dsttxt.Move(Pt(-Width(dsttxt)/2, 0))
 }
@@ -443,30 +345,16 @@ func (c *connection) KeyPress(event KeyEvent) {
 	if c.editing {
 		switch event.Key {
 		case KeyBackslash:
-			if c.src == nil || c.dst == nil {
-				c.feedback = !c.feedback
+			if c.src() == nil || c.dst() == nil {
+				c.model.Feedback = !c.model.Feedback
 				c.reform()
 			}
 		case KeyLeft, KeyRight, KeyDown, KeyUp:
-			b := c.blk.outermost()
+			b := c.block().outermost()
 			ports := []View{}
-			p1 := c.src
-			if c.focusSrc {
-				p1 = c.dst
-			}
-			for _, n := range b.allNodes() {
-				p := n.inputs()
-				if c.focusSrc {
-					p = n.outputs()
-				}
-				for _, p2 := range p {
-					src, dst := p1, p2
-					if c.focusSrc {
-						src, dst = dst, src
-					}
-					if (src != c.src || dst != c.dst) && c.connectable(src, dst) {
-						ports = append(ports, p2)
-					}
+			for _, p := range c.candidates() {
+				if p != c.src() && p != c.dst() {
+					ports = append(ports, p)
 				}
 			}
 
@@ -495,11 +383,11 @@ func (c *connection) KeyPress(event KeyEvent) {
 	if event.Alt {
 		switch event.Key {
 		case KeyLeft, KeyRight, KeyDown, KeyUp:
-			p := c.dst
+			p := c.dst()
 			if c.focusSrc {
-				p = c.src
+				p = c.src()
 			}
-			c.blk.focusNearestView(p, event.Key)
+			c.block().focusNearestView(p, event.Key)
 			return
 		}
 	}
@@ -507,7 +395,7 @@ func (c *connection) KeyPress(event KeyEvent) {
 	switch event.Key {
 	case KeyUp:
 		if c.focusSrc {
-			SetKeyFocus(c.src)
+			SetKeyFocus(c.src())
 		} else {
 			c.focus(true)
 		}
@@ -515,31 +403,32 @@ func (c *connection) KeyPress(event KeyEvent) {
 		if c.focusSrc {
 			c.focus(false)
 		} else {
-			SetKeyFocus(c.dst)
+			SetKeyFocus(c.dst())
 		}
 	case KeyRight, KeyLeft:
-		p := c.dst
+		p := c.dst()
 		if c.focusSrc {
-			p = c.src
+			p = c.src()
 		}
 		p.focusNextConn(c.dstPt.Sub(c.srcPt).Angle(), event.Key)
 	case KeyBackspace:
-		SetKeyFocus(c.src)
-		c.blk.removeConn(c)
+		SetKeyFocus(c.src())
+		c.block().removeConn(c)
 	case KeyDelete:
-		SetKeyFocus(c.dst)
-		c.blk.removeConn(c)
+		SetKeyFocus(c.dst())
+		c.block().removeConn(c)
 	case KeyEnter:
 		c.startEditing()
 	case KeyEscape:
 		if c.focusSrc {
-			SetKeyFocus(c.src)
+			SetKeyFocus(c.src())
 		} else {
-			SetKeyFocus(c.dst)
+			SetKeyFocus(c.dst())
 		}
 	default:
 		if event.Text == "_" {
-			if c.src.obj.Type != seqType {
+			if c.src().obj.Type != seqType {
+				c.edited(nil)
 				c.toggleHidden()
 			}
 		} else {
@@ -549,6 +438,13 @@ func (c *connection) KeyPress(event KeyEvent) {
 }
 
 func (c *connection) Mouse(m MouseEvent) {
+	if m.Button == MouseButtonRight {
+		if m.Press && !c.editing {
+			c.focus(m.Pos.Sub(c.srcPt).Len() < m.Pos.Sub(c.dstPt).Len())
+			openContextMenu(c, m.Pos)
+		}
+		return
+	}
 	if m.Press {
 		if m.Pos.Sub(c.srcPt).Len() < 2*portSize {
 			c.focus(true)
@@ -562,19 +458,11 @@ func (c *connection) Mouse(m MouseEvent) {
 		return
 	}
 
-	p1 := c.src
-	if c.focusSrc {
-		p1 = c.dst
-	}
 	var p *port
-	b := c.blk.outermost()
-	if p2, ok := ViewAt(b, Map(m.Pos, c, b)).(*port); ok {
-		src, dst := p1, p2
-		if c.focusSrc {
-			src, dst = dst, src
-		}
-		if src == c.src && dst == c.dst || c.connectable(src, dst) {
+	for _, p2 := range c.highlighted {
+		if Map(m.Pos, c, p2).In(Rect(p2)) {
 			p = p2
+			break
 		}
 	}
 	if c.focusSrc {
@@ -590,11 +478,11 @@ func (c *connection) Mouse(m MouseEvent) {
 	}
 }
 
-func (c *connection) Paint() {
+func (c *connection) Paint(cv Canvas) {
 	start, end := c.srcPt, c.dstPt
 	d := end.Sub(start)
 	mid := start.Add(d.Div(2))
-	if c.feedback {
+	if c.model.Feedback {
 		mid.X = math.Max(start.X, end.X) + 128
 	}
 	off := Pt(0, math.Abs(d.Y/3))
@@ -603,46 +491,46 @@ func (c *connection) Paint() {
 	p3 := end.Add(off)
 	pts := []Point{start, p1, p2, p3, end}
 
-	SetColor(lineColor)
-	SetLineWidth(3)
-	if c.src != nil && c.src.obj.Type == seqType || c.dst != nil && c.dst.obj.Type == seqType {
+	if c.src() != nil {
+		cv.SetColor(connectionColor(c.src().obj.Type))
+	} else {
+		cv.SetColor(connectionColor(c.dst().obj.Type))
+	}
+	cv.SetLineWidth(3)
+	if c.src() != nil && c.src().obj.Type == seqType || c.dst() != nil && c.dst().obj.Type == seqType {
 		n := d.Len() / 3
 		d = d.Div(n)
 		p := start
 		for i := 0; i < int(n+.5); i += 2 {
 			q := p.Add(d)
-			DrawLine(p, q)
+			cv.DrawLine(p, q)
 			p = q.Add(d)
 		}
 		pts = []Point{start, end}
 	} else if !c.hidden {
-		DrawBezier(pts...)
+		cv.DrawBezier(pts...)
 	}
 
 	if c.focused {
 		c2 := focusColor
 		if c.editing {
-			c2 = Color{1, .5, 0, .5}
+			c2 = editingColor
 		}
 		c1 := c2
 		c1.A = 0
 		if c.focusSrc {
 			c1, c2 = c2, c1
 		}
-		ctrlPts := []Double{Double(c1.R), Double(c1.G), Double(c1.B), Double(c1.A), Double(c2.R), Double(c2.G), Double(c2.B), Double(c2.A)}
-		Map1d(MAP1_COLOR_4, 0, 1, 4, 2, &ctrlPts[0])
-		Enable(MAP1_COLOR_4)
-		SetLineWidth(7)
-		DrawBezier(pts...)
-		Disable(MAP1_COLOR_4)
+		cv.SetLineWidth(7)
+		cv.DrawGradientBezier(c1, c2, pts...)
 	}
 	if c.bad {
-		SetColor(Color{1, 0, 0, 1})
-		SetLineWidth(3)
+		cv.SetColor(errorColor)
+		cv.SetLineWidth(3)
 		p := Center(c)
 		d := Pt(6, 6)
-		DrawLine(p.Add(d), p.Sub(d))
+		cv.DrawLine(p.Add(d), p.Sub(d))
 		d = Pt(6, -6)
-		DrawLine(p.Add(d), p.Sub(d))
+		cv.DrawLine(p.Add(d), p.Sub(d))
 	}
 }
@@ -38,8 +38,8 @@ func (n *convertNode) editType() {
 		if t := *n.typ.typ; t != nil {
 			n.setType(t)
 		} else {
-			n.blk.removeNode(n)
-			SetKeyFocus(n.blk)
+			n.block().removeNode(n)
+			SetKeyFocus(n.block())
 		}
 	})
 }
@@ -47,7 +47,7 @@ func (n *convertNode) editType() {
 func (n *convertNode) setType(t types.Type) {
 	n.typ.setType(t)
 	if t != nil {
-		n.blk.func_().addPkgRef(t)
+		n.block().func_().addPkgRef(t)
 		n.reform()
 		SetKeyFocus(n)
 	}
@@ -0,0 +1,314 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"fmt"
+	"go/token"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// speak conveys a description of the focused element to the user, for example through a screen reader.  It is nil unless descriptions are turned on.
+var speak func(text string)
+
+var lastSpoken string
+
+// newSpeaker returns a speak func that runs the command cmd with each description as its last argument, interrupting the one before, or that prints the descriptions to standard output if cmd is "stdout" or can't be run.
+func newSpeaker(cmd string) func(string) {
+	args := strings.Fields(cmd)
+	if len(args) == 0 || args[0] == "stdout" {
+		return func(text string) { fmt.Println(text) }
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return newSpeaker("stdout")
+	}
+	var running *exec.Cmd
+	return func(text string) {
+		if running != nil && running.Process != nil {
+			running.Process.Kill()
+		}
+		running = exec.Command(args[0], append(args[1:], text)...)
+		if err := running.Start(); err != nil {
+			fmt.Println(text)
+			return
+		}
+		go running.Wait()
+	}
+}
+
+// announce speaks the description of v, unless it is the one last spoken.
+func announce(v View) {
+	if speak == nil {
+		return
+	}
+	if s := describe(v); s != "" && s != lastSpoken {
+		lastSpoken = s
+		speak(s)
+	}
+}
+
+// announceAgain speaks the description of v even if it was the one last spoken, printing it if descriptions are turned off.
+func announceAgain(v View) {
+	if speak == nil {
+		fmt.Println(describe(v))
+		return
+	}
+	lastSpoken = ""
+	announce(v)
+}
+
+// describe returns a description of v in words, for those who can't see it.
+func describe(v View) string {
+	switch v := v.(type) {
+	case *port:
+		return describePortFully(v)
+	case *connection:
+		s := fmt.Sprintf("connection from %s to %s", describeEnd(v.src()), describeEnd(v.dst()))
+		if v.src() != nil && v.src().obj.Type != seqType {
+			s += ", " + typeString(v, v.src().obj.Type)
+		}
+		return s
+	case *block:
+		if n, ok := v.node.(*ifNode); ok {
+			for i, b := range n.blocks {
+				if b == v {
+					return fmt.Sprintf("block %d of %d of if, %s", i+1, len(n.blocks), countNodes(v))
+				}
+			}
+		}
+		return describeNodeFully(v.node)
+	case node:
+		return describeNodeFully(v)
+	case *browser:
+		return describeBrowser(v)
+	case *typeView:
+		return "type " + typeString(v, *v.typ)
+	case *contextMenu:
+		if len(v.cmds) == 0 {
+			return "no commands"
+		}
+		b := v.cmds[v.i]
+		return fmt.Sprintf("%s, %s, %d of %d", b.name, keyName(b.key), v.i+1, len(v.cmds))
+	case *Text:
+		return "editing " + strconv.Quote(v.Text())
+	}
+	return ""
+}
+
+// speakNode returns a description of n, naming what it does rather than how it is drawn.
+func speakNode(n node) string {
+	switch n := n.(type) {
+	case *callNode:
+		s := n.godefer + "call"
+		if n.obj != nil {
+			s += " " + qualifiedName(n, n.obj)
+		}
+		return s
+	case *basicLiteralNode:
+		switch s := n.text.Text(); n.kind {
+		case token.STRING:
+			return "literal " + strconv.Quote(s)
+		case token.CHAR:
+			r := []rune(s)
+			if len(r) == 1 {
+				return "literal " + strconv.QuoteRune(r[0])
+			}
+		default:
+			return "literal " + s
+		}
+	case *valueNode:
+		if n.obj != nil {
+			if n.set {
+				return "set " + qualifiedName(n, n.obj)
+			}
+			return "value " + qualifiedName(n, n.obj)
+		}
+		if n.set {
+			return "assign"
+		}
+		return "indirect"
+	case *portsNode:
+		return describeNode(n) + " of " + speakNode(n.block().node)
+	case *loopNode:
+		if t := n.input.obj.Type; t != nil {
+			return "loop over " + typeString(n, t)
+		}
+		return "loop"
+	case *funcNode:
+		if !n.literal {
+			return "func " + n.obj.GetName()
+		}
+	}
+	return describeNode(n)
+}
+
+// describeNodeFully describes n and, if it has blocks, what is in them.
+func describeNodeFully(n node) string {
+	switch n := n.(type) {
+	case *loopNode:
+		return speakNode(n) + ", " + countNodes(n.loopblk)
+	case *ifNode:
+		return fmt.Sprintf("if, %d blocks", len(n.blocks))
+	case *funcNode:
+		return speakNode(n) + ", " + countNodes(n.funcblk)
+	}
+	return speakNode(n)
+}
+
+// countNodes returns the number of nodes in b, in words.
+func countNodes(b *block) string {
+	n := 0
+	for _, x := range b.nodes() {
+		if _, ok := x.(*portsNode); !ok {
+			n++
+		}
+	}
+	if n == 1 {
+		return "1 node"
+	}
+	return fmt.Sprintf("%d nodes", n)
+}
+
+// describePortFully describes p by its place on its node, its name and type, and what it is connected to.
+func describePortFully(p *port) string {
+	s := speakNode(p.node) + ", " + speakPort(p)
+	if p.obj.Type != seqType {
+		if p.obj.Name != "" {
+			s += ": " + p.obj.Name + " " + typeString(p, p.obj.Type)
+		} else {
+			s += ": " + typeString(p, p.obj.Type)
+		}
+	}
+	if len(p.conns()) == 0 {
+		return s + ", not connected"
+	}
+	ends := []string{}
+	for _, c := range p.conns() {
+		if p.out {
+			ends = append(ends, describeEnd(c.dst()))
+		} else {
+			ends = append(ends, describeEnd(c.src()))
+		}
+	}
+	if p.out {
+		return s + ", connected to " + strings.Join(ends, " and ")
+	}
+	return s + ", connected from " + strings.Join(ends, " and ")
+}
+
+// speakPort returns the place of p among the ports on its side of its node, as in "input 2 of 3".
+func speakPort(p *port) string {
+	kind, ports := "input", ins(p.node)
+	if p.out {
+		kind, ports = "output", outs(p.node)
+	}
+	if p.obj.Type == seqType {
+		return "sequencing " + kind
+	}
+	for i, q := range ports {
+		if q == p {
+			return fmt.Sprintf("%s %d of %d", kind, i+1, len(ports))
+		}
+	}
+	return kind
+}
+
+// describeEnd describes the node at the end p of a connection, and which of its ports p is if it has more than one on that side.
+func describeEnd(p *port) string {
+	if p == nil {
+		return "nothing"
+	}
+	ports := ins(p.node)
+	if p.out {
+		ports = outs(p.node)
+	}
+	_, portsNode := p.node.(*portsNode)
+	switch {
+	case p.obj.Type == seqType || len(ports) > 1 && p.obj.Name == "":
+		return speakPort(p) + " of " + speakNode(p.node)
+	case len(ports) > 1 || portsNode:
+		return p.obj.Name + " of " + speakNode(p.node)
+	}
+	return speakNode(p.node)
+}
+
+// describeBrowser describes the current item in b, its place in the list, and where the list is.
+func describeBrowser(b *browser) string {
+	obj := b.currentObj()
+	if obj == nil {
+		return "nothing found"
+	}
+	s := describeObj(b.currentPkg, obj)
+	if obj == b.newObj {
+		s = "new " + s
+	}
+	s += fmt.Sprintf(", %d of %d", b.i+1, len(b.objs))
+	if len(b.path) > 0 {
+		s += ", in " + b.path[0].GetName()
+	}
+	return s
+}
+
+func describeObj(pkg *types.Package, obj types.Object) string {
+	switch obj := obj.(type) {
+	case *pkgObject:
+		return "package " + obj.importPath
+	case *types.TypeName:
+		return "type " + obj.Name
+	case *types.Func:
+		if isOperator(obj) {
+			return "operator " + obj.Name
+		}
+		return "func " + obj.Name + strings.TrimPrefix(types.TypeString(pkg, obj.Type), "func")
+	case *types.Builtin:
+		return "builtin " + obj.GetName()
+	case field:
+		return "field " + obj.Name + " " + types.TypeString(pkg, obj.Type)
+	case *types.Var:
+		return "var " + obj.Name + " " + types.TypeString(pkg, obj.Type)
+	case *types.Const:
+		return "const " + obj.Name + " " + types.TypeString(pkg, obj.Type)
+	}
+	return obj.GetName()
+}
+
+// qualifiedName returns the name of obj, qualified by its package if that is not the package of the func containing v.
+func qualifiedName(v View, obj types.Object) string {
+	if p := obj.GetPkg(); p != nil && p != viewPkg(v) && !isMethod(obj) {
+		return p.Name + "." + obj.GetName()
+	}
+	return obj.GetName()
+}
+
+// typeString returns t as written in the package of the func containing v.
+func typeString(v View, t types.Type) string {
+	if t == nil {
+		return "no type"
+	}
+	return types.TypeString(viewPkg(v), t)
+}
+
+// viewPkg returns the package of the func or type being edited in the pane containing v, or nil if there is none.
+func viewPkg(v View) *types.Package {
+	for ; v != nil; v = Parent(v) {
+		switch x := v.(type) {
+		case *funcNode:
+			if !x.literal {
+				return x.obj.GetPkg()
+			}
+		case *typeView:
+			if x.currentPkg != nil {
+				return x.currentPkg
+			}
+		}
+	}
+	return nil
+}
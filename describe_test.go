@@ -0,0 +1,94 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"strings"
+	"testing"
+)
+
+// TestDescribe checks the descriptions of the elements of a func, as given to a screen reader.
+func TestDescribe(t *testing.T) {
+	pkg := audioPackage(t)
+	src := `// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func describeExample(a []Note, b float64) (c *SineBeat) {
+	var v []Note
+	var v2 float64
+	var v3 float64
+	var v4 float64
+	var v5 float64
+	v = a
+	v2 = b
+	v3 = b
+	v4 = b
+	const x = 0.5
+	v5 = x
+	x2 := NewSineBeat(v2, v5, v3, v4)
+	c = x2
+	for i := range v {
+		var v6 = &v[i]
+	}
+	return
+}
+`
+	notes := &types.Slice{Elem: pkg.Scope().Lookup("Note").GetType()}
+	sineBeat := &types.Pointer{Elem: pkg.Scope().Lookup("SineBeat").GetType()}
+	f := readTestFunc(t, pkg, "describeExample", []*types.Var{newVar("a", notes), newVar("b", types.Typ[types.Float64])}, []*types.Var{newVar("c", sineBeat)}, src)
+	defer func() { f.funcblk.close() }()
+	var call *callNode
+	var loop *loopNode
+	for _, n := range f.funcblk.allNodes() {
+		switch n := n.(type) {
+		case *callNode:
+			call = n
+		case *loopNode:
+			loop = n
+		}
+	}
+	for _, test := range []struct {
+		v    View
+		want string
+	}{
+		{call, "call NewSineBeat"},
+		{ins(call)[1], "call NewSineBeat, input 2 of 4: sineFreq float64, connected from literal 0.5"},
+		{ins(call)[0], "call NewSineBeat, input 1 of 4: amp float64, connected from b of inputs of func describeExample"},
+		{outs(call)[0], "call NewSineBeat, output 1 of 1: x *SineBeat, connected to c of outputs of func describeExample"},
+		{seqIn(call), "call NewSineBeat, sequencing input, not connected"},
+		{f.inputsNode.outputs()[1], "inputs of func describeExample, output 2 of 2: b float64, connected to amp of call NewSineBeat and beatFreq of call NewSineBeat and beatWidth of call NewSineBeat"},
+		{loop, "loop over []Note, 0 nodes"},
+		{loop.input.conns()[0], "connection from a of inputs of func describeExample to loop over []Note, []Note"},
+		{loop.loopblk, "loop over []Note, 0 nodes"},
+		{outs(loop.inputsNode)[1], "inputs of loop over []Note, output 2 of 2: *Note, not connected"},
+	} {
+		if got := describe(test.v); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+
+	spoken := []string{}
+	speak = func(s string) { spoken = append(spoken, s) }
+	defer func() { speak = nil }()
+	w := testWindow(f)
+	w.SetFocusHook(announce)
+	defer w.Close()
+	Do(w, func() { SetKeyFocus(call) })
+	w.PressKey(KeyEvent{Key: KeyDown})
+	w.PressKey(KeyEvent{Key: KeyDown})
+	Do(w, func() {
+		want := []string{
+			"call NewSineBeat",
+			"call NewSineBeat, output 1 of 1: x *SineBeat, connected to c of outputs of func describeExample",
+			"connection from call NewSineBeat to c of outputs of func describeExample, *SineBeat",
+		}
+		if strings.Join(spoken, "\n") != strings.Join(want, "\n") {
+			t.Errorf("spoke %q, want %q", spoken, want)
+		}
+	})
+}
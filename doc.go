@@ -10,11 +10,29 @@ Most operations can be canceled by pressing Escape.
 
 Press Command-N to open a new window.  Press Command-W to close a window.  Press Command-Q or close all windows to quit.
 
+A window is divided into panes, each showing the browser or an editor.  Press Command-D to split the focused pane in two side by side, or Shift-Command-D to split it top and bottom; the new pane shows the browser.  Press Command-] and Command-[ to move the focus to the next and previous panes.  Command-W closes the focused pane, saving its editor as Escape does; in the last pane, it closes the window.  When a function or type is saved, the editors in the other panes are reloaded to reflect it.
+
+When editing a name or other text, use the left and right arrow keys to move the caret by a character, or by a word while holding Alt or Control; Home and End move it to the start and end.  Hold Shift while moving the caret, or drag the mouse across the text, to select.  Typing replaces the selection; Backspace and Delete remove it.  Press Command-A to select all, and Command-C, Command-X, and Command-V to copy, cut, and paste.  Text may be entered with an input method.  To show text in another font or size, run "flux -font file.ttf -fontsize 18".
+
+The keys named herein are the defaults.  To bind a command to another key, list it in the file .flux/keymap in your home directory (or the file given by "flux -keymap file") as a line of the form "context: command = keys", for example "node: Inline = Command-L".  The command then no longer responds to its default key.  Run "flux keys" to list every command with its current key in this form.
+
+Flux is drawn in light text on a dark background.  To draw it in other colors, run "flux -theme light" or "flux -theme high-contrast", or give the path of a theme file.  A theme file lists colors as lines of the form "name = #rrggbb" (or "#rrggbbaa", with opacity); colors it doesn't list are those of the dark theme.  Run "flux theme [name]" to print every color of a theme in this form, as a starting point.  Press Command-T, or run "flux -typecolors", to color each connection by the type of the value it carries; connections carrying the same type have the same color.
+
+Flux can describe the focused item in words, for use without sight:  Run "flux -speak command" to have each newly focused item described by running the command (for example, "say" or "espeak") with the description as its argument, or "flux -speak stdout" to print the descriptions.  A port, for example, is described as "call strings.Join, input 2 of 2: sep string, connected from literal ", "" (the literal being a comma and a space).  Together with the arrow keys, which move the focus along the connections between nodes, this makes a function readable without looking at it.  Press Command-Slash to describe the focused item again.
+
+To check Flux files without opening a window, run "flux check [-w] [packages]".  Each Flux function in the named packages (import paths or directories, where "/..." matches all packages below) is loaded and written back out, and any problems (unknown objects or types, invalid ports or connections, cyclic blocks, or output that is not valid Go) are reported.  The exit status is nonzero if there were problems.  With -w, the rewritten functions are saved.
+
+To draw Flux functions as images without opening a window, run "flux export [-format svg|png|pdf] [-o dir] [-func name] [-theme theme] [-typecolors] [packages]".  Each Flux function in the named packages (or only the one named, with a method named as "Type.Method") is laid out and written as an SVG file, or a PNG or PDF file with -format, next to its Flux file or in the existing directory given by -o.
+
 
 Browser
 
 The browser is the first thing you see when starting Flux.  It provides a means of navigating the directories and packages under GOPATH and in the standard library and the objects within those packages, and of creating, deleting, and selecting such items.
 
+When Flux is started in a Go module (a directory containing a go.mod file, or below one), packages are found as the go command finds them:  in the module itself, in the modules it requires (in its vendor directory, if it has one, or else in the module cache), or where its replace directives say.  The browser then lists the standard library, the module, and the modules it requires directly, each by its module path.  A package created at the top level is placed in the module.  Set GO111MODULE=off to use GOPATH instead.
+
+Packages are loaded in the background as the browser needs them, each together with the packages it imports; the browser lists a package's contents once it is loaded.  When the files of a loaded package are changed outside of Flux, it and the packages importing it are loaded again the next time they are needed.
+
 Packages and directories are displayed in white, types in green, functions and methods in red, variables, struct fields, and constants in blue, and special items in yellow.  Use the up and down arrow keys to scroll through the list.  Type a prefix to filter the list.  When a package, directory, or type name is highlighted, press the right arrow key to view its children.  Press the left arrow key to go back to the parent.  Press Enter to select the current item.
 
 To create a new item, hold Command and press 1 (package or directory), 2 (type), 3 (func or method), 4 (var or struct field), or 5 (const); then, type the new item's name followed by Enter.  The new item will be opened for editing.
@@ -32,12 +50,14 @@ Function editor
 
 The function editor displays a function or method as a kind of graph.  The nodes of the graph specify operations such as function calls and control flow.  Nodes typically have some inputs and outputs (generally, ports) by which they can be connected.  A connection has an output as its source and an input as its destination, indicating that a value is passed from the output to the input.  An input may have zero or more connections; the value used is the last one to have been passed or the zero value if none.
 
-Every node belongs to a block.  Outermost is the function block, which is run when the function is called.  An if-node has one or more blocks, one of which is conditionally run.  A loop node has a loop block that is run zero or more times.  A function literal node has a function block that is run when the function value is called.  A select node has zero or more cases consisting of a channel operation and a block; one of these channel operations is run followed by its block.
+Every node belongs to a block.  Outermost is the function block, which is run when the function is called.  An if-node has one or more blocks, one of which is conditionally run.  A loop node has a loop block that is run zero or more times.  A function literal node has a function block that is run when the function value is called.  A select node has zero or more cases consisting of a channel operation and a block; one of these channel operations is run followed by its block.  A switch node compares a tag value with the values of its cases and runs the block of the first case having an equal value.  A type switch node runs the block of the first case whose type matches the dynamic type of its input, passing the input converted to that type into the block.
 
 The execution order of nodes is determined as follows:  Node A runs before node B if there is a connection with A as its source and B as its destination.  A connection that exits or enters a block has that block's containing node as a source or destination, respectively.
 
 The arrow keys are used to navigate the graph.  On their own, they move the focus between nodes, ports, and connections following the topology of the graph.  While holding Alt, they move the focus between nodes with no regard for connectivity.  Pressing Escape moves the focus from a connection end to its port, from a port to its node, and from a node to its containing node.  Pressing Escape when a top-level node is focused saves changes and exits the function editor.
 
+The view follows the focus.  It can also be panned by scrolling, or by clicking or dragging in the minimap at the bottom right, which shows the whole function with the visible part outlined and the focused node highlighted; press Command-M to show or hide the minimap.  To zoom in and out, press Command-Equals and Command-Minus, or scroll while holding Command; press Command-0 to restore the normal size.  When zoomed far out, port labels and connection names are not drawn.
+
 To create a named node (function or method, variable, constant, struct field, operator, special node), simply start typing its name; the browser will open, allowing you to select the desired item.  Hold Shift in the browser to treat functions and methods as values; otherwise they are treated as calls.
 
 A variable node or struct field node can be toggled between read and write using the Equals key.
@@ -48,18 +68,40 @@ A variadic function or method call node can have inputs added by pressing the Co
 
 To create a basic literal (numeric, string, or character) node, type a digit, double quote, or single quote character, respectively, followed by the value and Enter.  Press Enter to edit a basic literal node.  To create a composite literal node, type a left curly brace character and select the desired type from the browser.
 
+To read all of the fields of a struct (or of a struct pointed to), create an "unpack" node and connect the struct to its input; it has an output for each field, including the fields promoted from embedded structs.  Conversely, a "pack" node makes a struct (or a pointer to a new one) of the type selected from the browser, with an input for each field, including promoted fields.  A field and the fields promoted from it can't both be set.  Unconnected ports of these nodes are hidden except while the node or one of its ports is focused, or while they can be connected to.
+
 A function block always has at least two nodes, one for parameters and another for results.  To add a parameter or result, focus the appropriate node or port and press Comma (hold Shift to insert before a port), type the name and Enter, then select the type from the browser.  To delete a parameter or result, focus the port and press Backspace or Delete.  To toggle the signature's variadicity, focus the final parameter's port and press Control-Period.  To toggle between a pointer receiver and a value receiver on a method, focus the receiver's port and press '*'.
 
+A function (other than a method or function literal) may have type parameters, shown in brackets above its parameters node.  To edit them, focus the parameters node and press '['.  Press Comma to add a type parameter (hold Shift to insert before the focused one), type its name and Enter, then select its constraint from the browser; press Delete to remove one, and Escape to finish.  A call to a generic function infers its type arguments from the types connected to its inputs, and its ports take on the instantiated types once all of them are known.
+
+A return node, created by typing "return", returns early from the innermost function (or function literal) enclosing it, from any block.  It has an input for each of the function's results, which follow the results as they are added or removed.  A connected input returns its value; an unconnected one returns the result as set through the results node.
+
 To add a block to an if-node or a case to a select node, press Comma; press Backspace or Delete to remove it.  To toggle a select case between send and receive, press Equals.  To turn a select case into the default case (provided one doesn't already exist), focus its channel port and press Backspace or Delete.
 
+To add a case to a switch node, press Comma; to add a value to the focused case, press Shift-Comma.  To delete a case value, focus its port and press Backspace or Delete; a case without values is the default case, of which there may be only one.  To add a case to a type switch node, press Comma and select the case type from the browser, or press Escape to make it the default case.  Press Enter to change the type of the focused case.  Press Backspace or Delete to remove a case from either kind of switch node.
+
 To create a new connection, focus a port and press Enter to start editing.  Use the arrow keys to move the other end of the connection and press Enter to stop editing.  To edit an existing connection, focus one of its ends and press Enter.
 
+With the mouse, drag from a port to another port to connect them, or drag either end of an existing connection to move it.  While dragging, the ports the connection can be made to are highlighted.  Drag a node into another block (such as the body of a loop) to move it there; connections that can't be kept are removed.
+
+Click the right mouse button on a node, port, or connection to open a menu of the commands available on it, each listed with its key.  Choose one with the mouse, or with Up, Down, and Enter; press Escape to close the menu.  Press Command-K anywhere outside of a text to open the command palette, which lists every command available on the focused item; type part of a command's name to narrow the list.
+
 As an alternative to being drawn as a line, a connection may be named by pressing Underscore and typing a name followed by Enter.  Press Underscore to draw it as a line again.  All named connections having the same source share a name.
 
 To control the execution order of two nodes that are ambiguously ordered, a sequencing connection can be made.  Focus a node's sequencing input or output by pressing Alt-Shift-Up or Alt-Shift-Down, respectively; then, create a connection as usual.  A sequencing connection is drawn as a dashed line.
 
 Press Backspace or Delete to delete a node or connection.
 
+To select several nodes of a block, hold Shift and use the arrow keys, or drag a rectangle over them with the mouse.  Selected nodes are highlighted; all of them belong to the same block.  Press Escape to clear the selection, or Backspace or Delete to delete the selected nodes.
+
+Press Command-C to copy the selected nodes (or the focused node, if none are selected) along with the connections between them, Command-X to cut them, and Command-V to paste them into the block containing the focus, which may be in another function.  Cutting and pasting is also the way to move nodes from one block to another.  Connections that cross the boundary of the selection are handled as follows:  A connection entering the selection is restored when pasting if its source is still present and can be connected to from the block being pasted into; otherwise it is dropped.  A connection leaving the selection is restored in the same way after a cut, but dropped after a copy, as its destination remains connected to the original.  Sequencing connections crossing the boundary are always dropped.
+
+To extract the selected nodes (or the focused node) into a new function, press Command-E, then type the function's name and Enter.  The new function is added to the current package and the nodes are replaced with a call to it.  Its parameters come from the connections entering the nodes and its results from the outputs having connections leaving them.  Conversely, to inline a call to a Flux function in the current package, focus the call node and press Command-I.  The call is replaced with the function's nodes, which are left selected; its sequencing connections are dropped.  A function containing a return node can't be inlined.
+
+Press Command-Z to undo the latest change to the function and Shift-Command-Z to redo it.  Each change made by a single key press or mouse action (or by editing a connection or text from start to finish) is undone as a whole.  A function's history lasts until it is closed.
+
+To export the function as an SVG image, press Command-P; press Shift-Command-P for a PNG image or Alt-Command-P for a PDF document instead.  The image is written next to the function's file, with the extension .svg, .png, or .pdf in place of .flux.go.
+
 To save changes, press Command-S.
 
 
@@ -69,7 +111,7 @@ The type editor displays a type as a tree.  Composite types have their children
 
 Press Enter to move the focus from a composite type to one of its children.  Use the arrow keys to move the focus between the children of a composite type.  Press Escape to move the focus from a child to its parent.
 
-To replace the focused item, press Backspace.  For a named item (struct field, function parameter or result, or interface method), first type the name and Enter.  Otherwise just select the type from the browser.  After a composite type is created, each of its children is edited in turn.  Press Escape to stop entering new named items.  Press Comma to insert a new named item (hold Shift to insert before the focused item); to delete one, press Delete.
+To replace the focused item, press Backspace.  For a named item (struct field, function parameter or result, or interface method), first type the name and Enter.  Otherwise just select the type from the browser.  After a composite type is created, each of its children is edited in turn.  Press Escape to stop entering new named items.  Press Comma to insert a new named item (hold Shift to insert before the focused item); to delete one, press Delete.  Press '[' to edit the type parameters of a named type, as for a function.  When a generic type is selected from the browser, each of its type arguments is selected in turn.
 
 
 Invalid code
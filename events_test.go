@@ -0,0 +1,276 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"strings"
+	"testing"
+)
+
+// testWindow returns a headless window showing f, with its inputs node above its outputs node and any other nodes where they are, so that the outcome of keyboard and mouse navigation is predictable.
+func testWindow(f *funcNode) *Window {
+	return NewHeadlessWindow(nil, Pt(800, 600), func(w *Window) {
+		w.Add(f)
+		f.inputsNode.Move(Pt(0, 100))
+		f.outputsNode.Move(Pt(0, -100))
+		newBlockArrange(f.funcblk, nil, portmap{}).setRectReal()
+		ResizeToFit(f, 0)
+		f.Move(Pt(400, 300))
+		SetKeyFocus(f.inputsNode)
+	})
+}
+
+func testEventsFunc(t *testing.T) (*funcNode, *Window) {
+	pkg := audioPackage(t)
+	src := `// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func eventsExample(a int, b int) (c int, d int, e int) {
+	c = a //x
+	d = b //y
+	e = a
+	return
+}
+`
+	vars := func(names ...string) (v []*types.Var) {
+		for _, n := range names {
+			v = append(v, newVar(n, types.Typ[types.Int]))
+		}
+		return
+	}
+	f := readTestFunc(t, pkg, "eventsExample", vars("a", "b"), vars("c", "d", "e"), src)
+	return f, testWindow(f)
+}
+
+// TestEditHiddenConnectionSource moves the source of a connection between two outputs having named connections, which used to rename the second output's connections with the name of the first.
+func TestEditHiddenConnectionSource(t *testing.T) {
+	f, w := testEventsFunc(t)
+	defer w.Close()
+	defer f.funcblk.close()
+
+	a, b := f.inputsNode.outputs()[0], f.inputsNode.outputs()[1]
+	e := f.outputsNode.inputs()[2]
+	c := e.conns()[0]
+	Do(f, func() {
+		if got := a.conntxt.Text() + "," + b.conntxt.Text(); got != "x,y" {
+			t.Errorf("connection names are %s, want x,y", got)
+		}
+		c.focus(true)
+	})
+	w.PressKey(KeyEvent{Key: KeyEnter})
+	w.PressKey(KeyEvent{Key: KeyRight})
+	Do(f, func() {
+		if c.src() != b {
+			t.Errorf("connection source is %s, want b", describePort(c.src()))
+		}
+		if got := a.conntxt.Text() + "," + b.conntxt.Text(); got != "x,y" {
+			t.Errorf("connection names are %s while editing, want x,y", got)
+		}
+	})
+	w.PressKey(KeyEvent{Key: KeyEnter})
+	Do(f, func() {
+		if KeyFocus(f) != c || c.editing {
+			t.Error("the connection should be focused and no longer editing")
+		}
+		if got := a.conntxt.Text() + "," + b.conntxt.Text(); got != "x,y" {
+			t.Errorf("connection names are %s, want x,y", got)
+		}
+		if got := writeTestFunc(f); !strings.Contains(got, "\te = b\n") {
+			t.Errorf("the connection to e should come from b:\n%s", got)
+		}
+	})
+}
+
+// TestClickFocus checks that clicking a port focuses it and that Escape then moves the focus to its node.
+func TestClickFocus(t *testing.T) {
+	f, w := testEventsFunc(t)
+	defer w.Close()
+	defer f.funcblk.close()
+
+	d := f.outputsNode.inputs()[1]
+	var p Point
+	Do(f, func() { p = Map(Center(d), d, w) })
+	w.SendMouse(MouseEvent{Pos: p, Press: true})
+	w.SendMouse(MouseEvent{Pos: p, Release: true})
+	Do(f, func() {
+		if KeyFocus(f) != d {
+			t.Errorf("focus is %T, want the port d", KeyFocus(f))
+		}
+	})
+	w.PressKey(KeyEvent{Key: KeyEscape})
+	Do(f, func() {
+		if KeyFocus(f) != f.outputsNode {
+			t.Errorf("focus is %T, want the outputs node", KeyFocus(f))
+		}
+	})
+}
+
+// TestDragConnect drags from an input port to an output port and checks that a connection is made between them.
+func TestDragConnect(t *testing.T) {
+	f, w := testEventsFunc(t)
+	defer w.Close()
+	defer f.funcblk.close()
+
+	b, d := f.inputsNode.outputs()[1], f.outputsNode.inputs()[2]
+	var p, q Point
+	Do(f, func() {
+		p = Map(Center(d), d, w)
+		q = Map(Center(b), b, w)
+	})
+	w.SendMouse(MouseEvent{Pos: p, Press: true})
+	Do(f, func() {
+		if !b.highlighted {
+			t.Error("b should be highlighted as a candidate source")
+		}
+	})
+	w.SendMouse(MouseEvent{Pos: p.Add(q).Div(2), Move: true})
+	w.SendMouse(MouseEvent{Pos: q, Move: true})
+	w.SendMouse(MouseEvent{Pos: q, Release: true})
+	Do(f, func() {
+		if b.highlighted {
+			t.Error("b should no longer be highlighted")
+		}
+		if got := writeTestFunc(f); !strings.Contains(got, "\te = b\n") {
+			t.Errorf("e should be connected to b:\n%s", got)
+		}
+	})
+}
+
+// TestContextMenu opens the context menu of a port with the right mouse button and runs its first command, which starts a connection.
+func TestContextMenu(t *testing.T) {
+	f, w := testEventsFunc(t)
+	defer w.Close()
+	defer f.funcblk.close()
+
+	a := f.inputsNode.outputs()[0]
+	var p Point
+	Do(f, func() { p = Map(Center(a), a, w) })
+	w.SendMouse(MouseEvent{Pos: p, Press: true, Button: MouseButtonRight})
+	w.SendMouse(MouseEvent{Pos: p, Release: true, Button: MouseButtonRight})
+	Do(f, func() {
+		m, ok := KeyFocus(f).(*contextMenu)
+		if !ok {
+			t.Fatalf("focus is %T, want the context menu", KeyFocus(f))
+		}
+		if m.cmds[0].name != "Connect" {
+			t.Errorf("first command is %s, want Connect", m.cmds[0].name)
+		}
+	})
+	w.PressKey(KeyEvent{Key: KeyEnter})
+	Do(f, func() {
+		c, ok := KeyFocus(f).(*connection)
+		if !ok || !c.editing || c.src() != a {
+			t.Errorf("focus is %T, want a new connection from a", KeyFocus(f))
+		}
+	})
+	w.PressKey(KeyEvent{Key: KeyEscape})
+}
+
+// TestZoom zooms out on a func and checks that clicking a port still focuses it and that connection names are no longer painted.
+func TestZoom(t *testing.T) {
+	f, w := testEventsFunc(t)
+	defer w.Close()
+	defer f.funcblk.close()
+
+	svg := func() string {
+		c := NewSVGCanvas(Pt(800, 600))
+		PaintTo(w, c)
+		buf := &bytes.Buffer{}
+		c.WriteTo(buf)
+		return buf.String()
+	}
+	d := f.outputsNode.inputs()[1]
+	var p Point
+	Do(f, func() {
+		if !strings.Contains(svg(), ">x</text>") {
+			t.Error("connection name x should be painted at full size")
+		}
+		SetScale(f, labelZoom/2)
+		p = Map(Center(d), d, w)
+		if q := Map(p, w, d); q.Sub(Center(d)).Len() > 1e-9 {
+			t.Errorf("mapping to the window and back gives %v, want %v", q, Center(d))
+		}
+		if strings.Contains(svg(), ">x</text>") {
+			t.Error("connection name x should not be painted when zoomed out")
+		}
+	})
+	w.SendMouse(MouseEvent{Pos: p, Press: true})
+	w.SendMouse(MouseEvent{Pos: p, Release: true})
+	Do(f, func() {
+		if KeyFocus(f) != d {
+			t.Errorf("focus is %T, want the port d", KeyFocus(f))
+		}
+	})
+}
+
+// TestEditText moves the caret through multibyte characters, selects and cuts and pastes text, and composes text with an input method.
+func TestEditText(t *testing.T) {
+	var text *Text
+	w := NewHeadlessWindow(nil, Pt(400, 100), func(w *Window) {
+		text = NewText("")
+		w.Add(text)
+		text.Move(Pt(100, 50))
+		SetKeyFocus(text)
+	})
+	defer w.Close()
+	check := func(want string, i, j int) {
+		Do(text, func() {
+			if text.Text() != want {
+				t.Errorf("text is %q, want %q", text.Text(), want)
+			}
+			if i2, j2 := text.Selection(); i2 != i || j2 != j {
+				t.Errorf("selection of %q is %d-%d, want %d-%d", text.Text(), i2, j2, i, j)
+			}
+		})
+	}
+
+	w.TypeText("héllo wörld")
+	check("héllo wörld", 13, 13)
+	w.PressKey(KeyEvent{Key: KeyLeft, Alt: true})
+	w.PressKey(KeyEvent{Key: KeyLeft})
+	w.PressKey(KeyEvent{Key: KeyLeft})
+	check("héllo wörld", 5, 5)
+	w.TypeText("ß")
+	check("héllßo wörld", 7, 7)
+	w.PressKey(KeyEvent{Key: KeyBackspace})
+	w.PressKey(KeyEvent{Key: KeyHome, Shift: true})
+	check("héllo wörld", 0, 5)
+	w.PressKey(KeyEvent{Key: KeyX, Command: true})
+	check("o wörld", 0, 0)
+	if w.Clipboard() != "héll" {
+		t.Errorf("clipboard is %q, want %q", w.Clipboard(), "héll")
+	}
+	w.PressKey(KeyEvent{Key: KeyEnd})
+	w.PressKey(KeyEvent{Key: KeyV, Command: true})
+	check("o wörldhéll", 13, 13)
+	w.PressKey(KeyEvent{Key: KeyBackspace, Alt: true})
+	w.PressKey(KeyEvent{Key: KeyRight, Shift: true})
+	check("o ", 2, 2)
+
+	var width float64
+	Do(text, func() { width = Width(text) })
+	w.SendComposition("にほ")
+	Do(text, func() {
+		if Width(text) <= width {
+			t.Error("composed text should widen the text")
+		}
+	})
+	check("o ", 2, 2)
+	w.SendComposition("")
+	w.TypeText("日本")
+	check("o 日本", 8, 8)
+
+	w.PressKey(KeyEvent{Key: KeyA, Command: true})
+	check("o 日本", 0, 8)
+	var p Point
+	Do(text, func() { p = Map(Pt(0, Height(text)/2), text, w) })
+	w.SendMouse(MouseEvent{Pos: p, Press: true})
+	w.SendMouse(MouseEvent{Pos: p, Release: true})
+	check("o 日本", 0, 0)
+}
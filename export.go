@@ -0,0 +1,189 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const exportMargin = 16
+
+// exportFormats holds the extensions of the file formats that exportFunc can write.
+var exportFormats = map[string]bool{".svg": true, ".png": true, ".pdf": true}
+
+// exportCmd implements the "flux export" command, which draws the Flux funcs in the given packages to image files without opening a window.
+func exportCmd(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "svg", "the format to write:  svg, png, or pdf")
+	dir := flags.String("o", "", "an existing directory to write the images to; by default, each image is written next to its Flux file")
+	name := flags.String("func", "", `export only the func (or method, as "Type.Method") with this name`)
+	theme := flags.String("theme", "dark", "the colors to draw in:  dark, light, high-contrast, or a theme file")
+	flags.BoolVar(&typeColors, "typecolors", false, "color each connection by the type it carries")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: flux export [-format svg|png|pdf] [-o dir] [-func name] [-theme theme] [-typecolors] [packages]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if err := loadTheme(*theme); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	ext := "." + *format
+	if !exportFormats[ext] {
+		fmt.Printf("can't export to %s; use svg, png, or pdf\n", *format)
+		return 2
+	}
+	if *dir != "" {
+		if fi, err := os.Stat(*dir); err != nil {
+			fmt.Println(err)
+			return 1
+		} else if !fi.IsDir() {
+			fmt.Printf("%s is not a directory\n", *dir)
+			return 1
+		}
+	}
+
+	status := 0
+	paths, err := matchPackages(patterns)
+	if err != nil {
+		fmt.Println(err)
+		status = 1
+	}
+	found := false
+	for _, path := range paths {
+		pkg, err := getPackage(path)
+		if err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			status = 1
+			continue
+		}
+		for _, obj := range pkgFluxFuncs(pkg) {
+			if *name != "" && funcName(obj) != *name {
+				continue
+			}
+			found = true
+			out := exportPath(obj, ext)
+			if *dir != "" {
+				out = filepath.Join(*dir, filepath.Base(out))
+			}
+			if err := exportFile(obj, out); err != nil {
+				fmt.Printf("%s: %s\n", fluxPath(obj), err)
+				status = 1
+			}
+		}
+	}
+	if *name != "" && !found {
+		fmt.Printf("no Flux func named %s\n", *name)
+		status = 1
+	}
+	return status
+}
+
+// funcName returns the name of func obj, qualified by its receiver's type name if it is a method.
+func funcName(obj types.Object) string {
+	if isMethod(obj) {
+		t, _ := indirect(obj.GetType().(*types.Signature).Recv.Type)
+		return t.(*types.Named).Obj.Name + "." + obj.GetName()
+	}
+	return obj.GetName()
+}
+
+// exportPath returns the path of the image file with extension ext that is written next to obj's Flux file.
+func exportPath(obj types.Object, ext string) string {
+	return strings.TrimSuffix(fluxPath(obj), ".flux.go") + ext
+}
+
+// exportFile loads the func obj, lays it out, and exports it to path.
+func exportFile(obj types.Object, path string) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("panic: %v", x)
+		}
+	}()
+
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, nil); err != nil {
+		return err
+	}
+	layOut(f, 5*time.Second)
+	return exportFunc(f, path)
+}
+
+// layOut arranges the nodes of f, which is not in a window, by applying the arranger's results until no better one has come for a while or timeout has passed.
+func layOut(f *funcNode, timeout time.Duration) {
+	deadline := time.After(timeout)
+	var quiet <-chan time.Time
+	for {
+		select {
+		case b := <-f.animate:
+			f.laidOut = true
+			for i := 0; i < 100*fps && !b.animate(); i++ {
+			}
+			ResizeToFit(f, 0)
+			quiet = time.After(time.Second / 4)
+		case <-quiet:
+			return
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// exportFunc draws f to the file at path, as SVG, PNG, or PDF according to its extension.  f is drawn unzoomed.
+func exportFunc(f *funcNode, path string) error {
+	ext := filepath.Ext(path)
+	if !exportFormats[ext] {
+		return fmt.Errorf("can't export to %s files; use .svg, .png, or .pdf", ext)
+	}
+	defer SetScale(f, Scale(f))
+	SetScale(f, 1)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	size := Size(f).Add(Pt(2*exportMargin, 2*exportMargin))
+	switch ext {
+	case ".svg":
+		c := NewSVGCanvas(size)
+		paintExport(f, c, size)
+		_, err = c.WriteTo(file)
+	case ".png":
+		img := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(size.X)), int(math.Ceil(size.Y))))
+		paintExport(f, NewImageCanvas(img), size)
+		err = png.Encode(file, img)
+	case ".pdf":
+		c := NewPDFCanvas(size)
+		paintExport(f, c, size)
+		_, err = c.WriteTo(file)
+	}
+	if err2 := file.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func paintExport(f *funcNode, c Canvas, size Point) {
+	c.SetColor(backgroundColor)
+	c.FillRect(Rectangle{ZP, size})
+	c.Translate(Pt(exportMargin, exportMargin))
+	PaintTo(f, c)
+}
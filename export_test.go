@@ -0,0 +1,159 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/types"
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestExport exports an audio func without a window, as SVG and PNG, and checks that the files are well formed and show the func's nodes.
+func TestExport(t *testing.T) {
+	pkg := audioPackage(t)
+	var obj types.Object
+	for _, o := range pkgFluxFuncs(pkg) {
+		if funcName(o) == "MultiVoice.Sing" {
+			obj = o
+		}
+	}
+	if obj == nil {
+		t.Fatal("MultiVoice.Sing not found")
+	}
+	dir, err := ioutil.TempDir("", "fluxexport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Sing.svg")
+	if err := exportFile(obj, path); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	texts := map[string]bool{}
+	d := xml.NewDecoder(file)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("malformed SVG: %s", err)
+		}
+		if text, ok := tok.(xml.CharData); ok {
+			texts[string(text)] = true
+		}
+	}
+	for _, s := range []string{".Lock", ".Unlock", "delete"} {
+		if !texts[s] {
+			t.Errorf("SVG has no text %q", s)
+		}
+	}
+
+	path = filepath.Join(dir, "Sing.png")
+	if err := exportFile(obj, path); err != nil {
+		t.Fatal(err)
+	}
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("malformed PNG: %s", err)
+	}
+	if b := img.Bounds(); b.Dx() < 100 || b.Dy() < 100 {
+		t.Errorf("PNG is only %dx%d", b.Dx(), b.Dy())
+	}
+
+	path = filepath.Join(dir, "Sing.pdf")
+	if err := exportFile(obj, path); err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkPDF(pdf); err != nil {
+		t.Fatalf("malformed PDF: %s", err)
+	}
+	for _, s := range []string{".Lock", ".Unlock", "delete"} {
+		if !bytes.Contains(pdf, []byte("("+s+") Tj")) {
+			t.Errorf("PDF has no text %q", s)
+		}
+	}
+
+	if err := exportFile(obj, filepath.Join(dir, "Sing.jpg")); err == nil {
+		t.Error("exporting to JPEG should fail")
+	}
+}
+
+// checkPDF checks that the cross-reference table of pdf points at each of its objects.
+func checkPDF(pdf []byte) error {
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		return fmt.Errorf("no PDF header or trailer")
+	}
+	i := bytes.LastIndex(pdf, []byte("startxref\n"))
+	if i < 0 {
+		return fmt.Errorf("no startxref")
+	}
+	var xref int
+	if _, err := fmt.Sscan(string(pdf[i+len("startxref\n"):]), &xref); err != nil || xref >= len(pdf) || !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		return fmt.Errorf("bad startxref")
+	}
+	var first, n int
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	if _, err := fmt.Sscan(lines[1], &first, &n); err != nil || len(lines) < n+2 {
+		return fmt.Errorf("bad xref subsection header %q", lines[1])
+	}
+	for obj := 1; obj < n; obj++ {
+		off := -1
+		if f := strings.Fields(lines[2+obj]); len(f) > 0 {
+			off, _ = strconv.Atoi(f[0]) // not Sscan, which reads the zero-padded offset as octal
+		}
+		if off < 0 || off >= len(pdf) || !bytes.HasPrefix(pdf[off:], []byte(fmt.Sprintf("%d 0 obj\n", obj))) {
+			return fmt.Errorf("xref entry for object %d points at the wrong offset", obj)
+		}
+	}
+	return nil
+}
+
+// TestExportCmdArgs checks that the export command rejects an unknown format and an -o that is not a directory.
+func TestExportCmdArgs(t *testing.T) {
+	file, err := ioutil.TempFile("", "fluxexport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	for _, x := range []struct {
+		args   []string
+		status int
+	}{
+		{[]string{"-format", "jpg", "./audio"}, 2},
+		{[]string{"-o", file.Name(), "./audio"}, 1},
+		{[]string{"-o", file.Name() + ".missing", "./audio"}, 1},
+	} {
+		if status := exportCmd(x.args); status != x.status {
+			t.Errorf("flux export %s: got status %d, want %d", strings.Join(x.args, " "), status, x.status)
+		}
+	}
+}
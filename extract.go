@@ -0,0 +1,156 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"fmt"
+)
+
+// editExtractFunc prompts for the name of a new func into which to extract nodes, which must all belong to b.
+func (b *block) editExtractFunc(nodes []node) {
+	text := NewText("")
+	text.SetTextColor(color(&types.Func{}, true, false))
+	text.Validate = validateID
+	b.Add(text)
+	MoveCenter(text, RectInParent(nodes[0]).Center())
+	text.Accept = func(name string) {
+		if name == "" || b.func_().pkg().Scope().Lookup(name) != nil {
+			return
+		}
+		text.Close()
+		if n := b.extractFunc(nodes, name); n != nil {
+			SetKeyFocus(n)
+		} else {
+			SetKeyFocus(b)
+		}
+	}
+	text.Reject = func() {
+		text.Close()
+		SetKeyFocus(nodes[0])
+	}
+	SetKeyFocus(text)
+}
+
+// extractFunc moves nodes, which must all belong to b, into a new func named name in the current package and replaces them with a call to it.
+// The connections entering the nodes become the func's params and those leaving them become its results, one for each output they leave from.
+func (b *block) extractFunc(nodes []node, name string) *callNode {
+	c := copyNodes(nodes)
+	if c == nil {
+		return nil
+	}
+	pkg := b.func_().pkg()
+	sig := c.obj.GetType().(*types.Signature)
+	obj := types.NewFunc(0, pkg, name, sig)
+	f := newFuncNode(obj, nil)
+	if err := readFunc(f, c.src); err != nil {
+		fmt.Printf("error extracting func: %s\n", err)
+		f.funcblk.close()
+		return nil
+	}
+	results := [][]*port{}
+	for i := len(c.outs) - 1; i >= 0; i-- {
+		if len(c.outs[i]) == 0 {
+			f.outputsNode.removePort(f.outputsNode.inputs()[i]) // an unconnected output
+		} else {
+			results = append([][]*port{c.outs[i]}, results...)
+		}
+	}
+	pkg.Scope().Insert(obj)
+	saveFunc(f)
+	f.funcblk.close()
+
+	center := RectInParent(nodes[0]).Center()
+	for _, n := range nodes {
+		b.removeNode(n)
+	}
+	n := newCallNode(obj, pkg, "").(*callNode)
+	b.addNode(n)
+	MoveCenter(n, center)
+	for i, src := range c.ins {
+		connect(src, ins(n)[i])
+	}
+	for i, dsts := range results {
+		for _, dst := range dsts {
+			connect(outs(n)[i], dst)
+		}
+	}
+	return n
+}
+
+// connect connects src to dst if they are both present and connectable.
+func connect(src, dst *port) {
+	if src == nil || dst == nil {
+		return
+	}
+	c := newConnection()
+	if c.connectable(src, dst) {
+		c.setSrc(src)
+		c.setDst(dst)
+	}
+}
+
+// inline replaces n, a call to a Flux func in the current package, with the nodes of that func.
+// Each param's connections are made from the sources of the corresponding input of n, and each result's sources are connected to the destinations of the corresponding output of n.  Sequencing connections of n are dropped.
+// A func containing a return node can't be inlined, as it would return from the enclosing func.
+func (b *block) inline(n *callNode) []node {
+	obj, ok := n.obj.(*types.Func)
+	if !ok || n.godefer != "" || obj.GetPkg() != b.func_().pkg() {
+		return nil
+	}
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, nil); err != nil {
+		return nil
+	}
+	if len(f.inputsNode.outputs()) != len(ins(n)) || len(f.outputsNode.inputs()) != len(outs(n)) {
+		return nil // a variadic call with separate element inputs
+	}
+	for _, m := range f.funcblk.allNodes() {
+		if _, ok := m.(*returnNode); ok {
+			return nil
+		}
+	}
+
+	var nodes []node
+	f.funcblk.walkInOrder(func(m node) {
+		if m.block() == f.funcblk {
+			nodes = append(nodes, m)
+		}
+	})
+	b.moveNodes(nodes)
+	for i, p := range f.inputsNode.outputs() {
+		for _, c := range append([]*connection{}, p.conns()...) {
+			for _, c2 := range ins(n)[i].conns() {
+				connect(c2.src(), c.dst())
+			}
+			c.block().removeConn(c)
+		}
+	}
+	for i, p := range f.outputsNode.inputs() {
+		for _, c := range append([]*connection{}, p.conns()...) {
+			for _, c2 := range outs(n)[i].conns() {
+				connect(c.src(), c2.dst())
+			}
+			c.block().removeConn(c)
+		}
+	}
+	b.removeNode(n)
+	b.removeNode(f.inputsNode)
+	b.removeNode(f.outputsNode)
+
+	var inlined []node
+	b.walkInOrder(func(m node) {
+		if m.block() == b {
+			for _, m2 := range nodes {
+				if m == m2 && m != f.inputsNode && m != f.outputsNode {
+					inlined = append(inlined, m)
+				}
+			}
+		}
+	})
+	return inlined
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"os"
+	"testing"
+)
+
+// TestExtractInline extracts the operator nodes of a func into a new func, checking that the call replacing them is connected in their place, and inlines the call again.
+func TestExtractInline(t *testing.T) {
+	fn, src := opsFunc("extractExample")
+	testEdit(t, editTest{
+		fn:  fn,
+		src: src,
+		edit: func(t *testing.T, f *funcNode) {
+			pkg := f.obj.GetPkg()
+			nodes := []node{operator(t, f, "+"), operator(t, f, "*")}
+			n := f.funcblk.extractFunc(nodes, "extracted")
+			if n == nil {
+				t.Fatal("extract failed")
+			}
+			obj := pkg.Scope().Lookup("extracted")
+			defer func() {
+				os.Remove(fluxPath(obj))
+				delete(pkg.Scope().Objects, "extracted")
+				setFluxObj(obj, false)
+			}()
+			if obj != n.obj {
+				t.Fatal("extracted func is not in the package scope")
+			}
+			sig := obj.GetType().(*types.Signature)
+			if len(sig.Params) != 3 || len(sig.Results) != 1 {
+				t.Errorf("extracted func has %d params and %d results, want 3 and 1", len(sig.Params), len(sig.Results))
+			}
+			for i, in := range ins(n) {
+				if len(in.conns()) != 1 {
+					t.Errorf("call input %d has %d connections, want 1", i, len(in.conns()))
+				}
+			}
+			if c := outs(n)[0].conns(); len(c) != 1 || c[0].dst() != f.outputsNode.inputs()[0] {
+				t.Error("call output is not connected to the result")
+			}
+			if nodes := f.funcblk.inline(n); len(nodes) != 2 {
+				t.Errorf("inlined %d nodes, want 2", len(nodes))
+			}
+		},
+	})
+}
@@ -5,17 +5,52 @@
 package main
 
 import (
-	"github.com/gordonklaus/flux/go/types"
 	. "github.com/gordonklaus/flux/gui"
 	"github.com/gordonklaus/refactor"
+	"flag"
 	"fmt"
-	"math"
+	"os"
 	"runtime"
-	"time"
 )
 
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
+	if err := loadMainModule(); err != nil {
+		fmt.Println(err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(check(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(importGo(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(exportCmd(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(keysCmd(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "theme" {
+		os.Exit(themeCmd(os.Args[2:]))
+	}
+	font := flag.String("font", "", "the TrueType font file in which to show text (default the Times New Roman that comes with Flux)")
+	fontSize := flag.Float64("fontsize", 18, "the size of text, in pixels per em")
+	keymap := flag.String("keymap", defaultKeymapPath(), "the file binding actions to keys, as listed by \"flux keys\"")
+	theme := flag.String("theme", "dark", "the colors to draw in:  dark, light, high-contrast, or a theme file as printed by \"flux theme\"")
+	flag.BoolVar(&typeColors, "typecolors", false, "color each connection by the type it carries")
+	speech := flag.String("speak", "", "describe the focused element with this command, for example \"say\", or on standard output if \"stdout\"")
+	flag.Parse()
+	SetDefaultFont(*font, *fontSize)
+	if err := loadKeymap(*keymap); err != nil {
+		fmt.Println(err)
+	}
+	if err := loadTheme(*theme); err != nil {
+		fmt.Println(err)
+	}
+	if *speech != "" {
+		speak = newSpeaker(*speech)
+	}
+
 	go refactor.ReportShadowedPackages()
 	if err := Run(newFluxWindow); err != nil {
 		fmt.Println(err)
@@ -24,90 +59,38 @@ func main() {
 
 type fluxWindow struct {
 	*Window
-	*Panner
-	browser *browser
-
-	target chan Point
-	pause  chan bool
+	tiles *tile
 }
 
 func newFluxWindow() {
 	w := &fluxWindow{}
-	NewWindow(w, "Flux", func(win *Window) {
-		w.Window = win
-		w.Panner = NewPanner(w)
-		w.browser = newBrowser(browserOptions{objFilter: isFluxObj, acceptTypes: true, enterTypes: true, mutable: true}, nil)
-		w.Add(w.browser)
-		w.SetRect(Rect(w))
-		w.browser.accepted = func(obj types.Object) {
-			switch obj := obj.(type) {
-			case *types.TypeName:
-				w.SetTitle(obj.Pkg.Path + "." + obj.Name)
-				typ := obj.Type.(*types.Named)
-				Hide(w.browser)
-				v := newTypeView(&typ.UnderlyingT, obj.Pkg)
-				w.Add(v)
-				MoveCenter(v, Center(w))
-				reset := func() {
-					w.Remove(v)
-					Show(w.browser)
-					w.browser.clearText()
-					SetKeyFocus(w.browser)
-					w.SetTitle("Flux")
-				}
-				if typ.UnderlyingT == nil {
-					v.edit(func() {
-						if typ.UnderlyingT == nil {
-							delete(obj.Pkg.Scope().Objects, obj.Name)
-						} else {
-							saveType(typ)
-						}
-						reset()
-					})
-				} else {
-					v.done = func() {
-						saveType(typ)
-						reset()
-					}
-					SetKeyFocus(v)
-				}
-			case *types.Func:
-				prefix := obj.Pkg.Path + "."
-				if recv := obj.Type.(*types.Signature).Recv; recv != nil {
-					t, _ := indirect(recv.Type)
-					prefix += t.(*types.Named).Obj.Name + "."
-				}
-				w.SetTitle(prefix + obj.Name)
-				Hide(w.browser)
-				f := loadFunc(obj)
-				w.Add(f)
-				go animate(f.animate, f.stop)
-				f.Move(Center(w))
-				f.done = func() {
-					Show(w.browser)
-					w.browser.clearText()
-					SetKeyFocus(w.browser)
-					w.SetTitle("Flux")
-				}
-				SetKeyFocus(f.inputsNode)
-			}
-		}
-		w.browser.canceled = func() {}
-		SetKeyFocus(w.browser)
+	NewWindow(w, "Flux", w.init)
+}
 
-		w.target = make(chan Point)
-		w.pause = make(chan bool)
-		go w.animate()
-	})
+func (w *fluxWindow) init(win *Window) {
+	w.Window = win
+	win.SetKeyMap(mapKey)
+	win.SetBackgroundColor(backgroundColor)
+	win.SetFocusHook(announce)
+	p := newPane(w)
+	w.tiles = &tile{pane: p}
+	w.Add(p)
+	w.SetRect(Rect(w))
+	SetKeyFocus(p.browser)
 }
 
-func panTo(v View, p Point) {
-	w := window(v)
-	if w == nil {
+func panTo(v View, pt Point) {
+	p := paneOf(v)
+	if p == nil {
 		return
 	}
-	p = Map(p, v, w)
-	go func() { w.target <- p }()
+	pt = Map(pt, v, p)
+	go func() {
+		select {
+		case p.target <- pt:
+		case <-p.closed:
+		}
+	}()
 }
 
 func window(v View) *fluxWindow {
@@ -120,35 +103,11 @@ func window(v View) *fluxWindow {
 	return window(Parent(v))
 }
 
-func (w *fluxWindow) animate() {
-	target := <-w.target
-	vel := ZP
-	for {
-		next := time.After(time.Second / fps)
-		r := ZR
-		Do(w, func() {
-			Pan(w, Rect(w).Min.Add(vel.Div(fps)))
-			r = Rect(w)
-		})
-		d := target.Sub(r.Center())
-		d.X = math.Copysign(math.Max(0, math.Abs(d.X)-r.Dx()/3), d.X)
-		d.Y = math.Copysign(math.Max(0, math.Abs(d.Y)-r.Dy()/3), d.Y)
-		vel = vel.Add(d).Mul(.8)
-		if vel.Len() < .1 {
-			next = nil
-		}
-		select {
-		case <-next:
-		case target = <-w.target:
-		case <-w.pause:
-			target = <-w.target
-		}
-	}
-}
-
 func (w *fluxWindow) SetRect(r Rectangle) {
 	w.Window.SetRect(r)
-	w.browser.Move(Center(w))
+	if w.tiles != nil {
+		w.tiles.layOut(r)
+	}
 }
 
 func (w *fluxWindow) KeyPress(k KeyEvent) {
@@ -158,11 +117,3 @@ func (w *fluxWindow) KeyPress(k KeyEvent) {
 		w.Window.KeyPress(k)
 	}
 }
-
-func (w *fluxWindow) Scroll(s ScrollEvent) {
-	select {
-	case w.pause <- true:
-	default:
-	}
-	Pan(w, Rect(w).Min.Sub(s.Delta.Mul(4)))
-}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
 	"fmt"
 )
@@ -13,16 +14,20 @@ import (
 type funcNode struct {
 	*ViewBase
 	AggregateMouser
-	blk                     *block
+	model                   *graph.Node
 	output                  *port
 	funcblk                 *block
 	inputsNode, outputsNode *portsNode
+	tparams                 *typeParamsView // nil for a literal or a method
 	focused                 bool
 
-	obj     types.Object
-	literal bool
-	pkgRefs map[*types.Package]int
-	done    func()
+	obj      types.Object
+	literal  bool
+	pkgRefs  map[*types.Package]int
+	done     func()
+	laidOut  bool          // whether the arranger has placed its nodes, so that their positions are worth saving
+	history  *history      // nil for a literal or a func that isn't open for editing
+	selected map[node]bool // nil for a literal
 
 	animate blockchan
 	stop    stopchan
@@ -31,13 +36,15 @@ type funcNode struct {
 func newFuncNode(obj types.Object, arranged blockchan) *funcNode {
 	n := &funcNode{obj: obj, literal: obj == nil}
 	n.ViewBase = NewView(n)
-	n.AggregateMouser = AggregateMouser{NewClickFocuser(n), NewMover(n)}
+	n.AggregateMouser = nodeMouser(n)
+	n.model = newGraphNode(n)
 	if n.literal {
 		n.output = newOutput(n, newVar("", &types.Signature{}))
 		MoveCenter(n.output, Pt(0, -portSize))
-		n.Add(n.output)
+		addPort(n.output, nil)
 	} else {
 		n.pkgRefs = map[*types.Package]int{}
+		n.selected = map[node]bool{}
 		n.animate = make(blockchan)
 		n.stop = make(stopchan)
 		arranged = n.animate
@@ -49,11 +56,19 @@ func newFuncNode(obj types.Object, arranged blockchan) *funcNode {
 	n.outputsNode = newOutputsNode()
 	n.outputsNode.editable = true
 	n.funcblk.addNode(n.outputsNode)
+	if !n.literal && !isMethod(obj) {
+		n.tparams = newTypeParamsView(&n.sig().TypeParams, n.pkg())
+		n.tparams.done = func() { SetKeyFocus(n.inputsNode) }
+		n.tparams.reformed = func() { MoveCenter(n.tparams, Pt(0, Height(n.tparams)/2+portSize)) }
+		n.tparams.reform()
+		n.inputsNode.Add(n.tparams)
+	}
 	return n
 }
 
 func (n *funcNode) Close() {
 	if !n.literal {
+		n.history = nil
 		saveFunc(n)
 		n.funcblk.close()
 		n.stop.stop()
@@ -62,6 +77,15 @@ func (n *funcNode) Close() {
 	n.ViewBase.Close()
 }
 
+// resultsChanged updates the return nodes returning from n after its results are edited.
+func (n *funcNode) resultsChanged() {
+	for _, m := range n.funcblk.allNodes() {
+		if r, ok := m.(*returnNode); ok && enclosingFunc(r.block()) == n {
+			r.syncResults()
+		}
+	}
+}
+
 func (n *funcNode) sig() *types.Signature {
 	obj := n.obj
 	if obj == nil {
@@ -108,24 +132,63 @@ func (n *funcNode) subPkgRef(x interface{}) {
 	}
 }
 
-func (n funcNode) block() *block      { return n.blk }
-func (n *funcNode) setBlock(b *block) { n.blk = b }
-func (n funcNode) inputs() []*port    { return nil }
-func (n funcNode) outputs() (p []*port) {
-	if n.output != nil {
-		p = append(p, n.output)
+// pkgRefs returns the objects and types by which n (but not its nested nodes) refers to other packages, as passed to addPkgRef.
+func pkgRefs(n node) (refs []interface{}) {
+	switch n := n.(type) {
+	case *callNode:
+		if n.obj != nil && !isMethod(n.obj) {
+			refs = append(refs, n.obj)
+		}
+	case *valueNode:
+		switch obj := n.obj.(type) {
+		case *types.Const, *types.Var:
+			refs = append(refs, obj)
+		case *types.Func:
+			if !isMethod(obj) {
+				refs = append(refs, obj)
+			}
+		}
+	case *compositeLiteralNode:
+		refs = appendType(refs, *n.typ.typ)
+	case *structPackNode:
+		refs = appendType(refs, *n.typ.typ)
+	case *convertNode:
+		refs = appendType(refs, *n.typ.typ)
+	case *typeAssertNode:
+		refs = appendType(refs, *n.typ.typ)
+	case *makeNode:
+		refs = appendType(refs, *n.typ.typ)
+	case *newNode:
+		refs = appendType(refs, *n.typ.typ)
+	case *typeSwitchNode:
+		for _, c := range n.cases {
+			if c.typ != nil {
+				refs = appendType(refs, *c.typ.typ)
+			}
+		}
+	case *funcNode:
+		sig := n.sig()
+		for _, v := range append(sig.Params, sig.Results...) {
+			refs = appendType(refs, v.Type)
+		}
 	}
 	return
 }
-func (n funcNode) inConns() []*connection { return n.funcblk.inConns() }
-func (n funcNode) outConns() []*connection {
-	c := n.funcblk.outConns()
-	if n.output != nil {
-		c = append(c, n.output.conns...)
+
+func appendType(refs []interface{}, t types.Type) []interface{} {
+	if t != nil {
+		refs = append(refs, t)
 	}
-	return c
+	return refs
 }
 
+func (n funcNode) block() *block           { return blockView(n.model.Block) }
+func (n funcNode) inputs() []*port         { return portViews(n.model.Ins) }
+func (n funcNode) outputs() []*port        { return portViews(n.model.Outs) }
+func (n funcNode) graphNode() *graph.Node  { return n.model }
+func (n funcNode) inConns() []*connection  { return connViews(n.model.InConns()) }
+func (n funcNode) outConns() []*connection { return connViews(n.model.OutConns()) }
+
 func (n *funcNode) Move(p Point) {
 	n.ViewBase.Move(p)
 	nodeMoved(n)
@@ -145,6 +208,23 @@ func (n *funcNode) LostKeyFocus() {
 func (n *funcNode) KeyPress(event KeyEvent) {
 	if event.Command && event.Key == KeyS && !n.literal {
 		saveFunc(n)
+		refreshOtherPanes(n)
+	} else if event.Command && event.Key == KeyP && !n.literal {
+		ext := ".svg"
+		if event.Shift {
+			ext = ".png"
+		} else if event.Alt {
+			ext = ".pdf"
+		}
+		if err := exportFunc(n, exportPath(n.obj, ext)); err != nil {
+			fmt.Println(err)
+		}
+	} else if event.Command && event.Key == KeyZ && n.history != nil {
+		if event.Shift {
+			n.redo()
+		} else {
+			n.undo()
+		}
 	} else if event.Key == KeyUp && n.literal {
 		SetKeyFocus(n.outputsNode)
 	} else {
@@ -152,14 +232,14 @@ func (n *funcNode) KeyPress(event KeyEvent) {
 	}
 }
 
-func (n funcNode) Paint() {
+func (n funcNode) Paint(cv Canvas) {
 	if n.literal {
-		SetColor(lineColor)
-		DrawLine(Pt(0, portSize/2), Pt(0, -portSize))
+		cv.SetColor(lineColor)
+		cv.DrawLine(Pt(0, portSize/2), Pt(0, -portSize))
 		if n.focused {
-			SetPointSize(2 * portSize)
-			SetColor(focusColor)
-			DrawPoint(ZP)
+			cv.SetPointSize(2 * portSize)
+			cv.SetColor(focusColor)
+			cv.DrawPoint(ZP)
 		}
 	}
 }
@@ -0,0 +1,87 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"testing"
+)
+
+// genericExample returns a generic func genericExample[T any](a []T, x T) (b []T) in pkg.
+func genericExample(pkg *types.Package) *types.Func {
+	tn := types.NewTypeName(0, pkg, "T", nil)
+	T := types.NewTypeParam(tn, 0, types.Universe.Lookup("any").GetType())
+	sig := types.NewSignature(nil, nil, []*types.Var{newVar("a", &types.Slice{Elem: T}), newVar("x", T)}, []*types.Var{newVar("b", &types.Slice{Elem: T})}, false)
+	sig.TypeParams = []*types.TypeName{tn}
+	return types.NewFunc(0, pkg, "genericExample", sig)
+}
+
+// TestGenericCallInference checks that a call of a generic func infers its type arguments from the connected inputs and retypes its ports accordingly.
+func TestGenericCallInference(t *testing.T) {
+	pkg := audioPackage(t)
+	floats := &types.Slice{Elem: types.Typ[types.Float64]}
+	src := `// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func inferExample(a []float64) {
+	return
+}
+`
+	f := readTestFunc(t, pkg, "inferExample", []*types.Var{newVar("a", floats)}, nil, src)
+	defer f.funcblk.close()
+	call := newCallNode(genericExample(pkg), pkg, "").(*callNode)
+	f.funcblk.addNode(call)
+
+	a := f.inputsNode.outputs()[0]
+	if !call.connectable(floats, ins(call)[0]) {
+		t.Fatal("[]float64 is not connectable to a []T input")
+	}
+	if call.connectable(types.Typ[types.String], ins(call)[0]) {
+		t.Error("string is connectable to a []T input")
+	}
+	c := newConnection()
+	c.setSrc(a)
+	c.setDst(ins(call)[0])
+	if len(call.targs) != 1 || call.targs[0] != types.Type(types.Typ[types.Float64]) {
+		t.Fatalf("got type arguments %v, want [float64]", call.targs)
+	}
+	for i, want := range []types.Type{floats, types.Typ[types.Float64]} {
+		if got := ins(call)[i].obj.Type; !types.IsIdentical(got, want) {
+			t.Errorf("input %d: got %s, want %s", i, got, want)
+		}
+	}
+	if got := outs(call)[0].obj.Type; !types.IsIdentical(got, floats) {
+		t.Errorf("output: got %s, want %s", got, floats)
+	}
+
+	c.disconnect()
+	if call.targs != nil {
+		t.Errorf("got type arguments %v after disconnecting, want none", call.targs)
+	}
+	if _, ok := ins(call)[1].obj.Type.(*types.TypeParam); !ok {
+		t.Errorf("input 1: got %s after disconnecting, want T", ins(call)[1].obj.Type)
+	}
+}
+
+const genericSrc = srcHeader + `func genericExample[T any](a []T, x T) (b []T) {
+	var v []T
+	var v2 T
+	v = a
+	v2 = x
+	b2 := genericExample(v, v2)
+	b = b2
+	return
+}
+`
+
+// TestGenericRecursive reads a generic func calling itself and checks that it is written out and read back in unchanged.
+func TestGenericRecursive(t *testing.T) {
+	testEdit(t, editTest{
+		fn:        genericExample,
+		recursive: true,
+		src:       genericSrc,
+	})
+}
@@ -40,6 +40,12 @@ func (check *checker) assignment(x *operand, T Type) bool {
 		return false
 	}
 
+	if sig, _ := x.typ.(*Signature); sig != nil && sig.TypeParams != nil {
+		check.errorf(x.pos(), "cannot use generic function %s without instantiation", x.expr)
+		x.mode = invalid
+		return false
+	}
+
 	if isUntyped(x.typ) {
 		target := T
 		// spec: "If an untyped constant is assigned to a variable of interface
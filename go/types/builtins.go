@@ -68,7 +68,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// of S and the respective parameter passing rules apply."
 		S := x.typ
 		var T Type
-		if s, _ := S.Underlying().(*Slice); s != nil {
+		if s, _ := coreType(S).(*Slice); s != nil {
 			T = s.Elem
 		} else {
 			check.invalidArg(x.pos(), "%s is not a slice", x)
@@ -125,7 +125,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		mode := invalid
 		var typ Type
 		var val exact.Value
-		switch typ = implicitArrayDeref(x.typ.Underlying()); t := typ.(type) {
+		switch typ = implicitArrayDeref(coreType(x.typ)); t := typ.(type) {
 		case *Basic:
 			if isString(t) && id == _Len {
 				if x.mode == constant {
@@ -170,7 +170,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 	case _Close:
 		// close(c)
-		c, _ := x.typ.Underlying().(*Chan)
+		c, _ := coreType(x.typ).(*Chan)
 		if c == nil {
 			check.invalidArg(x.pos(), "%s is not a channel", x)
 			return
@@ -222,7 +222,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 		realT := x.typ
 		complexT := Typ[Invalid]
-		switch realT.Underlying().(*Basic).Kind {
+		switch coreType(realT).(*Basic).Kind {
 		case Float32:
 			complexT = Typ[Complex64]
 		case Float64:
@@ -261,7 +261,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 	case _Copy:
 		// copy(x, y []T) int
 		var dst Type
-		if t, _ := x.typ.Underlying().(*Slice); t != nil {
+		if t, _ := coreType(x.typ).(*Slice); t != nil {
 			dst = t.Elem
 		}
 
@@ -271,7 +271,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			return
 		}
 		var src Type
-		switch t := y.typ.Underlying().(type) {
+		switch t := coreType(y.typ).(type) {
 		case *Basic:
 			if isString(y.typ) {
 				src = universeByte
@@ -297,9 +297,68 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, S, S))
 		}
 
+	case _Clear:
+		// clear(m) or clear(s)
+		switch coreType(x.typ).(type) {
+		case *Map, *Slice:
+		default:
+			check.invalidArg(x.pos(), "%s is not a map or slice", x)
+			return
+		}
+
+		x.mode = novalue
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(nil, x.typ))
+		}
+
+	case _Max, _Min:
+		// max(x, y...) T
+		// min(x, y...) T
+		for i := 1; i < nargs; i++ {
+			var y operand
+			arg(&y, i)
+			if y.mode == invalid {
+				return
+			}
+			check.convertUntyped(x, y.typ)
+			if x.mode == invalid {
+				return
+			}
+			check.convertUntyped(&y, x.typ)
+			if y.mode == invalid {
+				return
+			}
+			if !IsIdentical(x.typ, y.typ) {
+				check.invalidArg(x.pos(), "mismatched types %s and %s", x.typ, y.typ)
+				return
+			}
+			if x.mode == constant && y.mode == constant {
+				op := token.LSS
+				if id == _Max {
+					op = token.GTR
+				}
+				if exact.Compare(y.val, op, x.val) {
+					x.val = y.val
+				}
+			} else {
+				x.mode = value
+			}
+		}
+		if !isOrdered(x.typ) {
+			check.invalidArg(x.pos(), "%s cannot be ordered", x)
+			return
+		}
+		if check.Types != nil && x.mode != constant {
+			params := make([]Type, nargs)
+			for i := range params {
+				params[i] = x.typ
+			}
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, params...))
+		}
+
 	case _Delete:
 		// delete(m, k)
-		m, _ := x.typ.Underlying().(*Map)
+		m, _ := coreType(x.typ).(*Map)
 		if m == nil {
 			check.invalidArg(x.pos(), "%s is not a map", x)
 			return
@@ -322,7 +381,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 	case _Imag, _Real:
 		// imag(complexT) realT
 		// real(complexT) realT
-		if !isComplex(x.typ) {
+		if _, ok := coreType(x.typ).(*Basic); !ok || !isComplex(x.typ) {
 			check.invalidArg(x.pos(), "%s must be a complex number", x)
 			return
 		}
@@ -336,7 +395,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			x.mode = value
 		}
 		var k BasicKind
-		switch x.typ.Underlying().(*Basic).Kind {
+		switch coreType(x.typ).(*Basic).Kind {
 		case Complex64:
 			k = Float32
 		case Complex128:
@@ -362,7 +421,7 @@ func (check *checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			return
 		}
 		var min int // minimum number of arguments
-		switch T.Underlying().(type) {
+		switch coreType(T).(type) {
 		case *Slice:
 			min = 2
 		case *Map, *Chan:
@@ -40,6 +40,9 @@ var builtinCalls = []struct {
 	{"len", `var c chan<-bool; _ = len(c)`, `func(chan<- bool) int`},
 	{"len", `var m map[string]float32; _ = len(m)`, `func(map[string]float32) int`},
 
+	{"clear", `var s []int; clear(s)`, `func([]int)`},
+	{"clear", `var m map[string]bool; clear(m)`, `func(map[string]bool)`},
+
 	{"close", `var c chan int; close(c)`, `func(chan int)`},
 	{"close", `var c chan<- chan string; close(c)`, `func(chan<- chan string)`},
 
@@ -70,6 +73,14 @@ var builtinCalls = []struct {
 	{"make", `_ = make([]int, 10)`, `func([]int, int) []int`},
 	{"make", `type T []byte; _ = make(T, 10, 20)`, `func(p.T, int, int) p.T`},
 
+	{"max", `_ = max(1, 2.5)`, `invalid type`}, // constant
+	{"max", `var x int; _ = max(x, 1)`, `func(int, int) int`},
+	{"max", `type T string; var s T; _ = max(s, "foo", s)`, `func(p.T, p.T, p.T) p.T`},
+
+	{"min", `_ = min(1, 2.5)`, `invalid type`}, // constant
+	{"min", `var x float32; _ = min(x)`, `func(float32) float32`},
+	{"min", `var x, y float64; _ = min(x, y)`, `func(float64, float64) float64`},
+
 	{"new", `_ = new(int)`, `func(int) *int`},
 	{"new", `type T struct{}; _ = new(T)`, `func(p.T) *p.T`},
 
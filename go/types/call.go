@@ -61,6 +61,14 @@ func (check *checker) call(x *operand, e *ast.CallExpr) exprKind {
 		}
 
 		arg, n, _ := unpack(func(x *operand, i int) { check.expr(x, e.Args[i]) }, len(e.Args), false)
+		if sig.TypeParams != nil {
+			sig, arg = check.inferCall(e, sig, arg, n)
+			if sig == nil {
+				x.mode = invalid
+				x.expr = e
+				return statement
+			}
+		}
 		check.arguments(x, e, sig, arg, n)
 
 		// determine result
@@ -80,6 +88,79 @@ func (check *checker) call(x *operand, e *ast.CallExpr) exprKind {
 	}
 }
 
+// instantiatedExpr type-checks the explicit instantiation of the generic type
+// or function x, as in e[args], reporting whether x is generic. A function
+// may be instantiated partially, leaving the rest of its type arguments to be
+// inferred when it is called.
+func (check *checker) instantiatedExpr(x *operand, e ast.Expr, args []ast.Expr) bool {
+	switch x.mode {
+	case typexpr:
+		x.typ = check.instantiatedType(e, args, nil, false)
+		if x.typ == Typ[Invalid] {
+			x.mode = invalid
+		}
+		return true
+
+	case value:
+		sig, _ := x.typ.(*Signature)
+		if sig == nil || sig.TypeParams == nil {
+			return false
+		}
+		targs := check.typeArgs(args)
+		if targs == nil {
+			x.mode = invalid
+			return true
+		}
+		if len(targs) > len(sig.TypeParams) {
+			check.errorf(args[0].Pos(), "got %d type arguments for %s, want %d", len(targs), x, len(sig.TypeParams))
+			x.mode = invalid
+			return true
+		}
+		if len(targs) == len(sig.TypeParams) {
+			check.verifyTypeArgs(args[0].Pos(), sig.TypeParams, targs)
+		}
+		x.typ = instantiateSignature(sig, targs)
+		return true
+	}
+	return false
+}
+
+// inferCall infers the type arguments of the generic function sig from the
+// arguments of call, which arg provides. It returns the instantiated signature
+// (or nil if inference fails) and a getter for the evaluated arguments.
+func (check *checker) inferCall(call *ast.CallExpr, sig *Signature, arg getter, n int) (*Signature, getter) {
+	args := make([]operand, n)
+	params := make([]Type, n)
+	types := make([]Type, n)
+	for i := range args {
+		arg(&args[i], i)
+		switch np := len(sig.Params); {
+		case sig.IsVariadic && i >= np-1 && !call.Ellipsis.IsValid():
+			params[i] = sig.Params[np-1].Type.(*Slice).Elem
+		case i < np:
+			params[i] = sig.Params[i].Type
+		}
+		if args[i].mode != invalid {
+			types[i] = args[i].typ
+		}
+	}
+	get := func(x *operand, i int) { *x = args[i] }
+
+	targs, ok := Infer(sig.TypeParams, params, types)
+	if !ok {
+		check.errorf(call.Rparen, "inconsistent type arguments in call to %s", call.Fun)
+		return nil, get
+	}
+	for i, t := range targs {
+		if t == nil {
+			check.errorf(call.Rparen, "cannot infer %s in call to %s", sig.TypeParams[i].Name, call.Fun)
+			return nil, get
+		}
+	}
+	check.verifyTypeArgs(call.Rparen, sig.TypeParams, targs)
+	return instantiateSignature(sig, targs), get
+}
+
 // use type-checks each list element.
 // Useful to make sure a list of expressions is evaluated
 // (and variables are "used") in the presence of other errors.
@@ -47,6 +47,9 @@ type checker struct {
 	iota     exact.Value          // current value of iota in a constant declaration; nil otherwise
 	decl     *declInfo            // current package-level declaration whose init expression/body is type-checked
 
+	constraintIface *ast.InterfaceType // interface literal of the type parameter constraint being type-checked, which may contain type terms
+	usedImports     map[Object]bool    // dot-imported objects that are used (see check.markUsed)
+
 	// functions
 	funcList []funcInfo // list of functions/methods with correct signatures and non-empty bodies
 	funcSig  *Signature // signature of currently type-checked function
@@ -234,6 +237,9 @@ func (conf *Config) check(pkgPath string, fset *token.FileSet, files []*ast.File
 	}
 	check.delayed = nil // not needed anymore
 
+	// the instances of the package's generic types are shared once it is imported
+	expandInstances(pkg)
+
 	// remaining untyped expressions must indeed be untyped
 	if debug {
 		for x, info := range check.untyped {
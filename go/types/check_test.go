@@ -78,6 +78,7 @@ var tests = [][]string{
 	{"testdata/gotos.src"},
 	{"testdata/labels.src"},
 	{"testdata/issues.src"},
+	{"testdata/typeparams.src"},
 }
 
 var fset = token.NewFileSet()
@@ -96,7 +96,7 @@ func (check *checker) unary(x *operand, op token.Token) {
 		return
 
 	case token.ARROW:
-		typ, ok := x.typ.Underlying().(*Chan)
+		typ, ok := coreType(x.typ).(*Chan)
 		if !ok {
 			check.invalidOp(x.pos(), "cannot receive from non-channel %s", x)
 			x.mode = invalid
@@ -459,6 +459,10 @@ func (check *checker) updateExprType(x ast.Expr, typ Type, final bool) {
 	}
 
 	// Everything's fine, record final type and value for x.
+	// Values of type parameter type are never constant.
+	if _, ok := typ.(*TypeParam); ok {
+		old.val = nil
+	}
 	check.recordTypeAndValue(x, typ, old.val)
 }
 
@@ -546,6 +550,33 @@ func (check *checker) convertUntyped(x *operand, target Type) {
 		}
 		// keep nil untyped - see comment for interfaces, above
 		target = Typ[UntypedNil]
+	case *TypeParam:
+		// x must be convertible to each type in the type set;
+		// the result is not constant
+		terms := t.Interface().Terms
+		if len(terms) == 0 {
+			goto Error
+		}
+		for _, term := range terms {
+			b, _ := term.Type.Underlying().(*Basic)
+			switch {
+			case b == nil:
+				if !x.isNil() || !hasNil(term.Type) {
+					goto Error
+				}
+			case x.mode == constant:
+				if !isRepresentableConst(x.val, check.conf, b.Kind, nil) {
+					goto Error
+				}
+			case x.typ == Typ[UntypedBool] && !isBoolean(b),
+				isNumeric(x.typ) && !isNumeric(b),
+				x.isNil() && b.Kind != UnsafePointer:
+				goto Error
+			}
+		}
+		if x.mode == constant {
+			x.mode = value
+		}
 	default:
 		goto Error
 	}
@@ -997,7 +1028,7 @@ func (check *checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			goto Error
 		}
 
-		switch typ, _ := deref(typ); utyp := typ.Underlying().(type) {
+		switch typ, _ := deref(typ); utyp := coreType(typ).(type) {
 		case *Struct:
 			if len(e.Elts) == 0 {
 				break
@@ -1124,14 +1155,24 @@ func (check *checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		check.selector(x, e)
 
 	case *ast.IndexExpr:
-		check.expr(x, e.X)
+		check.exprOrType(x, e.X)
 		if x.mode == invalid {
 			goto Error
 		}
+		if check.instantiatedExpr(x, e.X, []ast.Expr{e.Index}) {
+			if x.mode == invalid {
+				goto Error
+			}
+			break
+		}
+		if x.mode == typexpr || x.mode == builtin {
+			check.errorf(x.pos(), "%s is not an expression", x)
+			goto Error
+		}
 
 		valid := false
 		length := int64(-1) // valid if >= 0
-		switch typ := x.typ.Underlying().(type) {
+		switch typ := coreType(x.typ).(type) {
 		case *Basic:
 			if isString(typ) {
 				valid = true
@@ -1194,6 +1235,19 @@ func (check *checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		check.index(e.Index, length)
 		// ok to continue
 
+	case *ast.IndexListExpr:
+		check.exprOrType(x, e.X)
+		if x.mode == invalid {
+			goto Error
+		}
+		if !check.instantiatedExpr(x, e.X, e.Indices) {
+			check.invalidOp(x.pos(), "%s is not a generic type or function", x)
+			goto Error
+		}
+		if x.mode == invalid {
+			goto Error
+		}
+
 	case *ast.SliceExpr:
 		check.expr(x, e.X)
 		if x.mode == invalid {
@@ -1202,7 +1256,7 @@ func (check *checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 
 		valid := false
 		length := int64(-1) // valid if >= 0
-		switch typ := x.typ.Underlying().(type) {
+		switch typ := coreType(x.typ).(type) {
 		case *Basic:
 			if isString(typ) {
 				if slice3(e) {
@@ -1329,7 +1383,7 @@ func (check *checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		case typexpr:
 			x.typ = &Pointer{Elem: x.typ}
 		default:
-			if typ, ok := x.typ.Underlying().(*Pointer); ok {
+			if typ, ok := coreType(x.typ).(*Pointer); ok {
 				x.mode = variable
 				x.typ = typ.Elem
 			} else {
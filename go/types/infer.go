@@ -0,0 +1,286 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements type argument inference.
+
+package types
+
+// Infer infers the type arguments of the generic function with type parameters
+// tparams from the types args of the arguments passed for the parameters of
+// types params. A nil argument type is unknown and ignored. Untyped arguments
+// only contribute their default type, and only where nothing else determines
+// a type argument. Type parameters constrained to a single type are inferred
+// from the type arguments of the others, and vice versa.
+//
+// The result has an entry for each type parameter, which is nil if it could
+// not be inferred (yet); ok reports whether the arguments are consistent with
+// each other and with params.
+func Infer(tparams []*TypeName, params, args []Type) (targs []Type, ok bool) {
+	// The type parameters are renamed so that arguments mentioning them, as
+	// in a recursive call, are not mistaken for them.
+	tparams, rename := renameTypeParams(tparams)
+	u := unifier{substMap{}}
+	for _, tn := range tparams {
+		u.m[tn.Type.(*TypeParam)] = nil
+	}
+
+	ok = true
+	untyped := map[*TypeParam]*Basic{}
+	for i, arg := range args {
+		if arg == nil || i >= len(params) {
+			continue
+		}
+		param := rename.subst(params[i])
+		if u.resolved(param) {
+			continue // nothing to infer; assignability is checked elsewhere
+		}
+		if b, _ := arg.(*Basic); b != nil && b.Info&IsUntyped != 0 {
+			if tp, _ := param.(*TypeParam); tp != nil && b.Kind != UntypedNil {
+				if prev := untyped[tp]; prev == nil || prev.Kind < b.Kind {
+					untyped[tp] = b
+				}
+			}
+			continue
+		}
+		if !u.unify(param, arg) {
+			ok = false
+		}
+	}
+	u.constraints(tparams)
+	for tp, b := range untyped {
+		if u.m[tp] == nil {
+			u.m[tp] = defaultType(b)
+		}
+	}
+	u.constraints(tparams)
+
+	targs = make([]Type, len(tparams))
+	for i, tn := range tparams {
+		targs[i] = u.m.subst(tn.Type)
+		if !u.resolved(targs[i]) {
+			targs[i] = nil
+		}
+	}
+	return
+}
+
+// renameTypeParams returns copies of tparams, with constraints referring to
+// the copies, and the substitution of the copies for tparams.
+func renameTypeParams(tparams []*TypeName) ([]*TypeName, substMap) {
+	copies := make([]*TypeName, len(tparams))
+	types := make([]Type, len(tparams))
+	for i, tn := range tparams {
+		copies[i] = NewTypeName(tn.pos, tn.Pkg, tn.Name, nil)
+		types[i] = NewTypeParam(copies[i], i, nil)
+	}
+	m := newSubstMap(tparams, types)
+	for i, tn := range tparams {
+		copies[i].Type.(*TypeParam).Constraint = m.subst(tn.Type.(*TypeParam).Constraint)
+	}
+	return copies, m
+}
+
+// A unifier records the types inferred for type parameters.
+type unifier struct {
+	m substMap // the inferred type for each type parameter being inferred, or nil
+}
+
+// resolved reports whether t mentions none of the type parameters being inferred.
+func (u unifier) resolved(t Type) bool {
+	found := false
+	walkTypeParams(t, func(tp *TypeParam) {
+		if _, ok := u.m[tp]; ok {
+			found = true
+		}
+	})
+	return !found
+}
+
+// unify reports whether x, which may mention type parameters being inferred,
+// matches y, recording the types inferred along the way. Named types match
+// unnamed types with the same structure, as for assignability.
+func (u unifier) unify(x, y Type) bool {
+	if tp, _ := x.(*TypeParam); tp != nil {
+		if t, ok := u.m[tp]; ok {
+			if t == nil {
+				u.m[tp] = y
+				return true
+			}
+			return u.unify(t, y) || u.unify(y, t)
+		}
+	}
+	if x == y {
+		return true
+	}
+	if _, ok := x.(*Named); !ok {
+		if _, ok := y.(*TypeParam); !ok {
+			y = y.Underlying()
+		}
+	}
+
+	switch x := x.(type) {
+	case *Array:
+		if y, ok := y.(*Array); ok {
+			return x.Len == y.Len && u.unify(x.Elem, y.Elem)
+		}
+	case *Slice:
+		if y, ok := y.(*Slice); ok {
+			return u.unify(x.Elem, y.Elem)
+		}
+	case *Pointer:
+		if y, ok := y.(*Pointer); ok {
+			return u.unify(x.Elem, y.Elem)
+		}
+	case *Map:
+		if y, ok := y.(*Map); ok {
+			return u.unify(x.Key, y.Key) && u.unify(x.Elem, y.Elem)
+		}
+	case *Chan:
+		if y, ok := y.(*Chan); ok {
+			return (x.Dir == y.Dir || y.Dir == SendRecv) && u.unify(x.Elem, y.Elem)
+		}
+	case *Struct:
+		if y, ok := y.(*Struct); ok && len(x.Fields) == len(y.Fields) {
+			for i, f := range x.Fields {
+				g := y.Fields[i]
+				if f.Anonymous != g.Anonymous || !f.sameId(g.Pkg, g.Name) || !u.unify(f.Type, g.Type) {
+					return false
+				}
+			}
+			return true
+		}
+	case *Signature:
+		if y, ok := y.(*Signature); ok && x.IsVariadic == y.IsVariadic && len(x.Params) == len(y.Params) && len(x.Results) == len(y.Results) {
+			for i, p := range x.Params {
+				if !u.unify(p.Type, y.Params[i].Type) {
+					return false
+				}
+			}
+			for i, r := range x.Results {
+				if !u.unify(r.Type, y.Results[i].Type) {
+					return false
+				}
+			}
+			return true
+		}
+	case *Named:
+		if y, ok := y.(*Named); ok && x.Orig != nil && y.Orig == x.Orig {
+			for i, a := range x.TypeArgs {
+				if !u.unify(a, y.TypeArgs[i]) {
+					return false
+				}
+			}
+			return true
+		}
+		if x.Orig == nil && len(x.TypeParams) > 0 {
+			if y, ok := y.(*Named); ok && y.Orig == x {
+				// x is the generic type standing for its own instance
+				for i, tn := range x.TypeParams {
+					if !u.unify(tn.Type, y.TypeArgs[i]) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	}
+	return IsIdentical(x, y)
+}
+
+// constraints infers the type parameters constrained to a single type (as in
+// [S ~[]E, E any]) from that type and vice versa, until nothing more is inferred.
+func (u unifier) constraints(tparams []*TypeName) {
+	for changed := true; changed; {
+		changed = false
+		for _, tn := range tparams {
+			tp := tn.Type.(*TypeParam)
+			terms := tp.Interface().Terms
+			if len(terms) != 1 {
+				continue
+			}
+			term := terms[0]
+			if t := u.m[tp]; t != nil {
+				before := u.count()
+				if term.Tilde {
+					t = t.Underlying()
+				}
+				u.unify(term.Type, t)
+				changed = changed || u.count() > before
+			} else if u.resolved(u.m.subst(term.Type)) {
+				u.m[tp] = u.m.subst(term.Type)
+				changed = true
+			}
+		}
+	}
+}
+
+// count returns the number of type parameters inferred so far.
+func (u unifier) count() int {
+	n := 0
+	for _, t := range u.m {
+		if t != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// walkTypeParams calls f for each type parameter mentioned in t.
+func walkTypeParams(t Type, f func(*TypeParam)) {
+	walkTypeParams1(t, f, map[Type]bool{})
+}
+
+func walkTypeParams1(t Type, f func(*TypeParam), seen map[Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	walk := func(t Type) { walkTypeParams1(t, f, seen) }
+	switch t := t.(type) {
+	case *TypeParam:
+		f(t)
+	case *Array:
+		walk(t.Elem)
+	case *Slice:
+		walk(t.Elem)
+	case *Pointer:
+		walk(t.Elem)
+	case *Map:
+		walk(t.Key)
+		walk(t.Elem)
+	case *Chan:
+		walk(t.Elem)
+	case *Struct:
+		for _, f := range t.Fields {
+			walk(f.Type)
+		}
+	case *Tuple:
+		for _, v := range *t {
+			walk(v.Type)
+		}
+	case *Signature:
+		for _, v := range t.Params {
+			walk(v.Type)
+		}
+		for _, v := range t.Results {
+			walk(v.Type)
+		}
+	case *Interface:
+		for _, m := range t.allMethods {
+			walk(m.Type)
+		}
+		for _, term := range t.Terms {
+			walk(term.Type)
+		}
+	case *Named:
+		for _, a := range t.TypeArgs {
+			walk(a)
+		}
+		if t.Orig == nil {
+			for _, tn := range t.TypeParams {
+				walk(tn.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements instantiation of generic types and functions.
+
+package types
+
+import "sync"
+
+// instancesMu guards the instances of generic types and their expansion, which
+// the checks of different packages, running concurrently, may share:  the
+// instances of an imported generic type are shared by all packages importing
+// it.  An instance is completely expanded, and not modified afterwards, before
+// it is accessible outside of instancesMu (see expandInstances).
+var instancesMu sync.Mutex
+
+// Instantiate returns the instance of the generic named type or function
+// signature typ for the given type arguments. There must be as many type
+// arguments as type parameters; whether they satisfy their constraints is
+// not checked (see Verify). For a signature, a nil type argument leaves its
+// type parameter in place. Any other typ is returned unchanged.
+func Instantiate(typ Type, targs []Type) Type {
+	switch t := typ.(type) {
+	case *Named:
+		if len(t.TypeParams) == len(targs) && len(targs) > 0 {
+			return instantiate(t, targs)
+		}
+	case *Signature:
+		if len(t.TypeParams) == len(targs) && len(targs) > 0 {
+			return instantiateSignature(t, targs)
+		}
+	}
+	return typ
+}
+
+// instantiate returns the instance of the generic named type orig for targs.
+// Instantiating orig with its own type parameters yields orig itself, as
+// happens for the receiver of a method or a reference to orig within its own
+// declaration.
+func instantiate(orig *Named, targs []Type) *Named {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	return instance(orig, targs)
+}
+
+// instance is instantiate for a caller holding instancesMu.
+func instance(orig *Named, targs []Type) *Named {
+	if orig.Orig != nil {
+		orig = orig.Orig
+	}
+	own := true
+	for i, tn := range orig.TypeParams {
+		if targs[i] != tn.Type {
+			own = false
+		}
+	}
+	if own {
+		return orig
+	}
+	for _, t := range orig.instances {
+		if identicalTypes(t.TypeArgs, targs) {
+			return t
+		}
+	}
+	t := &Named{Obj: orig.Obj, UnderlyingT: Typ[Invalid], TypeArgs: targs, Orig: orig}
+	orig.instances = append(orig.instances, t) // before expanding, so that recursive references find t
+	t.expandLocked()
+	return t
+}
+
+// expand substitutes t's type arguments into the underlying type and methods
+// of its generic type, as far as they are known. Once the generic type is
+// completely declared, t is complete, too; methods declared later are added
+// on later calls.
+func (t *Named) expand() {
+	if t.Orig == nil {
+		return
+	}
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	t.expandLocked()
+}
+
+// expandLocked is expand for a caller holding instancesMu.
+func (t *Named) expandLocked() {
+	if t.Orig == nil || t.complete && len(t.Methods) == len(t.Orig.Methods) {
+		return
+	}
+	m := newSubstMap(t.Orig.TypeParams, t.TypeArgs)
+	if !t.complete && t.Orig.complete {
+		t.complete = true
+		t.UnderlyingT = m.typ(t.Orig.UnderlyingT)
+	}
+	for i := len(t.Methods); i < len(t.Orig.Methods); i = len(t.Methods) {
+		// append before substituting, in case substitution expands t again
+		t.Methods = append(t.Methods, t.Orig.Methods[i])
+		t.Methods[i] = m.fun(t.Orig.Methods[i])
+	}
+}
+
+// expandInstances expands the instances of the generic types of pkg once it
+// is checked, so that they aren't modified while shared with the checks of
+// the packages importing pkg.
+func expandInstances(pkg *Package) {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	for _, obj := range pkg.scope.Objects {
+		if _, ok := obj.(*TypeName); !ok {
+			continue
+		}
+		if t, _ := obj.GetType().(*Named); t != nil {
+			for i := 0; i < len(t.instances); i++ { // expanding may add instances
+				t.instances[i].expandLocked()
+			}
+		}
+	}
+}
+
+// instantiateSignature returns the signature of the generic function sig for
+// the leading type arguments targs. Type parameters for which there are no
+// type arguments remain type parameters of the result.
+func instantiateSignature(sig *Signature, targs []Type) *Signature {
+	m := newSubstMap(sig.TypeParams[:len(targs)], targs)
+	s := *m.subst(sig).(*Signature)
+	s.TypeParams = sig.TypeParams[len(targs):]
+	if len(s.TypeParams) == 0 {
+		s.TypeParams = nil
+	}
+	return &s
+}
+
+// Satisfies reports whether type t satisfies the type parameter constraint
+// constraint: it must be in the constraint's type set, be comparable if the
+// constraint requires it, and implement the constraint's methods. A nil
+// constraint is satisfied by any type.
+func Satisfies(t, constraint Type) bool {
+	iface := constraintInterface(constraint)
+	if iface.Terms != nil {
+		if tp, _ := t.(*TypeParam); tp != nil {
+			terms := tp.Interface().Terms
+			if terms == nil {
+				return false
+			}
+			for _, term := range terms {
+				if !includesTerm(iface.Terms, term) {
+					return false
+				}
+			}
+		} else if !includes(iface.Terms, t) {
+			return false
+		}
+	}
+	if iface.IsComparable && !Comparable(t) {
+		return false
+	}
+	m, _ := MissingMethod(t, iface, true)
+	return m == nil
+}
+
+// Verify returns the index of the first of the type arguments targs that does
+// not satisfy the constraint of its type parameter in tparams, or -1 if they
+// all do. Type arguments are substituted for their type parameters in the
+// constraints. A nil type argument is not known yet and satisfies any constraint.
+func Verify(tparams []*TypeName, targs []Type) int {
+	m := newSubstMap(tparams[:len(targs)], targs)
+	for i, tn := range tparams[:len(targs)] {
+		if targs[i] != nil && !Satisfies(targs[i], m.subst(tn.Type.(*TypeParam).Constraint)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// includes reports whether one of terms permits type t.
+func includes(terms []*Term, t Type) bool {
+	for _, term := range terms {
+		if term.includes(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesTerm reports whether all types permitted by x are permitted by one of terms.
+func includesTerm(terms []*Term, x *Term) bool {
+	for _, term := range terms {
+		if term.Tilde && IsIdentical(term.Type, x.Type.Underlying()) || !x.Tilde && term.includes(x.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// identicalTypes reports whether the type lists x and y are identical.
+func identicalTypes(x, y []Type) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !IsIdentical(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// A substMap maps type parameters to the types that replace them.
+type substMap map[*TypeParam]Type
+
+func newSubstMap(tparams []*TypeName, targs []Type) substMap {
+	m := substMap{}
+	for i, tn := range tparams {
+		m[tn.Type.(*TypeParam)] = targs[i]
+	}
+	return m
+}
+
+// subst returns t with the type parameters in m replaced.  Parts of t that
+// contain none of them are not copied.
+func (m substMap) subst(t Type) Type {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	return m.typ(t)
+}
+
+// typ is subst for a caller holding instancesMu.
+func (m substMap) typ(t Type) Type {
+	switch t := t.(type) {
+	case *TypeParam:
+		if u, ok := m[t]; ok && u != nil {
+			return u
+		}
+	case *Array:
+		if elem := m.typ(t.Elem); elem != t.Elem {
+			return &Array{t.Len, elem}
+		}
+	case *Slice:
+		if elem := m.typ(t.Elem); elem != t.Elem {
+			return &Slice{elem}
+		}
+	case *Pointer:
+		if elem := m.typ(t.Elem); elem != t.Elem {
+			return &Pointer{Elem: elem}
+		}
+	case *Map:
+		if key, elem := m.typ(t.Key), m.typ(t.Elem); key != t.Key || elem != t.Elem {
+			return &Map{key, elem}
+		}
+	case *Chan:
+		if elem := m.typ(t.Elem); elem != t.Elem {
+			return &Chan{t.Dir, elem}
+		}
+	case *Struct:
+		if fields, ok := m.vars(t.Fields); ok {
+			return &Struct{Fields: fields, tags: t.tags}
+		}
+	case *Tuple:
+		if vars, ok := m.vars(*t); ok {
+			return NewTuple(vars...)
+		}
+	case *Signature:
+		var recv []*Var
+		if t.Recv != nil {
+			// the receiver of an interface method is the interface itself; substituting it would not terminate
+			if _, ok := t.Recv.Type.(*Interface); !ok {
+				recv = []*Var{t.Recv}
+			}
+		}
+		recv, ok1 := m.vars(recv)
+		params, ok2 := m.vars(t.Params)
+		results, ok3 := m.vars(t.Results)
+		if ok1 || ok2 || ok3 {
+			s := *t
+			if len(recv) > 0 {
+				s.Recv = recv[0]
+			}
+			s.Params = params
+			s.Results = results
+			return &s
+		}
+	case *Interface:
+		changed := false
+		methods := make([]*Func, len(t.Methods))
+		for i, f := range t.Methods {
+			methods[i] = m.fun(f)
+			changed = changed || methods[i] != f
+		}
+		allMethods := make([]*Func, len(t.allMethods))
+		for i, f := range t.allMethods {
+			allMethods[i] = m.fun(f)
+			changed = changed || allMethods[i] != f
+		}
+		embeddeds := make([]*Named, len(t.Embeddeds))
+		for i, e := range t.Embeddeds {
+			embeddeds[i] = m.typ(e).(*Named)
+			changed = changed || embeddeds[i] != e
+		}
+		var terms []*Term
+		if t.Terms != nil {
+			terms = make([]*Term, len(t.Terms))
+			for i, term := range t.Terms {
+				terms[i] = term
+				if typ := m.typ(term.Type); typ != term.Type {
+					terms[i] = &Term{term.Tilde, typ}
+					changed = true
+				}
+			}
+		}
+		if changed {
+			return &Interface{Methods: methods, Embeddeds: embeddeds, Terms: terms, IsComparable: t.IsComparable, allMethods: allMethods}
+		}
+	case *Named:
+		targs := t.TypeArgs
+		if t.Orig == nil {
+			// a generic type referred to within its own declaration stands for its own instance
+			targs = make([]Type, len(t.TypeParams))
+			for i, tn := range t.TypeParams {
+				targs[i] = tn.Type
+			}
+		}
+		changed := false
+		args := make([]Type, len(targs))
+		for i, a := range targs {
+			args[i] = m.typ(a)
+			changed = changed || args[i] != a
+		}
+		if changed {
+			return instance(t, args)
+		}
+	}
+	return t
+}
+
+// vars returns vs with the type parameters in m replaced in their types, and whether any were replaced.
+func (m substMap) vars(vs []*Var) ([]*Var, bool) {
+	changed := false
+	res := make([]*Var, len(vs))
+	for i, v := range vs {
+		res[i] = v
+		if typ := m.typ(v.Type); typ != v.Type {
+			w := *v
+			w.Type = typ
+			res[i] = &w
+			changed = true
+		}
+	}
+	if !changed {
+		return vs, false
+	}
+	return res, true
+}
+
+// fun returns f with the type parameters in m replaced in its signature.
+func (m substMap) fun(f *Func) *Func {
+	if typ := m.typ(f.Type); typ != f.Type {
+		g := *f
+		g.Type = typ
+		return &g
+	}
+	return f
+}
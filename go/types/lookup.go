@@ -55,7 +55,7 @@ func LookupFieldOrMethod(T Type, pkg *Package, name string) (obj Object, index [
 	//           outlaw named types to pointer types - they are almost
 	//           never what one wants, anyway.
 	if t, _ := T.(*Named); t != nil {
-		u := t.UnderlyingT
+		u := t.Underlying()
 		if _, ok := u.(*Pointer); ok {
 			// typ is a named type with an underlying type of the form *T,
 			// start the search with the underlying type *T
@@ -82,11 +82,21 @@ func lookupFieldOrMethod(T Type, pkg *Package, name string) (obj Object, index [
 	typ, isPtr := deref(T)
 	named, _ := typ.(*Named)
 
+	// A type parameter has the methods of its constraint.
+	if tp, _ := typ.(*TypeParam); tp != nil {
+		if !isPtr {
+			if i, m := lookupMethod(tp.Interface().allMethods, pkg, name); m != nil {
+				return m, []int{i}, false
+			}
+		}
+		return
+	}
+
 	// *typ where typ is an interface has no methods.
 	if isPtr {
 		utyp := typ
 		if named != nil {
-			utyp = named.UnderlyingT
+			utyp = named.Underlying()
 		}
 		if _, ok := utyp.(*Interface); ok {
 			return
@@ -124,6 +134,7 @@ func lookupFieldOrMethod(T Type, pkg *Package, name string) (obj Object, index [
 				seen[e.typ] = true
 
 				// look for a matching attached method
+				e.typ.expand()
 				if i, m := lookupMethod(e.typ.Methods, pkg, name); m != nil {
 					// potential match
 					assert(m.Type != nil)
@@ -138,7 +149,7 @@ func lookupFieldOrMethod(T Type, pkg *Package, name string) (obj Object, index [
 				}
 
 				// continue with underlying type
-				typ = e.typ.UnderlyingT
+				typ = e.typ.Underlying()
 			}
 
 			switch t := typ.(type) {
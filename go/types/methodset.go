@@ -98,7 +98,7 @@ func NewMethodSet(T Type) *MethodSet {
 	if isPtr {
 		utyp := typ
 		if named != nil {
-			utyp = named.UnderlyingT
+			utyp = named.Underlying()
 		}
 		if _, ok := utyp.(*Interface); ok {
 			return &emptyMethodSet
@@ -181,7 +181,7 @@ func NewFieldSet(T Type) *FieldSet {
 	// If T is a named type with underlying type *V then T has all the fields of V but none of its methods.
 	// Furthermore, such a T can have no fields or methods of its own, so no need to worry about shadowing.
 	if t, _ := T.(*Named); t != nil {
-		u := t.UnderlyingT
+		u := t.Underlying()
 		if _, ok := u.(*Pointer); ok {
 			sel = selections(u)
 		}
@@ -245,10 +245,11 @@ func selections(T Type) selectionSet {
 				}
 				seen[e.typ] = true
 
+				e.typ.expand()
 				set = set.addMethods(e.typ.Methods, e.index, e.indirect, e.multiples)
 
 				// continue with underlying type
-				typ = e.typ.UnderlyingT
+				typ = e.typ.Underlying()
 			}
 
 			switch t := typ.(type) {
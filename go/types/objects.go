@@ -31,8 +31,8 @@ type Object interface {
 	// String returns a human-readable string of the object.
 	String() string
 
-	// isUsed reports whether the object was marked as 'used'.
-	isUsed() bool
+	// setUsed marks the object as 'used'.
+	setUsed()
 
 	// setParent sets the parent scope of the object.
 	setParent(*Scope)
@@ -85,7 +85,7 @@ func (obj *object) IsExported() bool { return ast.IsExported(obj.Name) }
 func (obj *object) Id() string       { return Id(obj.Pkg, obj.Name) }
 func (obj *object) String() string   { panic("abstract") }
 
-func (obj *object) isUsed() bool { return obj.used }
+func (obj *object) setUsed() { obj.used = true }
 
 func (obj *object) setParent(parent *Scope) { obj.parent = parent }
 
@@ -16,39 +16,48 @@ func isNamed(typ Type) bool {
 	return ok
 }
 
-func isBoolean(typ Type) bool {
+// is reports whether typ is a basic type with the properties info or, if it is
+// a type parameter, whether all types in its constraint's type set are.
+func is(typ Type, info BasicInfo) bool {
+	if tp, _ := typ.(*TypeParam); tp != nil {
+		terms := tp.Interface().Terms
+		for _, term := range terms {
+			if !is(term.Type, info) {
+				return false
+			}
+		}
+		return len(terms) > 0
+	}
 	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsBoolean != 0
+	return ok && t.Info&info != 0
+}
+
+func isBoolean(typ Type) bool {
+	return is(typ, IsBoolean)
 }
 
 func isInteger(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsInteger != 0
+	return is(typ, IsInteger)
 }
 
 func isUnsigned(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsUnsigned != 0
+	return is(typ, IsUnsigned)
 }
 
 func isFloat(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsFloat != 0
+	return is(typ, IsFloat)
 }
 
 func isComplex(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsComplex != 0
+	return is(typ, IsComplex)
 }
 
 func isNumeric(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsNumeric != 0
+	return is(typ, IsNumeric)
 }
 
 func isString(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsString != 0
+	return is(typ, IsString)
 }
 
 func isTyped(typ Type) bool {
@@ -62,8 +71,7 @@ func isUntyped(typ Type) bool {
 }
 
 func isOrdered(typ Type) bool {
-	t, ok := typ.Underlying().(*Basic)
-	return ok && t.Info&IsOrdered != 0
+	return is(typ, IsOrdered)
 }
 
 func isConstType(typ Type) bool {
@@ -71,6 +79,35 @@ func isConstType(typ Type) bool {
 	return ok && t.Info&IsConstType != 0
 }
 
+// coreType returns the underlying type of typ or, if typ is a type parameter,
+// the underlying type shared by all types in its constraint's type set.
+// If they have none in common, the result is typ itself.
+func coreType(typ Type) Type {
+	tp, _ := typ.(*TypeParam)
+	if tp == nil {
+		return typ.Underlying()
+	}
+	var u Type
+	for _, term := range tp.Interface().Terms {
+		switch tu := term.Type.Underlying(); {
+		case u == nil:
+			u = tu
+		case !IsIdentical(u, tu):
+			return typ
+		}
+	}
+	if u == nil {
+		return typ
+	}
+	return u
+}
+
+// isGeneric reports whether typ is a generic named type, which must be instantiated to be used.
+func isGeneric(typ Type) bool {
+	t, _ := typ.(*Named)
+	return t != nil && t.Orig == nil && len(t.TypeParams) > 0
+}
+
 func isInterface(typ Type) bool {
 	_, ok := typ.Underlying().(*Interface)
 	return ok
@@ -79,6 +116,17 @@ func isInterface(typ Type) bool {
 // Comparable reports whether values of type T are comparable.
 func Comparable(T Type) bool {
 	switch t := T.Underlying().(type) {
+	case *TypeParam:
+		iface := t.Interface()
+		if iface.IsComparable {
+			return true
+		}
+		for _, term := range iface.Terms {
+			if !Comparable(term.Type) {
+				return false
+			}
+		}
+		return len(iface.Terms) > 0
 	case *Basic:
 		// assume invalid types to be comparable
 		// to avoid follow-up errors
@@ -101,6 +149,14 @@ func Comparable(T Type) bool {
 // hasNil reports whether a type includes the nil value.
 func hasNil(typ Type) bool {
 	switch t := typ.Underlying().(type) {
+	case *TypeParam:
+		terms := t.Interface().Terms
+		for _, term := range terms {
+			if !hasNil(term.Type) {
+				return false
+			}
+		}
+		return len(terms) > 0
 	case *Basic:
 		return t.Kind == UnsafePointer
 	case *Slice, *Pointer, *Signature, *Interface, *Map, *Chan:
@@ -212,7 +268,7 @@ func isIdenticalInternal(x, y Type, p *ifacePair) bool {
 		if y, ok := y.(*Interface); ok {
 			a := x.allMethods
 			b := y.allMethods
-			if len(a) == len(b) {
+			if len(a) == len(b) && x.IsComparable == y.IsComparable && identicalTerms(x.Terms, y.Terms) {
 				// Interface types are the only types where cycles can occur
 				// that are not "terminated" via named types; and such cycles
 				// can only be created via method parameter types that are
@@ -272,10 +328,15 @@ func isIdenticalInternal(x, y Type, p *ifacePair) bool {
 	case *Named:
 		// Two named types are identical if their type names originate
 		// in the same type declaration.
+		// Two instances of a generic type are identical if their type
+		// arguments are identical.
 		if y, ok := y.(*Named); ok {
-			return x.Obj == y.Obj
+			return x.Obj == y.Obj && identicalTypes(x.TypeArgs, y.TypeArgs)
 		}
 
+	case *TypeParam:
+		// A type parameter is only identical to itself (see x == y, above).
+
 	default:
 		unreachable()
 	}
@@ -283,6 +344,19 @@ func isIdenticalInternal(x, y Type, p *ifacePair) bool {
 	return false
 }
 
+// identicalTerms reports whether x and y permit the same types, in the same order.
+func identicalTerms(x, y []*Term) bool {
+	if (x == nil) != (y == nil) || len(x) != len(y) {
+		return false
+	}
+	for i, t := range x {
+		if t.Tilde != y[i].Tilde || !IsIdentical(t.Type, y[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
 // defaultType returns the default "typed" type for an "untyped" type;
 // it returns the incoming type for all other types. The default type
 // for untyped nil is untyped nil.
@@ -38,11 +38,13 @@ func (check *checker) declare(scope *Scope, id *ast.Ident, obj Object) {
 
 // A declInfo describes a package-level const, type, var, or func declaration.
 type declInfo struct {
-	file  *Scope        // scope of file containing this declaration
-	lhs   []*Var        // lhs of n:1 variable declarations, or nil
-	typ   ast.Expr      // type, or nil
-	init  ast.Expr      // init expression, or nil
-	fdecl *ast.FuncDecl // func declaration, or nil
+	file    *Scope         // scope of file containing this declaration
+	lhs     []*Var         // lhs of n:1 variable declarations, or nil
+	typ     ast.Expr       // type, or nil
+	tparams *ast.FieldList // type parameters of a generic type declaration, or nil
+	alias   bool           // whether this is an alias declaration (type A = B)
+	init    ast.Expr       // init expression, or nil
+	fdecl   *ast.FuncDecl  // func declaration, or nil
 
 	deps map[Object]*declInfo // init dependencies; lazily allocated
 	mark int                  // see check.dependencies
@@ -317,7 +319,7 @@ func (check *checker) resolveFiles(files []*ast.File) {
 
 					case *ast.TypeSpec:
 						obj := NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)
-						declare(s.Name, obj, &declInfo{file: fileScope, typ: s.Type})
+						declare(s.Name, obj, &declInfo{file: fileScope, typ: s.Type, tparams: s.TypeParams, alias: s.Assign.IsValid()})
 
 					default:
 						check.invalidAST(s.Pos(), "unknown ast.Spec node %T", s)
@@ -351,6 +353,9 @@ func (check *checker) resolveFiles(files []*ast.File) {
 						if ptr, _ := typ.(*ast.StarExpr); ptr != nil {
 							typ = ptr.X
 						}
+						if base, _ := recvTypeParams(d.Recv); base != nil {
+							typ = base
+						}
 						if base, _ := typ.(*ast.Ident); base != nil && base.Name != "_" {
 							check.methods[base.Name] = append(check.methods[base.Name], obj)
 						}
@@ -449,7 +454,7 @@ func (check *checker) resolveFiles(files []*ast.File) {
 				// All other objects in the file scope must be dot-
 				// imported. If an object was used, mark its package
 				// as used.
-				if obj.isUsed() {
+				if check.usedImports[obj] {
 					if usedDotImports == nil {
 						usedDotImports = make(map[*Package]bool)
 					}
@@ -569,7 +574,11 @@ func (check *checker) objDecl(obj Object, def *Named, cycleOk bool) {
 		check.decl = d // new package-level var decl
 		check.varDecl(obj, d.lhs, d.typ, d.init)
 	case *TypeName:
-		check.typeDecl(obj, d.typ, def, cycleOk)
+		if d.alias {
+			check.aliasDecl(obj, d.typ, def, cycleOk)
+		} else {
+			check.typeDecl(obj, d.typ, d.tparams, def, cycleOk)
+		}
 	case *Func:
 		check.funcDecl(obj, d)
 	default:
@@ -665,7 +674,14 @@ func (check *checker) varDecl(obj *Var, lhs []*Var, typ, init ast.Expr) {
 	check.initVars(lhs, []ast.Expr{init}, token.NoPos)
 }
 
-func (check *checker) typeDecl(obj *TypeName, typ ast.Expr, def *Named, cycleOk bool) {
+// aliasDecl declares obj as another name for the type denoted by typ.
+func (check *checker) aliasDecl(obj *TypeName, typ ast.Expr, def *Named, cycleOk bool) {
+	assert(obj.GetType() == nil)
+	obj.Type = Typ[Invalid] // make sure recursive alias declarations terminate
+	obj.Type = check.typ(typ, def, cycleOk)
+}
+
+func (check *checker) typeDecl(obj *TypeName, typ ast.Expr, tparams *ast.FieldList, def *Named, cycleOk bool) {
 	assert(obj.GetType() == nil)
 
 	// type declarations cannot use iota
@@ -691,7 +707,19 @@ func (check *checker) typeDecl(obj *TypeName, typ ast.Expr, def *Named, cycleOk
 	//	)
 	//
 	// When we declare object C, typ is the identifier A which is incomplete.
+	//
+	// The type parameters of a generic type are in scope in typ.
+	oldScope := check.topScope
+	if tparams != nil {
+		check.topScope = NewScope(check.topScope)
+		named.TypeParams = check.collectTypeParams(check.topScope, tparams)
+	}
 	u := check.typ(typ, named, cycleOk)
+	check.topScope = oldScope
+	if _, ok := u.(*TypeParam); ok {
+		check.errorf(typ.Pos(), "cannot use a type parameter as the type of %s", obj.Name)
+		u = Typ[Invalid]
+	}
 
 	// Determine the unnamed underlying type.
 	// In the above example, the underlying type of A was (temporarily) set
@@ -863,7 +891,11 @@ func (check *checker) declStmt(decl ast.Decl) {
 			case *ast.TypeSpec:
 				obj := NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)
 				check.declare(check.topScope, s.Name, obj)
-				check.typeDecl(obj, s.Type, nil, false)
+				if s.Assign.IsValid() {
+					check.aliasDecl(obj, s.Type, nil, false)
+				} else {
+					check.typeDecl(obj, s.Type, s.TypeParams, nil, false)
+				}
 
 			default:
 				check.invalidAST(s.Pos(), "const, type, or var declaration expected")
@@ -254,7 +254,7 @@ func (check *checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		if ch.mode == invalid || x.mode == invalid {
 			return
 		}
-		if tch, ok := ch.typ.Underlying().(*Chan); !ok || tch.Dir == RecvOnly || !check.assignment(&x, tch.Elem) {
+		if tch, ok := coreType(ch.typ).(*Chan); !ok || tch.Dir == RecvOnly || !check.assignment(&x, tch.Elem) {
 			if x.mode != invalid {
 				check.invalidOp(ch.pos(), "cannot send %s to channel %s", &x, &ch)
 			}
@@ -605,7 +605,7 @@ func (check *checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 		// determine key/value types
 		var key, val Type
-		switch typ := x.typ.Underlying().(type) {
+		switch typ := coreType(x.typ).(type) {
 		case *Basic:
 			if isString(typ) {
 				key = Typ[Int]
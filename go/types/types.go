@@ -165,11 +165,12 @@ func NewTuple(x ...*Var) *Tuple {
 
 // A Signature represents a (non-builtin) function or method type.
 type Signature struct {
-	scope      *Scope // function scope, always present
-	Recv       *Var   // nil if not a method
-	Params     []*Var // (incoming) parameters from left to right; or nil
-	Results    []*Var // (outgoing) results from left to right; or nil
-	IsVariadic bool   // true if the last parameter's type is of the form ...T
+	scope      *Scope      // function scope, always present
+	Recv       *Var        // nil if not a method
+	Params     []*Var      // (incoming) parameters from left to right; or nil
+	Results    []*Var      // (outgoing) results from left to right; or nil
+	IsVariadic bool        // true if the last parameter's type is of the form ...T
+	TypeParams []*TypeName // type parameters of a generic function, each of type *TypeParam; or nil
 }
 
 // NewSignature returns a new function type for the given receiver, parameters,
@@ -188,7 +189,7 @@ func NewSignature(scope *Scope, recv *Var, params, results []*Var, isVariadic bo
 			panic("types.NewSignature: variadic parameter must be of unnamed slice type")
 		}
 	}
-	return &Signature{scope, recv, params, results, isVariadic}
+	return &Signature{scope: scope, Recv: recv, Params: params, Results: results, IsVariadic: isVariadic}
 }
 
 // Recv returns the receiver of signature s (if a method), or nil if a
@@ -200,8 +201,10 @@ func NewSignature(scope *Scope, recv *Var, params, results []*Var, isVariadic bo
 
 // An Interface represents an interface type.
 type Interface struct {
-	Methods   []*Func  // ordered list of explicitly declared methods
-	Embeddeds []*Named // ordered list of explicitly embedded types
+	Methods      []*Func  // ordered list of explicitly declared methods
+	Embeddeds    []*Named // ordered list of explicitly embedded types
+	Terms        []*Term  // types permitted by a constraint interface, or nil if any type is permitted
+	IsComparable bool     // if set, only comparable types are permitted (the interface is, or embeds, comparable)
 
 	allMethods []*Func         // ordered list of methods declared with or embedded in this interface (TODO(gri): replace with mset)
 	mset       cachedMethodSet // method set for interface, lazily initialized
@@ -242,6 +245,11 @@ func NewInterface(methods []*Func, embeddeds []*Named) *Interface {
 		sort.Sort(byUniqueTypeName(embeddeds))
 		sort.Sort(byUniqueMethodName(allMethods))
 	}
+	for _, t := range embeddeds {
+		it := t.Underlying().(*Interface)
+		typ.Terms = intersectTerms(typ.Terms, it.Terms)
+		typ.IsComparable = typ.IsComparable || it.IsComparable
+	}
 
 	typ.Methods = methods
 	typ.Embeddeds = embeddeds
@@ -271,7 +279,53 @@ func (t *Interface) NumMethods() int { return len(t.allMethods) }
 func (t *Interface) Method(i int) *Func { return t.allMethods[i] }
 
 // Empty returns true if t is the empty interface.
-func (t *Interface) Empty() bool { return len(t.allMethods) == 0 }
+func (t *Interface) Empty() bool {
+	return len(t.allMethods) == 0 && t.Terms == nil && !t.IsComparable
+}
+
+// IsConstraint reports whether t may only be used as a type parameter constraint.
+func (t *Interface) IsConstraint() bool { return t.Terms != nil || t.IsComparable }
+
+// A Term is an element of a constraint's type set:  the type Type, or, if Tilde is set, all types whose underlying type is Type.
+type Term struct {
+	Tilde bool
+	Type  Type
+}
+
+// NewTerm returns a new term for the given type.
+func NewTerm(tilde bool, typ Type) *Term { return &Term{tilde, typ} }
+
+// includes reports whether the term t permits type x.
+func (t *Term) includes(x Type) bool {
+	if t.Tilde {
+		x = x.Underlying()
+	}
+	return IsIdentical(t.Type, x)
+}
+
+// intersectTerms returns the terms permitted by both a and b, where nil means that any type is permitted.
+func intersectTerms(a, b []*Term) []*Term {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	terms := []*Term{}
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case x.Tilde && y.Tilde && IsIdentical(x.Type, y.Type):
+				terms = append(terms, x)
+			case x.Tilde && x.includes(y.Type) && !y.Tilde:
+				terms = append(terms, y)
+			case !x.Tilde && y.includes(x.Type):
+				terms = append(terms, x)
+			}
+		}
+	}
+	return terms
+}
 
 // A Map represents a map type.
 type Map struct {
@@ -311,6 +365,11 @@ type Named struct {
 	complete    bool            // if set, the underlying type has been determined
 	Methods     []*Func         // methods declared for this type (not the method set of this type)
 	mset, pmset cachedMethodSet // method set for T, *T, lazily initialized
+
+	TypeParams []*TypeName // type parameters of a generic type, each of type *TypeParam; or nil
+	TypeArgs   []Type      // type arguments of an instance of a generic type; or nil
+	Orig       *Named      // the generic type of which this is an instance; or nil
+	instances  []*Named    // instances of a generic type, lazily allocated
 }
 
 // NewNamed returns a new named type for the given type name, underlying type, and associated methods.
@@ -353,6 +412,39 @@ func (t *Named) AddMethod(m *Func) {
 	}
 }
 
+// A TypeParam represents a type parameter of a generic function or named type.
+type TypeParam struct {
+	Obj        *TypeName // corresponding declared object
+	Index      int       // index in its type parameter list
+	Constraint Type      // constraint, whose underlying type is an *Interface
+}
+
+// NewTypeParam returns a new type parameter for the given type name, index, and constraint, and sets obj's type to it.
+func NewTypeParam(obj *TypeName, index int, constraint Type) *TypeParam {
+	typ := &TypeParam{obj, index, constraint}
+	obj.Type = typ
+	return typ
+}
+
+// Interface returns the underlying interface of t's constraint.
+func (t *TypeParam) Interface() *Interface {
+	return constraintInterface(t.Constraint)
+}
+
+// constraintInterface returns the interface denoted by constraint.  A constraint
+// that is not an interface, such as int, stands for interface{int}.
+func constraintInterface(constraint Type) *Interface {
+	if constraint == nil {
+		return &emptyInterface
+	}
+	if i, _ := constraint.Underlying().(*Interface); i != nil {
+		return i
+	}
+	return &Interface{Terms: []*Term{{false, constraint}}}
+}
+
+var emptyInterface Interface
+
 // Implementations for Type methods.
 
 func (t *Basic) Underlying() Type     { return t }
@@ -365,7 +457,8 @@ func (t *Signature) Underlying() Type { return t }
 func (t *Interface) Underlying() Type { return t }
 func (t *Map) Underlying() Type       { return t }
 func (t *Chan) Underlying() Type      { return t }
-func (t *Named) Underlying() Type     { return t.UnderlyingT }
+func (t *Named) Underlying() Type     { t.expand(); return t.UnderlyingT }
+func (t *TypeParam) Underlying() Type { return t }
 
 func (t *Basic) MethodSet() *MethodSet  { return &emptyMethodSet }
 func (t *Array) MethodSet() *MethodSet  { return &emptyMethodSet }
@@ -385,6 +478,7 @@ func (t *Interface) MethodSet() *MethodSet { return t.mset.of(t) }
 func (t *Map) MethodSet() *MethodSet       { return &emptyMethodSet }
 func (t *Chan) MethodSet() *MethodSet      { return &emptyMethodSet }
 func (t *Named) MethodSet() *MethodSet     { return t.mset.of(t) }
+func (t *TypeParam) MethodSet() *MethodSet { return t.Interface().MethodSet() }
 
 func (t *Basic) String() string     { return TypeString(nil, t) }
 func (t *Array) String() string     { return TypeString(nil, t) }
@@ -397,3 +491,4 @@ func (t *Interface) String() string { return TypeString(nil, t) }
 func (t *Map) String() string       { return TypeString(nil, t) }
 func (t *Chan) String() string      { return TypeString(nil, t) }
 func (t *Named) String() string     { return TypeString(nil, t) }
+func (t *TypeParam) String() string { return TypeString(nil, t) }
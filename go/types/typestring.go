@@ -133,6 +133,12 @@ func WriteType(buf *bytes.Buffer, this *Package, typ Type) {
 				}
 				WriteType(buf, this, typ)
 			}
+			if explicitTerms(t) {
+				if len(t.Methods) > 0 || len(t.Embeddeds) > 0 {
+					buf.WriteString("; ")
+				}
+				writeTerms(buf, this, t.Terms)
+			}
 		}
 		buf.WriteByte('}')
 
@@ -183,6 +189,19 @@ func WriteType(buf *bytes.Buffer, this *Package, typ Type) {
 			s = t.Obj.Name
 		}
 		buf.WriteString(s)
+		if len(t.TypeArgs) > 0 {
+			buf.WriteByte('[')
+			for i, a := range t.TypeArgs {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				WriteType(buf, this, a)
+			}
+			buf.WriteByte(']')
+		}
+
+	case *TypeParam:
+		buf.WriteString(t.Obj.Name)
 
 	default:
 		// For externally defined implementations of Type.
@@ -212,7 +231,62 @@ func writeTuple(buf *bytes.Buffer, this *Package, tup *Tuple, isVariadic bool) {
 	buf.WriteByte(')')
 }
 
+// WriteTypeParams writes the type parameter list tparams, with their constraints, to buf.
+func WriteTypeParams(buf *bytes.Buffer, this *Package, tparams []*TypeName) {
+	buf.WriteByte('[')
+	for i, tn := range tparams {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(tn.Name)
+		buf.WriteByte(' ')
+		writeConstraint(buf, this, tn.Type.(*TypeParam).Constraint)
+	}
+	buf.WriteByte(']')
+}
+
+// writeConstraint writes constraint to buf, writing an implicit interface (as in [T ~int | ~uint]) as just its terms.
+func writeConstraint(buf *bytes.Buffer, this *Package, constraint Type) {
+	if t, ok := constraint.(*Interface); ok && len(t.Methods) == 0 && len(t.Embeddeds) == 0 && len(t.Terms) > 0 {
+		writeTerms(buf, this, t.Terms)
+		return
+	}
+	if constraint == nil {
+		constraint = new(Interface)
+	}
+	if t, ok := constraint.(*Interface); ok && t.Empty() {
+		buf.WriteString("any")
+		return
+	}
+	WriteType(buf, this, constraint)
+}
+
+// explicitTerms reports whether t has terms that don't all come from an embedded interface.
+func explicitTerms(t *Interface) bool {
+	for _, e := range t.Embeddeds {
+		if e.Underlying().(*Interface).Terms != nil {
+			return false
+		}
+	}
+	return len(t.Terms) > 0
+}
+
+func writeTerms(buf *bytes.Buffer, this *Package, terms []*Term) {
+	for i, term := range terms {
+		if i > 0 {
+			buf.WriteString(" | ")
+		}
+		if term.Tilde {
+			buf.WriteByte('~')
+		}
+		WriteType(buf, this, term.Type)
+	}
+}
+
 func writeSignature(buf *bytes.Buffer, this *Package, sig *Signature) {
+	if len(sig.TypeParams) > 0 {
+		WriteTypeParams(buf, this, sig.TypeParams)
+	}
 	writeTuple(buf, this, NewTuple(sig.Params...), sig.IsVariadic)
 
 	n := len(sig.Results)
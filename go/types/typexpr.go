@@ -57,7 +57,7 @@ func (check *checker) ident(x *operand, e *ast.Ident, def *Named, cycleOk bool)
 		// package was used. Same applies for other objects, below.
 		// (This code is only used for dot-imports. Without them, we
 		// would only have to mark Vars.)
-		obj.used = true
+		check.markUsed(obj)
 		if typ == Typ[Invalid] {
 			return
 		}
@@ -74,7 +74,7 @@ func (check *checker) ident(x *operand, e *ast.Ident, def *Named, cycleOk bool)
 		x.mode = constant
 
 	case *TypeName:
-		obj.used = true
+		check.markUsed(obj)
 		x.mode = typexpr
 		named, _ := typ.(*Named)
 		if !cycleOk && named != nil && !named.complete {
@@ -87,21 +87,21 @@ func (check *checker) ident(x *operand, e *ast.Ident, def *Named, cycleOk bool)
 		}
 
 	case *Var:
-		obj.used = true
+		check.markUsed(obj)
 		x.mode = variable
 		if typ != Typ[Invalid] {
 			check.addDeclDep(obj)
 		}
 
 	case *Func:
-		obj.used = true
+		check.markUsed(obj)
 		x.mode = value
 		if typ != Typ[Invalid] {
 			check.addDeclDep(obj)
 		}
 
 	case *Builtin:
-		obj.used = true // for built-ins defined by package unsafe
+		check.markUsed(obj) // for built-ins defined by package unsafe
 		x.mode = builtin
 		x.id = obj.id
 
@@ -116,6 +116,22 @@ func (check *checker) ident(x *operand, e *ast.Ident, def *Named, cycleOk bool)
 	x.typ = typ
 }
 
+// markUsed marks obj as used.  The objects of other packages, in the universe or
+// dot-imported, may be shared with the checks of other packages running
+// concurrently, so they are not marked themselves; instead, the uses of
+// dot-imported objects are recorded in check.usedImports.
+func (check *checker) markUsed(obj Object) {
+	switch pkg := obj.GetPkg(); {
+	case pkg == check.pkg:
+		obj.setUsed()
+	case pkg != nil:
+		if check.usedImports == nil {
+			check.usedImports = make(map[Object]bool)
+		}
+		check.usedImports[obj] = true
+	}
+}
+
 // typ type-checks the type expression e and returns its type, or Typ[Invalid].
 // If def != nil, e is the type specification for the named type def, declared
 // in a type declaration, and def.UnderlyingT will be set to the type of e before
@@ -147,12 +163,24 @@ func (check *checker) funcType(recv *ast.FieldList, ftyp *ast.FuncType, def *Nam
 		def.UnderlyingT = sig
 	}
 
+	// The type parameters of a generic function, or of the receiver
+	// base type of a method, are declared in a scope of their own, in
+	// which the parameter types are type-checked.
+	oldScope := check.topScope
+	var tparams []*TypeName
+	if ftyp.TypeParams != nil || isGenericRecv(recv) {
+		check.topScope = NewScope(check.topScope)
+		tparams = check.collectTypeParams(check.topScope, ftyp.TypeParams)
+		check.declareRecvTypeParams(check.topScope, recv)
+	}
+
 	scope := NewScope(check.topScope)
 	check.recordScope(ftyp, scope)
 
 	recv_, _ := check.collectParams(scope, recv, false)
 	params, isVariadic := check.collectParams(scope, ftyp.Params, true)
 	results, _ := check.collectParams(scope, ftyp.Results, false)
+	check.topScope = oldScope
 
 	if len(recv_) > 0 {
 		// There must be exactly one receiver.
@@ -198,10 +226,217 @@ func (check *checker) funcType(recv *ast.FieldList, ftyp *ast.FuncType, def *Nam
 	sig.Params = params
 	sig.Results = results
 	sig.IsVariadic = isVariadic
+	sig.TypeParams = tparams
 
 	return sig
 }
 
+// recvTypeParams returns the receiver base type name and the type parameter names of the method receiver recv, as in (s *Stack[T]), if it has any.
+func recvTypeParams(recv *ast.FieldList) (base ast.Expr, names []ast.Expr) {
+	if recv == nil || len(recv.List) == 0 {
+		return nil, nil
+	}
+	typ := recv.List[0].Type
+	if ptr, _ := typ.(*ast.StarExpr); ptr != nil {
+		typ = ptr.X
+	}
+	switch typ := typ.(type) {
+	case *ast.IndexExpr:
+		return typ.X, []ast.Expr{typ.Index}
+	case *ast.IndexListExpr:
+		return typ.X, typ.Indices
+	}
+	return nil, nil
+}
+
+func isGenericRecv(recv *ast.FieldList) bool {
+	_, names := recvTypeParams(recv)
+	return names != nil
+}
+
+// declareRecvTypeParams declares in scope the type parameter names of the receiver recv as the type parameters of its base type.
+func (check *checker) declareRecvTypeParams(scope *Scope, recv *ast.FieldList) {
+	base, names := recvTypeParams(recv)
+	ident, _ := base.(*ast.Ident)
+	if ident == nil {
+		return
+	}
+	var x operand
+	check.ident(&x, ident, nil, true)
+	named, _ := x.typ.(*Named)
+	if x.mode != typexpr || named == nil {
+		return // error reported when type-checking the receiver
+	}
+	if len(names) != len(named.TypeParams) {
+		check.errorf(base.Pos(), "got %d type parameters for %s, want %d", len(names), named, len(named.TypeParams))
+		return
+	}
+	for i, e := range names {
+		name, _ := e.(*ast.Ident)
+		if name == nil {
+			check.invalidAST(e.Pos(), "receiver type parameter %s must be an identifier", e)
+			continue
+		}
+		if name.Name != "_" {
+			check.declare(scope, name, NewTypeName(name.Pos(), check.pkg, name.Name, named.TypeParams[i].Type))
+		}
+	}
+}
+
+// collectTypeParams declares the type parameters in list in scope and
+// type-checks their constraints, which may refer to any of them.
+func (check *checker) collectTypeParams(scope *Scope, list *ast.FieldList) (tparams []*TypeName) {
+	if list == nil {
+		return
+	}
+
+	for _, f := range list.List {
+		for _, name := range f.Names {
+			tn := NewTypeName(name.Pos(), check.pkg, name.Name, nil)
+			NewTypeParam(tn, len(tparams), nil)
+			check.declare(scope, name, tn)
+			tparams = append(tparams, tn)
+		}
+	}
+
+	oldScope := check.topScope
+	check.topScope = scope
+	i := 0
+	for _, f := range list.List {
+		constraint := check.constraint(f.Type)
+		for _ = range f.Names {
+			tparams[i].Type.(*TypeParam).Constraint = constraint
+			i++
+		}
+	}
+	check.topScope = oldScope
+
+	return
+}
+
+// constraint type-checks the type parameter constraint e.  A constraint
+// that is not an interface (as in [T ~int | ~uint]) stands for an implicit
+// interface with the given terms.
+func (check *checker) constraint(e ast.Expr) Type {
+	if isTerms(e) {
+		return &Interface{Terms: check.terms(e)}
+	}
+	old := check.constraintIface
+	check.constraintIface, _ = unparen(e).(*ast.InterfaceType)
+	typ := check.typ(e, nil, true)
+	check.constraintIface = old
+	if typ == Typ[Invalid] {
+		return new(Interface)
+	}
+	if _, ok := typ.Underlying().(*Interface); !ok {
+		return &Interface{Terms: []*Term{{false, typ}}}
+	}
+	return typ
+}
+
+// isTerms reports whether e is a union or an approximation term, which can only appear in constraints.
+func isTerms(e ast.Expr) bool {
+	switch e := e.(type) {
+	case *ast.BinaryExpr:
+		return e.Op == token.OR
+	case *ast.UnaryExpr:
+		return e.Op == token.TILDE
+	case *ast.ParenExpr:
+		return isTerms(e.X)
+	}
+	return false
+}
+
+// terms type-checks the union of terms e.
+func (check *checker) terms(e ast.Expr) []*Term {
+	switch e := e.(type) {
+	case *ast.BinaryExpr:
+		if e.Op == token.OR {
+			return append(check.terms(e.X), check.terms(e.Y)...)
+		}
+	case *ast.UnaryExpr:
+		if e.Op == token.TILDE {
+			typ := check.typ(e.X, nil, true)
+			if typ == Typ[Invalid] {
+				return nil
+			}
+			if typ.Underlying() != typ {
+				check.errorf(e.Pos(), "invalid use of ~ (underlying type of %s is %s)", typ, typ.Underlying())
+			}
+			return []*Term{{true, typ}}
+		}
+	case *ast.ParenExpr:
+		return check.terms(e.X)
+	}
+	typ := check.typ(e, nil, true)
+	if typ == Typ[Invalid] {
+		return nil
+	}
+	return []*Term{{false, typ}}
+}
+
+// instantiatedType type-checks the instantiation of the generic type x with the type arguments args.
+func (check *checker) instantiatedType(x ast.Expr, args []ast.Expr, def *Named, cycleOk bool) Type {
+	var gen operand
+	switch x := x.(type) {
+	case *ast.Ident:
+		check.ident(&gen, x, nil, true)
+	case *ast.SelectorExpr:
+		check.selector(&gen, x)
+	default:
+		check.errorf(x.Pos(), "%s is not a generic type", x)
+		return Typ[Invalid]
+	}
+	if gen.mode == invalid {
+		return Typ[Invalid]
+	}
+	named, _ := gen.typ.(*Named)
+	if gen.mode != typexpr || named == nil || named.Orig != nil || len(named.TypeParams) == 0 {
+		check.errorf(x.Pos(), "%s is not a generic type", &gen)
+		return Typ[Invalid]
+	}
+	if !cycleOk && !named.complete {
+		check.errorf(x.Pos(), "illegal cycle in declaration of %s", named.Obj.Name)
+		return Typ[Invalid]
+	}
+	targs := check.typeArgs(args)
+	if targs == nil {
+		return Typ[Invalid]
+	}
+	if len(targs) != len(named.TypeParams) {
+		check.errorf(x.Pos(), "got %d type arguments for %s, want %d", len(targs), named, len(named.TypeParams))
+		return Typ[Invalid]
+	}
+	typ := instantiate(named, targs)
+	check.verifyTypeArgs(x.Pos(), named.TypeParams, targs)
+	if def != nil {
+		def.UnderlyingT = typ
+	}
+	return typ
+}
+
+// typeArgs type-checks the type arguments args, returning nil if any is invalid.
+func (check *checker) typeArgs(args []ast.Expr) []Type {
+	targs := make([]Type, len(args))
+	for i, a := range args {
+		targs[i] = check.typ(a, nil, true)
+		if targs[i] == Typ[Invalid] {
+			return nil
+		}
+	}
+	return targs
+}
+
+// verifyTypeArgs checks, once all types are set up, that the type arguments targs satisfy the constraints of tparams.
+func (check *checker) verifyTypeArgs(pos token.Pos, tparams []*TypeName, targs []Type) {
+	check.delay(func() {
+		if i := Verify(tparams, targs); i >= 0 {
+			m := newSubstMap(tparams[:len(targs)], targs)
+			check.errorf(pos, "%s does not satisfy %s", targs[i], m.subst(tparams[i].Type.(*TypeParam).Constraint))
+		}
+	})
+}
+
 // typInternal contains the core of type checking of types.
 // Must only be called by typ.
 //
@@ -216,6 +451,10 @@ func (check *checker) typInternal(e ast.Expr, def *Named, cycleOk bool) Type {
 
 		switch x.mode {
 		case typexpr:
+			if isGeneric(x.typ) {
+				check.errorf(x.pos(), "cannot use generic type %s without instantiation", x.typ)
+				break
+			}
 			return x.typ
 		case invalid:
 			// ignore - error reported before
@@ -231,6 +470,10 @@ func (check *checker) typInternal(e ast.Expr, def *Named, cycleOk bool) Type {
 
 		switch x.mode {
 		case typexpr:
+			if isGeneric(x.typ) {
+				check.errorf(x.pos(), "cannot use generic type %s without instantiation", x.typ)
+				break
+			}
 			return x.typ
 		case invalid:
 			// ignore - error reported before
@@ -243,6 +486,12 @@ func (check *checker) typInternal(e ast.Expr, def *Named, cycleOk bool) Type {
 	case *ast.ParenExpr:
 		return check.typ(e.X, def, cycleOk)
 
+	case *ast.IndexExpr:
+		return check.instantiatedType(e.X, []ast.Expr{e.Index}, def, cycleOk)
+
+	case *ast.IndexListExpr:
+		return check.instantiatedType(e.X, e.Indices, def, cycleOk)
+
 	case *ast.ArrayType:
 		if e.Len != nil {
 			var x operand
@@ -437,6 +686,7 @@ func (check *checker) declareInSet(oset *objset, pos token.Pos, obj Object) bool
 
 func (check *checker) interfaceType(ityp *ast.InterfaceType, def *Named, cycleOk bool) *Interface {
 	iface := new(Interface)
+	isConstraint := ityp == check.constraintIface
 	if def != nil {
 		def.UnderlyingT = iface
 	}
@@ -497,13 +747,21 @@ func (check *checker) interfaceType(ityp *ast.InterfaceType, def *Named, cycleOk
 
 	for _, e := range embedded {
 		pos := e.Pos()
+		if isTerms(e) {
+			iface.Terms = intersectTerms(iface.Terms, check.terms(e))
+			continue
+		}
 		typ := check.typ(e, nil, cycleOk)
 		if typ == Typ[Invalid] {
 			continue
 		}
 		named, _ := typ.(*Named)
 		if named == nil {
-			check.invalidAST(pos, "%s is not named type", typ)
+			if _, ok := typ.(*Interface); !ok && isConstraint {
+				iface.Terms = intersectTerms(iface.Terms, []*Term{{false, typ}})
+			} else {
+				check.invalidAST(pos, "%s is not named type", typ)
+			}
 			continue
 		}
 		// determine underlying (possibly incomplete) type
@@ -522,10 +780,17 @@ func (check *checker) interfaceType(ityp *ast.InterfaceType, def *Named, cycleOk
 		}
 		embed, _ := u.(*Interface)
 		if embed == nil {
-			check.errorf(pos, "%s is not an interface", named)
+			if isConstraint {
+				// a type term, as in [T interface{ time.Duration }]
+				iface.Terms = intersectTerms(iface.Terms, []*Term{{false, named}})
+			} else {
+				check.errorf(pos, "%s is not an interface", named)
+			}
 			continue
 		}
 		iface.Embeddeds = append(iface.Embeddeds, named)
+		iface.Terms = intersectTerms(iface.Terms, embed.Terms)
+		iface.IsComparable = iface.IsComparable || embed.IsComparable
 		// collect embedded methods
 		for _, m := range embed.allMethods {
 			if check.declareInSet(&mset, pos, m) {
@@ -534,6 +799,13 @@ func (check *checker) interfaceType(ityp *ast.InterfaceType, def *Named, cycleOk
 		}
 	}
 
+	// Only the interface of a constraint, or of a type declaration (such as
+	// type Number interface{ ~int | ~float64 }), may restrict the types
+	// permitted; other interfaces are used as the types of values.
+	if iface.IsConstraint() && !isConstraint && def == nil {
+		check.errorf(ityp.Pos(), "cannot use %s outside a type constraint: interface contains type constraints", ityp)
+	}
+
 	// Phase 3: At this point all methods have been collected for this interface.
 	//          It is now safe to type-check the signatures of all explicitly
 	//          declared methods, even if they refer to this interface via a cycle
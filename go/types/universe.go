@@ -72,6 +72,14 @@ func defPredeclaredTypes() {
 	typ := &Named{UnderlyingT: NewInterface([]*Func{err}, nil), complete: true}
 	sig.Recv = NewVar(token.NoPos, nil, "", typ)
 	def(NewTypeName(token.NoPos, nil, "error", typ))
+
+	// any is an alias for interface{}
+	def(NewTypeName(token.NoPos, nil, "any", new(Interface)))
+
+	// comparable is only usable as a type parameter constraint
+	obj := NewTypeName(token.NoPos, nil, "comparable", nil)
+	NewNamed(obj, &Interface{IsComparable: true}, nil)
+	def(obj)
 }
 
 var predeclaredConsts = [...]struct {
@@ -101,6 +109,7 @@ const (
 	// universe scope
 	_Append builtinId = iota
 	_Cap
+	_Clear
 	_Close
 	_Complex
 	_Copy
@@ -108,6 +117,8 @@ const (
 	_Imag
 	_Len
 	_Make
+	_Max
+	_Min
 	_New
 	_Panic
 	_Print
@@ -133,6 +144,7 @@ var predeclaredFuncs = [...]struct {
 }{
 	_Append:  {"append", 1, true, expression},
 	_Cap:     {"cap", 1, false, expression},
+	_Clear:   {"clear", 1, false, statement},
 	_Close:   {"close", 1, false, statement},
 	_Complex: {"complex", 2, false, expression},
 	_Copy:    {"copy", 2, false, statement},
@@ -140,6 +152,8 @@ var predeclaredFuncs = [...]struct {
 	_Imag:    {"imag", 1, false, expression},
 	_Len:     {"len", 1, false, expression},
 	_Make:    {"make", 1, true, expression},
+	_Max:     {"max", 1, true, expression},
+	_Min:     {"min", 1, true, expression},
 	_New:     {"new", 1, false, expression},
 	_Panic:   {"panic", 1, false, statement},
 	_Print:   {"print", 0, true, statement},
@@ -0,0 +1,407 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graph models Flux functions as dataflow graphs, independently of how they are displayed or edited.
+//
+// A function body is a Block of Nodes.  Nodes have input and output Ports which are joined by Connections.  Compound nodes (if, loop, select, switch, and func literal nodes) contain further Blocks.
+// Every object has a Data field in which a front end can keep a reference to its own representation of the object.  A front end that keeps a BlockObserver or PortObserver there is told of each change to the block or port, so that its representation can follow the model.
+package graph
+
+import "github.com/gordonklaus/flux/go/types"
+
+type Block struct {
+	Node  *Node // the node containing this block; for a func block, its Block is nil
+	Nodes []*Node
+	Conns []*Connection
+	Data  interface{}
+}
+
+type NodeKind int
+
+const (
+	Normal  NodeKind = iota
+	Inputs           // the node providing a block's inputs (func params, loop vars); it precedes all other nodes in its block
+	Outputs          // the node receiving a block's outputs (func results); it is not ordered
+	Loop             // a loop node; feedback connections originate in its loop block
+)
+
+type Node struct {
+	Kind   NodeKind
+	Block  *Block
+	Ins    []*Port
+	Outs   []*Port
+	Blocks []*Block
+
+	// Connectable, if not nil, reports whether a value of type t may be connected to the input dst.
+	// Otherwise, t must be assignable to dst's type.
+	Connectable func(t types.Type, dst *Port) bool
+
+	Data interface{}
+}
+
+type Port struct {
+	Node  *Node
+	Out   bool
+	Obj   *types.Var
+	Conns []*Connection
+	Data  interface{}
+}
+
+// A Connection passes values from its source (an output) to its destination (an input).
+// A feedback connection passes values from one iteration of a loop to the next.
+type Connection struct {
+	Block    *Block
+	Src, Dst *Port
+	Feedback bool
+	Data     interface{}
+}
+
+// A BlockObserver, kept in a Block's Data, is told when nodes and connections are added to or removed from the block.
+type BlockObserver interface {
+	NodeAdded(n *Node)
+	NodeRemoved(n *Node)
+	ConnAdded(c *Connection)
+	ConnRemoved(c *Connection)
+}
+
+// A PortObserver, kept in a Port's Data, is told when connections are added to or removed from the port.
+type PortObserver interface {
+	Connected(c *Connection)
+	Disconnected(c *Connection)
+}
+
+// NewBlock returns a new block belonging to the node n.
+func NewBlock(n *Node) *Block {
+	b := &Block{Node: n}
+	n.Blocks = append(n.Blocks, b)
+	return b
+}
+
+// Close removes b from its node.
+func (b *Block) Close() {
+	blocks := b.Node.Blocks
+	for i, b2 := range blocks {
+		if b2 == b {
+			b.Node.Blocks = append(blocks[:i], blocks[i+1:]...)
+			break
+		}
+	}
+}
+
+func (b *Block) Outer() *Block { return b.Node.Block }
+func (b *Block) Outermost() *Block {
+	if outer := b.Outer(); outer != nil {
+		return outer.Outermost()
+	}
+	return b
+}
+
+// AddNode adds n, which must not belong to another block, to b.
+func (b *Block) AddNode(n *Node) {
+	if n.Block == b {
+		return
+	}
+	b.Nodes = append(b.Nodes, n)
+	n.Block = b
+	if o, ok := b.Data.(BlockObserver); ok {
+		o.NodeAdded(n)
+	}
+}
+
+// RemoveNode removes n and its connections from b.
+func (b *Block) RemoveNode(n *Node) {
+	for i, n2 := range b.Nodes {
+		if n2 == n {
+			for _, c := range append(n.InConns(), n.OutConns()...) {
+				c.Disconnect()
+				c.Block.RemoveConn(c)
+			}
+			b.Nodes = append(b.Nodes[:i], b.Nodes[i+1:]...)
+			n.Block = nil
+			if o, ok := b.Data.(BlockObserver); ok {
+				o.NodeRemoved(n)
+			}
+			return
+		}
+	}
+}
+
+// MoveNodes moves nodes, which must all belong to one block, from that block to b, keeping their connections.
+// Each connection is moved into the block where it belongs with its nodes' new positions.  Connections between the moved nodes and those left behind must be valid afterward.
+func (b *Block) MoveNodes(nodes []*Node) {
+	for _, n := range nodes {
+		old := n.Block
+		for i, n2 := range old.Nodes {
+			if n2 == n {
+				old.Nodes = append(old.Nodes[:i], old.Nodes[i+1:]...)
+				break
+			}
+		}
+		n.Block = nil
+		if o, ok := old.Data.(BlockObserver); ok {
+			o.NodeRemoved(n)
+		}
+		b.AddNode(n)
+	}
+	for _, n := range nodes {
+		for _, c := range append(n.InConns(), n.OutConns()...) {
+			c.reblock()
+		}
+	}
+}
+
+func (b *Block) AddConn(c *Connection) {
+	if c.Block == b {
+		return
+	}
+	if c.Block != nil {
+		c.Block.RemoveConn(c)
+	}
+	b.Conns = append(b.Conns, c)
+	c.Block = b
+	if o, ok := b.Data.(BlockObserver); ok {
+		o.ConnAdded(c)
+	}
+}
+
+func (b *Block) RemoveConn(c *Connection) {
+	for i, c2 := range b.Conns {
+		if c2 == c {
+			b.Conns = append(b.Conns[:i], b.Conns[i+1:]...)
+			c.Block = nil
+			if o, ok := b.Data.(BlockObserver); ok {
+				o.ConnRemoved(c)
+			}
+			return
+		}
+	}
+}
+
+func (b *Block) hasConn(c *Connection) bool {
+	for _, c2 := range b.Conns {
+		if c2 == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls bf, nf, and cf (any of which may be nil) for b and all of the blocks, nodes, and connections nested in it.
+func (b *Block) Walk(bf func(*Block), nf func(*Node), cf func(*Connection)) {
+	if bf != nil {
+		bf(b)
+	}
+	for _, n := range b.Nodes {
+		if nf != nil {
+			nf(n)
+		}
+		for _, b := range n.Blocks {
+			b.Walk(bf, nf, cf)
+		}
+	}
+	if cf != nil {
+		for _, c := range b.Conns {
+			cf(c)
+		}
+	}
+}
+
+// InConns returns the connections entering b from outside.
+func (b *Block) InConns() (conns []*Connection) {
+	for _, n := range b.Nodes {
+		for _, c := range n.InConns() {
+			if !b.hasConn(c) {
+				conns = append(conns, c)
+			}
+		}
+	}
+	return
+}
+
+// OutConns returns the connections leaving b.
+func (b *Block) OutConns() (conns []*Connection) {
+	for _, n := range b.Nodes {
+		for _, c := range n.OutConns() {
+			if !b.hasConn(c) {
+				conns = append(conns, c)
+			}
+		}
+	}
+	return
+}
+
+// Find returns the node in b that is or contains n, or nil if there is none.
+func (b *Block) Find(n *Node) *Node {
+	for b2 := n.Block; b2 != nil; n, b2 = b2.Node, b2.Outer() {
+		if b2 == b {
+			return n
+		}
+	}
+	return nil
+}
+
+func (n *Node) NewInput(v *types.Var) *Port {
+	p := &Port{Obj: v}
+	n.AddPort(p)
+	return p
+}
+
+func (n *Node) NewOutput(v *types.Var) *Port {
+	p := &Port{Out: true, Obj: v}
+	n.AddPort(p)
+	return p
+}
+
+// AddPort adds p, which must not belong to another node, to the end of n's inputs or outputs.
+func (n *Node) AddPort(p *Port) {
+	n.InsertPort(p, len(n.ports(p.Out)))
+}
+
+// InsertPort inserts p, which must not belong to another node, at index i of n's inputs or outputs.  If p is already one of them, it is moved to index i.
+func (n *Node) InsertPort(p *Port, i int) {
+	ports := n.portsPtr(p.Out)
+	if j := n.PortIndex(p); j >= 0 {
+		*ports = append((*ports)[:j], (*ports)[j+1:]...)
+	}
+	p.Node = n
+	*ports = append((*ports)[:i], append([]*Port{p}, (*ports)[i:]...)...)
+}
+
+// RemovePort removes p and its connections from n.
+func (n *Node) RemovePort(p *Port) {
+	for _, c := range append([]*Connection{}, p.Conns...) {
+		c.Disconnect()
+		c.Block.RemoveConn(c)
+	}
+	if i := n.PortIndex(p); i >= 0 {
+		ports := n.portsPtr(p.Out)
+		*ports = append((*ports)[:i], (*ports)[i+1:]...)
+	}
+}
+
+// PortIndex returns the index of p among n's inputs or outputs, or -1 if p is not one of them.
+func (n *Node) PortIndex(p *Port) int {
+	for i, p2 := range n.ports(p.Out) {
+		if p2 == p {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *Node) ports(out bool) []*Port { return *n.portsPtr(out) }
+func (n *Node) portsPtr(out bool) *[]*Port {
+	if out {
+		return &n.Outs
+	}
+	return &n.Ins
+}
+
+// InConns returns the connections entering n, including those entering its blocks.
+func (n *Node) InConns() (conns []*Connection) {
+	for _, p := range n.Ins {
+		conns = append(conns, p.Conns...)
+	}
+	for _, b := range n.Blocks {
+		conns = append(conns, b.InConns()...)
+	}
+	return
+}
+
+// OutConns returns the connections leaving n, including those leaving its blocks.
+func (n *Node) OutConns() (conns []*Connection) {
+	for _, p := range n.Outs {
+		conns = append(conns, p.Conns...)
+	}
+	for _, b := range n.Blocks {
+		conns = append(conns, b.OutConns()...)
+	}
+	return
+}
+
+func (p *Port) connect(c *Connection) {
+	p.Conns = append(p.Conns, c)
+}
+
+func (p *Port) disconnect(c *Connection) {
+	for i, c2 := range p.Conns {
+		if c2 == c {
+			p.Conns = append(p.Conns[:i], p.Conns[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Connection) Connected() bool { return c.Src != nil && c.Dst != nil }
+
+func (c *Connection) Disconnect() {
+	c.SetSrc(nil)
+	c.SetDst(nil)
+}
+
+func (c *Connection) SetSrc(src *Port) {
+	old := c.Src
+	if old != nil {
+		old.disconnect(c)
+	}
+	c.Src = src
+	if src != nil {
+		src.connect(c)
+	}
+	c.reblock()
+	c.moved(old, src)
+}
+
+func (c *Connection) SetDst(dst *Port) {
+	old := c.Dst
+	if old != nil {
+		old.disconnect(c)
+	}
+	c.Dst = dst
+	if dst != nil {
+		dst.connect(c)
+	}
+	c.reblock()
+	c.moved(old, dst)
+}
+
+// moved tells the observers of the ports from and to (either of which may be nil) that c was disconnected from one and connected to the other.
+func (c *Connection) moved(from, to *Port) {
+	if from != nil {
+		if o, ok := from.Data.(PortObserver); ok {
+			o.Disconnected(c)
+		}
+	}
+	if to != nil {
+		if o, ok := to.Data.(PortObserver); ok {
+			o.Connected(c)
+		}
+	}
+}
+
+// reblock moves c into the innermost block that encloses its source and contains its destination or, for a feedback connection, into the block containing the loop.
+func (c *Connection) reblock() {
+	var b *Block
+	switch {
+	case c.Src == nil && c.Dst == nil:
+		return
+	case c.Src == nil:
+		b = c.Dst.Node.Block
+	case c.Dst == nil:
+		b = c.Src.Node.Block
+	default:
+		for b = c.Src.Node.Block; b.Find(c.Dst.Node) == nil; b = b.Outer() {
+		}
+		if c.Feedback {
+			for {
+				n := b.Node
+				b = n.Block
+				if n.Kind == Loop {
+					break
+				}
+			}
+		}
+	}
+	b.AddConn(c)
+}
@@ -0,0 +1,278 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graph
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"testing"
+)
+
+func newFunc() (*Node, *Block) {
+	f := &Node{}
+	return f, NewBlock(f)
+}
+
+func newNode(b *Block, ins, outs []types.Type) *Node {
+	n := &Node{}
+	for _, t := range ins {
+		n.NewInput(types.NewVar(0, nil, "", t))
+	}
+	for _, t := range outs {
+		n.NewOutput(types.NewVar(0, nil, "", t))
+	}
+	b.AddNode(n)
+	return n
+}
+
+func connect(src, dst *Port) *Connection {
+	c := &Connection{}
+	c.SetSrc(src)
+	c.SetDst(dst)
+	return c
+}
+
+var (
+	intType    = types.Typ[types.Int]
+	stringType = types.Typ[types.String]
+)
+
+func TestConnectReblock(t *testing.T) {
+	_, b := newFunc()
+	n1 := newNode(b, nil, []types.Type{intType})
+	loop := newNode(b, nil, nil)
+	loop.Kind = Loop
+	lb := NewBlock(loop)
+	n2 := newNode(lb, []types.Type{intType}, nil)
+
+	c := connect(n1.Outs[0], n2.Ins[0])
+	if c.Block != b {
+		t.Errorf("connection into loop block should belong to the outer block")
+	}
+	if len(lb.InConns()) != 1 || len(loop.InConns()) != 1 {
+		t.Errorf("loop block should have one incoming connection")
+	}
+
+	c.Disconnect()
+	if len(n1.Outs[0].Conns) != 0 || len(n2.Ins[0].Conns) != 0 {
+		t.Errorf("ports still connected after Disconnect")
+	}
+}
+
+func TestMoveNodes(t *testing.T) {
+	_, b := newFunc()
+	n1 := newNode(b, nil, []types.Type{intType})
+	n2 := newNode(b, []types.Type{intType}, []types.Type{intType})
+	n3 := newNode(b, []types.Type{intType}, nil)
+	c1 := connect(n1.Outs[0], n2.Ins[0])
+	c2 := connect(n2.Outs[0], n3.Ins[0])
+	loop := newNode(b, nil, nil)
+	loop.Kind = Loop
+	lb := NewBlock(loop)
+
+	lb.MoveNodes([]*Node{n2, n3})
+	if n2.Block != lb || n3.Block != lb || len(b.Nodes) != 2 || len(lb.Nodes) != 2 {
+		t.Errorf("nodes not moved into the loop block")
+	}
+	if c1.Block != b || c1.Src != n1.Outs[0] || c1.Dst != n2.Ins[0] {
+		t.Errorf("connection into the loop block should stay connected and belong to the outer block")
+	}
+	if c2.Block != lb {
+		t.Errorf("connection between moved nodes should belong to the loop block")
+	}
+	if len(b.Conns) != 1 || len(lb.Conns) != 1 {
+		t.Errorf("got %d and %d connections in the outer and loop blocks, want 1 and 1", len(b.Conns), len(lb.Conns))
+	}
+}
+
+func TestFeedbackReblock(t *testing.T) {
+	_, b := newFunc()
+	loop := newNode(b, nil, nil)
+	loop.Kind = Loop
+	lb := NewBlock(loop)
+	n1 := newNode(lb, []types.Type{intType}, []types.Type{intType})
+	n2 := newNode(lb, []types.Type{intType}, []types.Type{intType})
+
+	c := &Connection{Feedback: true}
+	c.SetSrc(n2.Outs[0])
+	c.SetDst(n1.Ins[0])
+	if c.Block != b {
+		t.Errorf("feedback connection should belong to the block containing the loop")
+	}
+	if order, cyclic := lb.NodeOrder(); cyclic || len(order) != 2 || order[0] != n1 {
+		t.Errorf("feedback connection should not order nodes: got %v, cyclic=%v", order, cyclic)
+	}
+}
+
+func TestNodeOrder(t *testing.T) {
+	_, b := newFunc()
+	out := newNode(b, []types.Type{intType}, nil)
+	out.Kind = Outputs
+	n2 := newNode(b, []types.Type{intType}, []types.Type{intType})
+	n1 := newNode(b, []types.Type{intType}, []types.Type{intType})
+	in := newNode(b, nil, []types.Type{intType})
+	in.Kind = Inputs
+	connect(in.Outs[0], n1.Ins[0])
+	connect(n1.Outs[0], n2.Ins[0])
+	connect(n2.Outs[0], out.Ins[0])
+
+	order, cyclic := b.NodeOrder()
+	if cyclic {
+		t.Fatal("unexpected cycle")
+	}
+	want := []*Node{in, n1, n2}
+	if len(order) != len(want) {
+		t.Fatalf("got %d nodes, want %d", len(order), len(want))
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("node %d out of order", i)
+		}
+	}
+	if !Precedes(n1, n2) || Precedes(n2, n1) {
+		t.Error("Precedes disagrees with connections")
+	}
+
+	connect(n2.Outs[0], n1.Ins[0])
+	if _, cyclic := b.NodeOrder(); !cyclic {
+		t.Error("cycle not detected")
+	}
+}
+
+func TestNodeOrderStable(t *testing.T) {
+	_, b := newFunc()
+	n3 := newNode(b, []types.Type{intType}, nil)
+	n1 := newNode(b, nil, []types.Type{intType})
+	n2 := newNode(b, nil, nil)
+	connect(n1.Outs[0], n3.Ins[0])
+
+	for i := 0; i < 10; i++ {
+		order, _ := b.NodeOrder()
+		if len(order) != 3 || order[0] != n1 || order[1] != n3 || order[2] != n2 {
+			t.Fatal("unconstrained nodes should keep their order in the block")
+		}
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	_, b := newFunc()
+	n1 := newNode(b, nil, []types.Type{intType})
+	n2 := newNode(b, []types.Type{intType}, nil)
+	c := connect(n1.Outs[0], n2.Ins[0])
+
+	b.RemoveNode(n1)
+	if len(b.Nodes) != 1 || len(b.Conns) != 0 || c.Block != nil || len(n2.Ins[0].Conns) != 0 {
+		t.Error("RemoveNode did not remove the node and its connections")
+	}
+}
+
+// observer records the changes it is told of.
+type observer struct{ events []string }
+
+func (o *observer) NodeAdded(*Node)            { o.events = append(o.events, "node added") }
+func (o *observer) NodeRemoved(*Node)          { o.events = append(o.events, "node removed") }
+func (o *observer) ConnAdded(*Connection)      { o.events = append(o.events, "conn added") }
+func (o *observer) ConnRemoved(*Connection)    { o.events = append(o.events, "conn removed") }
+func (o *observer) Connected(c *Connection)    { o.events = append(o.events, "connected") }
+func (o *observer) Disconnected(c *Connection) { o.events = append(o.events, "disconnected") }
+
+func TestObservers(t *testing.T) {
+	_, b := newFunc()
+	loop := newNode(b, nil, nil)
+	loop.Kind = Loop
+	lb := NewBlock(loop)
+	bo, lbo, po := &observer{}, &observer{}, &observer{}
+	b.Data, lb.Data = bo, lbo
+	n1 := newNode(b, nil, []types.Type{intType})
+	n2 := newNode(b, []types.Type{intType}, nil)
+	n1.Outs[0].Data = po
+
+	c := connect(n1.Outs[0], n2.Ins[0])
+	lb.MoveNodes([]*Node{n1, n2})
+	c.Disconnect()
+	lb.RemoveConn(c)
+	lb.RemoveNode(n2)
+
+	check := func(name string, o *observer, want ...string) {
+		if len(o.events) != len(want) {
+			t.Errorf("%s: got %v, want %v", name, o.events, want)
+			return
+		}
+		for i := range want {
+			if o.events[i] != want[i] {
+				t.Errorf("%s: got %v, want %v", name, o.events, want)
+				return
+			}
+		}
+	}
+	check("outer block", bo, "node added", "node added", "conn added", "node removed", "node removed", "conn removed")
+	check("loop block", lbo, "node added", "node added", "conn added", "conn removed", "node removed")
+	check("port", po, "connected", "disconnected")
+}
+
+func TestConnectable(t *testing.T) {
+	_, b := newFunc()
+	n1 := newNode(b, []types.Type{intType}, []types.Type{intType, stringType})
+	n2 := newNode(b, []types.Type{intType}, []types.Type{intType})
+	seq1 := n1.NewOutput(types.NewVar(0, nil, "", SeqType))
+	seq2 := n2.NewInput(types.NewVar(0, nil, "", SeqType))
+
+	c := &Connection{}
+	for _, x := range []struct {
+		src, dst *Port
+		ok       bool
+		what     string
+	}{
+		{n1.Outs[0], n2.Ins[0], true, "int to int"},
+		{n1.Outs[1], n2.Ins[0], false, "string to int"},
+		{n1.Outs[0], n1.Ins[0], false, "node to itself"},
+		{n1.Outs[0], n2.Outs[0], false, "output to output"},
+		{seq1, seq2, true, "sequencing"},
+		{seq1, n2.Ins[0], false, "sequencing to value"},
+	} {
+		if ok := c.Connectable(x.src, x.dst); ok != x.ok {
+			t.Errorf("%s: got %v, want %v", x.what, ok, x.ok)
+		}
+	}
+
+	connect(n1.Outs[0], n2.Ins[0])
+	if c.Connectable(n2.Outs[0], n1.Ins[0]) {
+		t.Error("connection creating a cycle should not be connectable")
+	}
+}
+
+func TestAssignable(t *testing.T) {
+	for _, x := range []struct {
+		t, u types.Type
+		ok   bool
+	}{
+		{types.Typ[types.UntypedInt], types.Typ[types.Float64], true},
+		{types.Typ[types.UntypedFloat], intType, false},
+		{types.Typ[types.UntypedString], stringType, true},
+		{intType, intType, true},
+		{intType, stringType, false},
+		{nil, intType, false},
+	} {
+		if ok := Assignable(x.t, x.u); ok != x.ok {
+			t.Errorf("Assignable(%v, %v) = %v, want %v", x.t, x.u, ok, x.ok)
+		}
+	}
+}
+
+func TestInputType(t *testing.T) {
+	_, b := newFunc()
+	src := newNode(b, nil, []types.Type{types.NewPointer(intType)})
+	dst := newNode(b, []types.Type{nil}, nil)
+	dst.Connectable = func(t types.Type, p *Port) bool {
+		_, ok := t.(*types.Pointer)
+		return !ok
+	}
+	if InputType(dst.Ins[0]) != nil {
+		t.Error("InputType of unconnected input should be nil")
+	}
+	connect(src.Outs[0], dst.Ins[0])
+	if T := InputType(dst.Ins[0]); T != intType {
+		t.Errorf("InputType should indirect a pointer: got %v", T)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graph
+
+// NodeOrder returns the nodes of b in execution order.  The Inputs node, if any, comes first; the Outputs node is omitted.
+// Where the connections allow it, nodes keep their order in b.Nodes, so that the order is stable across writing and reading.
+// cyclic reports whether the connections between the nodes form a cycle, in which case the order is incomplete.
+func (b *Block) NodeOrder() (order []*Node, cyclic bool) {
+	var inputsNode *Node
+
+	pending := map[*Node]int{} // the number of unordered sources of each node
+	dsts := map[*Node][]*Node{}
+	for _, n := range b.Nodes {
+		seen := map[*Node]bool{}
+		for _, src := range SrcsInBlock(n) {
+			if !seen[src] {
+				seen[src] = true
+				pending[n]++
+				dsts[src] = append(dsts[src], n)
+			}
+		}
+	}
+
+	ordered := map[*Node]bool{}
+	for len(ordered) < len(b.Nodes) {
+		var next *Node
+		for _, n := range b.Nodes {
+			if !ordered[n] && pending[n] == 0 {
+				next = n
+				break
+			}
+		}
+		if next == nil {
+			cyclic = true
+			break
+		}
+		ordered[next] = true
+		for _, dst := range dsts[next] {
+			pending[dst]--
+		}
+		switch next.Kind {
+		case Inputs:
+			inputsNode = next
+		case Outputs:
+		default:
+			order = append(order, next)
+		}
+	}
+	if inputsNode != nil {
+		order = append([]*Node{inputsNode}, order...)
+	}
+	return
+}
+
+// SrcsInBlock returns the nodes in n's block that must execute before n.
+func SrcsInBlock(n *Node) (srcs []*Node) {
+	b := n.Block
+	for _, c := range n.InConns() {
+		if c.Feedback || c.Src == nil {
+			continue
+		}
+		if src := b.Find(c.Src.Node); src != nil {
+			srcs = append(srcs, src)
+		}
+	}
+	for _, c := range n.OutConns() {
+		if !c.Feedback || c.Dst == nil {
+			continue
+		}
+		if dst := b.Find(c.Dst.Node); dst != nil && dst != n {
+			srcs = append(srcs, dst)
+		}
+	}
+	return
+}
+
+// DstsInBlock returns the nodes in n's block that must execute after n.
+func DstsInBlock(n *Node) (dsts []*Node) {
+	b := n.Block
+	for _, c := range n.OutConns() {
+		if c.Feedback || c.Dst == nil {
+			continue
+		}
+		if dst := b.Find(c.Dst.Node); dst != nil {
+			dsts = append(dsts, dst)
+		}
+	}
+	for _, c := range n.InConns() {
+		if !c.Feedback || c.Src == nil {
+			continue
+		}
+		if src := b.Find(c.Src.Node); src != nil && src != n {
+			dsts = append(dsts, src)
+		}
+	}
+	return
+}
+
+// Precedes reports whether n1 must execute before n2.
+func Precedes(n1, n2 *Node) bool {
+	for _, dst := range DstsInBlock(n1) {
+		if dst == n2 || Precedes(dst, n2) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graph
+
+import "github.com/gordonklaus/flux/go/types"
+
+// SeqType is the type of sequencing ports.  A connection between sequencing ports carries no value; it only orders the execution of two nodes.
+var SeqType = struct{ types.Type }{}
+
+// Connectable reports whether c may be connected from src to dst.
+func (c *Connection) Connectable(src, dst *Port) bool {
+	// Quietly disconnect c for the duration of this call to more accurately simulate the proposed scenario in which
+	// it has the new src and dst.  In particular, AssignableToAll must ignore the current state of this connection when
+	// reconnecting a generic input.
+	p, q := c.Src, c.Dst
+	c.Src, c.Dst = nil, nil
+	defer func() { c.Src, c.Dst = p, q }()
+
+	if src.Out == dst.Out {
+		return false
+	}
+	for _, c := range src.Conns {
+		if c.Dst == dst {
+			return false
+		}
+	}
+
+	t := src.Obj.Type
+	u := dst.Obj.Type
+	if t == nil {
+		return false
+	}
+	if (t == SeqType) != (u == SeqType) {
+		return false
+	}
+	if t == SeqType {
+		return src.Node.Block == dst.Node.Block && src.Node != dst.Node && !Precedes(src.Node, dst.Node) && !Precedes(dst.Node, src.Node)
+	}
+
+	f := func(t types.Type) bool { return Assignable(t, u) }
+	if n := dst.Node; n.Connectable != nil {
+		f = func(t types.Type) bool { return n.Connectable(t, dst) && AssignableToAll(t, dst) }
+	}
+	if !MaybeIndirect(t, f) {
+		return false
+	}
+
+	// TODO: recursive func literals
+
+	n1, n2 := src.Node, dst.Node
+	for b := n1.Block; ; n1, b = b.Node, b.Outer() {
+		if n := b.Find(n2); n != nil {
+			n2 = n
+			break
+		}
+	}
+	if c.Feedback {
+		for b := n1.Block; ; b = b.Outer() {
+			if b == nil {
+				return false
+			}
+			if b.Node.Kind == Loop {
+				break
+			}
+		}
+		n1, n2 = n2, n1
+	} else if n1 == n2 {
+		return false
+	}
+	return !Precedes(n2, n1)
+}
+
+// Assignable reports whether a value of type t is assignable to a variable of type u.
+func Assignable(t, u types.Type) bool {
+	if t == nil || u == nil {
+		return false
+	}
+	if t, ok := t.(*types.Basic); ok && t.Info&types.IsUntyped != 0 {
+		// TODO: consider representability of const values
+		switch u := underlying(u).(type) {
+		case *types.Interface:
+			return u.Empty()
+		case *types.Basic:
+			int := t.Info&types.IsInteger != 0
+			float := t.Info&types.IsFloat != 0
+			complex := t.Info&types.IsComplex != 0
+			switch {
+			case u.Info&types.IsBoolean != 0:
+				return t.Info&types.IsBoolean != 0
+			case u.Info&types.IsInteger != 0:
+				return int
+			case u.Info&types.IsFloat != 0:
+				return int || float
+			case u.Info&types.IsComplex != 0:
+				return int || float || complex
+			case u.Info&types.IsString != 0:
+				return t.Info&types.IsString != 0
+			}
+		}
+		return false
+	}
+	return types.IsAssignableTo(t, u)
+}
+
+// AssignableToAll returns true if t is assignable to or from all of the source types (or their indirections) connected to ins.
+// It does not indirect t as Connectable (via which this is meant to be called) already does so.
+func AssignableToAll(t types.Type, ins ...*Port) bool {
+	for _, p := range ins {
+		for _, c := range p.Conns {
+			if c.Src != nil {
+				if !MaybeIndirect(c.Src.Obj.Type, func(t2 types.Type) bool { return Assignable(t, t2) || Assignable(t2, t) }) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// InputType returns one of the source types (or its indirection) connected to ins to which all of the others (or their indirections) are assignable.
+// It returns nil if nothing is connected.  ins[0].Node.Connectable must not be nil.
+func InputType(ins ...*Port) (T types.Type) {
+	assign := func(t, u types.Type) bool {
+		if T == nil || Assignable(t, u) {
+			T = u
+			return true
+		}
+		return false
+	}
+	for _, p := range ins {
+		for _, c := range p.Conns {
+			if c.Src != nil {
+				MaybeIndirect(T, func(t types.Type) bool {
+					return MaybeIndirect(c.Src.Obj.Type, func(u types.Type) bool {
+						return assign(t, u) || assign(u, t)
+					})
+				})
+			}
+		}
+	}
+
+	if T != nil && !ins[0].Node.Connectable(T, ins[0]) {
+		T, _ = indirect(T)
+	}
+	return
+}
+
+// MaybeIndirect reports whether f is true for t or, if t is a pointer type, for its element type.
+func MaybeIndirect(t types.Type, f func(t types.Type) bool) bool {
+	p, ok := indirect(t)
+	return f(t) || ok && f(p)
+}
+
+func underlying(t types.Type) types.Type {
+	if nt, ok := t.(*types.Named); ok {
+		return nt.Underlying()
+	}
+	return t
+}
+
+func indirect(t types.Type) (types.Type, bool) {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem, true
+	}
+	return t, false
+}
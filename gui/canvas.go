@@ -0,0 +1,73 @@
+package gui
+
+// A Canvas is the target of painting.  Its coordinates have the origin at the bottom left with y increasing upward.
+// Push saves the current color, line width, point size, translation, scale, and clip rectangle; Pop restores them.
+type Canvas interface {
+	SetColor(c Color)
+	SetPointSize(x float64)
+	SetLineWidth(x float64)
+
+	DrawPoint(p Point)
+	DrawLine(p1, p2 Point)
+	DrawRect(r Rectangle)
+	FillRect(r Rectangle)
+	DrawPolygon(pts ...Point)
+	FillPolygon(pts ...Point)
+	DrawBezier(ctrlPts ...Point)
+	// DrawGradientBezier draws a bezier curve whose color varies from c1 at its start to c2 at its end.
+	DrawGradientBezier(c1, c2 Color, ctrlPts ...Point)
+	// DrawText draws text in font f with its baseline starting at p.
+	DrawText(f Font, text string, p Point)
+
+	Translate(p Point)
+	// Scale scales subsequent drawing by s about the current origin.  Line widths, point sizes, and text are scaled along with coordinates.
+	Scale(s float64)
+	// Zoom returns the product of the scales in effect, that is, the size on the canvas of one unit of drawing.
+	Zoom() float64
+	// Clip restricts drawing to r intersected with the current clip rectangle.
+	Clip(r Rectangle)
+	Push()
+	Pop()
+}
+
+type Color struct{ R, G, B, A float64 }
+
+// A Font measures text for a Canvas to draw.
+type Font interface {
+	Advance(text string) float64
+	Ascender() float64
+	Descender() float64 // negative, for a descent below the baseline
+}
+
+// PaintTo paints v and its descendants onto c, with the bottom left corner of v at the origin of c.
+func PaintTo(v View, c Canvas) {
+	c.Push()
+	defer c.Pop()
+	c.Translate(ZP.Sub(Pos(v)))
+	v.base().paint(c)
+}
+
+// bezierPoints returns points along the bezier curve having the given control points, about one per unit of length of its control polygon.
+func bezierPoints(ctrlPts []Point) []Point {
+	steps := 0.0
+	for i := 1; i < len(ctrlPts); i++ {
+		steps += ctrlPts[i].Sub(ctrlPts[i-1]).Len()
+	}
+	n := int(steps)
+	if n < 1 {
+		n = 1
+	}
+	pts := make([]Point, n+1)
+	tmp := make([]Point, len(ctrlPts))
+	for i := range pts {
+		t := float64(i) / float64(n)
+		copy(tmp, ctrlPts)
+		for k := len(tmp) - 1; k > 0; k-- {
+			for j := 0; j < k; j++ {
+				tmp[j] = tmp[j].Mul(1 - t).Add(tmp[j+1].Mul(t))
+			}
+		}
+		pts[i] = tmp[0]
+	}
+	return pts
+}
@@ -121,3 +121,9 @@ const (
 	KeyMenu         = 348
 	KeyLast         = KeyMenu
 )
+
+const (
+	MouseButtonLeft   = 0
+	MouseButtonRight  = 1
+	MouseButtonMiddle = 2
+)
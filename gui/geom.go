@@ -139,6 +139,11 @@ func (r Rectangle) Sub(p Point) Rectangle {
 	}
 }
 
+// Mul returns the rectangle r scaled by k about the origin.
+func (r Rectangle) Mul(k float64) Rectangle {
+	return Rectangle{r.Min.Mul(k), r.Max.Mul(k)}
+}
+
 // Inset returns the rectangle r inset by n, which may be negative. If either
 // of r's dimensions is less than 2*n then an empty rectangle near the center
 // of r will be returned.
@@ -1,36 +1,63 @@
 package gui
 
-import . "github.com/chsc/gogl/gl21"
+import (
+	. "github.com/chsc/gogl/gl21"
+)
 
-type Color struct{ R, G, B, A float64 }
+// glCanvas draws with the OpenGL context current on the calling thread.
+type glCanvas struct {
+	glState
+	stack []glState
+	scale float64 // framebuffer pixels per window unit
+}
+
+type glState struct {
+	color                Color
+	lineWidth, pointSize float64
+	offset               Point // the translation from window coordinates
+	zoom                 float64
+	clip                 Rectangle
+	clipped              bool
+}
+
+func (c *glCanvas) SetColor(col Color) {
+	c.color = col
+	Color4d(Double(col.R), Double(col.G), Double(col.B), Double(col.A))
+}
+
+func (c *glCanvas) SetPointSize(x float64) {
+	c.pointSize = x
+	PointSize(Float(x * c.zoom))
+}
 
-func SetColor(c Color)       { Color4d(Double(c.R), Double(c.G), Double(c.B), Double(c.A)) }
-func SetPointSize(x float64) { PointSize(Float(x)) }
-func SetLineWidth(x float64) { LineWidth(Float(x)) }
+func (c *glCanvas) SetLineWidth(x float64) {
+	c.lineWidth = x
+	LineWidth(Float(x * c.zoom))
+}
 
-func DrawPoint(p Point) {
+func (c *glCanvas) DrawPoint(p Point) {
 	Begin(POINTS)
 	defer End()
 	Vertex2d(Double(p.X), Double(p.Y))
 }
 
-func DrawLine(p1, p2 Point) {
-	DrawBezier(p1, p2)
+func (c *glCanvas) DrawLine(p1, p2 Point) {
+	c.DrawBezier(p1, p2)
 }
 
-func DrawRect(r Rectangle) {
+func (c *glCanvas) DrawRect(r Rectangle) {
 	p1, p2, p3, p4 := r.Min, Pt(r.Max.X, r.Min.Y), r.Max, Pt(r.Min.X, r.Max.Y)
-	DrawLine(p1, p2)
-	DrawLine(p2, p3)
-	DrawLine(p3, p4)
-	DrawLine(p4, p1)
+	c.DrawLine(p1, p2)
+	c.DrawLine(p2, p3)
+	c.DrawLine(p3, p4)
+	c.DrawLine(p4, p1)
 }
 
-func FillRect(r Rectangle) {
+func (c *glCanvas) FillRect(r Rectangle) {
 	Rectd(Double(r.Min.X), Double(r.Min.Y), Double(r.Max.X), Double(r.Max.Y))
 }
 
-func DrawPolygon(pts ...Point) {
+func (c *glCanvas) DrawPolygon(pts ...Point) {
 	Begin(LINE_LOOP)
 	defer End()
 	for _, p := range pts {
@@ -38,7 +65,7 @@ func DrawPolygon(pts ...Point) {
 	}
 }
 
-func FillPolygon(pts ...Point) {
+func (c *glCanvas) FillPolygon(pts ...Point) {
 	Begin(POLYGON)
 	defer End()
 	for _, p := range pts {
@@ -46,7 +73,7 @@ func FillPolygon(pts ...Point) {
 	}
 }
 
-func DrawBezier(ctrlPts ...Point) {
+func (c *glCanvas) DrawBezier(ctrlPts ...Point) {
 	pts := []Double{}
 	steps := 0.0
 	for i, p := range ctrlPts {
@@ -62,6 +89,80 @@ func DrawBezier(ctrlPts ...Point) {
 	EvalMesh1(LINE, 0, Int(steps))
 }
 
-func Rotate(rot float64) {
-	Rotated(Double(rot*360), 0, 0, 1)
+func (c *glCanvas) DrawGradientBezier(c1, c2 Color, ctrlPts ...Point) {
+	ctrlColors := []Double{Double(c1.R), Double(c1.G), Double(c1.B), Double(c1.A), Double(c2.R), Double(c2.G), Double(c2.B), Double(c2.A)}
+	Map1d(MAP1_COLOR_4, 0, 1, 4, 2, &ctrlColors[0])
+	Enable(MAP1_COLOR_4)
+	defer Disable(MAP1_COLOR_4)
+	c.DrawBezier(ctrlPts...)
+}
+
+func (c *glCanvas) DrawText(f Font, text string, p Point) {
+	if f, ok := f.(glFont); ok {
+		PushMatrix()
+		defer PopMatrix()
+		Translated(Double(p.X), Double(p.Y), 0)
+		f.Render(text)
+	}
+}
+
+func (c *glCanvas) Translate(p Point) {
+	c.offset = c.offset.Add(p.Mul(c.zoom))
+	Translated(Double(p.X), Double(p.Y), 0)
+}
+
+// Scale also rescales the current line width and point size, which OpenGL measures in pixels.
+func (c *glCanvas) Scale(s float64) {
+	c.zoom *= s
+	Scaled(Double(s), Double(s), 1)
+	c.SetLineWidth(c.lineWidth)
+	c.SetPointSize(c.pointSize)
+}
+
+func (c *glCanvas) Zoom() float64 { return c.zoom }
+
+func (c *glCanvas) Clip(r Rectangle) {
+	r = r.Mul(c.zoom).Add(c.offset)
+	if c.clipped {
+		r = r.Intersect(c.clip)
+	}
+	c.clip, c.clipped = r, true
+	c.scissor()
+}
+
+func (c *glCanvas) scissor() {
+	if !c.clipped {
+		Disable(SCISSOR_TEST)
+		return
+	}
+	Enable(SCISSOR_TEST)
+	r := c.clip
+	Scissor(Int(r.Min.X*c.scale), Int(r.Min.Y*c.scale), Sizei(r.Dx()*c.scale), Sizei(r.Dy()*c.scale))
+}
+
+func (c *glCanvas) Push() {
+	PushMatrix()
+	c.stack = append(c.stack, c.glState)
+}
+
+func (c *glCanvas) Pop() {
+	PopMatrix()
+	s := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	if s.color != c.color {
+		c.SetColor(s.color)
+	}
+	zoomed := s.zoom != c.zoom
+	c.zoom = s.zoom
+	if s.lineWidth != c.lineWidth || zoomed {
+		c.SetLineWidth(s.lineWidth)
+	}
+	if s.pointSize != c.pointSize || zoomed {
+		c.SetPointSize(s.pointSize)
+	}
+	if s.clip != c.clip || s.clipped != c.clipped {
+		c.glState = s
+		c.scissor()
+	}
+	c.glState = s
 }
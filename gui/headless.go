@@ -0,0 +1,76 @@
+package gui
+
+import (
+	"github.com/gordonklaus/glfw"
+	"unicode"
+)
+
+// NewHeadlessWindow creates a Window of the given size that is not shown on screen and that receives events only from the methods PressKey, ReleaseKey, TypeText, SendComposition, SendMouse, and SendScroll.
+// It is meant for testing the behavior of views:  Add views to it in init, drive them with scripted events, and inspect them inside Do.
+func NewHeadlessWindow(self View, size Point, init func(w *Window)) *Window {
+	w := &Window{}
+	if self == nil {
+		self = w
+	}
+	w.ViewBase = NewView(self)
+	w.mouser = make(map[int]MouserView)
+	w.paint = make(chan bool, 1)
+	w.do = make(chan func())
+	go w.runHeadless()
+	w.Do(func() {
+		Resize(w, size)
+		init(w)
+	})
+	return w
+}
+
+func (w *Window) runHeadless() {
+	for !w.close {
+		select {
+		case f := <-w.do:
+			f()
+		case <-w.paint:
+		}
+	}
+}
+
+func (w *Window) headless() bool { return w.w == nil }
+
+// PressKey delivers a press of k to the key focus, as if it came from the keyboard.
+func (w *Window) PressKey(k KeyEvent) {
+	k.action = glfw.Press
+	w.Do(func() { w.key(k) })
+}
+
+// ReleaseKey delivers a release of k to the key focus, as if it came from the keyboard.
+func (w *Window) ReleaseKey(k KeyEvent) {
+	k.action = glfw.Release
+	k.Text = ""
+	w.Do(func() { w.key(k) })
+}
+
+// TypeText delivers a key press to the key focus for each character of text, as if it were typed.
+func (w *Window) TypeText(text string) {
+	for _, r := range text {
+		k := KeyEvent{Text: string(r), Shift: unicode.IsUpper(r)}
+		if r < 128 {
+			k.Key = int(unicode.ToUpper(r))
+		}
+		w.PressKey(k)
+	}
+}
+
+// SendComposition delivers text, which an input method is composing, to the key focus, as if it came from the keyboard.  An empty text ends composition.
+func (w *Window) SendComposition(text string) {
+	w.Do(func() { w.compose(text) })
+}
+
+// SendMouse delivers m, whose position is in the window's coordinates, as if it came from the mouse.  One of m.Move, m.Press, or m.Release should be set; Drag, Enter, and Leave events are derived from these.
+func (w *Window) SendMouse(m MouseEvent) {
+	w.Do(func() { w.mouse(m) })
+}
+
+// SendScroll delivers s, whose position is in the window's coordinates, as if it came from the mouse's scroll wheel.
+func (w *Window) SendScroll(s ScrollEvent) {
+	w.Do(func() { w.scroll(s) })
+}
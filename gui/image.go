@@ -0,0 +1,250 @@
+package gui
+
+import (
+	"image"
+	"math"
+)
+
+// ImageCanvas is a Canvas that rasterizes in software into an image.RGBA, so that views can be painted without an OpenGL context.
+// Shapes are antialiased.  Lines are drawn as strokes of the current line width and points as discs of the current point size.
+type ImageCanvas struct {
+	img *image.RGBA
+	imageState
+	stack []imageState
+}
+
+type imageState struct {
+	color                Color
+	lineWidth, pointSize float64
+	offset               Point
+	zoom                 float64
+	clip                 image.Rectangle // in image coordinates
+}
+
+// NewImageCanvas returns a Canvas drawing into img, with the origin at the bottom left of img.Bounds().
+func NewImageCanvas(img *image.RGBA) *ImageCanvas {
+	return &ImageCanvas{img: img, imageState: imageState{color: Color{1, 1, 1, 1}, lineWidth: 1, pointSize: 1, zoom: 1, clip: img.Bounds()}}
+}
+
+func (c *ImageCanvas) SetColor(col Color)     { c.color = col }
+func (c *ImageCanvas) SetPointSize(x float64) { c.pointSize = x }
+func (c *ImageCanvas) SetLineWidth(x float64) { c.lineWidth = x }
+
+func (c *ImageCanvas) DrawPoint(p Point) {
+	c.fill(c.color, disc(p, c.pointSize/2))
+}
+
+func (c *ImageCanvas) DrawLine(p1, p2 Point) {
+	c.stroke(c.color, []Point{p1, p2}, false)
+}
+
+func (c *ImageCanvas) DrawRect(r Rectangle) {
+	c.DrawPolygon(r.Min, Pt(r.Max.X, r.Min.Y), r.Max, Pt(r.Min.X, r.Max.Y))
+}
+
+func (c *ImageCanvas) FillRect(r Rectangle) {
+	c.FillPolygon(r.Min, Pt(r.Max.X, r.Min.Y), r.Max, Pt(r.Min.X, r.Max.Y))
+}
+
+func (c *ImageCanvas) DrawPolygon(pts ...Point) {
+	c.stroke(c.color, pts, true)
+}
+
+func (c *ImageCanvas) FillPolygon(pts ...Point) {
+	c.fill(c.color, pts)
+}
+
+func (c *ImageCanvas) DrawBezier(ctrlPts ...Point) {
+	c.stroke(c.color, bezierPoints(ctrlPts), false)
+}
+
+func (c *ImageCanvas) DrawGradientBezier(c1, c2 Color, ctrlPts ...Point) {
+	pts := bezierPoints(ctrlPts)
+	for i := 1; i < len(pts); i++ {
+		t := (float64(i) - .5) / float64(len(pts)-1)
+		col := Color{c1.R + t*(c2.R-c1.R), c1.G + t*(c2.G-c1.G), c1.B + t*(c2.B-c1.B), c1.A + t*(c2.A-c1.A)}
+		c.stroke(col, pts[i-1:i+1], false)
+	}
+}
+
+func (c *ImageCanvas) DrawText(f Font, text string, p Point) {
+	tf, ok := f.(*TrueTypeFont)
+	if !ok {
+		if f, ok := f.(glFont); ok {
+			tf = loadTrueTypeFont(f.path, f.size)
+		} else {
+			tf = defaultFont()
+		}
+		if tf == nil {
+			return
+		}
+	}
+	c.fill(c.color, tf.outlines(text, p)...)
+}
+
+func (c *ImageCanvas) Translate(p Point) { c.offset = c.offset.Add(p.Mul(c.zoom)) }
+func (c *ImageCanvas) Scale(s float64)   { c.zoom *= s }
+func (c *ImageCanvas) Zoom() float64     { return c.zoom }
+
+func (c *ImageCanvas) Clip(r Rectangle) {
+	r = r.Mul(c.zoom).Add(c.offset)
+	b := c.img.Bounds()
+	ir := image.Rect(int(math.Floor(r.Min.X))+b.Min.X, b.Max.Y-int(math.Ceil(r.Max.Y)), int(math.Ceil(r.Max.X))+b.Min.X, b.Max.Y-int(math.Floor(r.Min.Y)))
+	c.clip = c.clip.Intersect(ir)
+}
+
+func (c *ImageCanvas) Push() { c.stack = append(c.stack, c.imageState) }
+
+func (c *ImageCanvas) Pop() {
+	c.imageState = c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// stroke draws the polyline through pts with the current line width.
+func (c *ImageCanvas) stroke(col Color, pts []Point, closed bool) {
+	if closed && len(pts) > 2 {
+		pts = append(pts[:len(pts):len(pts)], pts[0])
+	}
+	w := c.lineWidth / 2
+	paths := [][]Point{}
+	for i := 1; i < len(pts); i++ {
+		p, q := pts[i-1], pts[i]
+		d := q.Sub(p)
+		l := d.Len()
+		if l == 0 {
+			continue
+		}
+		n := Pt(-d.Y, d.X).Mul(w / l)
+		paths = append(paths, []Point{p.Sub(n), q.Sub(n), q.Add(n), p.Add(n)})
+		if i > 1 || closed {
+			paths = append(paths, disc(p, w)) // a round join
+		}
+	}
+	c.fill(col, paths...)
+}
+
+// disc returns a polygon approximating the circle of radius r centered at p.
+func disc(p Point, r float64) []Point {
+	n := int(math.Max(8, math.Min(64, 2*math.Pi*r)))
+	pts := make([]Point, n)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		pts[i] = p.Add(Pt(math.Cos(a), math.Sin(a)).Mul(r))
+	}
+	return pts
+}
+
+const subsamples = 4 // per pixel, in each dimension
+
+// fill fills the union of the regions enclosed by paths, by the nonzero winding rule, with col.
+func (c *ImageCanvas) fill(col Color, paths ...[]Point) {
+	if col.A <= 0 {
+		return
+	}
+	b := c.img.Bounds()
+	type edge struct {
+		p, q Point
+		dir  int
+	}
+	edges := []edge{}
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, path := range paths {
+		for i := range path {
+			p, q := path[i], path[(i+1)%len(path)]
+			// to image coordinates (y down), in units of pixels
+			p = p.Mul(c.zoom).Add(c.offset)
+			q = q.Mul(c.zoom).Add(c.offset)
+			p = Pt(p.X+float64(b.Min.X), float64(b.Max.Y)-p.Y)
+			q = Pt(q.X+float64(b.Min.X), float64(b.Max.Y)-q.Y)
+			if p.Y == q.Y {
+				continue
+			}
+			dir := 1
+			if p.Y > q.Y {
+				p, q, dir = q, p, -1
+			}
+			edges = append(edges, edge{p, q, dir})
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, q.Y)
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+	clip := c.clip.Intersect(b)
+	y0, y1 := int(math.Max(math.Floor(minY), float64(clip.Min.Y))), int(math.Min(math.Ceil(maxY), float64(clip.Max.Y)))
+	cover := make([]float64, clip.Dx()+1)
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	for y := y0; y < y1; y++ {
+		for i := range cover {
+			cover[i] = 0
+		}
+		any := false
+		for s := 0; s < subsamples; s++ {
+			sy := float64(y) + (float64(s)+.5)/subsamples
+			xs := []crossing{}
+			for _, e := range edges {
+				if e.p.Y <= sy && sy < e.q.Y {
+					x := e.p.X + (sy-e.p.Y)*(e.q.X-e.p.X)/(e.q.Y-e.p.Y)
+					xs = append(xs, crossing{x, e.dir})
+				}
+			}
+			for i := 1; i < len(xs); i++ {
+				for j := i; j > 0 && xs[j].x < xs[j-1].x; j-- {
+					xs[j], xs[j-1] = xs[j-1], xs[j]
+				}
+			}
+			wind := 0
+			for i, x := range xs {
+				wind += x.dir
+				if wind != 0 && i+1 < len(xs) {
+					any = true
+					c.span(cover, clip, x.x, xs[i+1].x)
+				}
+			}
+		}
+		if any {
+			c.blend(col, cover, clip, y)
+		}
+	}
+}
+
+// span adds the coverage of one subsample row from x0 to x1 to cover.
+func (c *ImageCanvas) span(cover []float64, clip image.Rectangle, x0, x1 float64) {
+	x0 = math.Max(x0, float64(clip.Min.X)) - float64(clip.Min.X)
+	x1 = math.Min(x1, float64(clip.Max.X)) - float64(clip.Min.X)
+	if x0 >= x1 {
+		return
+	}
+	i0, i1 := int(x0), int(x1)
+	if i0 == i1 {
+		cover[i0] += (x1 - x0) / subsamples
+		return
+	}
+	cover[i0] += (float64(i0+1) - x0) / subsamples
+	for i := i0 + 1; i < i1; i++ {
+		cover[i] += 1.0 / subsamples
+	}
+	if i1 < len(cover) {
+		cover[i1] += (x1 - float64(i1)) / subsamples
+	}
+}
+
+// blend composites col over row y of the image, weighted by cover.
+func (c *ImageCanvas) blend(col Color, cover []float64, clip image.Rectangle, y int) {
+	for i, cv := range cover {
+		x := clip.Min.X + i
+		if cv <= 0 || x >= clip.Max.X {
+			continue
+		}
+		a := col.A * math.Min(cv, 1)
+		o := c.img.PixOffset(x, y)
+		pix := c.img.Pix[o : o+4]
+		pix[0] = uint8(255*col.R*a + float64(pix[0])*(1-a) + .5)
+		pix[1] = uint8(255*col.G*a + float64(pix[1])*(1-a) + .5)
+		pix[2] = uint8(255*col.B*a + float64(pix[2])*(1-a) + .5)
+		pix[3] = uint8(255*a + float64(pix[3])*(1-a) + .5)
+	}
+}
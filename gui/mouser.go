@@ -46,7 +46,7 @@ func (d *Mover) Mouse(m MouseEvent) {
 		Raise(d.v)
 		d.p = m.Pos
 	case m.Drag, m.Release:
-		d.v.Move(Pos(d.v).Add(m.Pos.Sub(d.p)))
+		d.v.Move(Pos(d.v).Add(m.Pos.Sub(d.p).Mul(Scale(d.v))))
 	}
 }
 
@@ -0,0 +1,243 @@
+package gui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+)
+
+// PDFCanvas is a Canvas that records drawing as a one-page Portable Document Format document, for writing with WriteTo.
+// Text is drawn in the standard Times-Roman font, so that it remains searchable; characters outside Latin-1 are drawn as question marks.
+type PDFCanvas struct {
+	size     Point
+	body     bytes.Buffer
+	alphas   map[string]string // the name of the graphics state for each opacity
+	alphaSeq []string          // the opacities in alphas, in order of first use
+	pdfState
+	stack []pdfState
+}
+
+type pdfState struct {
+	color                Color
+	lineWidth, pointSize float64
+	offset               Point
+	zoom                 float64
+	clip                 Rectangle // in canvas coordinates
+	clipped              bool
+}
+
+// NewPDFCanvas returns a Canvas covering the rectangle from the origin to size, in points.
+func NewPDFCanvas(size Point) *PDFCanvas {
+	return &PDFCanvas{size: size, alphas: map[string]string{}, pdfState: pdfState{color: Color{1, 1, 1, 1}, lineWidth: 1, pointSize: 1, zoom: 1}}
+}
+
+func (c *PDFCanvas) SetColor(col Color)     { c.color = col }
+func (c *PDFCanvas) SetPointSize(x float64) { c.pointSize = x }
+func (c *PDFCanvas) SetLineWidth(x float64) { c.lineWidth = x }
+
+// DrawPoint draws a disc made of four cubic bezier curves.
+func (c *PDFCanvas) DrawPoint(p Point) {
+	p = c.pt(p)
+	r := c.zoom * c.pointSize / 2
+	k := r * 4 * (math.Sqrt2 - 1) / 3
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%.2f %.2f m\n", p.X+r, p.Y)
+	fmt.Fprintf(buf, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", p.X+r, p.Y+k, p.X+k, p.Y+r, p.X, p.Y+r)
+	fmt.Fprintf(buf, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", p.X-k, p.Y+r, p.X-r, p.Y+k, p.X-r, p.Y)
+	fmt.Fprintf(buf, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", p.X-r, p.Y-k, p.X-k, p.Y-r, p.X, p.Y-r)
+	fmt.Fprintf(buf, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", p.X+k, p.Y-r, p.X+r, p.Y-k, p.X+r, p.Y)
+	c.element(c.color, false, buf.String()+"f")
+}
+
+func (c *PDFCanvas) DrawLine(p1, p2 Point) {
+	c.element(c.color, true, c.path([]Point{p1, p2}, false)+"S")
+}
+
+func (c *PDFCanvas) DrawRect(r Rectangle) {
+	c.DrawPolygon(r.Min, Pt(r.Max.X, r.Min.Y), r.Max, Pt(r.Min.X, r.Max.Y))
+}
+
+func (c *PDFCanvas) FillRect(r Rectangle) {
+	c.FillPolygon(r.Min, Pt(r.Max.X, r.Min.Y), r.Max, Pt(r.Min.X, r.Max.Y))
+}
+
+func (c *PDFCanvas) DrawPolygon(pts ...Point) {
+	c.element(c.color, true, c.path(pts, true)+"S")
+}
+
+func (c *PDFCanvas) FillPolygon(pts ...Point) {
+	c.element(c.color, false, c.path(pts, true)+"f")
+}
+
+func (c *PDFCanvas) DrawBezier(ctrlPts ...Point) {
+	if len(ctrlPts) == 0 {
+		return
+	}
+	c.element(c.color, true, c.bezierPath(ctrlPts)+"S")
+}
+
+// DrawGradientBezier flattens the curve and strokes each segment in the color at its middle.
+func (c *PDFCanvas) DrawGradientBezier(c1, c2 Color, ctrlPts ...Point) {
+	pts := bezierPoints(ctrlPts)
+	for i := 1; i < len(pts); i++ {
+		t := (float64(i) - .5) / float64(len(pts)-1)
+		col := Color{c1.R + t*(c2.R-c1.R), c1.G + t*(c2.G-c1.G), c1.B + t*(c2.B-c1.B), c1.A + t*(c2.A-c1.A)}
+		c.element(col, true, c.path(pts[i-1:i+1], false)+"S")
+	}
+}
+
+func (c *PDFCanvas) DrawText(f Font, text string, p Point) {
+	if text == "" {
+		return
+	}
+	size := defaultFontSize
+	if f, ok := f.(interface {
+		Size() float64
+	}); ok {
+		size = f.Size()
+	}
+	p = c.pt(p)
+	c.element(c.color, false, fmt.Sprintf("BT /F1 %.2f Tf %.2f %.2f Td %s Tj ET", size*c.zoom, p.X, p.Y, pdfString(text)))
+}
+
+func (c *PDFCanvas) Translate(p Point) { c.offset = c.offset.Add(p.Mul(c.zoom)) }
+func (c *PDFCanvas) Scale(s float64)   { c.zoom *= s }
+func (c *PDFCanvas) Zoom() float64     { return c.zoom }
+
+func (c *PDFCanvas) Clip(r Rectangle) {
+	r = r.Mul(c.zoom).Add(c.offset)
+	if c.clipped {
+		r = r.Intersect(c.clip)
+	}
+	c.clip = r
+	c.clipped = true
+}
+
+func (c *PDFCanvas) Push() { c.stack = append(c.stack, c.pdfState) }
+
+func (c *PDFCanvas) Pop() {
+	c.pdfState = c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// WriteTo writes the PDF document to w.
+func (c *PDFCanvas) WriteTo(w io.Writer) (int64, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := []int{}
+	object := func(format string, args ...interface{}) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(buf, format, args...)
+		buf.WriteString("\nendobj\n")
+	}
+
+	gstates := &bytes.Buffer{}
+	for i, a := range c.alphaSeq {
+		fmt.Fprintf(gstates, "/%s %d 0 R ", c.alphas[a], 6+i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> /ExtGState << %s>> >> >>", c.size.X, c.size.Y, gstates)
+	content := "1 J 1 j\n" + c.body.String()
+	object("<< /Length %d >>\nstream\n%sendstream", len(content), content)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Times-Roman /Encoding /WinAnsiEncoding >>")
+	for _, a := range c.alphaSeq {
+		object("<< /Type /ExtGState /CA %s /ca %s >>", a, a)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.WriteTo(w)
+}
+
+// pt maps p to PDF coordinates, which, like a Canvas's, have the origin at the bottom left.
+func (c *PDFCanvas) pt(p Point) Point {
+	return p.Mul(c.zoom).Add(c.offset)
+}
+
+func (c *PDFCanvas) path(pts []Point, closed bool) string {
+	buf := &bytes.Buffer{}
+	for i, p := range pts {
+		p = c.pt(p)
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(buf, "%.2f %.2f %s\n", p.X, p.Y, op)
+	}
+	if closed {
+		buf.WriteString("h\n")
+	}
+	return buf.String()
+}
+
+// bezierPath returns a path for the bezier curve with the given control points.  Quadratic curves are raised to cubics and curves of degree higher than three are flattened.
+func (c *PDFCanvas) bezierPath(ctrlPts []Point) string {
+	switch len(ctrlPts) {
+	case 3:
+		p0, p1, p2 := ctrlPts[0], ctrlPts[1], ctrlPts[2]
+		ctrlPts = []Point{p0, p0.Add(p1.Sub(p0).Mul(2. / 3)), p2.Add(p1.Sub(p2).Mul(2. / 3)), p2}
+	case 4:
+	default:
+		return c.path(bezierPoints(ctrlPts), false)
+	}
+	p0, p1, p2, p3 := c.pt(ctrlPts[0]), c.pt(ctrlPts[1]), c.pt(ctrlPts[2]), c.pt(ctrlPts[3])
+	return fmt.Sprintf("%.2f %.2f m\n%.2f %.2f %.2f %.2f %.2f %.2f c\n", p0.X, p0.Y, p1.X, p1.Y, p2.X, p2.Y, p3.X, p3.Y)
+}
+
+// element writes the path or text ops to the content stream, in color col and clipped to the current clip rectangle.  stroke says whether col is for stroking, which also sets the line width, or for filling.
+func (c *PDFCanvas) element(col Color, stroke bool, ops string) {
+	c.body.WriteString("q\n")
+	if c.clipped {
+		r := c.clip
+		fmt.Fprintf(&c.body, "%.2f %.2f %.2f %.2f re W n\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	}
+	if col.A < 1 {
+		fmt.Fprintf(&c.body, "/%s gs\n", c.alpha(col.A))
+	}
+	b := func(x float64) float64 { return math.Max(0, math.Min(1, x)) }
+	if stroke {
+		fmt.Fprintf(&c.body, "%.3f %.3f %.3f RG %.2f w\n", b(col.R), b(col.G), b(col.B), c.zoom*c.lineWidth)
+	} else {
+		fmt.Fprintf(&c.body, "%.3f %.3f %.3f rg\n", b(col.R), b(col.G), b(col.B))
+	}
+	c.body.WriteString(ops)
+	c.body.WriteString("\nQ\n")
+}
+
+// alpha returns the name of a graphics state with stroking and filling opacity a.
+func (c *PDFCanvas) alpha(a float64) string {
+	s := fmt.Sprintf("%.3f", math.Max(0, a))
+	if name, ok := c.alphas[s]; ok {
+		return name
+	}
+	name := fmt.Sprintf("A%d", len(c.alphaSeq))
+	c.alphas[s] = name
+	c.alphaSeq = append(c.alphaSeq, s)
+	return name
+}
+
+// pdfString returns text as a PDF literal string in WinAnsiEncoding.
+func pdfString(text string) string {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < ' ' || r >= 0x7f && r < 0xa0 || r > 0xff:
+			buf.WriteByte('?')
+		default:
+			buf.WriteByte(byte(r))
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
@@ -0,0 +1,207 @@
+package gui
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+)
+
+// SVGCanvas is a Canvas that records drawing as Scalable Vector Graphics, for writing with WriteTo.
+// Text is written as text elements in the Times New Roman font (or any serif font, where that is missing), so that it remains searchable.
+type SVGCanvas struct {
+	size       Point
+	defs, body bytes.Buffer
+	ids        int
+	svgState
+	stack []svgState
+}
+
+type svgState struct {
+	color                Color
+	lineWidth, pointSize float64
+	offset               Point
+	zoom                 float64
+	clip                 Rectangle // in canvas coordinates
+	clipID               string
+}
+
+// NewSVGCanvas returns a Canvas covering the rectangle from the origin to size.
+func NewSVGCanvas(size Point) *SVGCanvas {
+	return &SVGCanvas{size: size, svgState: svgState{color: Color{1, 1, 1, 1}, lineWidth: 1, pointSize: 1, zoom: 1}}
+}
+
+func (c *SVGCanvas) SetColor(col Color)     { c.color = col }
+func (c *SVGCanvas) SetPointSize(x float64) { c.pointSize = x }
+func (c *SVGCanvas) SetLineWidth(x float64) { c.lineWidth = x }
+
+func (c *SVGCanvas) DrawPoint(p Point) {
+	p = c.pt(p)
+	c.element(`<circle cx="%.2f" cy="%.2f" r="%.2f"%s/>`, p.X, p.Y, c.zoom*c.pointSize/2, c.fill())
+}
+
+func (c *SVGCanvas) DrawLine(p1, p2 Point) {
+	p1, p2 = c.pt(p1), c.pt(p2)
+	c.element(`<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f"%s/>`, p1.X, p1.Y, p2.X, p2.Y, c.stroke(c.color.hex(), c.color.A))
+}
+
+func (c *SVGCanvas) DrawRect(r Rectangle) {
+	c.DrawPolygon(r.Min, Pt(r.Max.X, r.Min.Y), r.Max, Pt(r.Min.X, r.Max.Y))
+}
+
+func (c *SVGCanvas) FillRect(r Rectangle) {
+	c.FillPolygon(r.Min, Pt(r.Max.X, r.Min.Y), r.Max, Pt(r.Min.X, r.Max.Y))
+}
+
+func (c *SVGCanvas) DrawPolygon(pts ...Point) {
+	c.element(`<polygon points="%s" fill="none"%s/>`, c.points(pts), c.stroke(c.color.hex(), c.color.A))
+}
+
+func (c *SVGCanvas) FillPolygon(pts ...Point) {
+	c.element(`<polygon points="%s"%s/>`, c.points(pts), c.fill())
+}
+
+func (c *SVGCanvas) DrawBezier(ctrlPts ...Point) {
+	c.element(`<path d="%s" fill="none"%s/>`, c.bezierPath(ctrlPts), c.stroke(c.color.hex(), c.color.A))
+}
+
+// DrawGradientBezier approximates the gradient along the curve with a linear gradient from its start to its end.
+func (c *SVGCanvas) DrawGradientBezier(c1, c2 Color, ctrlPts ...Point) {
+	if len(ctrlPts) == 0 {
+		return
+	}
+	p1, p2 := c.pt(ctrlPts[0]), c.pt(ctrlPts[len(ctrlPts)-1])
+	id := c.newID("g")
+	fmt.Fprintf(&c.defs, `<linearGradient id="%s" gradientUnits="userSpaceOnUse" x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f">`, id, p1.X, p1.Y, p2.X, p2.Y)
+	fmt.Fprintf(&c.defs, `<stop offset="0" stop-color="%s" stop-opacity="%.3g"/>`, c1.hex(), c1.A)
+	fmt.Fprintf(&c.defs, `<stop offset="1" stop-color="%s" stop-opacity="%.3g"/>`, c2.hex(), c2.A)
+	c.defs.WriteString("</linearGradient>\n")
+	c.element(`<path d="%s" fill="none"%s/>`, c.bezierPath(ctrlPts), c.stroke("url(#"+id+")", 1))
+}
+
+func (c *SVGCanvas) DrawText(f Font, text string, p Point) {
+	if text == "" {
+		return
+	}
+	size := defaultFontSize
+	if f, ok := f.(interface {
+		Size() float64
+	}); ok {
+		size = f.Size()
+	}
+	size *= c.zoom
+	p = c.pt(p)
+	buf := &bytes.Buffer{}
+	xml.EscapeText(buf, []byte(text))
+	c.element(`<text x="%.2f" y="%.2f" font-family="Times New Roman, serif" font-size="%g" xml:space="preserve"%s>%s</text>`, p.X, p.Y, size, c.fill(), buf)
+}
+
+func (c *SVGCanvas) Translate(p Point) { c.offset = c.offset.Add(p.Mul(c.zoom)) }
+func (c *SVGCanvas) Scale(s float64)   { c.zoom *= s }
+func (c *SVGCanvas) Zoom() float64     { return c.zoom }
+
+func (c *SVGCanvas) Clip(r Rectangle) {
+	r = r.Mul(c.zoom).Add(c.offset)
+	if c.clipID != "" {
+		r = r.Intersect(c.clip)
+	}
+	c.clip = r
+	c.clipID = c.newID("c")
+	fmt.Fprintf(&c.defs, `<clipPath id="%s"><rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"/></clipPath>`+"\n", c.clipID, r.Min.X, c.size.Y-r.Max.Y, r.Dx(), r.Dy())
+}
+
+func (c *SVGCanvas) Push() { c.stack = append(c.stack, c.svgState) }
+
+func (c *SVGCanvas) Pop() {
+	c.svgState = c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// WriteTo writes the SVG document to w.
+func (c *SVGCanvas) WriteTo(w io.Writer) (int64, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.2f %.2f">
+`, math.Ceil(c.size.X), math.Ceil(c.size.Y), c.size.X, c.size.Y)
+	if c.defs.Len() > 0 {
+		buf.WriteString("<defs>\n")
+		buf.Write(c.defs.Bytes())
+		buf.WriteString("</defs>\n")
+	}
+	buf.Write(c.body.Bytes())
+	buf.WriteString("</svg>\n")
+	return buf.WriteTo(w)
+}
+
+// pt maps p to SVG coordinates, which have the origin at the top left.
+func (c *SVGCanvas) pt(p Point) Point {
+	p = p.Mul(c.zoom).Add(c.offset)
+	return Pt(p.X, c.size.Y-p.Y)
+}
+
+func (c *SVGCanvas) points(pts []Point) string {
+	buf := &bytes.Buffer{}
+	for i, p := range pts {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		p = c.pt(p)
+		fmt.Fprintf(buf, "%.2f,%.2f", p.X, p.Y)
+	}
+	return buf.String()
+}
+
+// bezierPath returns path data for the bezier curve with the given control points.  Curves of degree higher than three are flattened.
+func (c *SVGCanvas) bezierPath(ctrlPts []Point) string {
+	if len(ctrlPts) == 0 {
+		return ""
+	}
+	cmd := "L"
+	switch len(ctrlPts) {
+	case 3:
+		cmd = "Q"
+	case 4:
+		cmd = "C"
+	default:
+		if len(ctrlPts) > 4 {
+			ctrlPts = bezierPoints(ctrlPts)
+		}
+	}
+	p := c.pt(ctrlPts[0])
+	s := fmt.Sprintf("M%.2f,%.2f %s", p.X, p.Y, cmd)
+	for _, p := range ctrlPts[1:] {
+		p = c.pt(p)
+		s += fmt.Sprintf(" %.2f,%.2f", p.X, p.Y)
+	}
+	return s
+}
+
+func (c *SVGCanvas) fill() string {
+	return fmt.Sprintf(` fill="%s" fill-opacity="%.3g"`, c.color.hex(), c.color.A)
+}
+
+func (c *SVGCanvas) stroke(paint string, opacity float64) string {
+	return fmt.Sprintf(` stroke="%s" stroke-opacity="%.3g" stroke-width="%.3g" stroke-linecap="round" stroke-linejoin="round"`, paint, opacity, c.zoom*c.lineWidth)
+}
+
+func (c *SVGCanvas) element(format string, args ...interface{}) {
+	if c.clipID != "" {
+		fmt.Fprintf(&c.body, `<g clip-path="url(#%s)">`, c.clipID)
+	}
+	fmt.Fprintf(&c.body, format, args...)
+	if c.clipID != "" {
+		c.body.WriteString("</g>")
+	}
+	c.body.WriteByte('\n')
+}
+
+func (c *SVGCanvas) newID(prefix string) string {
+	c.ids++
+	return fmt.Sprintf("%s%d", prefix, c.ids)
+}
+
+func (c Color) hex() string {
+	b := func(x float64) int { return int(255*math.Max(0, math.Min(1, x)) + .5) }
+	return fmt.Sprintf("#%02x%02x%02x", b(c.R), b(c.G), b(c.B))
+}
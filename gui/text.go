@@ -3,18 +3,21 @@ package gui
 import (
 	"github.com/gordonklaus/ftgl"
 	"github.com/gordonklaus/glfw"
-	gl "github.com/chsc/gogl/gl21"
 	"go/build"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// A Text is a single line of editable text.  When it has the key focus, it shows a caret that can be moved, and a selection that can be copied, cut, or replaced.
 type Text struct {
 	*ViewBase
 	text                string
-	font                ftgl.Font
+	font                Font
 	textColor           Color
 	frameSize           float64
 	frameColor          Color
@@ -23,62 +26,135 @@ type Text struct {
 	Accept, TextChanged func(string)
 	Reject              func()
 
+	caret, anchor int    // byte offsets of the caret and of the other end of the selection
+	composing     string // text being composed by an input method, shown at the caret
+
 	cursor     bool
 	stopCursor chan chan bool
 }
 
+var (
+	defaultTextColor       = Color{1, 1, 1, 1}
+	defaultBackgroundColor = Color{0, 0, 0, 1}
+	selectionColor         = Color{.3, .5, 1, .5}
+)
+
+// SetDefaultTextColors sets the text and background colors of Texts created from now on, and the color of the selection in all Texts.
+func SetDefaultTextColors(text, background, selection Color) {
+	defaultTextColor, defaultBackgroundColor, selectionColor = text, background, selection
+}
+
 func NewText(text string) *Text {
 	t := &Text{}
 	t.ViewBase = NewView(t)
 	t.font = getFont()
-	t.textColor = Color{1, 1, 1, 1}
-	t.backgroundColor = Color{0, 0, 0, 1}
+	t.textColor = defaultTextColor
+	t.backgroundColor = defaultBackgroundColor
 	t.stopCursor = make(chan chan bool)
 	t.SetText(text)
 	return t
 }
 
+const fontSize = 18
+
+var (
+	defaultFontPath string
+	defaultFontSize float64 = fontSize
+)
+
+// SetDefaultFont sets the font of Texts created from now on to the TrueType font at path (or, if path is empty, the Times New Roman that comes with this package) at size pixels per em.
+func SetDefaultFont(path string, size float64) {
+	defaultFontPath, defaultFontSize = path, size
+}
+
+func getFont() Font { return LoadFont(defaultFontPath, defaultFontSize) }
+
+// glFont is an FTGL font, which can only be drawn in the OpenGL context that loaded it.
+type glFont struct {
+	ftgl.Font
+	path string
+	size float64
+}
+
+func (f glFont) Size() float64 { return f.size }
+
+type glFontKey struct {
+	w    *glfw.Window
+	path string
+	size float64
+}
+
 var fontCache = struct {
 	sync.Mutex
-	m map[*glfw.Window]ftgl.Font
-}{m: map[*glfw.Window]ftgl.Font{}}
+	m map[glFontKey]glFont
+}{m: map[glFontKey]glFont{}}
 
-// Must be called from a thread holding an OpenGL context, i.e., a window callback thread.
-func getFont() ftgl.Font {
+// LoadFont returns the TrueType font at path (or, if path is empty, the Times New Roman that comes with this package) at size pixels per em, or nil if it can't be read.
+// Should be called from a thread holding an OpenGL context, i.e., a window callback thread.
+// Without a context (e.g., when running headless) it returns a TrueTypeFont for drawing on an ImageCanvas; Texts without a font have no size and are not drawn.
+func LoadFont(path string, size float64) Font {
+	path = fontPath(path)
 	w := glfw.GetCurrentContext()
 	if w == nil {
-		panic("no current context")
+		if f := loadTrueTypeFont(path, size); f != nil {
+			return f
+		}
+		return nil
 	}
 	fontCache.Lock()
 	defer fontCache.Unlock()
-	font := fontCache.m[w]
-	if font.Nil() {
-		font = ftgl.NewTextureFont(filepath.Join(pkgDir(), "Times New Roman.ttf"))
-		font.SetFaceSize(18, 1)
-		fontCache.m[w] = font
+	key := glFontKey{w, path, size}
+	font, ok := fontCache.m[key]
+	if !ok {
+		font = glFont{ftgl.NewTextureFont(path), path, size}
+		if font.Nil() {
+			return nil
+		}
+		font.SetFaceSize(int(size), 1)
+		fontCache.m[key] = font
 	}
 	return font
 }
 
-func pkgDir() string {
+// fontPath returns path, or the path of the font that comes with this package if path is empty.
+func fontPath(path string) string {
+	if path != "" {
+		return path
+	}
+	dir, _ := pkgDir()
+	return filepath.Join(dir, "Times New Roman.ttf")
+}
+
+func pkgDir() (string, bool) {
 	for _, dir := range build.Default.SrcDirs() {
 		dir := filepath.Join(dir, "github.com/gordonklaus/flux/gui")
 		if _, err := os.Stat(dir); err == nil {
-			return dir
+			return dir, true
 		}
 	}
-	panic("unreachable")
+	return "", false
 }
 
 func (t Text) Text() string { return t.text }
+
+// SetText sets the text, with the caret at its end.
 func (t *Text) SetText(text string) {
 	t.text = text
-	Resize(t, Pt(2*t.frameSize+t.font.Advance(t.text), 2*t.frameSize-t.font.Descender()+t.font.Ascender()))
+	t.caret, t.anchor = len(text), len(text)
+	t.composing = ""
+	t.resize()
 	if t.TextChanged != nil {
 		t.TextChanged(text)
 	}
 }
 
+func (t *Text) Font() Font { return t.font }
+
+func (t *Text) SetFont(f Font) {
+	t.font = f
+	t.resize()
+}
+
 func (t *Text) SetTextColor(c Color) {
 	t.textColor = c
 	Repaint(t)
@@ -96,7 +172,60 @@ func (t *Text) SetFrameColor(c Color) {
 
 func (t *Text) SetFrameSize(size float64) {
 	t.frameSize = size
-	Resize(t, Pt(2*t.frameSize+t.font.Advance(t.text), 2*t.frameSize-t.font.Descender()+t.font.Ascender()))
+	t.resize()
+}
+
+func (t *Text) resize() {
+	if t.font == nil {
+		Resize(t, Pt(2*t.frameSize, 2*t.frameSize))
+		return
+	}
+	Resize(t, Pt(2*t.frameSize+t.font.Advance(t.text+t.composing), 2*t.frameSize-t.font.Descender()+t.font.Ascender()))
+}
+
+// Selection returns the byte offsets of the start and end of the selected text, which are equal (to the offset of the caret) if nothing is selected.
+func (t *Text) Selection() (int, int) {
+	if t.caret < t.anchor {
+		return t.caret, t.anchor
+	}
+	return t.anchor, t.caret
+}
+
+// Select selects the text between byte offsets i and j, with the caret at j.
+func (t *Text) Select(i, j int) {
+	t.anchor, t.caret = i, j
+	t.cursor = KeyFocus(t) == t
+	Repaint(t)
+}
+
+// move moves the caret to i, extending the selection if extend is set and otherwise clearing it.
+func (t *Text) move(i int, extend bool) {
+	if extend {
+		t.Select(t.anchor, i)
+	} else {
+		t.Select(i, i)
+	}
+}
+
+// replace replaces the text between byte offsets i and j with s, if Validate allows it, leaving the caret after s.  If Validate changes the length of the text, the caret goes to the end.
+func (t *Text) replace(i, j int, s string) {
+	text := t.text[:i] + s + t.text[j:]
+	n := len(text)
+	if t.Validate != nil && !t.Validate(&text) {
+		return
+	}
+	caret := i + len(s)
+	if len(text) != n {
+		caret = len(text)
+	}
+	t.SetText(text)
+	t.Select(caret, caret)
+}
+
+// Compose shows text, which an input method is composing, at the caret.  Composition ends with an empty text, after which the composed text arrives as ordinary key presses.
+func (t *Text) Compose(text string) {
+	t.composing = text
+	t.resize()
 }
 
 func (t *Text) TookKeyFocus() {
@@ -130,24 +259,88 @@ func (t *Text) LostKeyFocus() {
 	t.stopCursor <- ch
 	<-ch
 	t.cursor = false
+	t.anchor = t.caret
+	if t.composing != "" {
+		t.Compose("")
+	}
 	Repaint(t)
 }
 
+// KeyPress edits the text.  Left and Right move the caret by a character or, with Alt or Ctrl, by a word; Home and End (or Super-Left and Super-Right) move it to the start or end; Shift extends the selection as the caret moves.
+// Backspace and Delete remove the selection or the character (or, with Alt or Ctrl, the word) before or after the caret.  Command-A selects all, and Command-C, -X, and -V copy, cut, and paste using the system clipboard.
 func (t *Text) KeyPress(event KeyEvent) {
-	if len(event.Text) > 0 {
-		text := t.text + event.Text
-		if t.Validate == nil || t.Validate(&text) {
-			t.SetText(text)
-		}
+	i, j := t.Selection()
+	if len(event.Text) > 0 && !event.Command {
+		t.replace(i, j, event.Text)
+		return
 	}
+	word := event.Alt || event.Ctrl
 	switch event.Key {
+	case KeyLeft:
+		switch {
+		case event.Super:
+			t.move(0, event.Shift)
+		case word:
+			t.move(prevWord(t.text, t.caret), event.Shift)
+		case i < j && !event.Shift:
+			t.move(i, false)
+		default:
+			t.move(prevRune(t.text, t.caret), event.Shift)
+		}
+	case KeyRight:
+		switch {
+		case event.Super:
+			t.move(len(t.text), event.Shift)
+		case word:
+			t.move(nextWord(t.text, t.caret), event.Shift)
+		case i < j && !event.Shift:
+			t.move(j, false)
+		default:
+			t.move(nextRune(t.text, t.caret), event.Shift)
+		}
+	case KeyHome:
+		t.move(0, event.Shift)
+	case KeyEnd:
+		t.move(len(t.text), event.Shift)
 	case KeyBackspace:
-		if len(t.text) > 0 {
-			text := t.text[:len(t.text)-1]
-			if t.Validate == nil || t.Validate(&text) {
-				t.SetText(text)
+		if i == j {
+			if word {
+				i = prevWord(t.text, i)
+			} else {
+				i = prevRune(t.text, i)
+			}
+		}
+		if i < j {
+			t.replace(i, j, "")
+		}
+	case KeyDelete:
+		if i == j {
+			if word {
+				j = nextWord(t.text, j)
+			} else {
+				j = nextRune(t.text, j)
+			}
+		}
+		if i < j {
+			t.replace(i, j, "")
+		}
+	case KeyA:
+		if event.Command {
+			t.Select(0, len(t.text))
+		}
+	case KeyC, KeyX:
+		if event.Command && i < j {
+			if w := t.win(); w != nil {
+				w.SetClipboard(t.text[i:j])
+			}
+			if event.Key == KeyX {
+				t.replace(i, j, "")
 			}
 		}
+	case KeyV:
+		if w := t.win(); event.Command && w != nil {
+			t.replace(i, j, singleLine(w.Clipboard()))
+		}
 	case KeyEnter:
 		if t.Accept != nil {
 			t.Accept(t.text)
@@ -159,23 +352,128 @@ func (t *Text) KeyPress(event KeyEvent) {
 	}
 }
 
-func (t *Text) Paint() {
-	SetColor(t.backgroundColor)
-	FillRect(Rect(t).Inset(t.frameSize))
+// Mouse moves the caret to a click and selects the text dragged over, when t has the key focus.  Otherwise it passes presses and drags to the parent, so that, e.g., a node can be dragged by its label.
+func (t *Text) Mouse(m MouseEvent) {
+	if KeyFocus(t) != t {
+		if !m.Enter && !m.Leave {
+			MouseParent(t, m)
+		}
+		return
+	}
+	if m.Button == MouseButtonLeft && (m.Press || m.Drag) {
+		t.move(t.indexAt(m.Pos.X), m.Drag)
+	}
+}
+
+// indexAt returns the byte offset of the character boundary nearest to x.
+func (t *Text) indexAt(x float64) int {
+	if t.font == nil {
+		return 0
+	}
+	x -= t.frameSize
+	best, dist := 0, x
+	if dist < 0 {
+		dist = -dist
+	}
+	for i := range t.text + " " {
+		d := t.font.Advance(t.text[:i]) - x
+		if d < 0 {
+			d = -d
+		}
+		if d < dist {
+			best, dist = i, d
+		}
+	}
+	return best
+}
+
+func prevRune(s string, i int) int {
+	_, n := utf8.DecodeLastRuneInString(s[:i])
+	return i - n
+}
+
+func nextRune(s string, i int) int {
+	_, n := utf8.DecodeRuneInString(s[i:])
+	return i + n
+}
+
+// prevWord returns the byte offset of the start of the word before i.
+func prevWord(s string, i int) int {
+	for i > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		if isWordRune(r) {
+			break
+		}
+		i = prevRune(s, i)
+	}
+	for i > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		if !isWordRune(r) {
+			break
+		}
+		i = prevRune(s, i)
+	}
+	return i
+}
+
+// nextWord returns the byte offset of the end of the word after i.
+func nextWord(s string, i int) int {
+	for i < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		if isWordRune(r) {
+			break
+		}
+		i = nextRune(s, i)
+	}
+	for i < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		if !isWordRune(r) {
+			break
+		}
+		i = nextRune(s, i)
+	}
+	return i
+}
+
+func isWordRune(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+// singleLine returns s with its line breaks replaced by spaces.
+func singleLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+func (t *Text) Paint(cv Canvas) {
+	if t.font == nil {
+		return
+	}
+	cv.SetColor(t.backgroundColor)
+	cv.FillRect(Rect(t).Inset(t.frameSize))
 	if t.frameSize > 0 {
-		SetColor(t.frameColor)
-		SetLineWidth(t.frameSize)
-		DrawRect(Rect(t))
+		cv.SetColor(t.frameColor)
+		cv.SetLineWidth(t.frameSize)
+		cv.DrawRect(Rect(t))
+	}
+
+	x := func(i int) float64 { return t.frameSize + t.font.Advance(t.text[:i]) }
+	if i, j := t.Selection(); i < j && KeyFocus(t) == t {
+		cv.SetColor(selectionColor)
+		cv.FillRect(Rectangle{Pt(x(i), t.frameSize), Pt(x(j), Height(t)-t.frameSize)})
+	}
+
+	baseline := t.frameSize - t.font.Descender()
+	caret := x(t.caret) + t.font.Advance(t.composing)
+	if t.composing != "" {
+		cv.SetColor(t.textColor)
+		cv.SetLineWidth(1)
+		cv.DrawLine(Pt(x(t.caret), baseline-2), Pt(caret, baseline-2))
 	}
 
 	if t.cursor {
-		SetColor(t.textColor)
-		SetLineWidth(2)
-		x := t.frameSize + t.font.Advance(t.text)
-		DrawLine(Pt(x, t.frameSize), Pt(x, Height(t)-2*t.frameSize))
+		cv.SetColor(t.textColor)
+		cv.SetLineWidth(2)
+		cv.DrawLine(Pt(caret, t.frameSize), Pt(caret, Height(t)-2*t.frameSize))
 	}
 
-	SetColor(t.textColor)
-	gl.Translated(gl.Double(t.frameSize), gl.Double(t.frameSize-t.font.Descender()), 0)
-	t.font.Render(t.text)
+	cv.SetColor(t.textColor)
+	cv.DrawText(t.font, t.text[:t.caret]+t.composing+t.text[t.caret:], Pt(t.frameSize, baseline))
 }
@@ -0,0 +1,362 @@
+package gui
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"sync"
+)
+
+// TrueTypeFont is a Font read from a TrueType file, whose glyph outlines an ImageCanvas can draw without any native font library.
+// Only what Flux needs is supported:  a Unicode cmap (format 4), simple and compound glyphs, and horizontal metrics.  There is no hinting or kerning.
+type TrueTypeFont struct {
+	size                   float64
+	scale                  float64 // pixels per font unit
+	ascender, descender    float64
+	cmap, loca, glyf, hmtx []byte
+	longLoca               bool
+	numGlyphs, numHMetrics int
+}
+
+// ReadTrueTypeFont reads the TrueType font at path for drawing at size pixels per em.
+func ReadTrueTypeFont(path string, size float64) (*TrueTypeFont, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrueTypeFont(data, size)
+}
+
+var errBadFont = errors.New("malformed or unsupported TrueType font")
+
+// ParseTrueTypeFont parses a TrueType font for drawing at size pixels per em.
+func ParseTrueTypeFont(data []byte, size float64) (f *TrueTypeFont, err error) {
+	defer func() {
+		if recover() != nil { // an out of range index into a malformed font
+			f, err = nil, errBadFont
+		}
+	}()
+	f = &TrueTypeFont{size: size}
+	tables := map[string][]byte{}
+	n := int(u16(data, 4))
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		off, length := u32(rec, 8), u32(rec, 12)
+		tables[string(rec[:4])] = data[off : off+length]
+	}
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	f.cmap, f.loca, f.glyf, f.hmtx = tables["cmap"], tables["loca"], tables["glyf"], tables["hmtx"]
+	if head == nil || hhea == nil || maxp == nil || f.cmap == nil || f.loca == nil || f.glyf == nil || f.hmtx == nil {
+		return nil, errBadFont
+	}
+	f.scale = size / float64(u16(head, 18))
+	f.longLoca = u16(head, 50) != 0
+	f.ascender = float64(int16(u16(hhea, 4))) * f.scale
+	f.descender = float64(int16(u16(hhea, 6))) * f.scale
+	f.numHMetrics = int(u16(hhea, 34))
+	f.numGlyphs = int(u16(maxp, 4))
+	if f.cmap = findCmap(f.cmap); f.cmap == nil {
+		return nil, errBadFont
+	}
+	return f, nil
+}
+
+// findCmap returns the format 4 Unicode subtable of cmap, or nil.
+func findCmap(cmap []byte) []byte {
+	n := int(u16(cmap, 2))
+	for i := 0; i < n; i++ {
+		rec := cmap[4+8*i:]
+		platform, encoding := u16(rec, 0), u16(rec, 2)
+		if platform == 0 || platform == 3 && encoding == 1 {
+			if sub := cmap[u32(rec, 4):]; u16(sub, 0) == 4 {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+func u16(b []byte, i int) uint16 { return binary.BigEndian.Uint16(b[i:]) }
+func u32(b []byte, i int) uint32 { return binary.BigEndian.Uint32(b[i:]) }
+
+func (f *TrueTypeFont) Size() float64      { return f.size }
+func (f *TrueTypeFont) Ascender() float64  { return f.ascender }
+func (f *TrueTypeFont) Descender() float64 { return f.descender }
+
+func (f *TrueTypeFont) Advance(text string) float64 {
+	x := 0.0
+	for _, r := range text {
+		x += f.advance(f.index(r))
+	}
+	return x
+}
+
+func (f *TrueTypeFont) index(r rune) int {
+	if r > 0xffff {
+		return 0
+	}
+	c := uint16(r)
+	segs := int(u16(f.cmap, 6)) / 2
+	ends := f.cmap[14:]
+	starts := ends[2*segs+2:]
+	deltas := starts[2*segs:]
+	offsets := deltas[2*segs:]
+	for i := 0; i < segs; i++ {
+		if c > u16(ends, 2*i) {
+			continue
+		}
+		start := u16(starts, 2*i)
+		if c < start {
+			return 0
+		}
+		delta := u16(deltas, 2*i)
+		if off := int(u16(offsets, 2*i)); off != 0 {
+			g := u16(offsets, 2*i+off+2*int(c-start))
+			if g == 0 {
+				return 0
+			}
+			return int(g + delta)
+		}
+		return int(c + delta)
+	}
+	return 0
+}
+
+func (f *TrueTypeFont) advance(g int) float64 {
+	i := g
+	if i >= f.numHMetrics {
+		i = f.numHMetrics - 1
+	}
+	return float64(u16(f.hmtx, 4*i)) * f.scale
+}
+
+func (f *TrueTypeFont) glyphData(g int) []byte {
+	if g >= f.numGlyphs {
+		return nil
+	}
+	var start, end uint32
+	if f.longLoca {
+		start, end = u32(f.loca, 4*g), u32(f.loca, 4*g+4)
+	} else {
+		start, end = 2*uint32(u16(f.loca, 2*g)), 2*uint32(u16(f.loca, 2*g+2))
+	}
+	if start >= end {
+		return nil
+	}
+	return f.glyf[start:end]
+}
+
+// outlines returns the contours of text drawn with its baseline starting at p, with curves flattened.
+func (f *TrueTypeFont) outlines(text string, p Point) (paths [][]Point) {
+	defer func() {
+		if recover() != nil { // a malformed glyph; draw what was read
+		}
+	}()
+	for _, r := range text {
+		g := f.index(r)
+		for _, c := range f.contours(g, 0) {
+			path := []Point{}
+			for _, q := range c {
+				path = append(path, p.Add(q.Mul(f.scale)))
+			}
+			paths = append(paths, path)
+		}
+		p.X += f.advance(g)
+	}
+	return
+}
+
+// contours returns the flattened contours of glyph g, in font units.
+func (f *TrueTypeFont) contours(g, depth int) (contours [][]Point) {
+	b := f.glyphData(g)
+	if len(b) < 10 || depth > 8 {
+		return nil
+	}
+	n := int(int16(u16(b, 0)))
+	if n < 0 {
+		return f.compoundContours(b[10:], depth)
+	}
+	ends := make([]int, n)
+	for i := range ends {
+		ends[i] = int(u16(b, 10+2*i))
+	}
+	if n == 0 {
+		return nil
+	}
+	numPts := ends[n-1] + 1
+	i := 10 + 2*n
+	i += 2 + int(u16(b, i)) // instructions
+	flags := make([]byte, 0, numPts)
+	for len(flags) < numPts {
+		fl := b[i]
+		i++
+		flags = append(flags, fl)
+		if fl&8 != 0 {
+			for r := b[i]; r > 0; r-- {
+				flags = append(flags, fl)
+			}
+			i++
+		}
+	}
+	coords := func(short, same byte) []float64 {
+		v, x := make([]float64, numPts), 0
+		for k, fl := range flags[:numPts] {
+			switch {
+			case fl&short != 0:
+				d := int(b[i])
+				i++
+				if fl&same == 0 {
+					d = -d
+				}
+				x += d
+			case fl&same == 0:
+				x += int(int16(u16(b, i)))
+				i += 2
+			}
+			v[k] = float64(x)
+		}
+		return v
+	}
+	xs := coords(2, 16)
+	ys := coords(4, 32)
+	start := 0
+	for _, end := range ends {
+		pts := []Point{}
+		on := []bool{}
+		for k := start; k <= end; k++ {
+			pts = append(pts, Pt(xs[k], ys[k]))
+			on = append(on, flags[k]&1 != 0)
+		}
+		start = end + 1
+		contours = append(contours, flattenQuadratic(pts, on))
+	}
+	return
+}
+
+func (f *TrueTypeFont) compoundContours(b []byte, depth int) (contours [][]Point) {
+	for {
+		flags, g := u16(b, 0), int(u16(b, 2))
+		b = b[4:]
+		var dx, dy float64
+		if flags&1 != 0 {
+			dx, dy = float64(int16(u16(b, 0))), float64(int16(u16(b, 2)))
+			b = b[4:]
+		} else {
+			dx, dy = float64(int8(b[0])), float64(int8(b[1]))
+			b = b[2:]
+		}
+		if flags&2 == 0 { // point matching isn't supported
+			dx, dy = 0, 0
+		}
+		sx, sy, sxy, syx := 1.0, 1.0, 0.0, 0.0
+		f2dot14 := func(i int) float64 { return float64(int16(u16(b, i))) / (1 << 14) }
+		switch {
+		case flags&8 != 0:
+			sx = f2dot14(0)
+			sy = sx
+			b = b[2:]
+		case flags&0x40 != 0:
+			sx, sy = f2dot14(0), f2dot14(2)
+			b = b[4:]
+		case flags&0x80 != 0:
+			sx, syx, sxy, sy = f2dot14(0), f2dot14(2), f2dot14(4), f2dot14(6)
+			b = b[8:]
+		}
+		for _, c := range f.contours(g, depth+1) {
+			for i, p := range c {
+				c[i] = Pt(p.X*sx+p.Y*sxy+dx, p.X*syx+p.Y*sy+dy)
+			}
+			contours = append(contours, c)
+		}
+		if flags&0x20 == 0 {
+			return
+		}
+	}
+}
+
+// flattenQuadratic converts a closed TrueType contour, whose off-curve points are quadratic bezier control points, to a polygon.
+func flattenQuadratic(pts []Point, on []bool) (poly []Point) {
+	n := len(pts)
+	// start at an on-curve point, or at the implied one between the first two off-curve points
+	first := -1
+	for i := range pts {
+		if on[i] {
+			first = i
+			break
+		}
+	}
+	var start Point
+	if first < 0 {
+		start, first = pts[0].Add(pts[1]).Div(2), 0
+	} else {
+		start = pts[first]
+		first++
+	}
+	poly = append(poly, start)
+	prev := start
+	var ctrl *Point
+	for k := 0; k < n; k++ {
+		i := (first + k) % n
+		p := pts[i]
+		if on[i] {
+			if ctrl != nil {
+				poly = append(poly, quadratic(prev, *ctrl, p)...)
+				ctrl = nil
+			} else {
+				poly = append(poly, p)
+			}
+			prev = p
+			continue
+		}
+		if ctrl != nil {
+			mid := ctrl.Add(p).Div(2)
+			poly = append(poly, quadratic(prev, *ctrl, mid)...)
+			prev = mid
+		}
+		p2 := p
+		ctrl = &p2
+	}
+	if ctrl != nil {
+		poly = append(poly, quadratic(prev, *ctrl, start)...)
+	}
+	return
+}
+
+// quadratic returns points along the quadratic bezier curve from p0 to p2 with control point p1, excluding p0.
+func quadratic(p0, p1, p2 Point) []Point {
+	const n = 8
+	pts := make([]Point, n)
+	for i := range pts {
+		t := float64(i+1) / n
+		pts[i] = p0.Mul((1 - t) * (1 - t)).Add(p1.Mul(2 * t * (1 - t))).Add(p2.Mul(t * t))
+	}
+	return pts
+}
+
+type trueTypeFontKey struct {
+	path string
+	size float64
+}
+
+var trueTypeFonts = struct {
+	sync.Mutex
+	m map[trueTypeFontKey]*TrueTypeFont
+}{m: map[trueTypeFontKey]*TrueTypeFont{}}
+
+// loadTrueTypeFont returns the font at path at size pixels per em, reading it only the first time, or nil if it can't be read.
+func loadTrueTypeFont(path string, size float64) *TrueTypeFont {
+	trueTypeFonts.Lock()
+	defer trueTypeFonts.Unlock()
+	key := trueTypeFontKey{path, size}
+	f, ok := trueTypeFonts.m[key]
+	if !ok {
+		f, _ = ReadTrueTypeFont(path, size)
+		trueTypeFonts.m[key] = f
+	}
+	return f
+}
+
+// defaultFont returns the font used by Texts when there is no OpenGL context, or nil if it can't be read.
+func defaultFont() *TrueTypeFont {
+	return loadTrueTypeFont(fontPath(defaultFontPath), defaultFontSize)
+}
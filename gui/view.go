@@ -2,7 +2,6 @@ package gui
 
 import (
 	. "github.com/gordonklaus/util"
-	gl "github.com/chsc/gogl/gl21"
 )
 
 type View interface {
@@ -22,7 +21,7 @@ type View interface {
 	KeyPress(KeyEvent)
 	KeyRelease(KeyEvent)
 
-	Paint()
+	Paint(Canvas)
 }
 
 type KeyEvent struct {
@@ -48,8 +47,15 @@ type ScrollerView interface {
 	View
 }
 
+// A Composer shows text that an input method is composing.  The composed text is delivered as ordinary key presses when composition ends.
+// GLFW does not report composition, so on screen a Composer receives only the composed text; headless windows deliver it with SendComposition.
+type Composer interface {
+	Compose(text string)
+}
+
 type ScrollEvent struct {
 	Pos, Delta Point
+	Command    bool // whether the command key is held
 }
 
 type ViewBase struct {
@@ -59,10 +65,12 @@ type ViewBase struct {
 	hidden   bool
 	rect     Rectangle
 	pos      Point
+	scale    float64
+	minZoom  float64
 }
 
 func NewView(self View) *ViewBase {
-	v := &ViewBase{}
+	v := &ViewBase{scale: 1}
 	if self == nil {
 		self = v
 	}
@@ -100,8 +108,9 @@ func (v *ViewBase) Close() {
 	}
 }
 
-func Show(v View) { v.base().hidden = false; Repaint(v) }
-func Hide(v View) { v.base().hidden = true; Repaint(v) }
+func Show(v View)        { v.base().hidden = false; Repaint(v) }
+func Hide(v View)        { v.base().hidden = true; Repaint(v) }
+func Hidden(v View) bool { return v.base().hidden }
 
 func Raise(v View) {
 	if Parent(v) != nil {
@@ -133,6 +142,23 @@ func (v *ViewBase) Move(p Point) { v.pos = p; Repaint(v.Self) }
 func MoveCenter(v View, p Point) { v.Move(p.Sub(Size(v).Div(2))) }
 func MoveOrigin(v View, p Point) { v.Move(p.Add(Rect(v).Min)) }
 
+// Scale returns the factor by which v is scaled relative to its parent.
+func Scale(v View) float64 { return v.base().scale }
+
+// SetScale scales v by s relative to its parent, about the bottom left corner of its rect, which stays at Pos(v).
+func SetScale(v View, s float64) { v.base().scale = s; Repaint(v) }
+
+// Zoom returns the factor by which v is scaled relative to its window.
+func Zoom(v View) (z float64) {
+	for z = 1; v != nil; v = Parent(v) {
+		z *= Scale(v)
+	}
+	return
+}
+
+// SetMinZoom sets the zoom below which v is left out of painting, so that detail is dropped from views zoomed too small to read.
+func SetMinZoom(v View, z float64) { v.base().minZoom = z; Repaint(v) }
+
 func Rect(v View) Rectangle { return v.base().rect }
 func RectInParent(v View) Rectangle {
 	r := Rect(v)
@@ -216,20 +242,23 @@ func Repaint(v View) {
 	}
 }
 
-func (v *ViewBase) paint() {
+func (v *ViewBase) paint(cv Canvas) {
 	if v.hidden {
 		return
 	}
-	gl.PushMatrix()
-	defer gl.PopMatrix()
-	d := MapToParent(ZP, v)
-	gl.Translated(gl.Double(d.X), gl.Double(d.Y), 0)
-	v.Self.Paint()
+	cv.Push()
+	defer cv.Pop()
+	cv.Translate(MapToParent(ZP, v))
+	cv.Scale(v.scale)
+	if cv.Zoom() < v.minZoom {
+		return
+	}
+	v.Self.Paint(cv)
 	for _, child := range v.children {
-		child.base().paint()
+		child.base().paint(cv)
 	}
 }
-func (v ViewBase) Paint() {}
+func (v ViewBase) Paint(Canvas) {}
 
 func Do(v View, f func()) {
 	w := v.win()
@@ -239,6 +268,9 @@ func Do(v View, f func()) {
 	w.Do(f)
 }
 
+// InWindow reports whether v is in a window, as it must be for Do and DoChan.
+func InWindow(v View) bool { return v.win() != nil }
+
 func DoChan(v View) chan<- func() {
 	w := v.win()
 	if w == nil {
@@ -263,11 +295,11 @@ func viewAtFunc(v View, p Point, f func(View) View) View {
 }
 
 func MapToParent(p Point, v View) Point {
-	return p.Sub(Rect(v).Min).Add(Pos(v))
+	return p.Sub(Rect(v).Min).Mul(Scale(v)).Add(Pos(v))
 }
 
 func MapFromParent(p Point, v View) Point {
-	return p.Sub(Pos(v)).Add(Rect(v).Min)
+	return p.Sub(Pos(v)).Div(Scale(v)).Add(Rect(v).Min)
 }
 
 func Map(p Point, from, to View) Point {
@@ -281,9 +313,12 @@ func Map(p Point, from, to View) Point {
 		p = MapToParent(p, from)
 		from = Parent(from)
 	}
-	for to != v {
-		p = MapFromParent(p, to)
-		to = Parent(to)
+	path := []View{}
+	for ; to != v; to = Parent(to) {
+		path = append(path, to)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		p = MapFromParent(p, path[i])
 	}
 	return p
 }
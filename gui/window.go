@@ -14,6 +14,12 @@ type Window struct {
 	mouseIn     MouserView
 	mouser      map[int]MouserView
 	close       bool
+	canvas      *glCanvas
+	fbSize      Point // the framebuffer size, which differs from the window size on high resolution displays
+	clipboard   string
+	keyMap      func(focus View, k KeyEvent) (KeyEvent, bool)
+	focusHook   func(View)
+	background  Color
 	paint       chan bool
 	do          chan func()
 }
@@ -49,6 +55,8 @@ func (w *Window) run() {
 	Resize(w, Pt(float64(width), float64(height)))
 	width, height = w.w.FramebufferSize()
 	gl.Viewport(0, 0, gl.Sizei(width), gl.Sizei(height))
+	w.fbSize = Pt(float64(width), float64(height))
+	w.canvas = &glCanvas{glState: glState{zoom: 1}}
 
 	gl.Enable(gl.BLEND)
 	gl.Enable(gl.POINT_SMOOTH)
@@ -63,8 +71,11 @@ func (w *Window) run() {
 			gl.MatrixMode(gl.MODELVIEW)
 			gl.LoadIdentity()
 
+			c := w.background
+			gl.ClearColor(gl.Clampf(c.R), gl.Clampf(c.G), gl.Clampf(c.B), gl.Clampf(c.A))
 			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-			w.base().paint()
+			w.canvas.scale = w.fbSize.X / Width(w)
+			w.base().paint(w.canvas)
 			w.w.SwapBuffers()
 		}
 	}
@@ -99,12 +110,22 @@ func (w *Window) registerCallbacks() {
 	w.w.OnFramebufferResize(func(width, height int) {
 		w.Do(func() {
 			gl.Viewport(0, 0, gl.Sizei(width), gl.Sizei(height))
+			w.fbSize = Pt(float64(width), float64(height))
 		})
 	})
 
 	k := KeyEvent{}
 	w.w.OnKey(func(key, scancode, action, mods int) {
+		// The clipboard can only be read on the main thread, so read it here in case this key pastes.
+		clip, err := "", error(nil)
+		cmd := action != glfw.Release && mods&(glfw.ModControl|glfw.ModSuper) != 0
+		if cmd {
+			clip, err = w.w.GetClipboardString()
+		}
 		w.Do(func() {
+			if cmd && err == nil {
+				w.clipboard = clip
+			}
 			k.Key = key
 			k.action = action
 			k.Repeat = action == glfw.Repeat
@@ -115,13 +136,7 @@ func (w *Window) registerCallbacks() {
 			k.Command = commandKey(k)
 			if key >= KeyEscape || action == glfw.Release {
 				k.Text = ""
-				if w.keyFocus != nil {
-					if k.action != glfw.Release {
-						w.keyFocus.KeyPress(k)
-					} else {
-						w.keyFocus.KeyRelease(k)
-					}
-				}
+				w.key(k)
 			}
 		})
 	})
@@ -129,13 +144,7 @@ func (w *Window) registerCallbacks() {
 		w.Do(func() {
 			if char < KeyEscape {
 				k.Text = string(char)
-				if w.keyFocus != nil {
-					if k.action != glfw.Release {
-						w.keyFocus.KeyPress(k)
-					} else {
-						w.keyFocus.KeyRelease(k)
-					}
-				}
+				w.key(k)
 			}
 		})
 	})
@@ -144,86 +153,120 @@ func (w *Window) registerCallbacks() {
 	w.w.OnMouseMove(func(x, y float64) {
 		m.Pos = Pt(x, y)
 		m.Move, m.Press, m.Release, m.Drag = true, false, false, false
-		w.mouse(m)
+		m := m
+		w.Do(func() {
+			m.Pos = w.mapToWindow(m.Pos)
+			w.mouse(m)
+		})
 	})
 	w.w.OnMouseButton(func(button, action, mods int) {
 		m.Button = button
 		m.Move, m.Press, m.Release, m.Drag = false, action == glfw.Press, action == glfw.Release, false
-		w.mouse(m)
+		m := m
+		w.Do(func() {
+			m.Pos = w.mapToWindow(m.Pos)
+			w.mouse(m)
+		})
 	})
 	w.w.OnScroll(func(dx, dy float64) {
+		s := ScrollEvent{Pos: m.Pos, Delta: Pt(dx, -dy)}
 		w.Do(func() {
-			s := ScrollEvent{m.Pos, Pt(dx, -dy)}
 			s.Pos = w.mapToWindow(s.Pos)
-			v, _ := viewAtFunc(w.Self, s.Pos, func(v View) View {
-				v, _ = v.(ScrollerView)
-				return v
-			}).(ScrollerView)
-			if v != nil {
-				s.Pos = Map(s.Pos, w.Self, v)
-				v.Scroll(s)
-			}
+			s.Command = k.Command
+			w.scroll(s)
 		})
 	})
 }
 
-func (w *Window) mouse(m MouseEvent) {
-	w.Do(func() {
-		switch {
-		case m.Press:
-			m.Pos = w.mapToWindow(m.Pos)
-			v, _ := viewAtFunc(w.Self, m.Pos, func(v View) View {
-				v, _ = v.(MouserView)
-				return v
-			}).(MouserView)
-			if v != nil {
-				w.mouser[m.Button] = v
-				m.Pos = Map(m.Pos, w.Self, v)
-				v.Mouse(m)
-			}
-		case m.Move:
-			m.Pos = w.mapToWindow(m.Pos)
-			m.Move = false
-			v, _ := viewAtFunc(w.Self, m.Pos, func(v View) View {
-				v, _ = v.(MouserView)
-				return v
-			}).(MouserView)
-			if w.mouseIn != v {
-				p := commonParent(w.mouseIn, v)
-				for v := View(w.mouseIn); v != p && v != nil; v = Parent(v) {
-					if v, ok := v.(MouserView); ok {
-						m := m
-						m.Pos = Map(m.Pos, w.Self, v)
-						m.Leave = true
-						v.Mouse(m)
-					}
-				}
-				for v := View(v); v != p && v != nil; v = Parent(v) {
-					if v, ok := v.(MouserView); ok {
-						m := m
-						m.Pos = Map(m.Pos, w.Self, v)
-						m.Enter = true
-						v.Mouse(m)
-					}
+// key delivers k to the key focus.  It must be called on the window's goroutine.
+func (w *Window) key(k KeyEvent) {
+	if w.keyFocus != nil {
+		if k.action != glfw.Release {
+			if w.keyMap != nil {
+				var ok bool
+				if k, ok = w.keyMap(w.keyFocus, k); !ok {
+					return
 				}
-				w.mouseIn = v
 			}
-			for button, v := range w.mouser {
-				m := m
-				m.Pos = Map(m.Pos, w.Self, v)
-				m.Drag = true
-				m.Button = button
-				v.Mouse(m)
+			w.keyFocus.KeyPress(k)
+		} else {
+			w.keyFocus.KeyRelease(k)
+		}
+	}
+}
+
+// scroll delivers s, whose position is in window coordinates, to the topmost ScrollerView under it.  It must be called on the window's goroutine.
+func (w *Window) scroll(s ScrollEvent) {
+	v, _ := viewAtFunc(w.Self, s.Pos, func(v View) View {
+		v, _ = v.(ScrollerView)
+		return v
+	}).(ScrollerView)
+	if v != nil {
+		s.Pos = Map(s.Pos, w.Self, v)
+		v.Scroll(s)
+	}
+}
+
+// mouse delivers m, whose position is in window coordinates, to the MouserViews concerned.  It must be called on the window's goroutine.
+func (w *Window) mouse(m MouseEvent) {
+	switch {
+	case m.Press:
+		v, _ := viewAtFunc(w.Self, m.Pos, func(v View) View {
+			v, _ = v.(MouserView)
+			return v
+		}).(MouserView)
+		if v != nil {
+			w.mouser[m.Button] = v
+			m.Pos = Map(m.Pos, w.Self, v)
+			v.Mouse(m)
+		}
+	case m.Move:
+		m.Move = false
+		v, _ := viewAtFunc(w.Self, m.Pos, func(v View) View {
+			v, _ = v.(MouserView)
+			return v
+		}).(MouserView)
+		if w.mouseIn != v {
+			p := commonParent(w.mouseIn, v)
+			for v := View(w.mouseIn); v != p && v != nil; v = Parent(v) {
+				if v, ok := v.(MouserView); ok {
+					m := m
+					m.Pos = Map(m.Pos, w.Self, v)
+					m.Leave = true
+					v.Mouse(m)
+				}
 			}
-		case m.Release:
-			m.Pos = w.mapToWindow(m.Pos)
-			if v, ok := w.mouser[m.Button]; ok {
-				m.Pos = Map(m.Pos, w.Self, v)
-				v.Mouse(m)
-				delete(w.mouser, m.Button)
+			for v := View(v); v != p && v != nil; v = Parent(v) {
+				if v, ok := v.(MouserView); ok {
+					m := m
+					m.Pos = Map(m.Pos, w.Self, v)
+					m.Enter = true
+					v.Mouse(m)
+				}
 			}
+			w.mouseIn = v
 		}
-	})
+		for button, v := range w.mouser {
+			m := m
+			m.Pos = Map(m.Pos, w.Self, v)
+			m.Drag = true
+			m.Button = button
+			v.Mouse(m)
+		}
+	case m.Release:
+		if v, ok := w.mouser[m.Button]; ok {
+			m.Pos = Map(m.Pos, w.Self, v)
+			v.Mouse(m)
+			delete(w.mouser, m.Button)
+		}
+	}
+}
+
+// compose delivers text being composed by an input method to the key focus, if it is a Composer.  It must be called on the window's goroutine.
+func (w *Window) compose(text string) {
+	if c, ok := w.keyFocus.(Composer); ok {
+		c.Compose(text)
+	}
 }
 
 func (w *Window) mapToWindow(p Point) Point {
@@ -241,12 +284,31 @@ func (w *Window) Do(f func()) {
 }
 
 func (w *Window) Close() {
+	if w.headless() {
+		go w.Do(func() { w.close = true })
+		return
+	}
 	go doMain(func() {
 		closeWindow(w)
 	})
 }
 
-func (w *Window) SetTitle(s string) { w.w.SetTitle(s) }
+func (w *Window) SetTitle(s string) {
+	if !w.headless() {
+		w.w.SetTitle(s)
+	}
+}
+
+// Clipboard returns the text on the system clipboard as of the latest key press with the command key held.
+func (w *Window) Clipboard() string { return w.clipboard }
+
+// SetClipboard puts s on the system clipboard.
+func (w *Window) SetClipboard(s string) {
+	w.clipboard = s
+	if !w.headless() {
+		go doMain(func() { w.w.SetClipboardString(s) })
+	}
+}
 
 func (w *Window) win() *Window { return w }
 
@@ -275,9 +337,25 @@ func (w *Window) setKeyFocus(view View) {
 		if w.keyFocus != nil {
 			w.keyFocus.TookKeyFocus()
 		}
+		// TookKeyFocus may have passed the focus on, in which case the hook has already seen it
+		if w.focusHook != nil && w.keyFocus == view {
+			w.focusHook(view)
+		}
 	}
 }
 
+// SetBackgroundColor sets the color drawn behind the views in the window.
+func (w *Window) SetBackgroundColor(c Color) {
+	w.background = c
+	Repaint(w)
+}
+
+// SetKeyMap sets a function to translate each key press before it is delivered to the key focus.  A press is dropped if the function returns false.
+func (w *Window) SetKeyMap(f func(focus View, k KeyEvent) (KeyEvent, bool)) { w.keyMap = f }
+
+// SetFocusHook sets a function to be called with the new key focus each time it changes, for example to describe it to a screen reader.
+func (w *Window) SetFocusHook(f func(View)) { w.focusHook = f }
+
 func (w *Window) setMouser(m MouserView, button int) { w.mouser[button] = m }
 
 func (w *Window) KeyPress(k KeyEvent) {
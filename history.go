@@ -0,0 +1,187 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"fmt"
+)
+
+// history holds the past and future (undone) states of a func being edited.
+// A state is recorded just before the first change made in response to each event (or, without a window, between calls to endChange).  Changes made while a connection or text is being edited belong to the change that started the editing.
+type history struct {
+	past, future []*funcState
+	ended        chan struct{} // closed when the current change ends; nil if no state has been recorded for the current event
+}
+
+// funcState is a snapshot of a func, from which it can be read back in.
+type funcState struct {
+	src   []byte
+	sig   types.Signature
+	fixed []bool // whether each node, in walkInOrder order, was fixed
+	focus int    // the walkInOrder index of the innermost node holding the key focus, or -1
+}
+
+// edited is called just before a change to the graph containing n, to record the prior state of its func.
+func edited(n node) {
+	if f := func_(n); f != nil && f.history != nil {
+		f.history.record(f)
+	}
+}
+
+func (h *history) record(f *funcNode) {
+	if h.ended != nil || editInProgress(f) {
+		return
+	}
+	h.ended = make(chan struct{})
+	if InWindow(f) {
+		go func() {
+			DoChan(f) <- h.endChange
+		}()
+	}
+	s := saveState(f)
+	if n := len(h.past); n == 0 || !bytes.Equal(h.past[n-1].code(), s.code()) {
+		h.past = append(h.past, s)
+	}
+	h.future = nil
+}
+
+// endChange ends the current change, so that the next one is recorded anew.  A window calls it after each event; without a window, whoever makes the changes must call it after each one, as no event marks where a change ends.
+func (h *history) endChange() {
+	if h.ended != nil {
+		close(h.ended)
+		h.ended = nil
+	}
+}
+
+func editInProgress(f *funcNode) bool {
+	switch v := KeyFocus(f).(type) {
+	case *Text:
+		return true
+	case *connection:
+		return v.editing
+	}
+	return false
+}
+
+func saveState(f *funcNode) *funcState {
+	s := &funcState{sig: copySig(f.sig()), focus: -1}
+	laidOut := f.laidOut
+	f.laidOut = true // write all positions, so that nothing moves when the state is restored
+	buf := &bytes.Buffer{}
+	writeFunc(buf, f)
+	f.laidOut = laidOut
+	s.src = buf.Bytes()
+
+	focus := KeyFocus(f)
+	f.funcblk.walkInOrder(func(n node) {
+		for v := focus; v != nil; v = Parent(v) {
+			if v == n {
+				s.focus = len(s.fixed)
+			}
+		}
+		s.fixed = append(s.fixed, n.block().fixed[n])
+	})
+	return s
+}
+
+// code returns the source of s without its layout.
+func (s *funcState) code() []byte {
+	if i := bytes.Index(s.src, []byte("\n// "+layoutComment+"\n")); i >= 0 {
+		return s.src[:i]
+	}
+	return s.src
+}
+
+func copySig(sig *types.Signature) types.Signature {
+	c := *sig
+	if sig.Recv != nil {
+		v := *sig.Recv
+		c.Recv = &v
+	}
+	c.Params = copyVars(sig.Params)
+	c.Results = copyVars(sig.Results)
+	return c
+}
+
+func copyVars(vars []*types.Var) (c []*types.Var) {
+	for _, v := range vars {
+		v := *v
+		c = append(c, &v)
+	}
+	return
+}
+
+// undo restores f to the state before its latest change, returning the funcNode that replaces f.
+func (f *funcNode) undo() *funcNode {
+	return f.step(&f.history.past, &f.history.future)
+}
+
+// redo restores f to the state before its latest undo, returning the funcNode that replaces f.
+func (f *funcNode) redo() *funcNode {
+	return f.step(&f.history.future, &f.history.past)
+}
+
+// step restores f to the latest state in from that differs from its current state, which is then added to to.
+func (f *funcNode) step(from, to *[]*funcState) *funcNode {
+	cur := saveState(f)
+	for len(*from) > 0 {
+		s := (*from)[len(*from)-1]
+		*from = (*from)[:len(*from)-1]
+		if !bytes.Equal(s.code(), cur.code()) {
+			*to = append(*to, cur)
+			return f.restore(s)
+		}
+	}
+	return f
+}
+
+// restore replaces f in its parent with a funcNode read from s, which takes over f's history.
+func (f *funcNode) restore(s *funcState) *funcNode {
+	sig := f.sig()
+	old := *sig
+	*sig = copySig(&s.sig)
+	f2 := newFuncNode(f.obj, nil)
+	if err := readFunc(f2, s.src); err != nil {
+		fmt.Printf("error restoring %s: %s\n", f.obj.GetName(), err)
+		*sig = old
+		f2.funcblk.close()
+		return f
+	}
+	var focus View = f2.inputsNode
+	i := 0
+	f2.funcblk.walkInOrder(func(n node) {
+		if i < len(s.fixed) && !s.fixed[i] {
+			delete(n.block().fixed, n)
+		}
+		if i == s.focus {
+			focus = n
+		}
+		i++
+	})
+	f2.laidOut = f.laidOut
+	f2.done = f.done
+	f2.history, f.history = f.history, nil
+
+	focused := contains(f, KeyFocus(f))
+	if p := Parent(f); p != nil {
+		f.stop.stop()
+		p.Add(f2)
+		go animate(f2.animate, f2.stop)
+		SetScale(f2, Scale(f))
+		f2.Move(Pos(f))
+		if p, ok := p.(*pane); ok {
+			p.replaceFunc(f, f2, focus)
+		}
+	}
+	f.ViewBase.Close()
+	f.funcblk.close() // after removing f from the window, so that removing its nodes doesn't move the focus
+	if focused {
+		SetKeyFocus(focus)
+	}
+	return f2
+}
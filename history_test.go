@@ -0,0 +1,131 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"testing"
+)
+
+// TestUndoRedo makes some changes to a func and checks that undoing them restores the func and its signature and that redoing them restores the changes.
+func TestUndoRedo(t *testing.T) {
+	pkg := audioPackage(t)
+	params := []*types.Var{newVar("a", types.Typ[types.Int]), newVar("b", types.Typ[types.Int])}
+	results := []*types.Var{newVar("c", types.Typ[types.Int])}
+	obj := types.NewFunc(0, pkg, "undoExample", types.NewSignature(nil, nil, params, results, false))
+	src := []byte(`// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func undoExample(a int, b int) (c int) {
+	var v int
+	var v2 int
+	var v3 int
+	var v4 int
+	v = a
+	v2 = b
+	x := v + v2
+	v3 = x
+	const x2 = 2
+	v4 = x2
+	x3 := v3 * v4
+	c = x3
+	return
+}
+`)
+
+	f := newFuncNode(obj, nil)
+	defer func() { f.funcblk.close() }()
+	if err := readFunc(f, src); err != nil {
+		t.Fatal(err)
+	}
+	h := &history{}
+	f.history = h
+	states := [][]string{graphLines(f)}
+
+	// one event removing the operator nodes and the constant, which would be left as dead code and so not be recorded
+	for _, n := range f.funcblk.allNodes() {
+		switch n.(type) {
+		case *operatorNode, *basicLiteralNode:
+			f.funcblk.removeNode(n)
+		}
+	}
+	h.endChange()
+	states = append(states, graphLines(f))
+
+	// another event removing a parameter
+	f.inputsNode.removePort(f.inputsNode.outputs()[1])
+	h.endChange()
+	states = append(states, graphLines(f))
+
+	if len(h.past) != 2 {
+		t.Errorf("recorded %d states, want 2", len(h.past))
+	}
+	for i := len(states) - 2; i >= 0; i-- {
+		f = f.undo()
+		if got := graphLines(f); !equalLines(got, states[i]) {
+			t.Errorf("undo to state %d (- want, + got):\n%s", i, diffLines(states[i], got))
+		}
+	}
+	if n := len(f.sig().Params); n != 2 {
+		t.Errorf("%d params after undo, want 2", n)
+	}
+	if f.undo() != f {
+		t.Error("undo with no history replaced the func")
+	}
+	for i := 1; i < len(states); i++ {
+		f = f.redo()
+		if got := graphLines(f); !equalLines(got, states[i]) {
+			t.Errorf("redo to state %d (- want, + got):\n%s", i, diffLines(states[i], got))
+		}
+	}
+	if n := len(f.sig().Params); n != 1 {
+		t.Errorf("%d params after redo, want 1", n)
+	}
+
+	f = f.undo()
+	f = f.undo()
+	for _, n := range f.funcblk.allNodes() {
+		if _, ok := n.(*operatorNode); ok {
+			f.funcblk.removeNode(n)
+		}
+	}
+	if len(h.future) != 0 {
+		t.Error("a change after undo didn't clear the redo history")
+	}
+}
+
+// TestHistoryInWindow checks that, for a func in a window, each event's changes are recorded as one change without ending it explicitly.
+func TestHistoryInWindow(t *testing.T) {
+	pkg := audioPackage(t)
+	params := []*types.Var{newVar("a", types.Typ[types.Int]), newVar("b", types.Typ[types.Int]), newVar("c", types.Typ[types.Int])}
+	obj := types.NewFunc(0, pkg, "undoExample", types.NewSignature(nil, nil, params, nil, false))
+	f := newFuncNode(obj, nil)
+	src := "// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.\n\npackage audio\n\nfunc undoExample(a int, b int, c int) {\n\treturn\n}\n"
+	if err := readFunc(f, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	h := &history{}
+	f.history = h
+	w := NewHeadlessWindow(nil, Pt(400, 300), func(w *Window) { w.Add(f) })
+	defer w.Close()
+	defer w.Do(func() { f.funcblk.close() })
+
+	// one event removing two params, and another removing the third
+	var ended chan struct{}
+	w.Do(func() {
+		f.inputsNode.removePort(f.inputsNode.outputs()[0])
+		f.inputsNode.removePort(f.inputsNode.outputs()[0])
+		ended = h.ended
+	})
+	<-ended
+	w.Do(func() {
+		f.inputsNode.removePort(f.inputsNode.outputs()[0])
+		if len(h.past) != 2 {
+			t.Errorf("recorded %d states, want one for each of 2 events", len(h.past))
+		}
+	})
+}
@@ -6,13 +6,14 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
 )
 
 type ifNode struct {
 	*ViewBase
 	AggregateMouser
-	blk           *block
+	model         *graph.Node
 	seqIn, seqOut *port
 
 	cond    []*port
@@ -25,13 +26,14 @@ type ifNode struct {
 func newIfNode(arranged blockchan) *ifNode {
 	n := &ifNode{focused: -1, arranged: arranged}
 	n.ViewBase = NewView(n)
-	n.AggregateMouser = AggregateMouser{NewClickFocuser(n), NewMover(n)}
+	n.AggregateMouser = nodeMouser(n)
+	n.model = newGraphNode(n)
 
 	n.seqIn = newInput(n, newVar("seq", seqType))
 	MoveCenter(n.seqIn, Pt(0, -portSize))
-	n.Add(n.seqIn)
+	addPort(n.seqIn, nil)
 	n.seqOut = newOutput(n, newVar("seq", seqType))
-	n.Add(n.seqOut)
+	addPort(n.seqOut, nil)
 
 	return n
 }
@@ -45,9 +47,9 @@ func (n *ifNode) newBlock() (b *block, cond *port) {
 		cond.setType(untypedToTyped(inputType(cond)))
 	}
 	n.cond = append(n.cond, cond)
-	n.Add(cond)
+	addPort(cond, nil)
 
-	rearrange(n.blk)
+	rearrange(n.block())
 	return
 }
 
@@ -56,29 +58,14 @@ func (n *ifNode) connectable(t types.Type, dst *port) bool {
 	return ok && b.Info&types.IsBoolean != 0
 }
 
-func (n ifNode) block() *block      { return n.blk }
-func (n *ifNode) setBlock(b *block) { n.blk = b }
-func (n ifNode) inputs() []*port    { return append([]*port{n.seqIn}, n.cond...) }
-func (n ifNode) outputs() []*port   { return []*port{n.seqOut} }
+func (n ifNode) block() *block          { return blockView(n.model.Block) }
+func (n ifNode) inputs() []*port        { return portViews(n.model.Ins) }
+func (n ifNode) outputs() []*port       { return portViews(n.model.Outs) }
+func (n ifNode) graphNode() *graph.Node { return n.model }
 
-func (n ifNode) inConns() []*connection {
-	c := n.seqIn.conns
-	for _, p := range n.cond {
-		c = append(c, p.conns...)
-	}
-	for _, b := range n.blocks {
-		c = append(c, b.inConns()...)
-	}
-	return c
-}
+func (n ifNode) inConns() []*connection { return connViews(n.model.InConns()) }
 
-func (n ifNode) outConns() []*connection {
-	c := n.seqOut.conns
-	for _, b := range n.blocks {
-		c = append(c, b.outConns()...)
-	}
-	return c
-}
+func (n ifNode) outConns() []*connection { return connViews(n.model.OutConns()) }
 
 func (n *ifNode) focus(i int) {
 	n.focused = i
@@ -137,6 +124,7 @@ func (n *ifNode) KeyPress(event KeyEvent) {
 			n.focus(n.focused + 1)
 		}
 	case KeyComma:
+		edited(n)
 		n.newBlock()
 		n.focus(len(n.blocks) - 1)
 	case KeyBackspace, KeyDelete:
@@ -144,12 +132,10 @@ func (n *ifNode) KeyPress(event KeyEvent) {
 			n.ViewBase.KeyPress(event)
 			return
 		}
+		edited(n)
 		i := n.focused
 		n.blocks[i].close()
-		for _, c := range n.cond[i].conns {
-			c.blk.removeConn(c)
-		}
-		n.Remove(n.cond[i])
+		deletePort(n.cond[i])
 		n.Remove(n.blocks[i])
 		n.cond = append(n.cond[:i], n.cond[i+1:]...)
 		n.blocks = append(n.blocks[:i], n.blocks[i+1:]...)
@@ -157,13 +143,13 @@ func (n *ifNode) KeyPress(event KeyEvent) {
 			i--
 		}
 		n.focus(i)
-		rearrange(n.blk)
+		rearrange(n.block())
 	default:
 		n.ViewBase.KeyPress(event)
 	}
 }
 
-func (n ifNode) Paint() {
+func (n ifNode) Paint(cv Canvas) {
 	for i, b := range n.blocks {
 		r := RectInParent(b)
 		left := r.Min.X - portSize/2
@@ -175,14 +161,14 @@ func (n ifNode) Paint() {
 		if i == len(n.blocks)-1 {
 			right = center
 		}
-		SetColor(lineColor)
-		SetLineWidth(3)
-		DrawLine(Pt(left, 0), Pt(right, 0))
-		DrawLine(Pt(center, portSize), Pt(center, -portSize))
+		cv.SetColor(lineColor)
+		cv.SetLineWidth(3)
+		cv.DrawLine(Pt(left, 0), Pt(right, 0))
+		cv.DrawLine(Pt(center, portSize), Pt(center, -portSize))
 		if i == n.focused {
-			SetPointSize(2 * portSize)
-			SetColor(focusColor)
-			DrawPoint(Pt(center, 0))
+			cv.SetPointSize(2 * portSize)
+			cv.SetColor(focusColor)
+			cv.DrawPoint(Pt(center, 0))
 		}
 	}
 }
@@ -0,0 +1,293 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/exact"
+	"github.com/gordonklaus/flux/go/types"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// importGo implements the "flux import" command, which converts the funcs and methods in the given Go files into Flux funcs.
+// Each func is normalized into the subset of Go that the reader understands, read into a graph, and written back out as Flux.  The result is printed, or, with -w, saved to the func's Flux file and removed from the Go file.
+// Problems are printed to stdout.  The returned exit status is nonzero if there were any.
+func importGo(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	write := flags.Bool("w", false, "save the Flux funcs and remove them from the Go files instead of only printing them")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: flux import [-w] files")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		if !importFile(path, *write) {
+			status = 1
+		}
+	}
+	return status
+}
+
+// importFile imports the funcs in the Go file at path, reporting whether it succeeded for all of them.
+func importFile(path string, write bool) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	if strings.HasSuffix(path, ".flux.go") {
+		fmt.Printf("%s: already a Flux file\n", path)
+		return false
+	}
+	fset, file, info, pkg, err := checkFile(path)
+	if err != nil {
+		fmt.Printf("%s: %s\n", path, err)
+		return false
+	}
+
+	ok := true
+	var imported []*ast.FuncDecl
+	for _, d := range file.Decls {
+		decl, isFunc := d.(*ast.FuncDecl)
+		if !isFunc || decl.Body == nil || decl.Recv == nil && decl.Name.Name == "init" {
+			continue
+		}
+		src, problems := importFunc(fset, info, pkg, decl, write)
+		for _, p := range problems {
+			fmt.Printf("%s: %s: %s\n", fset.Position(decl.Pos()), decl.Name.Name, p)
+		}
+		if len(problems) > 0 {
+			ok = false
+			continue
+		}
+		if !write {
+			fmt.Printf("%s", src)
+		}
+		imported = append(imported, decl)
+	}
+	if write && len(imported) > 0 {
+		if err := removeFuncs(path, fset, file, imported); err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// checkFile type-checks, including func bodies, the package containing the Go file at path, returning the parsed file and its type information.
+func checkFile(path string) (*token.FileSet, *ast.File, *types.Info, *types.Package, error) {
+	buildPkg, err := importDir(filepath.Dir(path), 0)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	fset := token.NewFileSet()
+	var file *ast.File
+	files := []*ast.File{}
+	for _, fileName := range append(buildPkg.GoFiles, buildPkg.CgoFiles...) {
+		fileName = filepath.Join(buildPkg.Dir, fileName)
+		f, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		files = append(files, f)
+		if fileName == path {
+			file = f
+		}
+	}
+	if file == nil {
+		return nil, nil, nil, nil, fmt.Errorf("not part of package %s", buildPkg.ImportPath)
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.Type{},
+		Values:     map[ast.Expr]exact.Value{},
+		Objects:    map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:     map[ast.Node]*types.Scope{},
+	}
+	// only errors in the file itself matter; others (e.g., from unsupported dependencies) are ignored, as by typeCheck
+	var errs []string
+	cfg := types.Config{FakeImportC: true, Import: srcImport, Error: func(err error) {
+		if strings.HasPrefix(err.Error(), path+":") {
+			errs = append(errs, err.Error())
+		}
+	}}
+	pkg, _ := cfg.Check(buildPkg.ImportPath, fset, files, info)
+	if len(errs) > 0 {
+		return nil, nil, nil, nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return fset, file, info, pkg, nil
+}
+
+// importFunc converts decl into a Flux func, saving it if write is true, and returns the Flux source.
+func importFunc(fset *token.FileSet, info *types.Info, pkg *types.Package, decl *ast.FuncDecl, write bool) (src []byte, problems []string) {
+	defer func() {
+		if err := recover(); err != nil {
+			problems = append(problems, fmt.Sprintf("panic: %v", err))
+		}
+	}()
+
+	obj := lookupFunc(pkg.Path, decl)
+	if obj == nil {
+		return nil, []string{"not found in package " + pkg.Path}
+	}
+	normal, err := normalize(fset, info, pkg, decl)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, normal); err != nil {
+		return nil, []string{"normalized func is unreadable: " + err.Error()}
+	}
+	buf := &bytes.Buffer{}
+	if problems = append(checkBlock(f.funcblk), writeFunc(buf, f)...); len(problems) > 0 {
+		return nil, problems
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), fluxPath(obj), buf.Bytes(), 0); err != nil {
+		return nil, []string{"writer produced invalid Go: " + err.Error()}
+	}
+	if write {
+		saveFunc(f)
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupFunc returns the object of the func or method declared by decl in the package that the reader and writer use (as loaded by getPackage, without func bodies).
+func lookupFunc(path string, decl *ast.FuncDecl) types.Object {
+	pkg, err := getPackage(path)
+	if err != nil {
+		return nil
+	}
+	if decl.Recv == nil {
+		obj, _ := pkg.Scope().Lookup(decl.Name.Name).(*types.Func)
+		return obj
+	}
+	recv := decl.Recv.List[0].Type
+	if s, ok := recv.(*ast.StarExpr); ok {
+		recv = s.X
+	}
+	id, ok := recv.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	t, ok := pkg.Scope().Lookup(id.Name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	for _, m := range t.Type.(*types.Named).Methods {
+		if m.Name == decl.Name.Name {
+			return m
+		}
+	}
+	return nil
+}
+
+// removeFuncs removes decls (and their doc comments) from the Go file at path along with any imports that are no longer used.  If nothing but imports remains, the file is removed.
+func removeFuncs(path string, fset *token.FileSet, file *ast.File, decls []*ast.FuncDecl) error {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	removed := map[ast.Decl]bool{}
+	spans := []span{}
+	for _, d := range decls {
+		removed[d] = true
+		start := d.Pos()
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+		spans = append(spans, span{fset.Position(start).Offset, fset.Position(d.End()).Offset})
+	}
+
+	used := map[string]bool{}
+	remaining := 0
+	for _, d := range file.Decls {
+		if removed[d] {
+			continue
+		}
+		if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.IMPORT {
+			continue
+		}
+		remaining++
+		ast.Inspect(d, func(x ast.Node) bool {
+			if s, ok := x.(*ast.SelectorExpr); ok {
+				if id, ok := s.X.(*ast.Ident); ok && id.Obj == nil {
+					used[id.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	if remaining == 0 {
+		return os.Remove(path)
+	}
+	for _, d := range file.Decls {
+		g, ok := d.(*ast.GenDecl)
+		if !ok || g.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range g.Specs {
+			spec := spec.(*ast.ImportSpec)
+			name := ""
+			if spec.Name != nil {
+				name = spec.Name.Name
+			} else if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+				if pkg, err := getPackage(p); err == nil {
+					name = pkg.Name
+				}
+			}
+			if name == "_" || name == "." || name == "" || used[name] {
+				continue
+			}
+			if !g.Lparen.IsValid() { // remove the whole "import path" declaration
+				spans = append(spans, span{fset.Position(g.Pos()).Offset, fset.Position(g.End()).Offset})
+			} else {
+				spans = append(spans, span{fset.Position(spec.Pos()).Offset, fset.Position(spec.End()).Offset})
+			}
+		}
+	}
+
+	sort.Sort(spansByStart(spans))
+	buf := &bytes.Buffer{}
+	i := 0
+	for _, s := range spans {
+		buf.Write(src[i:s.start])
+		i = s.end
+	}
+	buf.Write(src[i:])
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0666)
+}
+
+type span struct{ start, end int }
+
+type spansByStart []span
+
+func (s spansByStart) Len() int           { return len(s) }
+func (s spansByStart) Less(i, j int) bool { return s[i].start < s[j].start }
+func (s spansByStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
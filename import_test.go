@@ -0,0 +1,154 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestImport imports each func in the hand-written Go files of the audio package and checks that the result is free of problems, that it type-checks in place of the original func, and that it is written out identically after reading it back in.
+func TestImport(t *testing.T) {
+	audioPackage(t)
+	paths, err := filepath.Glob("audio/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".flux.go") {
+			continue
+		}
+		path, _ = filepath.Abs(path)
+		fset, file, info, pkg, err := checkFile(path)
+		if err != nil {
+			t.Errorf("%s: %s", path, err)
+			continue
+		}
+		orig, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range file.Decls {
+			decl, ok := d.(*ast.FuncDecl)
+			if !ok || decl.Body == nil {
+				continue
+			}
+			n++
+			name := decl.Name.Name
+			src, problems := importFunc(fset, info, pkg, decl, false)
+			if len(problems) > 0 {
+				t.Errorf("%s: %s", name, strings.Join(problems, "\n"))
+				continue
+			}
+
+			// the original func is blanked out rather than removed so that its file still parses.
+			start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+			goSrc := append(append(append([]byte{}, orig[:start]...), bytes.Repeat([]byte{' '}, end-start)...), orig[end:]...)
+			obj := lookupFunc(pkg.Path, decl)
+			if err := typeCheck(pkg.Path, map[string][]byte{path: goSrc, fluxPath(obj): src}); err != nil {
+				t.Errorf("%s: imported func does not type-check: %s\n%s", name, err, src)
+				continue
+			}
+
+			f := newFuncNode(obj, nil)
+			if err := readFunc(f, src); err != nil {
+				t.Errorf("%s: imported func is unreadable: %s\n%s", name, err, src)
+			} else {
+				buf := &bytes.Buffer{}
+				writeFunc(buf, f)
+				if out := buf.Bytes(); !bytes.Equal(out, src) {
+					t.Errorf("%s: imported func changed by reading and writing (- imported, + rewritten):\n%s", name, diffLines(strings.Split(string(src), "\n"), strings.Split(string(out), "\n")))
+				}
+			}
+			f.funcblk.close()
+		}
+	}
+	if n == 0 {
+		t.Fatal("no Go funcs found in ./audio")
+	}
+}
+
+// TestImportRun imports each func in testdata/importrun and runs it alongside the original on a few inputs, checking that they return the same results.  The funcs are built with the installed Go toolchain.
+func TestImportRun(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("skipping: no go command to build the imported funcs")
+	}
+	orig, err := ioutil.ReadFile(filepath.Join("testdata", "importrun", "funcs.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the package is copied into a GOPATH of its own, as go/build gives no import path to packages in testdata.
+	dir, err := ioutil.TempDir("", "importrun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "src", "importrun", "funcs.go")
+	writeFiles(t, map[string][]byte{path: orig})
+	defer func(gopath string) { build.Default.GOPATH = gopath }(build.Default.GOPATH)
+	build.Default.GOPATH = dir
+	fset, file, info, pkg, err := checkFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := filepath.Join(dir, "run")
+	files := map[string][]byte{
+		filepath.Join(run, "go.mod"):           []byte("module importrun\n"),
+		filepath.Join(run, "orig", "funcs.go"): orig,
+	}
+	main := &bytes.Buffer{}
+	fmt.Fprint(main, "package main\n\nimport (\n\t\"fmt\"\n\tflux \"importrun/flux\"\n\torig \"importrun/orig\"\n)\n\nfunc main() {\n\tfor _, xs := range [][]int{nil, {1, 2, 3}, {5, -1, 4, 0}} {\n")
+	for _, d := range file.Decls {
+		decl, ok := d.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := decl.Name.Name
+		src, problems := importFunc(fset, info, pkg, decl, false)
+		if len(problems) > 0 {
+			t.Errorf("%s: %s", name, strings.Join(problems, "\n"))
+			continue
+		}
+		files[filepath.Join(run, "flux", name+".go")] = src
+		fmt.Fprintf(main, "\t\tif got, want := flux.%[1]s(xs), orig.%[1]s(xs); got != want {\n\t\t\tfmt.Printf(\"%[1]s(%%v) = %%v, want %%v\\n\", xs, got, want)\n\t\t}\n", name)
+	}
+	fmt.Fprint(main, "\t}\n}\n")
+	files[filepath.Join(run, "main.go")] = main.Bytes()
+	writeFiles(t, files)
+
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = run
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+	if len(out) > 0 {
+		t.Errorf("imported funcs differ from the originals:\n%s", out)
+	}
+}
+
+func writeFiles(t *testing.T, files map[string][]byte) {
+	for name, src := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, src, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -6,67 +6,292 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"fmt"
 	"go/ast"
-	"go/build"
 	"go/parser"
 	"go/token"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
-var pkgs = map[string]*types.Package{"unsafe": types.Unsafe}
+// Packages are loaded from source, each concurrently with the packages it imports, and kept until their files change.
+var (
+	pkgsMu   sync.Mutex // guards pkgs, fluxObjs, and loads
+	pkgs     = map[string]*types.Package{"unsafe": types.Unsafe}
+	fluxObjs = map[types.Object]bool{}
+	loads    = map[string]*pkgLoad{}
+)
+
+// staleCheckInterval is how often the files of a loaded package are checked for modifications.
+const staleCheckInterval = time.Second
 
-var fluxObjs = map[types.Object]bool{}
+// A pkgLoad is the loading of a package from source and, once it is done, what is needed to tell whether it is out of date.
+type pkgLoad struct {
+	done     chan struct{} // closed when pkg or err is set
+	pkg      *types.Package
+	err      error
+	dir      string
+	files    []string             // the names of the Go files in dir
+	modTimes map[string]time.Time // of dir and its files when they were read
+	imports  []string
+	checked  time.Time // when modTimes were last compared with the files
+}
 
+// getPackage returns the package at path, loading it (and the packages it imports) if it isn't loaded or if its files, or those of a package it imports, have changed since it was.
 func getPackage(path string) (*types.Package, error) {
+	return loadPackage(path, nil)
+}
+
+// getPackageLater is getPackage without the wait:  if the package at path is loaded and up to date, it returns it; otherwise, it returns false and loads the package in the background, calling done when finished.
+func getPackageLater(path string, done func(*types.Package, error)) (*types.Package, bool) {
+	pkgsMu.Lock()
+	forgetStale(path, time.Now())
+	pkg, ok := pkgs[path]
+	pkgsMu.Unlock()
+	if !ok {
+		go func() { done(getPackage(path)) }()
+	}
+	return pkg, ok
+}
+
+// loadPackage is getPackage for a package imported (directly or indirectly) by importers, among which it must not be.
+func loadPackage(path string, importers []string) (*types.Package, error) {
+	for _, p := range importers {
+		if p == path {
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(append(importers, path), " -> "))
+		}
+	}
+
+	pkgsMu.Lock()
+	forgetStale(path, time.Now())
 	if pkg, ok := pkgs[path]; ok {
+		pkgsMu.Unlock()
 		return pkg, nil
 	}
+	l, loading := loads[path]
+	if !loading {
+		l = &pkgLoad{done: make(chan struct{})}
+		loads[path] = l
+	}
+	pkgsMu.Unlock()
+
+	if loading {
+		<-l.done
+	} else {
+		l.load(path, importers)
+	}
+	return l.pkg, l.err
+}
+
+// load loads the package at path, after loading the packages it imports concurrently.
+func (l *pkgLoad) load(path string, importers []string) {
+	defer close(l.done)
+	pkg, err := l.check(path, importers)
 
-	buildPkg, err := build.Import(path, "", 0)
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
 	if err != nil {
-		return nil, err
+		l.err = err
+		delete(loads, path) // so that it is tried again
+		return
 	}
+	l.pkg = pkg
+	pkgs[path] = pkg
+	for _, fileName := range l.files {
+		if !strings.HasSuffix(fileName, ".flux.go") {
+			continue
+		}
+		n := strings.Split(fileName[:len(fileName)-8], ".")
+		for i := range n {
+			n[i] = strings.TrimRight(n[i], "-")
+		}
+		if len(n) == 1 {
+			fluxObjs[pkg.Scope().Lookup(n[0])] = true
+		} else {
+			for _, m := range pkg.Scope().Lookup(n[0]).GetType().(*types.Named).Methods {
+				if m.Name == n[1] {
+					fluxObjs[m] = true
+				}
+			}
+		}
+	}
+}
 
-	var fluxFiles []string
+func (l *pkgLoad) check(path string, importers []string) (*types.Package, error) {
+	buildPkg, err := importPkg(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	l.dir = buildPkg.Dir
+	l.files = append(buildPkg.GoFiles, buildPkg.CgoFiles...)
+	l.modTimes = modTimes(l.dir, l.files)
+	l.checked = time.Now()
+	for _, imp := range buildPkg.Imports {
+		if imp != "C" {
+			l.imports = append(l.imports, imp)
+		}
+	}
 
+	// parse while the imports load
+	imported := make([]*types.Package, len(l.imports))
+	importErrs := make([]error, len(l.imports))
+	var wg sync.WaitGroup
+	for i, imp := range l.imports {
+		wg.Add(1)
+		go func(i int, imp string) {
+			defer wg.Done()
+			imported[i], importErrs[i] = loadPackage(imp, append(importers[:len(importers):len(importers)], path))
+		}(i, imp)
+	}
 	files := []*ast.File{}
 	fset := token.NewFileSet()
-	for _, fileName := range append(buildPkg.GoFiles, buildPkg.CgoFiles...) {
-		file, err := parser.ParseFile(fset, filepath.Join(buildPkg.Dir, fileName), nil, 0)
+	for _, fileName := range l.files {
+		file, err := parser.ParseFile(fset, filepath.Join(l.dir, fileName), nil, 0)
 		if err != nil {
+			wg.Wait()
 			return nil, err
 		}
 		files = append(files, file)
-		if strings.HasSuffix(fileName, ".flux.go") {
-			fluxFiles = append(fluxFiles, fileName[:len(fileName)-8])
+	}
+	wg.Wait()
+
+	// the imports are all loaded, so importing can't wait on another check
+	importByPath := func(imports map[string]*types.Package, path string) (*types.Package, error) {
+		for i, imp := range l.imports {
+			if imp == path {
+				if importErrs[i] != nil {
+					return nil, importErrs[i]
+				}
+				imports[path] = imported[i]
+				return imported[i], nil
+			}
 		}
+		return nil, fmt.Errorf("unexpected import %q", path)
 	}
-	cfg := types.Config{IgnoreFuncBodies: true, FakeImportC: true, Import: srcImport}
+	cfg := types.Config{IgnoreFuncBodies: true, FakeImportC: true, Import: importByPath}
 	pkg, err := cfg.Check(path, fset, files, nil)
 	if err != nil {
 		return nil, err
 	}
 	pkg.Path = buildPkg.ImportPath
-	pkgs[path] = pkg
+	return pkg, nil
+}
 
-	for _, file := range fluxFiles {
-		n := strings.Split(file, ".")
-		for i := range n {
-			n[i] = strings.TrimRight(n[i], "-")
+// modTimes returns the modification times of dir, which change when files are added or removed, and of the named files in it.
+func modTimes(dir string, fileNames []string) map[string]time.Time {
+	times := map[string]time.Time{}
+	for _, name := range append([]string{"."}, fileNames...) {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			times[name] = info.ModTime()
 		}
-		if len(n) == 1 {
-			fluxObjs[pkg.Scope().Lookup(n[0])] = true
-		} else {
-			for _, m := range pkg.Scope().Lookup(n[0]).GetType().(*types.Named).Methods {
-				if m.Name == n[1] {
-					fluxObjs[m] = true
-				}
+	}
+	return times
+}
+
+// forgetStale forgets the loaded package at path, or the packages it imports, if they are out of date:  if any of their files have been modified, added, or removed since they were read.
+// Forgetting a package forgets those importing it, too.  Files are compared at most once per staleCheckInterval.  pkgsMu must be held.
+func forgetStale(path string, now time.Time) {
+	forgetStale1(path, now, map[string]bool{})
+}
+
+func forgetStale1(path string, now time.Time, seen map[string]bool) {
+	l, ok := loads[path]
+	if !ok || l.pkg == nil || seen[path] {
+		return
+	}
+	seen[path] = true
+	for _, imp := range l.imports {
+		forgetStale1(imp, now, seen)
+	}
+	if now.Sub(l.checked) < staleCheckInterval {
+		return
+	}
+	l.checked = now
+	for name, t := range l.modTimes {
+		if info, err := os.Stat(filepath.Join(l.dir, name)); err != nil || !info.ModTime().Equal(t) {
+			forget(path)
+			return
+		}
+	}
+}
+
+// forget forgets the package at path and the packages importing it, so that they are loaded anew.  pkgsMu must be held.
+func forget(path string) {
+	if _, ok := pkgs[path]; !ok {
+		return
+	}
+	pkg := pkgs[path]
+	delete(pkgs, path)
+	delete(loads, path)
+	for obj := range fluxObjs {
+		if obj.GetPkg() == pkg {
+			delete(fluxObjs, obj)
+		}
+	}
+	for p, l := range loads {
+		for _, imp := range l.imports {
+			if imp == path {
+				forget(p)
+				break
 			}
 		}
 	}
+}
 
-	return pkg, nil
+// forgetPackage forgets the package at path and the packages importing it, so that they are loaded anew.
+func forgetPackage(path string) {
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
+	forget(path)
+}
+
+// packageWritten notes that Flux has written to the files of the package at path, so that it is not considered out of date:  the loaded package already reflects the changes.
+func packageWritten(path string) {
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
+	if l, ok := loads[path]; ok && l.pkg != nil {
+		buildPkg, err := importPkg(path, 0)
+		if err != nil {
+			return
+		}
+		l.files = append(buildPkg.GoFiles, buildPkg.CgoFiles...)
+		l.modTimes = modTimes(l.dir, l.files)
+		l.checked = time.Now()
+	}
+}
+
+// loadedPackage returns the package at path if it is loaded (or was made in Flux), without loading it.
+func loadedPackage(path string) *types.Package {
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
+	return pkgs[path]
+}
+
+// addPackage adds pkg, which was made in Flux rather than loaded, as the package at its path.
+func addPackage(pkg *types.Package) {
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
+	pkgs[pkg.Path] = pkg
+}
+
+func isFluxObj(obj types.Object) bool {
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
+	return fluxObjs[obj]
+}
+
+// setFluxObj records whether obj is stored in a Flux file.
+func setFluxObj(obj types.Object, flux bool) {
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
+	if flux {
+		fluxObjs[obj] = true
+	} else {
+		delete(fluxObjs, obj)
+	}
 }
 
 func srcImport(imports map[string]*types.Package, path string) (*types.Package, error) {
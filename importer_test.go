@@ -0,0 +1,154 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/exact"
+	"github.com/gordonklaus/flux/go/types"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReload checks that a loaded package, and the packages importing it, are loaded anew when its files are changed outside of Flux, but not when Flux changes them.
+func TestReload(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"a/a.go": "package a\n\nimport \"example.com/proj/b\"\n\nconst X = b.Y\n",
+		"b/b.go": "package b\n\nconst Y = 1\n",
+	})
+	defer os.RemoveAll(dir)
+	defer func() { mainModule = nil }()
+	defer forgetPackage("example.com/proj/b")
+
+	a, err := getPackage("example.com/proj/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := loadedPackage("example.com/proj/b")
+	if b == nil {
+		t.Fatal("imported package was not loaded")
+	}
+	if a2, _ := getPackage("example.com/proj/a"); a2 != a {
+		t.Error("unchanged package was loaded anew")
+	}
+
+	// Flux's own writes are already reflected in the loaded package
+	touch(t, filepath.Join(dir, "b", "b.go"), "package b\n\nconst Y = 1\n")
+	packageWritten("example.com/proj/b")
+	expireStaleChecks()
+	if a2, _ := getPackage("example.com/proj/a"); a2 != a {
+		t.Error("package was loaded anew after Flux wrote to an imported package")
+	}
+
+	touch(t, filepath.Join(dir, "b", "b.go"), "package b\n\nconst Y = 2\n")
+	expireStaleChecks()
+	a2, err := getPackage("example.com/proj/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a2 == a {
+		t.Fatal("package was not loaded anew after an imported package changed")
+	}
+	if loadedPackage("example.com/proj/b") == b {
+		t.Error("changed package was not loaded anew")
+	}
+	if x := a2.Scope().Lookup("X").(*types.Const); !exact.Compare(x.Val(), token.EQL, exact.MakeInt64(2)) {
+		t.Errorf("got X = %s, want 2", x.Val())
+	}
+
+	// a new file is noticed, too
+	touch(t, filepath.Join(dir, "b", "c.go"), "package b\n\nconst Z = 3\n")
+	expireStaleChecks()
+	if b, _ := getPackage("example.com/proj/b"); b.Scope().Lookup("Z") == nil {
+		t.Error("added file was not loaded")
+	}
+}
+
+// TestLoadLater checks that getPackageLater loads a package in the background.
+func TestLoadLater(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"a/a.go": "package a\n\nimport (\n\t\"example.com/proj/b\"\n\t\"example.com/proj/c\"\n)\n\nconst X = b.Y + c.Y\n",
+		"b/b.go": "package b\n\nimport \"example.com/proj/d\"\n\nconst Y = d.Z\n",
+		"c/c.go": "package c\n\nimport \"example.com/proj/d\"\n\nconst Y = d.Z\n",
+		"d/d.go": "package d\n\nconst Z = 1\n",
+		"e/e.go": "package e\n\nimport \"example.com/proj/f\"\n\nconst X = f.X\n",
+		"f/f.go": "package f\n\nimport \"example.com/proj/e\"\n\nconst X = e.X\n",
+	})
+	defer os.RemoveAll(dir)
+	defer func() { mainModule = nil }()
+	defer forgetPackage("example.com/proj/d")
+
+	done := make(chan error, 1)
+	if _, ok := getPackageLater("example.com/proj/a", func(pkg *types.Package, err error) { done <- err }); ok {
+		t.Fatal("package was available before it was loaded")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("package was not loaded")
+	}
+	if _, ok := getPackageLater("example.com/proj/a", nil); !ok {
+		t.Error("loaded package is not available")
+	}
+
+	if _, err := getPackage("example.com/proj/e"); err == nil || !strings.Contains(err.Error(), "import cycle") {
+		t.Errorf("got error %v, want an import cycle", err)
+	}
+}
+
+// writeTestModule writes the files of a module example.com/proj to a new directory, which it returns, and makes it the main module.
+func writeTestModule(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "fluxload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["go.mod"] = "module example.com/proj\n"
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if mainModule, err = findModule(dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+var touches time.Duration
+
+// touch writes src to the file at path, with a modification time distinct from its last one even if the file system's clock is coarse.
+func touch(t *testing.T, path, src string) {
+	if err := ioutil.WriteFile(path, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	touches++
+	later := time.Now().Add(touches * time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Dir(path), later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expireStaleChecks makes the next getPackage compare the files of the loaded packages with those on disk, rather than waiting staleCheckInterval.
+func expireStaleChecks() {
+	pkgsMu.Lock()
+	defer pkgsMu.Unlock()
+	for _, l := range loads {
+		l.checked = time.Time{}
+	}
+}
@@ -0,0 +1,571 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// A binding names an action, the context in which it applies, and the key press that invokes it.
+// Actions are carried out by the KeyPress methods of the views in their context, which know only the default keys; mapKey translates the keys chosen in the keymap file to these.
+type binding struct {
+	context, name string
+	dflt, key     KeyEvent
+	valid         func(v View) bool // whether the action applies when v has the key focus; nil means always
+	menu          bool              // whether the action is listed in context menus
+}
+
+func cmdKey(key int) KeyEvent { return KeyEvent{Key: key, Command: true} }
+
+var (
+	enterKey     = KeyEvent{Key: KeyEnter}
+	escapeKey    = KeyEvent{Key: KeyEscape}
+	backspaceKey = KeyEvent{Key: KeyBackspace}
+	deleteKey    = KeyEvent{Key: KeyDelete}
+	commaKey     = KeyEvent{Key: KeyComma, Text: ","}
+	equalsKey    = KeyEvent{Key: KeyEqual, Text: "="}
+	bracketKey   = KeyEvent{Key: KeyLeftBracket, Text: "["}
+)
+
+func plainKey(key int) KeyEvent    { return KeyEvent{Key: key} }
+func shiftKey(k KeyEvent) KeyEvent { k.Shift = true; return k }
+func altKey(k KeyEvent) KeyEvent   { k.Alt = true; return k }
+
+// bindings lists every action, innermost context first.
+var bindings = []*binding{
+	{context: "port", name: "Connect", dflt: enterKey, menu: true},
+	{context: "port", name: "Select field or method", dflt: plainKey(KeyPeriod), menu: true, valid: func(v View) bool {
+		p := v.(*port)
+		return p.out && p.obj.Type != seqType
+	}},
+	{context: "port", name: "Insert after", dflt: commaKey, menu: true, valid: editablePort},
+	{context: "port", name: "Insert before", dflt: shiftKey(commaKey), menu: true, valid: editablePort},
+	{context: "port", name: "Toggle variadic", dflt: KeyEvent{Key: KeyPeriod, Ctrl: true}, menu: true, valid: func(v View) bool {
+		p := v.(*port)
+		n, ok := p.node.(*portsNode)
+		if !ok || !n.editable || n.out {
+			return false
+		}
+		l := len(n.outputs())
+		return n.outputs()[l-1] == p && (l > 1 || n.block().node.(*funcNode).sig().Recv == nil)
+	}},
+	{context: "port", name: "Toggle pointer receiver", dflt: KeyEvent{Text: "*"}, menu: true, valid: func(v View) bool {
+		p := v.(*port)
+		n, ok := p.node.(*portsNode)
+		return ok && p.out && n.outputs()[0] == p && n.block().node.(*funcNode).sig().Recv != nil
+	}},
+	{context: "port", name: "Delete", dflt: backspaceKey, menu: true, valid: func(v View) bool {
+		_, ok := v.(*port).node.(interface {
+			removePort(*port)
+		})
+		return ok
+	}},
+	{context: "port", name: "Next port", dflt: plainKey(KeyRight)},
+	{context: "port", name: "Previous port", dflt: plainKey(KeyLeft)},
+
+	{context: "connection", name: "Edit", dflt: enterKey, menu: true},
+	{context: "connection", name: "Name", dflt: KeyEvent{Text: "_"}, menu: true, valid: func(v View) bool {
+		c := v.(*connection)
+		return c.src().obj.Type != seqType && !c.hidden
+	}},
+	{context: "connection", name: "Draw as line", dflt: KeyEvent{Text: "_"}, menu: true, valid: func(v View) bool {
+		c := v.(*connection)
+		return c.src().obj.Type != seqType && c.hidden
+	}},
+	{context: "connection", name: "Toggle feedback", dflt: plainKey(KeyBackslash), valid: func(v View) bool {
+		c := v.(*connection)
+		return c.editing && (c.src() == nil || c.dst() == nil)
+	}},
+	{context: "connection", name: "Delete", dflt: backspaceKey, menu: true},
+	{context: "connection", name: "Delete and focus destination", dflt: deleteKey},
+	{context: "connection", name: "Next connection", dflt: plainKey(KeyRight)},
+	{context: "connection", name: "Previous connection", dflt: plainKey(KeyLeft)},
+
+	{context: "node", name: "Add parameter", dflt: commaKey, menu: true, valid: func(v View) bool {
+		n, ok := v.(*portsNode)
+		return ok && n.editable && !n.out
+	}},
+	{context: "node", name: "Add result", dflt: commaKey, menu: true, valid: func(v View) bool {
+		n, ok := v.(*portsNode)
+		return ok && n.editable && n.out
+	}},
+	{context: "node", name: "Edit type parameters", dflt: bracketKey, menu: true, valid: func(v View) bool {
+		n, ok := v.(*portsNode)
+		if !ok || !n.editable || n.out {
+			return false
+		}
+		f, ok := n.block().node.(*funcNode)
+		return ok && f.tparams != nil
+	}},
+	{context: "node", name: "Add input", dflt: commaKey, menu: true, valid: variadicNode},
+	{context: "node", name: "Toggle ellipsis", dflt: KeyEvent{Key: KeyPeriod, Ctrl: true}, valid: variadicNode},
+	{context: "node", name: "Inline", dflt: cmdKey(KeyI), menu: true, valid: func(v View) bool {
+		n, ok := v.(*callNode)
+		if !ok {
+			return false
+		}
+		obj, ok := n.obj.(*types.Func)
+		return ok && isFluxObj(obj) && obj.Pkg == n.block().func_().pkg()
+	}},
+	{context: "node", name: "Edit", dflt: enterKey, menu: true, valid: func(v View) bool {
+		_, ok := v.(*basicLiteralNode)
+		return ok
+	}},
+	{context: "node", name: "Toggle read/write", dflt: equalsKey, menu: true, valid: func(v View) bool {
+		switch n := nodeOf(v).(type) {
+		case *valueNode:
+			return n.addressable
+		case *indexNode:
+			_, ok := underlying(inputType(n.x)).(*types.Map)
+			return ok || n.addressable
+		}
+		return false
+	}},
+	{context: "node", name: "Toggle send/receive", dflt: equalsKey, menu: true, valid: func(v View) bool {
+		switch n := nodeOf(v).(type) {
+		case *chanNode:
+			t, _ := underlying(inputType(n.ch)).(*types.Chan)
+			return t == nil || t.Dir == types.SendRecv
+		case *selectNode:
+			return n.focused >= 0 && n.cases[n.focused].ch != nil
+		}
+		return false
+	}},
+	{context: "node", name: "Add block", dflt: commaKey, menu: true, valid: func(v View) bool {
+		_, ok := v.(*ifNode)
+		return ok
+	}},
+	{context: "node", name: "Add case", dflt: commaKey, menu: true, valid: func(v View) bool {
+		switch v.(type) {
+		case *selectNode, *switchNode, *typeSwitchNode:
+			return true
+		}
+		return false
+	}},
+	{context: "node", name: "Add case value", dflt: shiftKey(commaKey), menu: true, valid: func(v View) bool {
+		n, ok := v.(*switchNode)
+		return ok && n.focused >= 0
+	}},
+	{context: "node", name: "Change case type", dflt: enterKey, menu: true, valid: func(v View) bool {
+		n, ok := v.(*typeSwitchNode)
+		return ok && n.focused >= 0
+	}},
+	{context: "node", name: "Toggle slice bounds", dflt: commaKey, menu: true, valid: func(v View) bool {
+		_, ok := v.(*sliceNode)
+		return ok
+	}},
+	{context: "node", name: "Previous block or case", dflt: plainKey(KeyLeft), valid: multiBlockNode},
+	{context: "node", name: "Next block or case", dflt: plainKey(KeyRight), valid: multiBlockNode},
+	{context: "node", name: "Cut", dflt: cmdKey(KeyX), menu: true, valid: movableNode},
+	{context: "node", name: "Copy", dflt: cmdKey(KeyC), menu: true, valid: movableNode},
+	{context: "node", name: "Extract function", dflt: cmdKey(KeyE), menu: true, valid: movableNode},
+	{context: "node", name: "Delete", dflt: backspaceKey, menu: true, valid: movableNode},
+	{context: "node", name: "Delete and focus destination", dflt: deleteKey, valid: movableNode},
+	{context: "node", name: "Focus sequencing input", dflt: altKey(shiftKey(plainKey(KeyUp)))},
+	{context: "node", name: "Focus sequencing output", dflt: altKey(shiftKey(plainKey(KeyDown)))},
+
+	{context: "func", name: "Paste", dflt: cmdKey(KeyV), menu: true, valid: func(v View) bool {
+		switch v.(type) {
+		case *block, node:
+			return clipboard != nil
+		}
+		return false
+	}},
+	{context: "func", name: "Undo", dflt: cmdKey(KeyZ), menu: true},
+	{context: "func", name: "Redo", dflt: shiftKey(cmdKey(KeyZ)), menu: true},
+	{context: "func", name: "Save", dflt: cmdKey(KeyS), menu: true},
+	{context: "func", name: "Export as SVG", dflt: cmdKey(KeyP), menu: true},
+	{context: "func", name: "Export as PNG", dflt: shiftKey(cmdKey(KeyP))},
+	{context: "func", name: "Export as PDF", dflt: altKey(cmdKey(KeyP))},
+	{context: "func", name: "Focus up", dflt: plainKey(KeyUp)},
+	{context: "func", name: "Focus down", dflt: plainKey(KeyDown)},
+	{context: "func", name: "Focus nearest left", dflt: altKey(plainKey(KeyLeft))},
+	{context: "func", name: "Focus nearest right", dflt: altKey(plainKey(KeyRight))},
+	{context: "func", name: "Focus nearest up", dflt: altKey(plainKey(KeyUp))},
+	{context: "func", name: "Focus nearest down", dflt: altKey(plainKey(KeyDown))},
+	{context: "func", name: "Select left", dflt: shiftKey(plainKey(KeyLeft))},
+	{context: "func", name: "Select right", dflt: shiftKey(plainKey(KeyRight))},
+	{context: "func", name: "Select up", dflt: shiftKey(plainKey(KeyUp))},
+	{context: "func", name: "Select down", dflt: shiftKey(plainKey(KeyDown))},
+	{context: "func", name: "Back", dflt: escapeKey},
+
+	{context: "browser", name: "Previous item", dflt: plainKey(KeyUp)},
+	{context: "browser", name: "Next item", dflt: plainKey(KeyDown)},
+	{context: "browser", name: "Open package or type", dflt: plainKey(KeyRight)},
+	{context: "browser", name: "Back to parent", dflt: plainKey(KeyLeft)},
+	{context: "browser", name: "Select", dflt: enterKey},
+	{context: "browser", name: "Cancel", dflt: escapeKey},
+	{context: "browser", name: "New package", dflt: KeyEvent{Key: Key1, Text: "1", Command: true}, valid: mutableBrowser},
+	{context: "browser", name: "New type", dflt: KeyEvent{Key: Key2, Text: "2", Command: true}, valid: mutableBrowser},
+	{context: "browser", name: "New function", dflt: KeyEvent{Key: Key3, Text: "3", Command: true}, valid: mutableBrowser},
+	{context: "browser", name: "New variable", dflt: KeyEvent{Key: Key4, Text: "4", Command: true}, valid: mutableBrowser},
+	{context: "browser", name: "New constant", dflt: KeyEvent{Key: Key5, Text: "5", Command: true}, valid: mutableBrowser},
+	{context: "browser", name: "Delete", dflt: cmdKey(KeyBackspace), valid: mutableBrowser},
+
+	{context: "typeView", name: "Edit", dflt: enterKey},
+	{context: "typeView", name: "Back", dflt: escapeKey},
+	{context: "typeView", name: "Replace", dflt: backspaceKey},
+	{context: "typeView", name: "Insert after", dflt: commaKey},
+	{context: "typeView", name: "Insert before", dflt: shiftKey(commaKey)},
+	{context: "typeView", name: "Delete", dflt: deleteKey},
+	{context: "typeView", name: "Edit type parameters", dflt: bracketKey, valid: func(v View) bool {
+		t, ok := v.(*typeView)
+		return ok && t.tparams != nil
+	}},
+	{context: "typeView", name: "Focus left", dflt: plainKey(KeyLeft)},
+	{context: "typeView", name: "Focus right", dflt: plainKey(KeyRight)},
+	{context: "typeView", name: "Focus up", dflt: plainKey(KeyUp)},
+	{context: "typeView", name: "Focus down", dflt: plainKey(KeyDown)},
+
+	{context: "text", name: "Accept", dflt: enterKey},
+	{context: "text", name: "Cancel", dflt: escapeKey},
+	{context: "text", name: "Select all", dflt: cmdKey(KeyA)},
+	{context: "text", name: "Copy", dflt: cmdKey(KeyC)},
+	{context: "text", name: "Cut", dflt: cmdKey(KeyX)},
+	{context: "text", name: "Paste", dflt: cmdKey(KeyV)},
+	{context: "text", name: "Start", dflt: plainKey(KeyHome)},
+	{context: "text", name: "End", dflt: plainKey(KeyEnd)},
+	{context: "text", name: "Previous word", dflt: altKey(plainKey(KeyLeft))},
+	{context: "text", name: "Next word", dflt: altKey(plainKey(KeyRight))},
+
+	{context: "window", name: "Command palette", dflt: cmdKey(KeyK)},
+	{context: "window", name: "New window", dflt: cmdKey(KeyN)},
+	{context: "window", name: "Split pane", dflt: cmdKey(KeyD)},
+	{context: "window", name: "Split pane vertically", dflt: shiftKey(cmdKey(KeyD))},
+	{context: "window", name: "Next pane", dflt: cmdKey(KeyRightBracket)},
+	{context: "window", name: "Previous pane", dflt: cmdKey(KeyLeftBracket)},
+	{context: "window", name: "Close pane", dflt: cmdKey(KeyW)},
+	{context: "window", name: "Zoom in", dflt: cmdKey(KeyEqual)},
+	{context: "window", name: "Zoom out", dflt: cmdKey(KeyMinus)},
+	{context: "window", name: "Actual size", dflt: cmdKey(Key0)},
+	{context: "window", name: "Toggle minimap", dflt: cmdKey(KeyM)},
+	{context: "window", name: "Color connections by type", dflt: cmdKey(KeyT)},
+	{context: "window", name: "Describe focus", dflt: cmdKey(KeySlash)},
+	{context: "window", name: "Quit", dflt: cmdKey(KeyQ)},
+}
+
+func init() {
+	for _, b := range bindings {
+		b.key = b.dflt
+	}
+}
+
+func editablePort(v View) bool {
+	n, ok := v.(*port).node.(*portsNode)
+	return ok && n.editable
+}
+
+func variadicNode(v View) bool {
+	switch n := nodeOf(v).(type) {
+	case *callNode:
+		_, v := n.variadic()
+		return v != nil
+	case *appendNode:
+		_, ok := ins(n)[0].obj.Type.(*types.Slice)
+		return ok
+	}
+	return false
+}
+
+func multiBlockNode(v View) bool {
+	switch v.(type) {
+	case *ifNode, *selectNode, *switchNode, *typeSwitchNode:
+		return true
+	}
+	return false
+}
+
+func movableNode(v View) bool {
+	_, ok := v.(*portsNode)
+	_, isNode := v.(node)
+	return isNode && !ok
+}
+
+func mutableBrowser(v View) bool { return v.(*browser).options.mutable }
+
+// nodeOf returns v if it is a node, or the node of v if it is a port, or nil.
+func nodeOf(v View) node {
+	switch v := v.(type) {
+	case *port:
+		return v.node
+	case node:
+		return v
+	}
+	return nil
+}
+
+// contexts returns the contexts of the actions that apply when v has the key focus, innermost first.
+func contexts(v View) []string {
+	switch v.(type) {
+	case *Text:
+		return []string{"text"}
+	case *browser:
+		return []string{"browser", "window"}
+	case *typeView, *typeParamsView:
+		return []string{"typeView", "window"}
+	case *port:
+		return []string{"port", "node", "func", "window"}
+	case *connection:
+		return []string{"connection", "func", "window"}
+	case node:
+		return []string{"node", "func", "window"}
+	case *block:
+		return []string{"func", "window"}
+	case *pane:
+		return []string{"window"}
+	}
+	return nil
+}
+
+// commands returns the actions that apply when v has the key focus.
+func commands(v View) (cmds []*binding) {
+	for _, c := range contexts(v) {
+		for _, b := range bindings {
+			if b.context == c && (b.valid == nil || b.valid(v)) {
+				cmds = append(cmds, b)
+			}
+		}
+	}
+	return
+}
+
+// mapKey translates k, pressed while v has the key focus, to the default key of the action it is bound to.
+// It reports false if k should be dropped because the actions having it as their default key are all bound to other keys.
+func mapKey(v View, k KeyEvent) (KeyEvent, bool) {
+	cmds := commands(v)
+	for _, b := range cmds {
+		if sameKey(b.key, k) {
+			if b.key == b.dflt {
+				return k, true
+			}
+			return b.dflt, true
+		}
+	}
+	rebound := false
+	for _, b := range cmds {
+		if sameKey(b.dflt, k) {
+			if b.key == b.dflt {
+				return k, true
+			}
+			rebound = true
+		}
+	}
+	return k, !rebound
+}
+
+// sameKey reports whether the key press k is the one described by b.  A b without a Key describes the typing of its Text, however it is typed.
+func sameKey(b, k KeyEvent) bool {
+	if b.Key != 0 {
+		if b.Key != k.Key || b.Shift != k.Shift {
+			return false
+		}
+	} else if b.Text != k.Text {
+		return false
+	}
+	bcmd, bctrl := modifiers(b)
+	kcmd, kctrl := modifiers(k)
+	return b.Alt == k.Alt && bcmd == kcmd && bctrl == kctrl
+}
+
+// modifiers returns whether the command and control keys are held in k, counting Control as Command where it is the command key.
+func modifiers(k KeyEvent) (command, control bool) {
+	if runtime.GOOS == "darwin" {
+		return k.Command, k.Ctrl
+	}
+	return k.Command || k.Ctrl, false
+}
+
+var keyNames = map[int]string{
+	KeyEnter:        "Enter",
+	KeyEscape:       "Escape",
+	KeyBackspace:    "Backspace",
+	KeyDelete:       "Delete",
+	KeyTab:          "Tab",
+	KeySpace:        "Space",
+	KeyUp:           "Up",
+	KeyDown:         "Down",
+	KeyLeft:         "Left",
+	KeyRight:        "Right",
+	KeyHome:         "Home",
+	KeyEnd:          "End",
+	KeyComma:        "Comma",
+	KeyPeriod:       "Period",
+	KeyEqual:        "Equals",
+	KeyMinus:        "Minus",
+	KeyBackslash:    "Backslash",
+	KeySlash:        "Slash",
+	KeyLeftBracket:  "[",
+	KeyRightBracket: "]",
+}
+
+var textNames = map[string]string{
+	"_": "Underscore",
+	"*": "Asterisk",
+}
+
+// keyName returns the name of the keys pressed for k, as written in the documentation and the keymap file.
+func keyName(k KeyEvent) string {
+	s := []string{}
+	if k.Alt {
+		s = append(s, "Alt")
+	}
+	if k.Shift && k.Key != 0 {
+		s = append(s, "Shift")
+	}
+	if k.Ctrl {
+		s = append(s, "Control")
+	}
+	if k.Command {
+		s = append(s, "Command")
+	}
+	if name, ok := keyNames[k.Key]; ok {
+		s = append(s, name)
+	} else if k.Key == 0 {
+		if name, ok := textNames[k.Text]; ok {
+			s = append(s, name)
+		} else {
+			s = append(s, k.Text)
+		}
+	} else {
+		s = append(s, string(rune(k.Key)))
+	}
+	return strings.Join(s, "-")
+}
+
+// parseKey parses a key name as returned by keyName.
+func parseKey(s string) (k KeyEvent, err error) {
+	parts := strings.Split(s, "-")
+	for _, m := range parts[:len(parts)-1] {
+		switch m {
+		case "Alt":
+			k.Alt = true
+		case "Shift":
+			k.Shift = true
+		case "Control":
+			k.Ctrl = true
+		case "Command":
+			k.Command = true
+		default:
+			return k, fmt.Errorf("unknown modifier key %q", m)
+		}
+	}
+	name := parts[len(parts)-1]
+	for key, n := range keyNames {
+		if n == name {
+			k.Key = key
+			return k, nil
+		}
+	}
+	for text, n := range textNames {
+		if n == name {
+			k.Text = text
+			return k, nil
+		}
+	}
+	if r := []rune(strings.ToUpper(name)); len(r) == 1 && (r[0] >= 'A' && r[0] <= 'Z' || r[0] >= '0' && r[0] <= '9') {
+		k.Key = int(r[0])
+		return k, nil
+	}
+	if len([]rune(name)) == 1 && !k.Shift {
+		k.Text = name
+		return k, nil
+	}
+	return k, fmt.Errorf("unknown key %q", name)
+}
+
+// defaultKeymapPath returns the path of the keymap file read at startup unless another is given.
+func defaultKeymapPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".flux", "keymap")
+}
+
+// loadKeymap reads the keymap file at path, if there is one.
+func loadKeymap(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := readKeymap(f); err != nil {
+		return fmt.Errorf("%s:%v", path, err)
+	}
+	return nil
+}
+
+// readKeymap binds actions to keys as listed in r, one per line in the form "context: name = keys" as written by writeKeymap.  Blank lines and lines starting with # are ignored.
+func readKeymap(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i, j := strings.Index(text, ":"), strings.LastIndex(text, "=")
+		if i < 0 || j < i {
+			return fmt.Errorf("%d: want \"context: name = keys\"", line)
+		}
+		context, name := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:j])
+		b := findBinding(context, name)
+		if b == nil {
+			return fmt.Errorf("%d: unknown action %s: %s", line, context, name)
+		}
+		k, err := parseKey(strings.TrimSpace(text[j+1:]))
+		if err != nil {
+			return fmt.Errorf("%d: %v", line, err)
+		}
+		if sameKey(k, b.dflt) {
+			k = b.dflt // which may have Text, for the view handling it
+		}
+		b.key = k
+	}
+	return s.Err()
+}
+
+func findBinding(context, name string) *binding {
+	for _, b := range bindings {
+		if b.context == context && b.name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// writeKeymap lists the current key of every action in the form read by readKeymap.
+func writeKeymap(w io.Writer) {
+	context := ""
+	for _, b := range bindings {
+		if b.context != context {
+			if context != "" {
+				fmt.Fprintln(w)
+			}
+			context = b.context
+		}
+		fmt.Fprintf(w, "%s: %s = %s\n", b.context, b.name, keyName(b.key))
+	}
+}
+
+// keysCmd prints the keymap, as read from the file named in args or else the default keymap file, for use as a starting point for a keymap file.
+func keysCmd(args []string) int {
+	path := defaultKeymapPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if err := loadKeymap(path); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	writeKeymap(os.Stdout)
+	return 0
+}
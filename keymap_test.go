@@ -0,0 +1,116 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	. "github.com/gordonklaus/flux/gui"
+	"strings"
+	"testing"
+)
+
+func resetKeymap() {
+	for _, b := range bindings {
+		b.key = b.dflt
+	}
+}
+
+// TestKeymapFile writes the default keymap and reads it back, and checks that mistakes in a keymap file are reported.
+func TestKeymapFile(t *testing.T) {
+	defer resetKeymap()
+
+	buf := &bytes.Buffer{}
+	writeKeymap(buf)
+	for _, b := range bindings {
+		b.key = KeyEvent{}
+	}
+	if err := readKeymap(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range bindings {
+		if b.key != b.dflt {
+			t.Errorf("%s: %s is bound to %s, want %s", b.context, b.name, keyName(b.key), keyName(b.dflt))
+		}
+	}
+
+	for _, test := range []struct{ keymap, err string }{
+		{"node: Inline = Command-L", ""},
+		{"# comment\n\nfunc: Undo = Alt-Shift-U", ""},
+		{"node: Frobnicate = Command-F", "1: unknown action node: Frobnicate"},
+		{"window: Quit = Hyper-Q", "1: unknown modifier key \"Hyper\""},
+		{"window: Quit = Command-Fn", "1: unknown key \"Fn\""},
+		{"window Quit", "1: want \"context: name = keys\""},
+	} {
+		err := readKeymap(strings.NewReader(test.keymap))
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("reading %q: got error %v, want %q", test.keymap, err, test.err)
+		}
+	}
+}
+
+// TestRebind binds splitting a pane to another key and checks that the new key splits it and the old one no longer does.
+func TestRebind(t *testing.T) {
+	defer resetKeymap()
+	if err := readKeymap(strings.NewReader("window: Split pane = Command-L")); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &fluxWindow{}
+	NewHeadlessWindow(w, Pt(800, 600), w.init)
+	defer w.Close()
+
+	w.PressKey(KeyEvent{Key: KeyD, Command: true})
+	Do(w, func() {
+		if n := len(w.tiles.panes()); n != 1 {
+			t.Errorf("%d panes after Command-D, want 1", n)
+		}
+	})
+	w.PressKey(KeyEvent{Key: KeyL, Command: true})
+	Do(w, func() {
+		if n := len(w.tiles.panes()); n != 2 {
+			t.Errorf("%d panes after Command-L, want 2", n)
+		}
+	})
+	w.PressKey(KeyEvent{Key: KeyD, Command: true, Shift: true})
+	Do(w, func() {
+		if n := len(w.tiles.panes()); n != 3 {
+			t.Errorf("%d panes after Shift-Command-D, want 3", n)
+		}
+	})
+}
+
+// TestPalette opens the command palette on the browser, filters it, and runs a command from it.
+func TestPalette(t *testing.T) {
+	w := &fluxWindow{}
+	NewHeadlessWindow(w, Pt(800, 600), w.init)
+	defer w.Close()
+
+	w.PressKey(KeyEvent{Key: KeyK, Command: true})
+	w.TypeText("split")
+	Do(w, func() {
+		m, ok := KeyFocus(w).(*contextMenu)
+		if !ok {
+			t.Fatalf("focus is %T, want the command palette", KeyFocus(w))
+		}
+		names := []string{}
+		for _, b := range m.cmds {
+			names = append(names, b.name)
+		}
+		if got := strings.Join(names, ", "); got != "Split pane, Split pane vertically" {
+			t.Errorf("palette lists %s, want the split commands", got)
+		}
+	})
+	w.PressKey(KeyEvent{Key: KeyDown})
+	w.PressKey(KeyEvent{Key: KeyEnter})
+	Do(w, func() {
+		panes := w.tiles.panes()
+		if len(panes) != 2 || RectInParent(panes[1]).Min.Y != 0 {
+			t.Errorf("the pane should have been split top and bottom")
+		}
+		if KeyFocus(w) != panes[1].browser {
+			t.Errorf("focus is %T, want the new pane's browser", KeyFocus(w))
+		}
+	})
+}
@@ -6,13 +6,14 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
 )
 
 type loopNode struct {
 	*ViewBase
 	AggregateMouser
-	blk           *block
+	model         *graph.Node
 	input         *port
 	seqIn, seqOut *port
 	loopblk       *block
@@ -23,17 +24,19 @@ type loopNode struct {
 func newLoopNode(arranged blockchan) *loopNode {
 	n := &loopNode{}
 	n.ViewBase = NewView(n)
-	n.AggregateMouser = AggregateMouser{NewClickFocuser(n), NewMover(n)}
-	n.input = newInput(n, nil)
-	n.input.connsChanged = n.connsChanged
-	MoveCenter(n.input, Pt(0, portSize))
-	n.Add(n.input)
-
+	n.AggregateMouser = nodeMouser(n)
+	n.model = newGraphNode(n)
+	n.model.Kind = graph.Loop
 	n.seqIn = newInput(n, newVar("seq", seqType))
 	MoveCenter(n.seqIn, Pt(0, -portSize))
-	n.Add(n.seqIn)
+	addPort(n.seqIn, nil)
 	n.seqOut = newOutput(n, newVar("seq", seqType))
-	n.Add(n.seqOut)
+	addPort(n.seqOut, nil)
+
+	n.input = newInput(n, nil)
+	n.input.connsChanged = n.connsChanged
+	MoveCenter(n.input, Pt(0, portSize))
+	addPort(n.input, nil)
 
 	n.loopblk = newBlock(n, arranged)
 	n.inputsNode = newInputsNode()
@@ -43,16 +46,12 @@ func newLoopNode(arranged blockchan) *loopNode {
 	return n
 }
 
-func (n loopNode) block() *block      { return n.blk }
-func (n *loopNode) setBlock(b *block) { n.blk = b }
-func (n loopNode) inputs() []*port    { return []*port{n.seqIn, n.input} }
-func (n loopNode) outputs() []*port   { return []*port{n.seqOut} }
-func (n loopNode) inConns() []*connection {
-	return append(n.seqIn.conns, append(n.input.conns, n.loopblk.inConns()...)...)
-}
-func (n loopNode) outConns() []*connection {
-	return append(n.seqOut.conns, n.loopblk.outConns()...)
-}
+func (n loopNode) block() *block           { return blockView(n.model.Block) }
+func (n loopNode) inputs() []*port         { return portViews(n.model.Ins) }
+func (n loopNode) outputs() []*port        { return portViews(n.model.Outs) }
+func (n loopNode) graphNode() *graph.Node  { return n.model }
+func (n loopNode) inConns() []*connection  { return connViews(n.model.InConns()) }
+func (n loopNode) outConns() []*connection { return connViews(n.model.OutConns()) }
 
 func (n *loopNode) connectable(t types.Type, dst *port) bool {
 	ok := false
@@ -96,17 +95,17 @@ func (n *loopNode) connsChanged() {
 	}
 
 	in := n.inputsNode
-	if elemPort && len(in.outs) == 1 {
+	if elemPort && len(in.outputs()) == 1 {
 		in.newOutput(nil)
 	}
-	if !elemPort && len(in.outs) == 2 {
-		in.removePortBase(in.outs[1])
+	if !elemPort && len(in.outputs()) == 2 {
+		in.removePortBase(in.outputs()[1])
 	}
 
 	n.input.setType(t)
-	in.outs[0].setType(key)
+	in.outputs()[0].setType(key)
 	if elemPort {
-		in.outs[1].setType(elem)
+		in.outputs()[1].setType(elem)
 	}
 }
 
@@ -145,13 +144,13 @@ func (n *loopNode) KeyPress(event KeyEvent) {
 	}
 }
 
-func (n loopNode) Paint() {
-	SetColor(lineColor)
-	SetLineWidth(3)
-	DrawLine(Pt(0, -portSize), Pt(0, portSize))
+func (n loopNode) Paint(cv Canvas) {
+	cv.SetColor(lineColor)
+	cv.SetLineWidth(3)
+	cv.DrawLine(Pt(0, -portSize), Pt(0, portSize))
 	if n.focused {
-		SetPointSize(2 * portSize)
-		SetColor(focusColor)
-		DrawPoint(ZP)
+		cv.SetPointSize(2 * portSize)
+		cv.SetColor(focusColor)
+		cv.DrawPoint(ZP)
 	}
 }
@@ -0,0 +1,181 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	. "github.com/gordonklaus/flux/gui"
+	"strings"
+)
+
+// contextMenu lists the commands available on a view, delivering the chosen one to the view as a key press.
+// A command palette is a contextMenu with a filter text; it lists only the commands whose names contain the filter.
+type contextMenu struct {
+	*ViewBase
+	target View
+	all    []*binding
+	cmds   []*binding // the commands listed, those of all matching the filter
+	texts  []*Text
+	filter *Text
+	i      int
+}
+
+const paletteWidth = 300
+
+func newContextMenu(v View, cmds []*binding) *contextMenu {
+	m := &contextMenu{target: v, all: cmds}
+	m.ViewBase = NewView(m)
+	return m
+}
+
+// openContextMenu opens the context menu of v, which has the key focus, at p in v's coordinates.
+func openContextMenu(v View, p Point) {
+	var f *funcNode
+	for x := v; x != nil; x = Parent(x) {
+		if fn, ok := x.(*funcNode); ok && !fn.literal {
+			f = fn
+		}
+	}
+	if f == nil {
+		return
+	}
+	cmds := []*binding{}
+	for _, b := range commands(v) {
+		if b.menu {
+			cmds = append(cmds, b)
+		}
+	}
+	m := newContextMenu(v, cmds)
+	m.list()
+	f.Add(m)
+	m.Move(Map(p, v, f).Sub(Pt(0, Height(m))))
+	SetKeyFocus(m)
+}
+
+// openPalette opens a command palette listing the commands that apply to v, which has the key focus, at the top of the pane containing v.
+func openPalette(v View) {
+	p := paneOf(v)
+	if p == nil {
+		return
+	}
+	m := newContextMenu(v, commands(v))
+	m.filter = NewText("")
+	m.filter.SetBackgroundColor(highlightColor)
+	m.Add(m.filter)
+	m.filter.TextChanged = func(string) {
+		top := Pos(m).Y + Height(m)
+		m.list()
+		m.Move(Pt(Pos(m).X, top-Height(m)))
+	}
+	m.list()
+	p.Add(m)
+	r := Rect(p)
+	m.Move(Pt(r.Center().X-Width(m)/2, r.Max.Y-Height(m)-minimapMargin))
+	SetKeyFocus(m)
+}
+
+// list lists the commands matching the filter, the first at the top, below the filter text.
+func (m *contextMenu) list() {
+	for _, t := range m.texts {
+		t.Close()
+	}
+	m.cmds, m.texts, m.i = nil, nil, 0
+	width := 0.0
+	if m.filter != nil {
+		width = paletteWidth
+	}
+	for _, b := range m.all {
+		if m.filter != nil && !strings.Contains(strings.ToLower(b.name), strings.ToLower(m.filter.Text())) {
+			continue
+		}
+		t := NewText(b.name + "  (" + keyName(b.key) + ")")
+		t.SetBackgroundColor(noColor)
+		m.Add(t)
+		m.cmds = append(m.cmds, b)
+		m.texts = append(m.texts, t)
+		if w := Width(t); w > width {
+			width = w
+		}
+	}
+	y := 0.0
+	for i := len(m.texts) - 1; i >= 0; i-- {
+		m.texts[i].Move(Pt(0, y))
+		y += Height(m.texts[i])
+	}
+	if m.filter != nil {
+		m.filter.Move(Pt(0, y))
+		y += Height(m.filter)
+	}
+	m.SetRect(Rectangle{ZP, Pt(width, y)})
+}
+
+// newMenuOpener returns a Mouser that focuses v and opens its context menu when it is clicked with the right mouse button.
+func newMenuOpener(v View) Clicker {
+	return func(m MouseEvent) {
+		if m.Press && m.Button == MouseButtonRight {
+			SetKeyFocus(v)
+			openContextMenu(v, m.Pos)
+		}
+	}
+}
+
+func (m *contextMenu) LostKeyFocus() { m.Close() }
+
+func (m *contextMenu) KeyPress(event KeyEvent) {
+	switch event.Key {
+	case KeyUp:
+		if len(m.cmds) > 0 {
+			m.i = (m.i + len(m.cmds) - 1) % len(m.cmds)
+			Repaint(m)
+		}
+	case KeyDown:
+		if len(m.cmds) > 0 {
+			m.i = (m.i + 1) % len(m.cmds)
+			Repaint(m)
+		}
+	case KeyEnter:
+		if len(m.cmds) > 0 {
+			m.run(m.i)
+		}
+	case KeyEscape:
+		SetKeyFocus(m.target)
+	default:
+		if m.filter != nil {
+			m.filter.KeyPress(event)
+		}
+	}
+	if KeyFocus(m) == m {
+		announce(m)
+	}
+}
+
+func (m *contextMenu) Mouse(e MouseEvent) {
+	if !e.Press {
+		return
+	}
+	for i, t := range m.texts {
+		if e.Pos.In(RectInParent(t)) {
+			m.run(i)
+			return
+		}
+	}
+}
+
+// run closes m and delivers the i'th command to its target.
+func (m *contextMenu) run(i int) {
+	SetKeyFocus(m.target)
+	m.target.KeyPress(m.cmds[i].dflt)
+}
+
+func (m *contextMenu) Paint(cv Canvas) {
+	cv.SetColor(panelColor)
+	cv.FillRect(Rect(m))
+	cv.SetColor(lineColor)
+	cv.SetLineWidth(1)
+	cv.DrawRect(Rect(m))
+	if len(m.texts) > 0 {
+		cv.SetColor(selectionColor)
+		cv.FillRect(RectInParent(m.texts[m.i]))
+	}
+}
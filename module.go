@@ -0,0 +1,240 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/build"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// A module is a Go module:  the tree of packages below the directory containing its go.mod file, whose import paths begin with the module path.
+type module struct {
+	path, dir string
+	requires  []moduleVersion          // the modules required to build this one, including indirectly
+	replaces  map[string]moduleVersion // replacements by module path, or by path@version for a single version; a replacement without a version is a directory
+	vendor    bool                     // whether the required modules are loaded from dir/vendor rather than the module cache
+}
+
+type moduleVersion struct {
+	path, version string
+	indirect      bool // required only by other required modules
+}
+
+// mainModule is the module containing the working directory.  It is nil if there is none or if GO111MODULE=off, in which case packages are found in GOPATH.
+var mainModule *module
+
+// loadMainModule sets mainModule to the module containing the working directory.
+func loadMainModule() error {
+	if os.Getenv("GO111MODULE") == "off" {
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	mainModule, err = findModule(wd)
+	return err
+}
+
+// findModule returns the module containing dir, or nil if there is none.
+func findModule(dir string) (*module, error) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return readModule(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// readModule reads the module whose go.mod file is in dir.  Only the module, require, and replace directives are used.
+func readModule(dir string) (*module, error) {
+	file := filepath.Join(dir, "go.mod")
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &module{dir: dir, replaces: map[string]moduleVersion{}}
+	block := "" // the directive of the parenthesized block being read, if any
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		indirect := strings.Contains(text, "// indirect")
+		if i := strings.Index(text, "//"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		directive := block
+		if block == "" {
+			directive, fields = fields[0], fields[1:]
+			if len(fields) == 1 && fields[0] == "(" {
+				block = directive
+				continue
+			}
+		} else if fields[0] == ")" {
+			block = ""
+			continue
+		}
+		for i, f := range fields {
+			fields[i] = strings.Trim(f, "\"`")
+		}
+		switch directive {
+		case "module":
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("%s:%d: want \"module path\"", file, line)
+			}
+			m.path = fields[0]
+		case "require":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("%s:%d: want \"require path version\"", file, line)
+			}
+			m.requires = append(m.requires, moduleVersion{fields[0], fields[1], indirect})
+		case "replace":
+			i := 0
+			for i < len(fields) && fields[i] != "=>" {
+				i++
+			}
+			if i < 1 || i > 2 || len(fields)-i-1 < 1 || len(fields)-i-1 > 2 {
+				return nil, fmt.Errorf("%s:%d: want \"replace path [version] => path [version]\"", file, line)
+			}
+			old := strings.Join(fields[:i], "@")
+			r := moduleVersion{path: fields[i+1]}
+			if len(fields) == i+3 {
+				r.version = fields[i+2]
+			}
+			m.replaces[old] = r
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if m.path == "" {
+		return nil, fmt.Errorf("%s: no module directive", file)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "vendor", "modules.txt")); err == nil {
+		m.vendor = true
+	}
+	return m, nil
+}
+
+// moduleDir returns the directory holding the module at modPath as required by m, and whether m requires it.
+func (m *module) moduleDir(modPath string) (string, bool) {
+	for _, r := range m.requires {
+		if r.path != modPath {
+			continue
+		}
+		if m.vendor {
+			return filepath.Join(m.dir, "vendor", filepath.FromSlash(modPath)), true
+		}
+		rep, ok := m.replaces[r.path+"@"+r.version]
+		if !ok {
+			rep, ok = m.replaces[r.path]
+		}
+		if ok {
+			if rep.version == "" {
+				dir := filepath.FromSlash(rep.path)
+				if !filepath.IsAbs(dir) {
+					dir = filepath.Join(m.dir, dir)
+				}
+				return dir, true
+			}
+			r = rep
+		}
+		return filepath.Join(moduleCache(), escapeModulePath(r.path)+"@"+escapeModulePath(r.version)), true
+	}
+	return "", false
+}
+
+// packageDir returns the directory of the package at importPath in m or in one of the modules it requires, and whether there is one.  The package belongs to the module with the longest path prefixing importPath.
+func (m *module) packageDir(importPath string) (string, bool) {
+	best, dir := "", ""
+	try := func(modPath, modDir string) {
+		if len(modPath) > len(best) && (importPath == modPath || strings.HasPrefix(importPath, modPath+"/")) {
+			best, dir = modPath, filepath.Join(modDir, filepath.FromSlash(importPath[len(modPath):]))
+		}
+	}
+	try(m.path, m.dir)
+	for _, r := range m.requires {
+		if d, ok := m.moduleDir(r.path); ok {
+			try(r.path, d)
+		}
+	}
+	return dir, best != ""
+}
+
+// importPath returns the import path of the package in dir, and whether dir is in m.
+func (m *module) importPath(dir string) (string, bool) {
+	rel, err := filepath.Rel(m.dir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path.Join(m.path, filepath.ToSlash(rel)), true
+}
+
+// moduleCache returns the directory holding downloaded modules.
+func moduleCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	return filepath.Join(filepath.SplitList(build.Default.GOPATH)[0], "pkg", "mod")
+}
+
+// escapeModulePath escapes a module path or version as in the module cache, where each upper-case letter is replaced by an exclamation mark followed by its lower-case letter, so that paths differing only in case don't collide on case-insensitive file systems.
+func escapeModulePath(s string) string {
+	buf := []rune{}
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			buf = append(buf, '!', unicode.ToLower(r))
+		} else {
+			buf = append(buf, r)
+		}
+	}
+	return string(buf)
+}
+
+// importPkg is like build.Import but finds packages in mainModule and the modules it requires, before looking in GOROOT and GOPATH.
+func importPkg(importPath string, mode build.ImportMode) (*build.Package, error) {
+	if mainModule != nil {
+		if dir, ok := mainModule.packageDir(importPath); ok {
+			p, err := build.ImportDir(dir, mode)
+			p.ImportPath = importPath
+			return p, err
+		}
+	}
+	p, err := build.Import(importPath, "", mode)
+	if err != nil {
+		// the standard library vendors some packages from other modules
+		if p, err := build.ImportDir(filepath.Join(build.Default.GOROOT, "src", "vendor", filepath.FromSlash(importPath)), mode); err == nil {
+			p.ImportPath = importPath
+			return p, nil
+		}
+	}
+	return p, err
+}
+
+// importDir is like build.ImportDir but knows the import paths of directories in mainModule.
+func importDir(dir string, mode build.ImportMode) (*build.Package, error) {
+	p, err := build.ImportDir(dir, mode)
+	if mainModule != nil {
+		if importPath, ok := mainModule.importPath(dir); ok {
+			p.ImportPath = importPath
+		}
+	}
+	return p, err
+}
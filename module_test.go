@@ -0,0 +1,96 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestModule loads a package of a module that imports packages of a required module in the module cache and of a replacement module in a local directory.
+func TestModule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fluxmodule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, src := range map[string]string{
+		"proj/go.mod": `module example.com/proj
+
+go 1.21
+
+require (
+	example.com/Dep v1.2.0
+	example.com/local v0.0.0 // indirect
+)
+
+replace example.com/local => ../local
+`,
+		"proj/a/a.go":                          "package a\n\nimport (\n\t\"example.com/Dep/d\"\n\t\"example.com/local\"\n)\n\nvar X = d.Y + local.Z\n",
+		"cache/example.com/!dep@v1.2.0/d/d.go": "package d\n\nconst Y = 1\n",
+		"local/go.mod":                         "module example.com/local\n",
+		"local/local.go":                       "package local\n\nconst Z = 2\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Setenv("GOMODCACHE", os.Getenv("GOMODCACHE"))
+	os.Setenv("GOMODCACHE", filepath.Join(dir, "cache"))
+
+	m, err := findModule(filepath.Join(dir, "proj", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.path != "example.com/proj" || m.dir != filepath.Join(dir, "proj") {
+		t.Fatalf("got module %+v, want example.com/proj in %s", m, filepath.Join(dir, "proj"))
+	}
+	mainModule = m
+	defer func() { mainModule = nil }()
+	defer func() {
+		for _, path := range []string{"example.com/proj/a", "example.com/Dep/d", "example.com/local"} {
+			forgetPackage(path)
+		}
+	}()
+
+	for _, test := range []struct{ importPath, dir string }{
+		{"example.com/proj/a", "proj/a"},
+		{"example.com/Dep/d", "cache/example.com/!dep@v1.2.0/d"},
+		{"example.com/local", "local"},
+	} {
+		if got, _ := m.packageDir(test.importPath); got != filepath.Join(dir, filepath.FromSlash(test.dir)) {
+			t.Errorf("%s: got dir %s, want %s", test.importPath, got, test.dir)
+		}
+	}
+	if _, ok := m.packageDir("example.com/other"); ok {
+		t.Error("found a package of a module that isn't required")
+	}
+	if p, err := importDir(filepath.Join(dir, "proj", "a"), 0); err != nil || p.ImportPath != "example.com/proj/a" {
+		t.Errorf("got import path %q (%v), want example.com/proj/a", p.ImportPath, err)
+	}
+
+	pkg, err := getPackage("example.com/proj/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Path != "example.com/proj/a" {
+		t.Errorf("got package path %s, want example.com/proj/a", pkg.Path)
+	}
+	if x := pkg.Scope().Lookup("X"); x == nil || !types.IsIdentical(x.GetType(), types.Typ[types.Int]) {
+		t.Errorf("got X %v, want an int var", x)
+	}
+
+	m.vendor = true
+	if got, _ := m.packageDir("example.com/Dep/d"); got != filepath.Join(dir, "proj", "vendor", "example.com", "Dep", "d") {
+		t.Errorf("got vendored dir %s", got)
+	}
+}
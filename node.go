@@ -6,42 +6,90 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
 	"go/token"
 	"math"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type node interface {
 	View
 	Mouser
 	block() *block
-	setBlock(b *block)
 	inputs() []*port
 	outputs() []*port
 	inConns() []*connection
 	outConns() []*connection
+	graphNode() *graph.Node
+}
+
+// newGraphNode returns the model of n, hooking up its connectable method if it has one.
+func newGraphNode(n node) *graph.Node {
+	g := &graph.Node{Data: n}
+	if c, ok := n.(connectable); ok {
+		g.Connectable = func(t types.Type, dst *graph.Port) bool { return c.connectable(t, dst.Data.(*port)) }
+	}
+	return g
+}
+
+func portView(p *graph.Port) *port {
+	if p == nil {
+		return nil
+	}
+	return p.Data.(*port)
+}
+
+// portViews returns the views of ports.
+func portViews(ports []*graph.Port) (views []*port) {
+	for _, p := range ports {
+		views = append(views, p.Data.(*port))
+	}
+	return
+}
+
+// addPort adds p to the view and model of its node, just after the port after or, if after is nil, after all of the others.
+func addPort(p, after *port) {
+	g := p.node.graphNode()
+	i := len(g.Ins)
+	if p.out {
+		i = len(g.Outs)
+	}
+	if after != nil {
+		i = g.PortIndex(after.model) + 1
+	}
+	p.node.Add(p)
+	g.InsertPort(p.model, i)
+}
+
+// deletePort removes p, and its connections, from the view and model of its node.
+func deletePort(p *port) {
+	for _, c := range p.conns() {
+		c.block().removeConn(c)
+	}
+	p.node.graphNode().RemovePort(p.model)
+	p.node.Remove(p)
 }
 
 type nodeBase struct {
 	*ViewBase
 	self node
 	AggregateMouser
+	model *graph.Node
 
-	blk  *block
 	pkg  *pkgText
 	text *Text
-	ins  []*port
-	outs []*port
 
 	godefer     string
 	godeferText *Text
 	typ         *typeView
 
-	focused bool
-	gap     float64
+	focused  bool
+	gap      float64
+	collapse bool // whether unconnected ports are hidden while neither n nor its ports hold the key focus
 }
 
 func newNodeBase(self node) *nodeBase {
@@ -51,7 +99,8 @@ func newNodeBase(self node) *nodeBase {
 func newGoDeferNodeBase(self node, godefer string) *nodeBase {
 	n := &nodeBase{self: self, godefer: godefer}
 	n.ViewBase = NewView(n)
-	n.AggregateMouser = AggregateMouser{NewClickFocuser(self), NewMover(self)}
+	n.model = newGraphNode(self)
+	n.AggregateMouser = nodeMouser(self)
 	n.pkg = newPkgText()
 	n.Add(n.pkg)
 	n.text = NewText("")
@@ -67,18 +116,20 @@ func newGoDeferNodeBase(self node, godefer string) *nodeBase {
 }
 
 func (n *nodeBase) newInput(v *types.Var) *port {
+	edited(n.self)
 	p := newInput(n.self, v)
 	n.Add(p)
-	n.ins = append(n.ins, p)
+	n.model.AddPort(p.model)
 	n.reform()
 	return p
 }
 
 func (n *nodeBase) newOutput(v *types.Var) *port {
+	edited(n.self)
 	p := newOutput(n.self, v)
 	if n.godefer == "" || v.Type == seqType {
 		n.Add(p)
-		n.outs = append(n.outs, p)
+		n.model.AddPort(p.model)
 		n.reform()
 	}
 	return p
@@ -91,29 +142,29 @@ func (n *nodeBase) addSeqPorts() {
 }
 
 func (n *nodeBase) removePortBase(p *port) { // intentionally named to not implement interface{removePort(*port)}
-	for _, c := range p.conns {
-		c.blk.removeConn(c)
+	edited(n.self)
+	i := n.model.PortIndex(p.model)
+	if i < 0 {
+		return
 	}
-	ports := &n.ins
-	if p.out {
-		ports = &n.outs
+	for _, c := range append([]*connection{}, p.conns()...) {
+		c.block().removeConn(c)
 	}
-	for i, p2 := range *ports {
-		if p2 == p {
-			*ports = append((*ports)[:i], (*ports)[i+1:]...)
-			n.Remove(p)
-			n.reform()
+	n.model.RemovePort(p.model)
+	n.Remove(p)
+	n.reform()
 
-			if i > 0 && (*ports)[i-1].obj.Type != seqType { // assumes sequencing port, if present, is at index 0
-				i--
-			}
-			if i < len(*ports) {
-				SetKeyFocus((*ports)[i])
-			} else {
-				SetKeyFocus(n.self)
-			}
-			break
-		}
+	ports := n.inputs()
+	if p.out {
+		ports = n.outputs()
+	}
+	if i > 0 && ports[i-1].obj.Type != seqType { // assumes sequencing port, if present, is at index 0
+		i--
+	}
+	if i < len(ports) {
+		SetKeyFocus(ports[i])
+	} else {
+		SetKeyFocus(n.self)
 	}
 }
 
@@ -141,7 +192,7 @@ func (n *nodeBase) reform() {
 		n.gap = math.Max(n.gap, Height(n.typ)/2)
 	}
 
-	ins, outs := ins(n), outs(n)
+	ins, outs := n.shownPorts(ins(n)), n.shownPorts(outs(n))
 	numIn := float64(len(ins))
 	numOut := float64(len(outs))
 	rx, ry := (math.Max(numIn, numOut)+1)*portSize/2, 1.0*portSize
@@ -176,37 +227,135 @@ func (n *nodeBase) reform() {
 	} else {
 		ResizeToFit(n, 0)
 	}
-	rearrange(n.blk)
+	rearrange(n.block())
 }
 
-func (n nodeBase) block() *block      { return n.blk }
-func (n *nodeBase) setBlock(b *block) { n.blk = b }
-func (n nodeBase) inputs() []*port    { return n.ins }
-func (n nodeBase) outputs() []*port   { return n.outs }
-
-func (n nodeBase) inConns() (conns []*connection) {
-	for _, p := range n.inputs() {
-		for _, c := range p.conns {
-			conns = append(conns, c)
+// shownPorts shows and returns those of ports that are connected or highlighted, or all of them if n doesn't collapse or holds the focus (or one of its ports does).  It hides the rest.
+func (n *nodeBase) shownPorts(ports []*port) (shown []*port) {
+	expanded := !n.collapse || n.focused
+	for _, p := range append(n.inputs(), n.outputs()...) {
+		expanded = expanded || p.focused
+	}
+	for _, p := range ports {
+		if expanded || p.highlighted || len(p.conns()) > 0 {
+			Show(p)
+			shown = append(shown, p)
+		} else {
+			Hide(p)
 		}
 	}
 	return
 }
 
-func (n nodeBase) outConns() (conns []*connection) {
-	for _, p := range n.outputs() {
-		for _, c := range p.conns {
-			conns = append(conns, c)
-		}
+// portChanged lays out n anew if it collapses, after the connections, highlighting, or focus of one of its ports changed.
+func (n *nodeBase) portChanged() {
+	if n.collapse {
+		n.reform()
 	}
-	return
 }
 
+func (n nodeBase) block() *block          { return blockView(n.model.Block) }
+func (n nodeBase) inputs() []*port        { return portViews(n.model.Ins) }
+func (n nodeBase) outputs() []*port       { return portViews(n.model.Outs) }
+func (n nodeBase) graphNode() *graph.Node { return n.model }
+func (n nodeBase) nodeText() string       { return n.text.Text() }
+
+func (n nodeBase) inConns() []*connection { return connViews(n.model.InConns()) }
+
+func (n nodeBase) outConns() []*connection { return connViews(n.model.OutConns()) }
+
 func (n *nodeBase) Move(p Point) {
 	n.ViewBase.Move(p)
 	nodeMoved(n.self)
 }
 
+// nodeMouser returns the Mouser of node n:  Clicking focuses n, dragging it with the left button moves it (into another block, if dropped there), and clicking it with the right button opens its context menu.
+func nodeMouser(n node) AggregateMouser {
+	return AggregateMouser{NewClickFocuser(n), leftButton(NewMover(n)), leftButton(newFixer(n)), newMenuOpener(n)}
+}
+
+// leftButton returns a Mouser that passes only the events of the left mouse button to m.
+func leftButton(m Mouser) Clicker {
+	return func(e MouseEvent) {
+		if e.Button == MouseButtonLeft {
+			m.Mouse(e)
+		}
+	}
+}
+
+// newFixer returns a Mouser that fixes n in place when the user drags it, so that the arranger no longer moves it, or moves n into another block when it is dropped there.
+func newFixer(n node) Clicker {
+	return func(m MouseEvent) {
+		b := n.block()
+		if b == nil {
+			return
+		}
+		switch {
+		case m.Drag:
+			b.fixed[n] = true
+		case m.Release:
+			if b.fixed[n] && !dropNode(n, Map(m.Pos, n, b)) {
+				rearrange(b)
+			}
+		}
+	}
+}
+
+// dropNode moves n, which was dropped at p in the coordinates of its block, into the innermost other block under p.
+// n keeps its position and is fixed there.  Connections that can't be kept are removed.  dropNode reports whether n was moved.
+func dropNode(n node, p Point) bool {
+	if _, ok := n.(*portsNode); ok {
+		return false
+	}
+	b := n.block()
+	outer := b.outermost()
+	p = Map(p, b, outer)
+	var target *block
+	depth := -1
+	outer.walk(func(b2 *block) {
+		for x := b2; x != nil; x = x.outer() {
+			if x.node == n {
+				return
+			}
+		}
+		r := Rect(b2)
+		if b2 == b { // b has grown to contain n; consider it without n
+			r = ZR
+			for _, n2 := range b.nodes() {
+				if n2 != n {
+					if r == ZR {
+						r = RectInParent(n2)
+					} else {
+						r = r.Union(RectInParent(n2))
+					}
+				}
+			}
+			r = r.Inset(-blockRadius)
+		}
+		if !Map(p, outer, b2).In(r) {
+			return
+		}
+		d := 0
+		for x := b2.outer(); x != nil; x = x.outer() {
+			d++
+		}
+		if d > depth {
+			target, depth = b2, d
+		}
+	}, nil, nil)
+	if target == nil || target == b {
+		return false
+	}
+
+	c := Map(CenterInParent(n), b, target)
+	target.moveNodes([]node{n})
+	MoveCenter(n, c)
+	target.fixed[n] = true
+	rearrange(target)
+	SetKeyFocus(n)
+	return true
+}
+
 func nodeMoved(n node) {
 	for _, c := range append(n.inConns(), n.outConns()...) {
 		c.reform()
@@ -219,31 +368,36 @@ func nodeMoved(n node) {
 
 func (n *nodeBase) TookKeyFocus() {
 	n.focused = true
+	n.portChanged()
 	panTo(n, ZP)
 }
 
 func (n *nodeBase) LostKeyFocus() {
 	n.focused = false
+	n.portChanged()
 }
 
-func (n *nodeBase) Paint() {
-	SetColor(lineColor)
-	SetLineWidth(3)
+func (n *nodeBase) Paint(cv Canvas) {
+	cv.SetColor(lineColor)
+	cv.SetLineWidth(3)
 	for _, p := range append(ins(n), outs(n)...) {
+		if Hidden(p) {
+			continue
+		}
 		pt := CenterInParent(p)
 		dy := n.gap
 		if p.out {
 			dy = -dy
 		}
 		y := (pt.Y-dy)/2 + dy
-		DrawBezier(Pt(0, dy), Pt(0, y), Pt(pt.X, y), pt)
+		cv.DrawBezier(Pt(0, dy), Pt(0, y), Pt(pt.X, y), pt)
 	}
 	if n.focused && (n.text.Text() != "" || n.typ != nil) {
 		r := RectInParent(n.godeferText).Union(RectInParent(n.pkg)).Union(RectInParent(n.text))
 		if n.typ != nil {
 			r = r.Union(RectInParent(n.typ))
 		}
-		DrawRect(r)
+		cv.DrawRect(r)
 	}
 }
 
@@ -295,7 +449,7 @@ func (t *pkgText) Mouse(m MouseEvent) {
 	}
 }
 
-var seqType = struct{ types.Type }{}
+var seqType = graph.SeqType
 
 func seqIn(n node) *port {
 	for _, in := range n.inputs() {
@@ -388,7 +542,8 @@ func newBasicLiteralNode(kind token.Token) *basicLiteralNode {
 			if *s == "" {
 				return false
 			}
-			*s = (*s)[len(*s)-1:]
+			_, n := utf8.DecodeLastRuneInString(*s)
+			*s = (*s)[len(*s)-n:]
 			return true
 		}
 	}
@@ -399,11 +554,12 @@ func newBasicLiteralNode(kind token.Token) *basicLiteralNode {
 func (n *basicLiteralNode) KeyPress(k KeyEvent) {
 	switch k.Key {
 	case KeyEnter:
+		edited(n)
 		s := n.text.Text()
-		t := n.outs[0].obj.Type
+		t := n.outputs()[0].obj.Type
 		n.text.Reject = func() {
 			n.text.SetText(s)
-			n.outs[0].setType(t)
+			n.outputs()[0].setType(t)
 			SetKeyFocus(n)
 		}
 		SetKeyFocus(n.text)
@@ -431,20 +587,20 @@ func (n *compositeLiteralNode) editType() {
 		if t := *n.typ.typ; t != nil {
 			n.setType(t)
 		} else {
-			n.blk.removeNode(n)
-			SetKeyFocus(n.blk)
+			n.block().removeNode(n)
+			SetKeyFocus(n.block())
 		}
 	})
 }
 func (n *compositeLiteralNode) setType(t types.Type) {
 	n.typ.setType(t)
-	n.outs[0].setType(t)
-	n.blk.func_().addPkgRef(t)
+	n.outputs()[0].setType(t)
+	n.block().func_().addPkgRef(t)
 	t, _ = indirect(t)
 	local := true
 	if nt, ok := t.(*types.Named); ok {
-		t = nt.UnderlyingT
-		local = nt.Obj.Pkg == n.blk.func_().pkg()
+		t = nt.Underlying()
+		local = nt.Obj.Pkg == n.block().func_().pkg()
 	}
 	switch t := t.(type) {
 	case *types.Struct:
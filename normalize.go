@@ -0,0 +1,2179 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/exact"
+	"github.com/gordonklaus/flux/go/types"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// normalize rewrites the func decl, which has been type-checked into info as part of pkg, into the subset of Go that the reader understands.
+// Every nested expression is flattened into a node of its own; local variables become connections, or cells allocated by new if their addresses are needed; switch statements become if nodes; and control flow that the reader has no node for (labels, goto, fallthrough, and breaks out of the middle of a switch or select case) is reported as an error.
+func normalize(fset *token.FileSet, info *types.Info, pkg *types.Package, decl *ast.FuncDecl) (src []byte, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			e, ok := x.(normalizeError)
+			if !ok {
+				panic(x)
+			}
+			err = fmt.Errorf("%s: %s", fset.Position(e.pos), e.msg)
+		}
+	}()
+
+	buf := &bytes.Buffer{}
+	n := &normalizer{
+		w:       &writer{nopCloser{buf}, pkg, map[*types.Package]string{}, map[string]int{}, 0, map[node]int{}, map[*port]int{}, 0, nil},
+		info:    info,
+		ports:   map[string]*nnode{},
+		portSeq: map[string]int{},
+		srcs:    map[string][]string{},
+		defs:    map[def][]string{},
+		cells:   map[*types.Var]string{},
+		phiIns:  map[def]string{},
+		phiNode: map[def]*nnode{},
+	}
+	for _, name := range append(types.Universe.Names(), pkg.Scope().Names()...) {
+		n.w.name(name)
+	}
+	n.collectPkgs(decl)
+	n.locals = collectLocals(info, decl)
+	n.a = analyze(info, n.locals, decl)
+	n.blk = n.newBlock(nil)
+	n.stmtPos = decl
+	n.funcDecl(decl)
+
+	n.w.write("package %s\n\n", pkg.Name)
+	n.w.imports()
+	n.render()
+	return buf.Bytes(), nil
+}
+
+type normalizeError struct {
+	pos token.Pos
+	msg string
+}
+
+type normalizer struct {
+	w       *writer
+	info    *types.Info
+	locals  map[*types.Var]*local
+	a       *analyzer
+	at      ast.Node // as for analyzer.at
+	stmtPos ast.Node // the statement being normalized, for errors
+	lines   []*nline
+	nindent int
+	blk     *nblock
+	blocks  []*nblock
+	ports   map[string]*nnode   // the node that has each port
+	portSeq map[string]int      // the order in which ports were made
+	srcs    map[string][]string // the ports connected to each conn var
+	defs    map[def][]string    // the ports holding the value of each live def
+	cells   map[*types.Var]string
+	phiIns  map[def]string // the conn var that feeds each live phi
+	phiNode map[def]*nnode
+	results []*types.Var
+	targets []ast.Stmt
+}
+
+type nline struct {
+	indent int
+	text   string
+	node   *nnode // the node whose seq comment, if any, ends the line
+}
+
+// An effect describes how a node interacts with memory, and thus how it must be sequenced with other nodes.
+type effect int
+
+const (
+	noEffect effect = iota
+	readsMemory
+	writesMemory
+)
+
+// An nnode is a node in the graph being normalized, as far as is needed to compute its seq connections.
+type nnode struct {
+	blk    *nblock
+	kind   effect
+	seq    bool // whether the node has seq ports
+	preds  map[*nnode]bool
+	seqIns []*nnode
+	seqOut bool
+	id     int
+}
+
+// An nblock is a block in the graph being normalized.  inputs stands for the nodes that supply the ports that originate in the block itself, such as the parameters of a func or the key of a loop.
+type nblock struct {
+	node   *nnode
+	nodes  []*nnode
+	inputs *nnode
+}
+
+// A value is the result of evaluating an expression.  It is held by ports (more than one if it merges several defs of a variable) or is a constant that hasn't yet been given a node, or is nil.
+// fresh reports whether the ports were made for this value, rather than holding some variable's value that may since have been reassigned.
+type value struct {
+	ports []string
+	typ   types.Type
+	fresh bool
+	c     exact.Value
+	obj   types.Object // the named constant whose value is c, if any
+	isNil bool
+}
+
+func (n *normalizer) errorf(x interface {
+	Pos() token.Pos
+}, format string, a ...interface{}) {
+	panic(normalizeError{x.Pos(), fmt.Sprintf(format, a...)})
+}
+
+// collectPkgs names the packages referred to in decl before any other names are taken.
+func (n *normalizer) collectPkgs(decl *ast.FuncDecl) {
+	ast.Inspect(decl, func(x ast.Node) bool {
+		if e, ok := x.(ast.Expr); ok {
+			n.collectType(n.info.Types[e])
+		}
+		if id, ok := x.(*ast.Ident); ok {
+			switch obj := n.info.Objects[id].(type) {
+			case *types.PkgName:
+				n.pkgName(obj.Pkg)
+			case nil:
+			default:
+				n.collectType(obj.GetType())
+			}
+		}
+		return true
+	})
+}
+
+func (n *normalizer) collectType(t types.Type) {
+	switch t := t.(type) {
+	case nil:
+	case *types.Tuple:
+		if t == nil {
+			return
+		}
+		for _, v := range *t {
+			n.w.collectPkgs(v.Type)
+		}
+	default:
+		n.w.collectPkgs(t)
+	}
+}
+
+func (n *normalizer) pkgName(p *types.Package) {
+	if _, ok := n.w.pkgNames[p]; !ok && p != n.w.pkg {
+		n.w.pkgNames[p] = n.w.name(p.Name)
+	}
+}
+
+func (n *normalizer) emit(nd *nnode, format string, a ...interface{}) {
+	n.lines = append(n.lines, &nline{n.nindent, fmt.Sprintf(format, a...), nd})
+}
+
+func (n *normalizer) newBlock(nd *nnode) *nblock {
+	b := &nblock{node: nd}
+	b.inputs = &nnode{blk: b, preds: map[*nnode]bool{}}
+	n.blocks = append(n.blocks, b)
+	return b
+}
+
+// block emits the block of the compound node nd by calling body.
+func (n *normalizer) block(nd *nnode, body func()) {
+	blk := n.blk
+	n.blk = n.newBlock(nd)
+	n.nindent++
+	body()
+	n.nindent--
+	b := n.blk
+	n.blk = blk
+	if !nd.seq { // a func literal's body is executed when it is called, not where it is defined
+		return
+	}
+	for _, c := range b.nodes {
+		if c.kind > nd.kind {
+			nd.kind = c.kind
+		}
+	}
+}
+
+// node adds a node to the current block whose inputs are fed by the conn vars ins.
+func (n *normalizer) node(kind effect, seq bool, ins ...string) *nnode {
+	nd := &nnode{blk: n.blk, kind: kind, seq: seq, preds: map[*nnode]bool{}}
+	n.blk.nodes = append(n.blk.nodes, nd)
+	for _, v := range ins {
+		for _, p := range n.srcs[v] {
+			n.depend(n.ports[p], nd, false)
+		}
+	}
+	return nd
+}
+
+// port names a new output port of nd.
+func (n *normalizer) port(hint string, nd *nnode) string {
+	p := n.w.name(hint)
+	n.ports[p] = nd
+	n.portSeq[p] = len(n.portSeq)
+	return p
+}
+
+// inputPort names a new port that originates in the current block.
+func (n *normalizer) inputPort(hint string) string {
+	return n.port(hint, n.blk.inputs)
+}
+
+// depend records that dst depends on src.  The dependency is recorded between their ancestors in the innermost block that contains both.
+// A feedback dependency runs the other way:  src depends on dst.
+func (n *normalizer) depend(src, dst *nnode, feedback bool) {
+	ancestors := map[*nblock]*nnode{}
+	for s := src; s != nil; s = s.blk.node {
+		ancestors[s.blk] = s
+	}
+	for d := dst; d != nil; d = d.blk.node {
+		if s, ok := ancestors[d.blk]; ok {
+			if s != d {
+				if feedback {
+					s.preds[d] = true
+				} else {
+					d.preds[s] = true
+				}
+			}
+			return
+		}
+	}
+}
+
+// sequence adds the seq connections needed to keep the effects in b in their original order:  each node that reads memory follows the last node that writes it, and each node that writes memory follows the last node that writes it and the nodes that read it since.
+// A connection is added only if the order doesn't already follow from the other connections.
+func sequence(b *nblock) {
+	before := map[*nnode]map[*nnode]bool{}
+	var lastWrite *nnode
+	var reads []*nnode
+	for _, nd := range b.nodes {
+		all := map[*nnode]bool{}
+		add := func(p *nnode) {
+			all[p] = true
+			for q := range before[p] {
+				all[q] = true
+			}
+		}
+		for p := range nd.preds {
+			add(p)
+		}
+		before[nd] = all
+		if !nd.seq || nd.kind == noEffect {
+			continue
+		}
+		need := []*nnode{}
+		if nd.kind == writesMemory {
+			for i := len(reads) - 1; i >= 0; i-- {
+				need = append(need, reads[i])
+			}
+		}
+		if lastWrite != nil {
+			need = append(need, lastWrite)
+		}
+		for _, p := range need {
+			if !all[p] {
+				nd.seqIns = append(nd.seqIns, p)
+				p.seqOut = true
+				add(p)
+			}
+		}
+		if nd.kind == writesMemory {
+			lastWrite, reads = nd, nil
+		} else {
+			reads = append(reads, nd)
+		}
+	}
+}
+
+func (n *normalizer) render() {
+	for _, b := range n.blocks {
+		sequence(b)
+	}
+	id := 0
+	for _, l := range n.lines {
+		n.w.write("%s%s", strings.Repeat("\t", l.indent), l.text)
+		if nd := l.node; nd != nil && (len(nd.seqIns) > 0 || nd.seqOut) {
+			ids := []string{}
+			for _, p := range nd.seqIns {
+				ids = append(ids, strconv.Itoa(p.id))
+			}
+			sort.Strings(ids)
+			n.w.write(" //%s;", strings.Join(ids, ","))
+			if nd.seqOut {
+				nd.id = id
+				id++
+				n.w.write("%d", nd.id)
+			}
+		}
+		n.w.write("\n")
+	}
+}
+
+// in returns a new conn var connected to the ports of x.
+func (n *normalizer) in(x value) string {
+	if x.c != nil {
+		x = n.constant(x, true)
+	}
+	t := x.typ
+	if b, ok := t.(*types.Basic); ok && b.Info&types.IsUntyped != 0 {
+		if b.Kind == types.UntypedNil {
+			t = types.NewInterface(nil, nil)
+		} else {
+			t = untypedToTyped(b)
+		}
+	}
+	v := n.w.name("v")
+	n.emit(nil, "var %s %s", v, n.w.typ(t))
+	for _, p := range x.ports {
+		n.emit(nil, "%s = %s", v, p)
+	}
+	n.srcs[v] = x.ports
+	return v
+}
+
+// out returns the value of the single output port p of a new node.
+func out(p string, t types.Type) value {
+	return value{ports: []string{p}, typ: t, fresh: true}
+}
+
+func isTyped(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return !ok || b.Info&types.IsUntyped == 0
+}
+
+func isPointer(t types.Type) bool {
+	_, ok := underlying(t).(*types.Pointer)
+	return ok
+}
+
+func elem(t types.Type) types.Type {
+	return underlying(t).(*types.Pointer).Elem
+}
+
+// constant makes a node for the constant x.  If convert is true, the constant is converted to x's type if it is typed; otherwise the node's output is left untyped so that an operator can give it the type of its other operand.
+func (n *normalizer) constant(x value, convert bool) value {
+	nd := n.node(noEffect, true)
+	p := n.port("x", nd)
+	var t types.Type
+	if x.obj != nil {
+		n.emit(nd, "const %s = %s", p, n.w.qualifiedName(x.obj))
+		t = x.obj.GetType()
+	} else {
+		var lit string
+		lit, t = n.literal(x)
+		n.emit(nd, "const %s = %s", p, lit)
+	}
+	c := out(p, t)
+	if !convert || !isTyped(x.typ) || types.IsIdentical(untypedToTyped(t), x.typ) {
+		return c
+	}
+	if x.c.Kind() == exact.Int {
+		if _, ok := exact.Int64Val(x.c); !ok {
+			n.errorf(n.stmtPos, "constant %s is too large to convert", x.c)
+		}
+	}
+	return n.convert(c, x.typ)
+}
+
+// literal returns the text of a basic literal for the constant x and the literal's type.
+func (n *normalizer) literal(x value) (string, types.Type) {
+	b, _ := underlying(x.typ).(*types.Basic)
+	switch x.c.Kind() {
+	case exact.Bool:
+		return x.c.String(), types.Typ[types.UntypedBool]
+	case exact.String:
+		return strconv.Quote(exact.StringVal(x.c)), types.Typ[types.UntypedString]
+	case exact.Int:
+		if b != nil && b.Info&types.IsFloat != 0 {
+			f, _ := exact.Float64Val(x.c)
+			return formatFloat(f), types.Typ[types.UntypedFloat]
+		}
+		if b != nil && b.Kind == types.UntypedRune {
+			if r, ok := exact.Int64Val(x.c); ok && r >= ' ' && r <= '~' {
+				return strconv.QuoteRune(rune(r)), types.Typ[types.UntypedRune]
+			}
+		}
+		return x.c.String(), types.Typ[types.UntypedInt]
+	case exact.Float:
+		f, _ := exact.Float64Val(x.c)
+		return formatFloat(f), types.Typ[types.UntypedFloat]
+	}
+	n.errorf(n.stmtPos, "complex constants are not supported")
+	panic("unreachable")
+}
+
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+// zero returns a new value holding the zero value of type t.
+func (n *normalizer) zero(t types.Type) value {
+	if b, ok := underlying(t).(*types.Basic); ok && b.Info&(types.IsBoolean|types.IsString|types.IsInteger|types.IsFloat) != 0 {
+		c := exact.MakeInt64(0)
+		switch {
+		case b.Info&types.IsBoolean != 0:
+			c = exact.MakeBool(false)
+		case b.Info&types.IsString != 0:
+			c = exact.MakeString("")
+		}
+		return n.constant(value{typ: t, c: c}, true)
+	}
+	nd := n.node(noEffect, false)
+	p := n.port("x", nd)
+	n.emit(nd, "%s := new(%s)", p, n.w.typ(t))
+	return n.deref(out(p, types.NewPointer(t)), noEffect)
+}
+
+func (n *normalizer) deref(x value, kind effect) value {
+	v := n.in(x)
+	nd := n.node(kind, true, v)
+	p := n.port("x", nd)
+	n.emit(nd, "%s := *%s", p, v)
+	return out(p, elem(x.typ))
+}
+
+func (n *normalizer) convert(x value, t types.Type) value {
+	if x.c != nil {
+		x.typ = t
+		return n.constant(x, true)
+	}
+	if x.isNil || x.ports == nil {
+		return n.zero(t)
+	}
+	v := n.in(x)
+	nd := n.node(noEffect, false, v)
+	p := n.port("x", nd)
+	n.emit(nd, "%s := (%s)(%s)", p, n.w.typ(t), v)
+	return out(p, t)
+}
+
+// toInt converts an index or size to an int, which is what index, slice, and make nodes take.
+func (n *normalizer) toInt(x value) value {
+	t := types.Typ[types.Int]
+	if x.c != nil {
+		x.typ = t
+		return x
+	}
+	if !types.IsIdentical(x.typ, t) {
+		return n.convert(x, t)
+	}
+	return x
+}
+
+// valueFor returns the ports that are to hold x as a value of type t.  alias reports whether the ports of x may be reused even if they aren't fresh, which is so if they are the only ports that will hold the value.
+func (n *normalizer) valueFor(x value, t types.Type, alias bool) []string {
+	if x.c != nil {
+		x = n.constant(n.assignable(x, t), true)
+	}
+	switch {
+	case x.isNil || x.ports == nil:
+		return n.zero(t).ports
+	case isTyped(x.typ) && !types.IsIdentical(x.typ, t):
+		return n.convert(x, t).ports
+	case x.fresh || alias:
+		return x.ports
+	}
+	return n.convert(x, t).ports
+}
+
+// define makes x the value of the def d of a variable that flows along connections.
+func (n *normalizer) define(d def, x value) {
+	if l := n.locals[d.v]; l != nil && n.a.live[d] {
+		n.defs[d] = n.valueFor(x, d.v.Type, l.defs == 1)
+	}
+}
+
+// reachPorts returns the ports holding the defs in r.
+func (n *normalizer) reachPorts(r reach) []string {
+	seen := map[string]bool{}
+	ports := []string{}
+	for d := range r {
+		for _, p := range n.defs[d] {
+			if !seen[p] {
+				seen[p] = true
+				ports = append(ports, p)
+			}
+		}
+	}
+	sort.Sort(portsBySeq{ports, n.portSeq})
+	return ports
+}
+
+type portsBySeq struct {
+	ports []string
+	seq   map[string]int
+}
+
+func (p portsBySeq) Len() int           { return len(p.ports) }
+func (p portsBySeq) Less(i, j int) bool { return p.seq[p.ports[i]] < p.seq[p.ports[j]] }
+func (p portsBySeq) Swap(i, j int)      { p.ports[i], p.ports[j] = p.ports[j], p.ports[i] }
+
+// use returns the value of the local variable that flows along connections at id.
+func (n *normalizer) use(id *ast.Ident) value {
+	v := n.info.Objects[id].(*types.Var)
+	ports := n.reachPorts(n.a.uses[use{id, n.at}])
+	if len(ports) == 0 {
+		return n.zero(v.Type)
+	}
+	return value{ports: ports, typ: v.Type}
+}
+
+func (n *normalizer) newCell(v *types.Var) {
+	nd := n.node(noEffect, false)
+	p := n.port(v.Name+"P", nd)
+	n.emit(nd, "%s := new(%s)", p, n.w.typ(v.Type))
+	n.cells[v] = p
+}
+
+func (n *normalizer) cell(v *types.Var) value {
+	return out(n.cells[v], types.NewPointer(v.Type))
+}
+
+// declare declares the variable v with the value x, which is nil if v has its zero value.
+func (n *normalizer) declare(d def, x value) {
+	l := n.locals[d.v]
+	if l == nil {
+		return
+	}
+	if l.cell() {
+		n.newCell(d.v)
+		if !x.isNil {
+			n.storeTo(lvalue{kind: lvStar, x: n.cell(d.v), typ: d.v.Type}, x)
+		}
+		return
+	}
+	n.define(d, x)
+}
+
+func (n *normalizer) funcDecl(decl *ast.FuncDecl) {
+	names := map[*types.Var]string{}
+	recv := ""
+	if decl.Recv != nil {
+		v := fieldVars(n.info, decl.Recv)[0]
+		names[v] = n.w.name(v.Name)
+		recv = fmt.Sprintf("(%s %s) ", names[v], n.w.typ(v.Type))
+	}
+	n.emit(nil, "func %s%s%s {", recv, decl.Name.Name, n.signature(decl.Type, names))
+	n.nindent++
+	n.funcBody(fieldVars(n.info, decl.Recv, decl.Type.Params), fieldVars(n.info, decl.Type.Results), decl.Type, names, decl.Body)
+	n.nindent--
+	n.emit(nil, "}")
+}
+
+// signature returns the text of the signature of a func of type typ, naming its parameters and results in names.
+func (n *normalizer) signature(typ *ast.FuncType, names map[*types.Var]string) string {
+	list := func(vars []*types.Var, variadic bool) string {
+		s := []string{}
+		for i, v := range vars {
+			if _, ok := names[v]; !ok {
+				names[v] = n.w.name(v.Name)
+			}
+			t := n.w.typ(v.Type)
+			if variadic && i == len(vars)-1 {
+				t = "..." + n.w.typ(v.Type.(*types.Slice).Elem)
+			}
+			s = append(s, names[v]+" "+t)
+		}
+		return "(" + strings.Join(s, ", ") + ")"
+	}
+	variadic := false
+	if l := typ.Params.List; len(l) > 0 {
+		_, variadic = l[len(l)-1].Type.(*ast.Ellipsis)
+	}
+	s := list(fieldVars(n.info, typ.Params), variadic)
+	if results := fieldVars(n.info, typ.Results); len(results) > 0 {
+		s += " " + list(results, false)
+	}
+	return s
+}
+
+func (n *normalizer) funcBody(params, results []*types.Var, typ *ast.FuncType, names map[*types.Var]string, body *ast.BlockStmt) {
+	savedResults, savedTargets := n.results, n.targets
+	n.results, n.targets = results, nil
+	for _, v := range params {
+		p := names[v]
+		n.ports[p] = n.blk.inputs
+		n.portSeq[p] = len(n.portSeq)
+		n.declare(def{typ, nil, v}, out(p, v.Type))
+	}
+	for _, v := range results {
+		if l := n.locals[v]; l != nil && l.cell() {
+			n.errorf(v, "the address of result %s can't be taken", v.Name)
+		}
+		n.define(def{typ, nil, v}, value{typ: v.Type, isNil: true})
+	}
+	list := body.List
+	for i, s := range list {
+		if r, ok := s.(*ast.ReturnStmt); ok && i == len(list)-1 {
+			n.returnStmt(r, true)
+			break
+		}
+		n.stmt(s)
+		if terminates(s) {
+			break
+		}
+	}
+	for _, v := range results {
+		for _, p := range n.reachPorts(n.a.returns[v]) {
+			n.emit(nil, "%s = %s", names[v], p)
+		}
+	}
+	n.emit(nil, "return")
+	n.results, n.targets = savedResults, savedTargets
+}
+
+func terminates(s ast.Stmt) bool {
+	switch s.(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	}
+	return false
+}
+
+func (n *normalizer) stmts(list []ast.Stmt) {
+	for _, s := range list {
+		n.stmt(s)
+		if terminates(s) {
+			return
+		}
+	}
+}
+
+func (n *normalizer) stmt(s ast.Stmt) {
+	if s != nil {
+		n.stmtPos = s
+	}
+	switch s := s.(type) {
+	case nil, *ast.EmptyStmt:
+	case *ast.ExprStmt:
+		switch x := unparen(s.X).(type) {
+		case *ast.CallExpr:
+			n.call(x, "")
+		case *ast.UnaryExpr:
+			v := n.in(n.expr(x.X))
+			nd := n.node(writesMemory, true, v)
+			n.emit(nd, "<-%s", v)
+		default:
+			n.errorf(s, "unsupported statement")
+		}
+	case *ast.SendStmt:
+		ch := n.expr(s.Chan)
+		x := n.expr(s.Value)
+		c, v := n.in(ch), n.in(n.assignable(x, underlying(ch.typ).(*types.Chan).Elem))
+		nd := n.node(writesMemory, true, c, v)
+		n.emit(nd, "%s <- %s", c, v)
+	case *ast.IncDecStmt:
+		op := token.ADD
+		if s.Tok == token.DEC {
+			op = token.SUB
+		}
+		n.opAssign(s.X, op, func() value { return value{typ: n.info.Types[s.X], c: exact.MakeInt64(1)} })
+	case *ast.AssignStmt:
+		n.assign(s)
+	case *ast.GoStmt:
+		n.call(s.Call, "go ")
+	case *ast.DeferStmt:
+		n.call(s.Call, "defer ")
+	case *ast.DeclStmt:
+		n.declStmt(s)
+	case *ast.ReturnStmt:
+		n.returnStmt(s, false)
+	case *ast.BranchStmt:
+		n.branchStmt(s)
+	case *ast.BlockStmt:
+		n.stmts(s.List)
+	case *ast.IfStmt:
+		n.ifStmt(s)
+	case *ast.SwitchStmt:
+		n.switchStmt(s)
+	case *ast.TypeSwitchStmt:
+		n.typeSwitchStmt(s)
+	case *ast.SelectStmt:
+		n.selectStmt(s)
+	case *ast.ForStmt:
+		n.forStmt(s)
+	case *ast.RangeStmt:
+		n.rangeStmt(s)
+	case *ast.LabeledStmt:
+		n.errorf(s, "labels are not supported")
+	default:
+		n.errorf(s, "unsupported statement")
+	}
+}
+
+type lvalueKind int
+
+const (
+	lvBlank lvalueKind = iota
+	lvLocal
+	lvStar
+	lvPkgVar
+	lvField
+	lvIndex
+)
+
+// An lvalue is the evaluated left hand side of an assignment.
+type lvalue struct {
+	kind lvalueKind
+	id   *ast.Ident // for lvLocal
+	v    *types.Var // for lvLocal and lvPkgVar
+	x    value      // the pointer, struct pointer, slice, map, or array pointer that is assigned through
+	key  value      // for lvIndex
+	name string     // the field name for lvField
+	typ  types.Type // the type of the assigned variable
+}
+
+// lvalue evaluates the operands of the assignable expression e.  If define is true, e may declare a new variable.
+func (n *normalizer) lvalue(e ast.Expr, define bool) lvalue {
+	t := n.info.Types[e]
+	switch e := e.(type) {
+	case *ast.Ident:
+		v, ok := n.info.Objects[e].(*types.Var)
+		if !ok || e.Name == "_" {
+			return lvalue{kind: lvBlank}
+		}
+		l := n.locals[v]
+		if l == nil {
+			return lvalue{kind: lvPkgVar, v: v, typ: v.Type}
+		}
+		if l.cell() {
+			if define && v.Pos() == e.Pos() {
+				n.newCell(v)
+			}
+			return lvalue{kind: lvStar, x: n.cell(v), typ: v.Type}
+		}
+		return lvalue{kind: lvLocal, id: e, v: v, typ: v.Type}
+	case *ast.ParenExpr:
+		return n.lvalue(e.X, false)
+	case *ast.SelectorExpr:
+		if v, ok := n.pkgObj(e).(*types.Var); ok {
+			return lvalue{kind: lvPkgVar, v: v, typ: v.Type}
+		}
+		return lvalue{kind: lvField, x: n.fieldBase(e), name: e.Sel.Name, typ: t}
+	case *ast.IndexExpr:
+		switch underlying(n.info.Types[e.X]).(type) {
+		case *types.Map:
+			x := n.expr(e.X)
+			return lvalue{kind: lvIndex, x: x, key: n.expr(e.Index), typ: t}
+		case *types.Array:
+			x := n.addr(e.X)
+			return lvalue{kind: lvIndex, x: x, key: n.toInt(n.expr(e.Index)), typ: t}
+		}
+		x := n.expr(e.X)
+		return lvalue{kind: lvIndex, x: x, key: n.toInt(n.expr(e.Index)), typ: t}
+	case *ast.StarExpr:
+		return lvalue{kind: lvStar, x: n.expr(e.X), typ: t}
+	}
+	n.errorf(e, "unsupported assignment")
+	panic("unreachable")
+}
+
+// load returns the current value of lv, which was evaluated from e.
+func (n *normalizer) load(lv lvalue, e ast.Expr) value {
+	switch lv.kind {
+	case lvLocal:
+		return n.use(lv.id)
+	case lvStar:
+		return n.deref(lv.x, readsMemory)
+	case lvPkgVar:
+		return n.deref(n.pkgVarAddr(lv.v), readsMemory)
+	case lvField:
+		return n.deref(n.field(lv.x, lv.name), readsMemory)
+	case lvIndex:
+		if _, ok := underlying(lv.x.typ).(*types.Map); ok {
+			return n.mapIndex(lv.x, lv.key)[0]
+		}
+		return n.deref(n.indexAddr(lv.x, lv.key), readsMemory)
+	}
+	n.errorf(e, "unsupported assignment")
+	panic("unreachable")
+}
+
+// assignable prepares x to be stored in a variable of type t:  an untyped constant takes the type t, unless t is an interface, in which case the constant keeps its default type.
+func (n *normalizer) assignable(x value, t types.Type) value {
+	if _, ok := underlying(t).(*types.Interface); x.c != nil && !ok {
+		x.typ = t
+	}
+	return x
+}
+
+func (n *normalizer) storeTo(lv lvalue, x value) {
+	switch lv.kind {
+	case lvBlank:
+	case lvLocal:
+		n.define(def{lv.id, n.at, lv.v}, x)
+	case lvStar:
+		p, v := n.in(lv.x), n.in(n.assignable(x, lv.typ))
+		nd := n.node(writesMemory, true, p, v)
+		n.emit(nd, "*%s = %s", p, v)
+	case lvPkgVar:
+		v := n.in(n.assignable(x, lv.typ))
+		nd := n.node(writesMemory, true, v)
+		n.emit(nd, "%s = %s", n.w.qualifiedName(lv.v), v)
+	case lvField:
+		p, v := n.in(lv.x), n.in(n.assignable(x, lv.typ))
+		nd := n.node(writesMemory, true, p, v)
+		n.emit(nd, "%s.%s = %s", p, lv.name, v)
+	case lvIndex:
+		p, k, v := n.in(lv.x), n.in(lv.key), n.in(n.assignable(x, lv.typ))
+		nd := n.node(writesMemory, true, p, k, v)
+		n.emit(nd, "%s[%s] = %s", p, k, v)
+	}
+}
+
+func (n *normalizer) assign(s *ast.AssignStmt) {
+	if s.Tok != token.ASSIGN && s.Tok != token.DEFINE {
+		op := map[token.Token]token.Token{
+			token.ADD_ASSIGN: token.ADD, token.SUB_ASSIGN: token.SUB, token.MUL_ASSIGN: token.MUL, token.QUO_ASSIGN: token.QUO, token.REM_ASSIGN: token.REM,
+			token.AND_ASSIGN: token.AND, token.OR_ASSIGN: token.OR, token.XOR_ASSIGN: token.XOR, token.SHL_ASSIGN: token.SHL, token.SHR_ASSIGN: token.SHR, token.AND_NOT_ASSIGN: token.AND_NOT,
+		}[s.Tok]
+		n.opAssign(s.Lhs[0], op, func() value { return n.expr(s.Rhs[0]) })
+		return
+	}
+	lvs := []lvalue{}
+	for _, e := range s.Lhs {
+		lvs = append(lvs, n.lvalue(e, s.Tok == token.DEFINE))
+	}
+	vals := n.exprs(s.Rhs, len(s.Lhs))
+	for i, lv := range lvs {
+		n.storeTo(lv, vals[i])
+	}
+}
+
+// opAssign assigns the result of applying op to the value of lhs and the value returned by rhs.
+func (n *normalizer) opAssign(lhs ast.Expr, op token.Token, rhs func() value) {
+	lv := n.lvalue(lhs, false)
+	y := rhs()
+	x := n.load(lv, lhs)
+	n.storeTo(lv, n.binaryOp(op, x, y, n.info.Types[lhs]))
+}
+
+// exprs evaluates the expressions in list, which yield count values.
+func (n *normalizer) exprs(list []ast.Expr, count int) []value {
+	if len(list) == 1 && count > 1 {
+		return n.multi(list[0])
+	}
+	vals := []value{}
+	for _, e := range list {
+		vals = append(vals, n.expr(e))
+	}
+	return vals
+}
+
+func (n *normalizer) declStmt(s *ast.DeclStmt) {
+	d := s.Decl.(*ast.GenDecl)
+	switch d.Tok {
+	case token.CONST: // constants are substituted where they are used
+	case token.VAR:
+		for _, spec := range d.Specs {
+			spec := spec.(*ast.ValueSpec)
+			var vals []value
+			if spec.Values != nil {
+				vals = n.exprs(spec.Values, len(spec.Names))
+			}
+			for i, id := range spec.Names {
+				v, ok := n.info.Objects[id].(*types.Var)
+				if !ok || id.Name == "_" {
+					continue
+				}
+				x := value{typ: v.Type, isNil: true}
+				if vals != nil {
+					x = vals[i]
+				}
+				n.declare(def{id, nil, v}, x)
+			}
+		}
+	default:
+		n.errorf(s, "local type declarations are not supported")
+	}
+}
+
+func (n *normalizer) returnStmt(s *ast.ReturnStmt, final bool) {
+	if len(s.Results) > 0 {
+		vals := n.exprs(s.Results, len(n.results))
+		for i, v := range n.results {
+			if d := (def{s, nil, v}); n.a.live[d] {
+				n.defs[d] = n.valueFor(vals[i], v.Type, false)
+			}
+		}
+	}
+	if !final {
+		nd := n.node(writesMemory, true)
+		n.emit(nd, "return")
+	}
+}
+
+func (n *normalizer) branchStmt(s *ast.BranchStmt) {
+	if s.Label != nil {
+		n.errorf(s, "labels are not supported")
+	}
+	switch s.Tok {
+	case token.BREAK:
+		if len(n.targets) > 0 {
+			switch n.targets[len(n.targets)-1].(type) {
+			case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+				n.errorf(s, "break is only supported at the end of a switch or select case")
+			}
+		}
+	case token.CONTINUE:
+		if f, ok := n.innermostLoop().(*ast.ForStmt); ok && f.Post != nil && !n.isCountLoop(f) {
+			at := n.at
+			n.at = s
+			n.stmt(f.Post)
+			n.at = at
+		}
+	default:
+		n.errorf(s, "%s is not supported", s.Tok)
+	}
+	n.branch(s.Tok.String())
+}
+
+func (n *normalizer) innermostLoop() ast.Stmt {
+	for i := len(n.targets) - 1; i >= 0; i-- {
+		switch t := n.targets[i].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return t
+		}
+	}
+	return nil
+}
+
+func (n *normalizer) branch(tok string) {
+	nd := n.node(writesMemory, true)
+	n.emit(nd, "%s", tok)
+}
+
+func (n *normalizer) isCountLoop(s *ast.ForStmt) bool {
+	_, _, ok := countLoop(n.info, n.locals, s)
+	return ok
+}
+
+// caseBody returns the statements of a switch or select case without a trailing break, which is implicit.
+func caseBody(list []ast.Stmt) []ast.Stmt {
+	if len(list) > 0 {
+		if b, ok := list[len(list)-1].(*ast.BranchStmt); ok && b.Tok == token.BREAK && b.Label == nil {
+			return list[:len(list)-1]
+		}
+	}
+	return list
+}
+
+// ifNode emits an if node.  bodies emit its blocks, one per condition (a conn var) plus an optional else block.
+func (n *normalizer) ifNode(conds []string, bodies []func()) {
+	nd := n.node(noEffect, true, conds...)
+	for i, body := range bodies {
+		head := ""
+		if i > 0 {
+			head = "} else "
+		}
+		if i < len(conds) {
+			head += "if " + conds[i] + " "
+		}
+		n.emit(nil, "%s{", head)
+		n.block(nd, body)
+	}
+	n.emit(nd, "}")
+}
+
+// A branch is one condition and body of an if-else chain.  pure reports whether cond may be evaluated before the preceding conditions.
+type branch struct {
+	cond func() value
+	pure bool
+	body func()
+}
+
+// ifChain emits an if-else chain.  The first condition and any pure conditions following it share one if node; the rest of the chain is nested in its else block.
+func (n *normalizer) ifChain(branches []branch, els func()) {
+	conds := []string{n.in(branches[0].cond())}
+	i := 1
+	for ; i < len(branches) && branches[i].pure; i++ {
+		conds = append(conds, n.in(branches[i].cond()))
+	}
+	bodies := []func(){}
+	for _, b := range branches[:i] {
+		bodies = append(bodies, b.body)
+	}
+	if rest := branches[i:]; len(rest) > 0 {
+		bodies = append(bodies, func() { n.ifChain(rest, els) })
+	} else if els != nil {
+		bodies = append(bodies, els)
+	}
+	n.ifNode(conds, bodies)
+}
+
+func (n *normalizer) ifStmt(s *ast.IfStmt) {
+	n.stmt(s.Init)
+	var branches []branch
+	var els func()
+	for x := s; x != nil; {
+		x2 := x
+		branches = append(branches, branch{func() value { return n.expr(x2.Cond) }, pure(n.info, x2.Cond), func() { n.stmts(x2.Body.List) }})
+		x = nil
+		switch e := x2.Else.(type) {
+		case *ast.IfStmt:
+			if e.Init == nil {
+				x = e
+			} else {
+				els = func() { n.ifStmt(e) }
+			}
+		case *ast.BlockStmt:
+			els = func() { n.stmts(e.List) }
+		}
+	}
+	n.ifChain(branches, els)
+}
+
+func (n *normalizer) switchStmt(s *ast.SwitchStmt) {
+	n.stmt(s.Init)
+	var tag value
+	if s.Tag != nil {
+		tag = n.expr(s.Tag)
+		if tag.c != nil {
+			tag = n.constant(tag, true)
+		}
+	}
+	n.targets = append(n.targets, s)
+	var branches []branch
+	var els func()
+	for _, cc := range s.Body.List {
+		cc := cc.(*ast.CaseClause)
+		body := func() { n.stmts(caseBody(cc.Body)) }
+		if cc.List == nil {
+			els = body
+			continue
+		}
+		p := true
+		for i, e := range cc.List {
+			if !pure(n.info, e) {
+				if i > 0 {
+					n.errorf(e, "case expressions with side effects are only supported first in a case")
+				}
+				p = false
+			}
+		}
+		branches = append(branches, branch{func() value {
+			var c value
+			for i, e := range cc.List {
+				x := n.expr(e)
+				if s.Tag != nil {
+					x = n.binaryOp(token.EQL, tag, x, types.Typ[types.UntypedBool])
+				}
+				if i == 0 {
+					c = x
+				} else {
+					c = n.operator("||", types.Typ[types.UntypedBool], c, x)
+				}
+			}
+			return c
+		}, p, body})
+	}
+	if len(branches) > 0 {
+		n.ifChain(branches, els)
+	} else if els != nil {
+		els()
+	}
+	n.targets = n.targets[:len(n.targets)-1]
+}
+
+func (n *normalizer) typeSwitchStmt(s *ast.TypeSwitchStmt) {
+	n.stmt(s.Init)
+	x := n.expr(typeSwitchExpr(s))
+	n.targets = append(n.targets, s)
+	var branches []branch
+	var els func()
+	for _, cc := range s.Body.List {
+		cc := cc.(*ast.CaseClause)
+		v, _ := n.info.Implicits[cc].(*types.Var)
+		var val value
+		body := func() {
+			if v != nil {
+				if val.ports == nil {
+					val = x
+				}
+				n.declare(def{cc, nil, v}, val)
+			}
+			n.stmts(caseBody(cc.Body))
+		}
+		if cc.List == nil {
+			els = body
+			continue
+		}
+		branches = append(branches, branch{func() value {
+			var c value
+			for i, e := range cc.List {
+				var ok value
+				if b, isNil := n.info.Types[e].(*types.Basic); isNil && b.Kind == types.UntypedNil {
+					ok = n.operator("==", types.Typ[types.UntypedBool], x, value{typ: x.typ, isNil: true})
+				} else {
+					var y value
+					y, ok = n.typeAssert(x, n.info.Types[e])
+					if len(cc.List) == 1 {
+						val = y
+					}
+				}
+				if i == 0 {
+					c = ok
+				} else {
+					c = n.operator("||", types.Typ[types.UntypedBool], c, ok)
+				}
+			}
+			return c
+		}, true, body})
+	}
+	if len(branches) > 0 {
+		n.ifChain(branches, els)
+	} else if els != nil {
+		els()
+	}
+	n.targets = n.targets[:len(n.targets)-1]
+}
+
+func (n *normalizer) selectStmt(s *ast.SelectStmt) {
+	heads := []string{}
+	ins := []string{}
+	for _, cc := range s.Body.List {
+		switch c := cc.(*ast.CommClause).Comm.(type) {
+		case nil:
+			heads = append(heads, "default:")
+		case *ast.SendStmt:
+			ch := n.expr(c.Chan)
+			x := n.expr(c.Value)
+			v, e := n.in(ch), n.in(n.assignable(x, underlying(ch.typ).(*types.Chan).Elem))
+			heads = append(heads, fmt.Sprintf("case %s <- %s:", v, e))
+			ins = append(ins, v, e)
+		case *ast.ExprStmt:
+			v := n.in(n.expr(unparen(c.X).(*ast.UnaryExpr).X))
+			heads = append(heads, fmt.Sprintf("case <-%s:", v))
+			ins = append(ins, v)
+		case *ast.AssignStmt:
+			v := n.in(n.expr(unparen(c.Rhs[0]).(*ast.UnaryExpr).X))
+			heads = append(heads, fmt.Sprintf("case %%s, %%s := <-%s:", v))
+			ins = append(ins, v)
+		}
+	}
+	nd := n.node(writesMemory, true, ins...)
+	n.emit(nil, "select {")
+	n.targets = append(n.targets, s)
+	for i, cc := range s.Body.List {
+		cc := cc.(*ast.CommClause)
+		c, recv := cc.Comm.(*ast.AssignStmt)
+		var elem, ok string
+		head := heads[i]
+		if recv {
+			elem, ok = n.w.name("x"), n.w.name("ok")
+			head = fmt.Sprintf(head, elem, ok)
+		}
+		n.emit(nil, "%s", head)
+		n.block(nd, func() {
+			if recv {
+				n.ports[elem], n.ports[ok] = n.blk.inputs, n.blk.inputs
+				n.portSeq[elem], n.portSeq[ok] = len(n.portSeq), len(n.portSeq)+1
+				ch := n.info.Types[unparen(c.Rhs[0]).(*ast.UnaryExpr).X]
+				vals := []value{out(elem, underlying(ch).(*types.Chan).Elem), out(ok, types.Typ[types.Bool])}
+				n.storeRecv(c.Lhs, c.Tok == token.DEFINE, vals)
+			}
+			n.stmts(caseBody(cc.Body))
+		})
+	}
+	n.targets = n.targets[:len(n.targets)-1]
+	n.emit(nd, "}")
+}
+
+// storeRecv assigns the values received by a select case or produced by a range clause to lhs.
+func (n *normalizer) storeRecv(lhs []ast.Expr, define bool, vals []value) {
+	for i, e := range lhs {
+		if e == nil {
+			continue
+		}
+		if id, ok := e.(*ast.Ident); ok {
+			if v, ok := n.info.Objects[id].(*types.Var); ok && id.Name != "_" {
+				if l := n.locals[v]; l != nil && !l.cell() {
+					n.define(def{id, nil, v}, vals[i])
+					continue
+				}
+			}
+		}
+		n.storeTo(n.lvalue(e, define), vals[i])
+	}
+}
+
+// phisBefore connects the values that reach the loop x to the conn vars that feed its live phis.
+func (n *normalizer) phisBefore(x ast.Stmt) {
+	for _, v := range n.a.loopPhis[x] {
+		d := def{x, nil, v}
+		if !n.a.live[d] {
+			continue
+		}
+		pre := value{ports: n.reachPorts(n.a.phis[d].pre), typ: v.Type}
+		if pre.ports == nil {
+			pre = n.zero(v.Type)
+		}
+		n.phiIns[d] = n.in(pre)
+	}
+}
+
+// phisTop defines the live phis of the loop x at the start of each iteration.
+func (n *normalizer) phisTop(x ast.Stmt) {
+	for _, v := range n.a.loopPhis[x] {
+		d := def{x, nil, v}
+		if !n.a.live[d] {
+			continue
+		}
+		in := n.phiIns[d]
+		nd := n.node(noEffect, false, in)
+		p := n.port(v.Name, nd)
+		n.emit(nd, "%s := (%s)(%s)", p, n.w.typ(v.Type), in)
+		n.defs[d] = []string{p}
+		n.phiNode[d] = nd
+	}
+}
+
+// phisBack feeds the values that reach the end of an iteration of the loop x back to its live phis.
+func (n *normalizer) phisBack(x ast.Stmt) {
+	for _, v := range n.a.loopPhis[x] {
+		d := def{x, nil, v}
+		if !n.a.live[d] {
+			continue
+		}
+		for _, p := range n.reachPorts(n.a.phis[d].back) {
+			n.emit(nil, "%s = %s", n.phiIns[d], p)
+			n.depend(n.ports[p], n.phiNode[d], true)
+		}
+	}
+}
+
+func (n *normalizer) forStmt(s *ast.ForStmt) {
+	if i, lim, ok := countLoop(n.info, n.locals, s); ok {
+		v := n.info.Objects[i].(*types.Var)
+		in := n.in(n.assignable(n.expr(lim), v.Type))
+		n.phisBefore(s)
+		nd := n.node(noEffect, true, in)
+		key := n.w.name(i.Name)
+		n.emit(nil, "for %s := %s(0); %s < %s; %s++ {", key, n.w.typ(v.Type), key, in, key)
+		n.targets = append(n.targets, s)
+		n.block(nd, func() {
+			n.ports[key] = n.blk.inputs
+			n.portSeq[key] = len(n.portSeq)
+			n.phisTop(s)
+			n.define(def{i, nil, v}, out(key, v.Type))
+			n.stmts(s.Body.List)
+			n.phisBack(s)
+		})
+		n.targets = n.targets[:len(n.targets)-1]
+		n.emit(nd, "}")
+		return
+	}
+
+	n.stmt(s.Init)
+	n.phisBefore(s)
+	nd := n.node(noEffect, true)
+	n.emit(nil, "for {")
+	n.targets = append(n.targets, s)
+	n.block(nd, func() {
+		n.phisTop(s)
+		if s.Cond != nil {
+			c := n.in(n.expr(s.Cond))
+			n.ifNode([]string{c}, []func(){func() {}, func() { n.branch("break") }})
+		}
+		n.stmts(s.Body.List)
+		if s.Post != nil && !endsWithBranch(s.Body.List) {
+			at := n.at
+			n.at = s
+			n.stmt(s.Post)
+			n.at = at
+		}
+		n.phisBack(s)
+	})
+	n.targets = n.targets[:len(n.targets)-1]
+	n.emit(nd, "}")
+}
+
+func endsWithBranch(list []ast.Stmt) bool {
+	for _, s := range list {
+		if terminates(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *normalizer) rangeStmt(s *ast.RangeStmt) {
+	t := n.info.Types[s.X]
+	x := n.expr(s.X)
+	if b, ok := underlying(t).(*types.Basic); ok && b.Info&types.IsString != 0 {
+		n.errorf(s, "ranging over a string is not supported")
+	}
+	in := n.in(x)
+	n.phisBefore(s)
+	nd := n.node(noEffect, true, in)
+	keyHint, elemHint := "k", "e"
+	if id, ok := s.Key.(*ast.Ident); ok && id.Name != "_" {
+		keyHint = id.Name
+	}
+	if id, ok := s.Value.(*ast.Ident); ok && id.Name != "_" {
+		elemHint = id.Name
+	}
+	key := n.w.name(keyHint)
+	wantElem := s.Value != nil && !isBlank(s.Value)
+	elem := ""
+	if wantElem {
+		elem = n.w.name(elemHint)
+	}
+	var keyType, elemType types.Type
+	head := fmt.Sprintf("%s := range %s", key, in)
+	var elemLine string
+	switch u := underlying(t).(type) {
+	case *types.Slice:
+		keyType, elemType = types.Typ[types.Int], u.Elem
+		elemLine = fmt.Sprintf("var %s = &%s[%s]", elem, in, key)
+	case *types.Pointer:
+		keyType, elemType = types.Typ[types.Int], underlying(u.Elem).(*types.Array).Elem
+		elemLine = fmt.Sprintf("var %s = &%s[%s]", elem, in, key)
+	case *types.Array:
+		keyType, elemType = types.Typ[types.Int], u.Elem
+		elemLine = fmt.Sprintf("var %s = %s[%s]", elem, in, key)
+	case *types.Map:
+		keyType, elemType = u.Key, u.Elem
+		if wantElem {
+			head = fmt.Sprintf("%s, %s := range %s", key, elem, in)
+		}
+	case *types.Chan:
+		keyType = u.Elem
+	}
+	n.emit(nil, "for %s {", head)
+	n.targets = append(n.targets, s)
+	n.block(nd, func() {
+		n.ports[key] = n.blk.inputs
+		n.portSeq[key] = len(n.portSeq)
+		vals := []value{out(key, keyType), {}}
+		if wantElem {
+			n.ports[elem] = n.blk.inputs
+			n.portSeq[elem] = len(n.portSeq)
+			vals[1] = out(elem, elemType)
+			if elemLine != "" {
+				n.emit(nil, "%s", elemLine)
+			}
+		}
+		n.phisTop(s)
+		switch underlying(t).(type) {
+		case *types.Slice, *types.Pointer:
+			if wantElem {
+				vals[1] = n.deref(out(elem, types.NewPointer(elemType)), readsMemory)
+			}
+		}
+		n.storeRecv([]ast.Expr{s.Key, s.Value}, s.Tok == token.DEFINE, vals)
+		n.stmts(s.Body.List)
+		n.phisBack(s)
+	})
+	n.targets = n.targets[:len(n.targets)-1]
+	n.emit(nd, "}")
+}
+
+func isBlank(x ast.Expr) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == "_"
+}
+
+func unparen(x ast.Expr) ast.Expr {
+	if p, ok := x.(*ast.ParenExpr); ok {
+		return unparen(p.X)
+	}
+	return x
+}
+
+// pkgObj returns the package-level object denoted by the qualified identifier x, if it is one.
+func (n *normalizer) pkgObj(x *ast.SelectorExpr) types.Object {
+	if id, ok := x.X.(*ast.Ident); ok {
+		if _, ok := n.info.Objects[id].(*types.PkgName); ok {
+			return n.info.Objects[x.Sel]
+		}
+	}
+	return nil
+}
+
+// constObj returns the named constant denoted by x, if it is one that a value node can refer to.
+func (n *normalizer) constObj(x ast.Expr) types.Object {
+	var obj types.Object
+	switch x := x.(type) {
+	case *ast.Ident:
+		obj = n.info.Objects[x]
+	case *ast.SelectorExpr:
+		obj = n.pkgObj(x)
+	}
+	c, ok := obj.(*types.Const)
+	if !ok || c.Pkg != nil && c.Pkg.Scope().Lookup(c.Name) != c {
+		return nil
+	}
+	return c
+}
+
+func (n *normalizer) expr(x ast.Expr) value {
+	t := n.info.Types[x]
+	if c := n.info.Values[x]; c != nil {
+		return value{typ: t, c: c, obj: n.constObj(x)}
+	}
+	switch x := x.(type) {
+	case *ast.Ident:
+		switch obj := n.info.Objects[x].(type) {
+		case *types.Nil:
+			return value{typ: t, isNil: true}
+		case *types.Var:
+			if l := n.locals[obj]; l != nil {
+				if l.cell() {
+					return n.deref(n.cell(obj), readsMemory)
+				}
+				return n.use(x)
+			}
+			return n.deref(n.pkgVarAddr(obj), readsMemory)
+		case *types.Func:
+			return n.funcValue(obj, t)
+		}
+	case *ast.ParenExpr:
+		return n.expr(x.X)
+	case *ast.SelectorExpr:
+		switch obj := n.pkgObj(x).(type) {
+		case *types.Var:
+			return n.deref(n.pkgVarAddr(obj), readsMemory)
+		case *types.Func:
+			return n.funcValue(obj, t)
+		}
+		sel := n.info.Selections[x]
+		switch sel.Kind {
+		case types.FieldVal:
+			if sel.Indirect || n.addressable(x.X) {
+				return n.deref(n.field(n.fieldBase(x), x.Sel.Name), readsMemory)
+			}
+			return n.field(n.expr(x.X), x.Sel.Name)
+		case types.MethodVal:
+			v := n.in(n.recv(x, sel))
+			nd := n.node(noEffect, true, v)
+			p := n.port(x.Sel.Name, nd)
+			n.emit(nd, "%s := %s.%s", p, v, x.Sel.Name)
+			return out(p, t)
+		}
+		n.errorf(x, "method expressions are not supported")
+	case *ast.StarExpr:
+		return n.deref(n.expr(x.X), readsMemory)
+	case *ast.UnaryExpr:
+		return n.unary(x, t)
+	case *ast.BinaryExpr:
+		if x.Op == token.LAND || x.Op == token.LOR {
+			return n.logical(x, t)
+		}
+		l := n.expr(x.X)
+		r := n.expr(x.Y)
+		return n.binaryOp(x.Op, l, r, t)
+	case *ast.CallExpr:
+		vals := n.call(x, "")
+		if len(vals) == 0 {
+			n.errorf(x, "call has no value")
+		}
+		return vals[0]
+	case *ast.IndexExpr:
+		return n.index(x)[0]
+	case *ast.SliceExpr:
+		return n.slice(x, t)
+	case *ast.TypeAssertExpr:
+		y, ok := n.typeAssert(n.expr(x.X), t)
+		n.ifNode([]string{n.in(ok)}, []func(){func() {}, func() {
+			msg := value{typ: types.Typ[types.String], c: exact.MakeString("interface conversion: interface is not " + t.String())}
+			v := n.in(msg)
+			nd := n.node(writesMemory, true, v)
+			n.emit(nd, "panic(%s)", v)
+		}})
+		return y
+	case *ast.CompositeLit:
+		return n.compositeLit(x, t, false)
+	case *ast.FuncLit:
+		return n.funcLit(x, t)
+	}
+	n.errorf(x, "unsupported expression")
+	panic("unreachable")
+}
+
+// multi evaluates the multi-valued expression x.
+func (n *normalizer) multi(x ast.Expr) []value {
+	switch x := unparen(x).(type) {
+	case *ast.CallExpr:
+		return n.call(x, "")
+	case *ast.IndexExpr:
+		return n.index(x)
+	case *ast.TypeAssertExpr:
+		y, ok := n.typeAssert(n.expr(x.X), n.info.Types[x.Type])
+		return []value{y, ok}
+	case *ast.UnaryExpr:
+		return n.recvOp(n.expr(x.X))
+	}
+	n.errorf(x, "unsupported expression")
+	panic("unreachable")
+}
+
+func (n *normalizer) funcValue(obj *types.Func, t types.Type) value {
+	nd := n.node(noEffect, true)
+	p := n.port(obj.Name, nd)
+	n.emit(nd, "%s := %s", p, n.w.qualifiedName(obj))
+	return out(p, t)
+}
+
+func (n *normalizer) pkgVarAddr(v *types.Var) value {
+	nd := n.node(noEffect, true)
+	p := n.port(v.Name+"P", nd)
+	n.emit(nd, "%s := &%s", p, n.w.qualifiedName(v))
+	return out(p, types.NewPointer(v.Type))
+}
+
+// addressable reports whether x denotes memory whose address can be taken.
+func (n *normalizer) addressable(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if v, ok := n.info.Objects[x].(*types.Var); ok {
+			l := n.locals[v]
+			return l == nil || l.cell()
+		}
+	case *ast.ParenExpr:
+		return n.addressable(x.X)
+	case *ast.SelectorExpr:
+		if obj := n.pkgObj(x); obj != nil {
+			_, ok := obj.(*types.Var)
+			return ok
+		}
+		sel := n.info.Selections[x]
+		return sel.Kind == types.FieldVal && (sel.Indirect || n.addressable(x.X))
+	case *ast.IndexExpr:
+		switch underlying(n.info.Types[x.X]).(type) {
+		case *types.Slice, *types.Pointer:
+			return true
+		case *types.Array:
+			return n.addressable(x.X)
+		}
+	case *ast.StarExpr:
+		return true
+	}
+	return false
+}
+
+// addr returns the address of x.
+func (n *normalizer) addr(x ast.Expr) value {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if v, ok := n.info.Objects[x].(*types.Var); ok {
+			if l := n.locals[v]; l == nil {
+				return n.pkgVarAddr(v)
+			} else if l.cell() {
+				return n.cell(v)
+			}
+		}
+	case *ast.ParenExpr:
+		return n.addr(x.X)
+	case *ast.SelectorExpr:
+		if v, ok := n.pkgObj(x).(*types.Var); ok {
+			return n.pkgVarAddr(v)
+		}
+		if p := n.field(n.fieldBase(x), x.Sel.Name); isPointer(p.typ) {
+			return p
+		}
+	case *ast.IndexExpr:
+		var base value
+		if _, ok := underlying(n.info.Types[x.X]).(*types.Array); ok {
+			base = n.addr(x.X)
+		} else {
+			base = n.expr(x.X)
+		}
+		return n.indexAddr(base, n.toInt(n.expr(x.Index)))
+	case *ast.StarExpr:
+		return n.expr(x.X)
+	case *ast.CompositeLit:
+		return n.compositeLit(x, n.info.Types[x], true)
+	}
+	n.errorf(x, "can't take the address of this expression")
+	panic("unreachable")
+}
+
+// fieldBase evaluates the operand of the field selector x to a value whose field is addressable if possible.
+func (n *normalizer) fieldBase(x *ast.SelectorExpr) value {
+	if isPointer(n.info.Types[x.X]) || !n.addressable(x.X) {
+		return n.expr(x.X)
+	}
+	return n.addr(x.X)
+}
+
+// field selects the named field of x.  The result is a pointer to the field if it is addressable.
+func (n *normalizer) field(x value, name string) value {
+	obj, index, indirect := types.LookupFieldOrMethod(x.typ, n.w.pkg, name)
+	f, ok := obj.(*types.Var)
+	if !ok {
+		n.errorf(n.stmtPos, "unknown field %s", name)
+	}
+	kind := noEffect
+	if len(index) > 1 && indirect {
+		kind = readsMemory // embedded pointers are followed
+	}
+	v := n.in(x)
+	nd := n.node(kind, true, v)
+	p := n.port(name, nd)
+	n.emit(nd, "%s := %s.%s", p, v, name)
+	t := f.Type
+	if indirect {
+		t = types.NewPointer(t)
+	}
+	return out(p, t)
+}
+
+// recv evaluates the receiver of the method selector x, following any embedded fields and taking its address or dereferencing it as the method requires.
+func (n *normalizer) recv(x *ast.SelectorExpr, sel *types.Selection) value {
+	ptrRecv := isPointer(sel.Obj.GetType().(*types.Signature).Recv.Type)
+	var base value
+	if !isPointer(n.info.Types[x.X]) && ptrRecv && n.addressable(x.X) {
+		base = n.addr(x.X)
+	} else {
+		base = n.expr(x.X)
+	}
+	for _, i := range sel.Index[:len(sel.Index)-1] {
+		t := base.typ
+		if isPointer(t) {
+			t = elem(t)
+		}
+		base = n.field(base, underlying(t).(*types.Struct).Fields[i].Name)
+	}
+	if isPointer(base.typ) && !ptrRecv {
+		base = n.deref(base, readsMemory)
+	}
+	return base
+}
+
+func (n *normalizer) unary(x *ast.UnaryExpr, t types.Type) value {
+	switch x.Op {
+	case token.AND:
+		return n.addr(unparen(x.X))
+	case token.ARROW:
+		return n.recvOp(n.expr(x.X))[0]
+	case token.ADD:
+		return n.expr(x.X)
+	case token.NOT:
+		return n.operator("!", t, n.expr(x.X))
+	case token.SUB:
+		return n.operator("-", t, value{typ: t, c: exact.MakeInt64(0)}, n.expr(x.X))
+	case token.XOR:
+		mask := exact.MakeInt64(-1)
+		if b := underlying(t).(*types.Basic); b.Info&types.IsUnsigned != 0 {
+			bits := map[types.BasicKind]uint{types.Uint8: 8, types.Uint16: 16, types.Uint32: 32}[b.Kind]
+			if bits == 0 {
+				bits = 64
+			}
+			mask = exact.MakeUint64(1<<bits - 1)
+		}
+		return n.operator("^", t, value{typ: t, c: mask}, n.expr(x.X))
+	}
+	n.errorf(x, "unsupported operator %s", x.Op)
+	panic("unreachable")
+}
+
+// recvOp receives from the channel ch, returning the received value and whether it was sent.
+func (n *normalizer) recvOp(ch value) []value {
+	v := n.in(ch)
+	nd := n.node(writesMemory, true, v)
+	e, ok := n.port("x", nd), n.port("ok", nd)
+	n.emit(nd, "%s, %s := <-%s", e, ok, v)
+	return []value{out(e, underlying(ch.typ).(*types.Chan).Elem), out(ok, types.Typ[types.Bool])}
+}
+
+// operator applies the operator op to xs.  Constant operands are left untyped so that the operator node gives them the type of the other operand.
+func (n *normalizer) operator(op string, t types.Type, xs ...value) value {
+	vs := []string{}
+	for _, x := range xs {
+		if x.c != nil {
+			x = n.constant(x, false)
+		}
+		vs = append(vs, n.in(x))
+	}
+	nd := n.node(noEffect, false, vs...)
+	p := n.port("x", nd)
+	if len(vs) == 1 {
+		n.emit(nd, "%s := %s%s", p, op, vs[0])
+	} else {
+		n.emit(nd, "%s := %s %s %s", p, vs[0], op, vs[1])
+	}
+	return out(p, t)
+}
+
+func (n *normalizer) binaryOp(op token.Token, x, y value, t types.Type) value {
+	switch op {
+	case token.SHL, token.SHR:
+		if x.c != nil {
+			x.typ = t
+			x = n.constant(x, true)
+		}
+		uintType := types.Typ[types.Uint]
+		if y.c != nil {
+			y.typ = uintType
+			y = n.constant(y, true)
+		} else if b := underlying(y.typ).(*types.Basic); b.Info&types.IsUnsigned == 0 {
+			y = n.convert(y, uintType)
+		}
+	case token.NEQ:
+		for _, z := range []value{x, y} {
+			switch underlying(z.typ).(type) {
+			case *types.Slice, *types.Map, *types.Signature:
+				return n.operator("!", types.Typ[types.Bool], n.operator("==", t, x, y))
+			}
+		}
+	}
+	return n.operator(op.String(), t, x, y)
+}
+
+// logical evaluates x && y or x || y.  y is only evaluated if it is needed, unless it is pure.
+func (n *normalizer) logical(x *ast.BinaryExpr, t types.Type) value {
+	l := n.expr(x.X)
+	if pure(n.info, x.Y) {
+		return n.operator(x.Op.String(), t, l, n.expr(x.Y))
+	}
+	var then, els []string
+	eval := func() {
+		then = n.valueFor(n.expr(x.Y), types.Typ[types.Bool], false)
+	}
+	short := func() {
+		els = n.valueFor(value{typ: types.Typ[types.Bool], c: exact.MakeBool(x.Op == token.LOR)}, types.Typ[types.Bool], false)
+	}
+	if x.Op == token.LAND {
+		n.ifNode([]string{n.in(l)}, []func(){eval, short})
+	} else {
+		n.ifNode([]string{n.in(l)}, []func(){short, eval})
+	}
+	return value{ports: append(then, els...), typ: types.Typ[types.Bool], fresh: true}
+}
+
+// call evaluates the call x, returning its results.  godefer is "go " or "defer " for a go or defer statement.
+func (n *normalizer) call(x *ast.CallExpr, godefer string) []value {
+	t := n.info.Types[x]
+	if isTypeExpr(n.info, x.Fun) {
+		return []value{n.convert(n.expr(x.Args[0]), t)}
+	}
+	if id, ok := unparen(x.Fun).(*ast.Ident); ok {
+		if _, ok := n.info.Objects[id].(*types.Builtin); ok {
+			return n.builtin(x, id.Name, t, godefer)
+		}
+	}
+
+	var sig *types.Signature
+	var f string
+	var ins []string
+	if sel, ok := unparen(x.Fun).(*ast.SelectorExpr); ok && n.info.Selections[sel] != nil && n.info.Selections[sel].Kind == types.MethodVal {
+		s := n.info.Selections[sel]
+		v := n.in(n.recv(sel, s))
+		ins = append(ins, v)
+		sig = s.Obj.GetType().(*types.Signature)
+		f = v + "." + sel.Sel.Name
+	} else if obj := n.funcObj(x.Fun); obj != nil {
+		sig = obj.Type.(*types.Signature)
+		f = n.w.qualifiedName(obj)
+	} else {
+		fv := n.expr(x.Fun)
+		v := n.in(fv)
+		ins = append(ins, v)
+		sig = underlying(fv.typ).(*types.Signature)
+		f = v
+	}
+	args := n.args(x)
+	ins = append(ins, args...)
+	if x.Ellipsis != token.NoPos {
+		args[len(args)-1] += "..."
+	}
+
+	nd := n.node(writesMemory, true, ins...)
+	results := []value{}
+	names := []string{}
+	for _, r := range sig.Results {
+		p := n.port("r", nd)
+		names = append(names, p)
+		results = append(results, out(p, r.Type))
+	}
+	lhs := ""
+	if len(names) > 0 && godefer == "" {
+		lhs = strings.Join(names, ", ") + " := "
+	}
+	n.emit(nd, "%s%s%s(%s)", lhs, godefer, f, strings.Join(args, ", "))
+	return results
+}
+
+// funcObj returns the func denoted by the (possibly qualified) identifier x, if it is one.
+func (n *normalizer) funcObj(x ast.Expr) *types.Func {
+	var obj types.Object
+	switch x := unparen(x).(type) {
+	case *ast.Ident:
+		obj = n.info.Objects[x]
+	case *ast.SelectorExpr:
+		obj = n.pkgObj(x)
+	}
+	f, _ := obj.(*types.Func)
+	return f
+}
+
+// args evaluates the arguments of the call x to a func of type sig.
+func (n *normalizer) args(x *ast.CallExpr) []string {
+	var vals []value
+	if len(x.Args) == 1 {
+		if _, ok := n.info.Types[x.Args[0]].(*types.Tuple); ok {
+			vals = n.multi(x.Args[0])
+		}
+	}
+	if vals == nil {
+		for _, e := range x.Args {
+			vals = append(vals, n.expr(e))
+		}
+	}
+	ins := []string{}
+	for _, v := range vals {
+		ins = append(ins, n.in(v))
+	}
+	return ins
+}
+
+func (n *normalizer) builtin(x *ast.CallExpr, name string, t types.Type, godefer string) []value {
+	switch name {
+	case "close", "copy", "delete", "panic", "recover":
+	default:
+		if godefer != "" {
+			n.errorf(x, "%s%s is not supported", godefer, name)
+		}
+	}
+	switch name {
+	case "len", "cap":
+		a := n.expr(x.Args[0])
+		kind := noEffect
+		switch underlying(a.typ).(type) {
+		case *types.Map, *types.Chan:
+			kind = readsMemory
+		}
+		v := n.in(a)
+		nd := n.node(kind, true, v)
+		p := n.port("n", nd)
+		n.emit(nd, "%s := %s(%s)", p, name, v)
+		return []value{out(p, t)}
+	case "append":
+		s := n.expr(x.Args[0])
+		if s.isNil || s.ports == nil {
+			s = n.zero(t)
+		}
+		args := []string{n.in(s)}
+		elemType := underlying(t).(*types.Slice).Elem
+		for _, e := range x.Args[1:] {
+			v := n.expr(e)
+			if x.Ellipsis != token.NoPos {
+				if b, ok := underlying(v.typ).(*types.Basic); ok && b.Info&types.IsString != 0 {
+					v = n.convert(v, types.NewSlice(elemType))
+				}
+			} else {
+				v = n.assignable(v, elemType)
+			}
+			args = append(args, n.in(v))
+		}
+		nd := n.node(writesMemory, true, args...)
+		if x.Ellipsis != token.NoPos {
+			args[len(args)-1] += "..."
+		}
+		p := n.port("s", nd)
+		n.emit(nd, "%s := append(%s)", p, strings.Join(args, ", "))
+		return []value{out(p, t)}
+	case "copy":
+		dst := n.expr(x.Args[0])
+		src := n.expr(x.Args[1])
+		if b, ok := underlying(src.typ).(*types.Basic); ok && b.Info&types.IsString != 0 {
+			src = n.convert(src, types.NewSlice(types.Typ[types.Byte]))
+		}
+		d, s := n.in(dst), n.in(src)
+		nd := n.node(writesMemory, true, d, s)
+		p := n.port("n", nd)
+		lhs := p + " := "
+		if godefer != "" {
+			lhs = ""
+		}
+		n.emit(nd, "%s%scopy(%s, %s)", lhs, godefer, d, s)
+		return []value{out(p, t)}
+	case "delete":
+		m := n.expr(x.Args[0])
+		k := n.expr(x.Args[1])
+		mv, kv := n.in(m), n.in(n.assignable(k, underlying(m.typ).(*types.Map).Key))
+		nd := n.node(writesMemory, true, mv, kv)
+		n.emit(nd, "%sdelete(%s, %s)", godefer, mv, kv)
+		return nil
+	case "close":
+		v := n.in(n.expr(x.Args[0]))
+		nd := n.node(writesMemory, true, v)
+		n.emit(nd, "%sclose(%s)", godefer, v)
+		return nil
+	case "panic":
+		v := n.in(n.expr(x.Args[0]))
+		nd := n.node(writesMemory, true, v)
+		n.emit(nd, "%spanic(%s)", godefer, v)
+		return nil
+	case "recover":
+		nd := n.node(writesMemory, true)
+		p := n.port("r", nd)
+		if godefer != "" {
+			n.emit(nd, "%srecover()", godefer)
+		} else {
+			n.emit(nd, "%s := recover()", p)
+		}
+		return []value{out(p, t)}
+	case "make":
+		args := []string{}
+		for _, e := range x.Args[1:] {
+			args = append(args, n.in(n.toInt(n.expr(e))))
+		}
+		nd := n.node(noEffect, false, args...)
+		p := n.port("x", nd)
+		n.emit(nd, "%s := make(%s)", p, strings.Join(append([]string{n.w.typ(t)}, args...), ", "))
+		return []value{out(p, t)}
+	case "new":
+		nd := n.node(noEffect, false)
+		p := n.port("x", nd)
+		n.emit(nd, "%s := new(%s)", p, n.w.typ(elem(t)))
+		return []value{out(p, t)}
+	case "complex", "real", "imag":
+		args := []string{}
+		for _, e := range x.Args {
+			args = append(args, n.in(n.expr(e)))
+		}
+		nd := n.node(noEffect, false, args...)
+		p := n.port("x", nd)
+		n.emit(nd, "%s := %s(%s)", p, name, strings.Join(args, ", "))
+		return []value{out(p, t)}
+	}
+	n.errorf(x, "%s is not supported", name)
+	panic("unreachable")
+}
+
+// index evaluates x, returning the element and, for a map, whether it was present.
+func (n *normalizer) index(x *ast.IndexExpr) []value {
+	switch u := underlying(n.info.Types[x.X]).(type) {
+	case *types.Map:
+		m := n.expr(x.X)
+		return n.mapIndex(m, n.assignable(n.expr(x.Index), u.Key))
+	case *types.Basic:
+		s := n.expr(x.X)
+		i := n.toInt(n.expr(x.Index))
+		sv, iv := n.in(s), n.in(i)
+		nd := n.node(noEffect, true, sv, iv)
+		p := n.port("b", nd)
+		n.emit(nd, "%s := %s[%s]", p, sv, iv)
+		return []value{out(p, types.Typ[types.Byte])}
+	case *types.Array:
+		if !n.addressable(x.X) {
+			a := n.expr(x.X)
+			i := n.toInt(n.expr(x.Index))
+			av, iv := n.in(a), n.in(i)
+			nd := n.node(noEffect, true, av, iv)
+			p := n.port("e", nd)
+			n.emit(nd, "%s := %s[%s]", p, av, iv)
+			return []value{out(p, u.Elem)}
+		}
+	}
+	return []value{n.deref(n.addr(x), readsMemory)}
+}
+
+func (n *normalizer) mapIndex(m, k value) []value {
+	mv, kv := n.in(m), n.in(k)
+	nd := n.node(readsMemory, true, mv, kv)
+	e, ok := n.port("e", nd), n.port("ok", nd)
+	n.emit(nd, "%s, %s := %s[%s]", e, ok, mv, kv)
+	return []value{out(e, underlying(m.typ).(*types.Map).Elem), out(ok, types.Typ[types.Bool])}
+}
+
+// indexAddr returns the address of the element of the slice or array pointer x at index i.
+func (n *normalizer) indexAddr(x, i value) value {
+	xv, iv := n.in(x), n.in(i)
+	nd := n.node(noEffect, true, xv, iv)
+	p := n.port("eP", nd)
+	n.emit(nd, "%s := &%s[%s]", p, xv, iv)
+	var t types.Type
+	switch u := underlying(x.typ).(type) {
+	case *types.Slice:
+		t = u.Elem
+	case *types.Pointer:
+		t = underlying(u.Elem).(*types.Array).Elem
+	}
+	return out(p, types.NewPointer(t))
+}
+
+func (n *normalizer) slice(x *ast.SliceExpr, t types.Type) value {
+	var base value
+	if _, ok := underlying(n.info.Types[x.X]).(*types.Array); ok {
+		base = n.addr(x.X)
+	} else {
+		base = n.expr(x.X)
+	}
+	ins := []string{n.in(base)}
+	idx := []string{}
+	for _, e := range []ast.Expr{x.Low, x.High, x.Max} {
+		if e == nil {
+			idx = append(idx, "")
+			continue
+		}
+		v := n.in(n.toInt(n.expr(e)))
+		ins = append(ins, v)
+		idx = append(idx, v)
+	}
+	if x.Max == nil {
+		idx = idx[:2]
+	}
+	nd := n.node(noEffect, false, ins...)
+	p := n.port("s", nd)
+	n.emit(nd, "%s := %s[%s]", p, ins[0], strings.Join(idx, ":"))
+	return out(p, t)
+}
+
+func (n *normalizer) typeAssert(x value, t types.Type) (y, ok value) {
+	v := n.in(x)
+	nd := n.node(noEffect, false, v)
+	p, okp := n.port("x", nd), n.port("ok", nd)
+	n.emit(nd, "%s, %s := %s.(%s)", p, okp, v, n.w.typ(t))
+	return out(p, t), out(okp, types.Typ[types.Bool])
+}
+
+// compositeLit evaluates the composite literal x of type t, or its address if ptr is true.
+func (n *normalizer) compositeLit(x *ast.CompositeLit, t types.Type, ptr bool) value {
+	if p, ok := underlying(t).(*types.Pointer); ok { // an element of a literal whose type &T is elided
+		t, ptr = p.Elem, true
+	}
+	switch u := underlying(t).(type) {
+	case *types.Struct:
+		fields := []string{}
+		ins := []string{}
+		for i, e := range x.Elts {
+			name := ""
+			if kv, ok := e.(*ast.KeyValueExpr); ok {
+				name, e = kv.Key.(*ast.Ident).Name, kv.Value
+			} else {
+				name = u.Fields[i].Name
+			}
+			v := n.elemExpr(e)
+			if v.isNil {
+				continue
+			}
+			for _, f := range u.Fields {
+				if f.Name == name {
+					v = n.assignable(v, f.Type)
+				}
+			}
+			in := n.in(v)
+			ins = append(ins, in)
+			fields = append(fields, name+": "+in)
+		}
+		nd := n.node(noEffect, true, ins...)
+		p := n.port("x", nd)
+		lit := fmt.Sprintf("%s{%s}", n.w.typ(t), strings.Join(fields, ", "))
+		if ptr {
+			n.emit(nd, "%s := &%s", p, lit)
+			return out(p, types.NewPointer(t))
+		}
+		n.emit(nd, "%s := %s", p, lit)
+		return out(p, t)
+	case *types.Slice:
+		elems, length := n.elems(x, u.Elem)
+		lv := n.in(value{typ: types.Typ[types.Int], c: exact.MakeInt64(length)})
+		nd := n.node(noEffect, false, lv)
+		p := n.port("s", nd)
+		n.emit(nd, "%s := make(%s, %s)", p, n.w.typ(t), lv)
+		s := out(p, t)
+		for _, e := range elems {
+			n.storeTo(lvalue{kind: lvIndex, x: s, key: e.key, typ: u.Elem}, e.val)
+		}
+		return n.litAddr(s, ptr)
+	case *types.Array:
+		elems, _ := n.elems(x, u.Elem)
+		nd := n.node(noEffect, false)
+		p := n.port("a", nd)
+		n.emit(nd, "%s := new(%s)", p, n.w.typ(t))
+		a := out(p, types.NewPointer(t))
+		for _, e := range elems {
+			n.storeTo(lvalue{kind: lvIndex, x: a, key: e.key, typ: u.Elem}, e.val)
+		}
+		if ptr {
+			return a
+		}
+		return n.deref(a, readsMemory)
+	case *types.Map:
+		nd := n.node(noEffect, false)
+		p := n.port("m", nd)
+		n.emit(nd, "%s := make(%s)", p, n.w.typ(t))
+		m := out(p, t)
+		for _, e := range x.Elts {
+			kv := e.(*ast.KeyValueExpr)
+			k := n.assignable(n.elemExpr(kv.Key), u.Key)
+			v := n.elemExpr(kv.Value)
+			n.storeTo(lvalue{kind: lvIndex, x: m, key: k, typ: u.Elem}, v)
+		}
+		return n.litAddr(m, ptr)
+	}
+	n.errorf(x, "unsupported composite literal")
+	panic("unreachable")
+}
+
+// litAddr returns x or, if ptr is true, the address of a new variable holding x.
+func (n *normalizer) litAddr(x value, ptr bool) value {
+	if !ptr {
+		return x
+	}
+	nd := n.node(noEffect, false)
+	p := n.port("xP", nd)
+	n.emit(nd, "%s := new(%s)", p, n.w.typ(x.typ))
+	a := out(p, types.NewPointer(x.typ))
+	n.storeTo(lvalue{kind: lvStar, x: a, typ: x.typ}, x)
+	return a
+}
+
+type litElem struct {
+	key, val value
+}
+
+// elems evaluates the elements of the slice or array literal x, returning them with their indices and the literal's length.
+func (n *normalizer) elems(x *ast.CompositeLit, elemType types.Type) (elems []litElem, length int64) {
+	i := int64(0)
+	for _, e := range x.Elts {
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			i, _ = exact.Int64Val(n.info.Values[kv.Key])
+			e = kv.Value
+		}
+		v := n.assignable(n.elemExpr(e), elemType)
+		elems = append(elems, litElem{value{typ: types.Typ[types.Int], c: exact.MakeInt64(i)}, v})
+		i++
+		if i > length {
+			length = i
+		}
+	}
+	return
+}
+
+// elemExpr evaluates an element of a composite literal, whose type may be elided.
+func (n *normalizer) elemExpr(x ast.Expr) value {
+	if c, ok := x.(*ast.CompositeLit); ok && c.Type == nil {
+		return n.compositeLit(c, n.info.Types[x], false)
+	}
+	return n.expr(x)
+}
+
+func (n *normalizer) funcLit(x *ast.FuncLit, t types.Type) value {
+	names := map[*types.Var]string{}
+	nd := n.node(noEffect, false)
+	p := n.port("f", nd)
+	n.emit(nil, "%s := func%s {", p, n.signature(x.Type, names))
+	n.block(nd, func() {
+		n.funcBody(fieldVars(n.info, x.Type.Params), fieldVars(n.info, x.Type.Results), x.Type, names, x.Body)
+	})
+	n.emit(nil, "}")
+	return out(p, t)
+}
@@ -35,7 +35,7 @@ func (n *operatorNode) connectable(t types.Type, dst *port) bool {
 		return false
 	}
 	if n.op != "<<" && n.op != ">>" {
-		return assignableToAll(t, n.ins...)
+		return assignableToAll(t, n.inputs()...)
 	}
 	return true
 }
@@ -45,11 +45,11 @@ func (n *operatorNode) connectable1(t types.Type, dst *port) bool {
 		switch underlying(t).(type) {
 		case *types.Slice, *types.Map, *types.Signature:
 			// these types are comparable only with nil
-			other := n.ins[0]
+			other := n.inputs()[0]
 			if other == dst {
-				other = n.ins[1]
+				other = n.inputs()[1]
 			}
-			return len(other.conns) == 0
+			return len(other.conns()) == 0
 		}
 		return types.Comparable(t)
 	}
@@ -66,7 +66,7 @@ func (n *operatorNode) connectable1(t types.Type, dst *port) bool {
 	case "-", "*", "/":
 		return i&types.IsNumeric != 0
 	case "%", "&", "|", "^", "&^", "<<", ">>":
-		if (n.op == "<<" || n.op == ">>") && dst == n.ins[1] {
+		if (n.op == "<<" || n.op == ">>") && dst == n.inputs()[1] {
 			return i&types.IsUnsigned != 0
 		}
 		return i&types.IsInteger != 0
@@ -79,26 +79,26 @@ func (n *operatorNode) connectable1(t types.Type, dst *port) bool {
 func (n *operatorNode) connsChanged() {
 	switch n.op {
 	case "!", "&&", "||", "+", "-", "*", "/", "%", "&", "|", "^", "&^":
-		t := untypedToTyped(inputType(n.ins...))
-		n.ins[0].setType(t)
-		if len(n.ins) > 1 {
-			n.ins[1].setType(t)
+		t := untypedToTyped(inputType(n.inputs()...))
+		n.inputs()[0].setType(t)
+		if len(n.inputs()) > 1 {
+			n.inputs()[1].setType(t)
 		}
-		n.outs[0].setType(t)
+		n.outputs()[0].setType(t)
 	case "<<", ">>":
-		t := untypedToTyped(inputType(n.ins[0]))
-		u := untypedToTyped(inputType(n.ins[1]))
-		n.ins[0].setType(t)
-		n.ins[1].setType(u)
-		n.outs[0].setType(t)
+		t := untypedToTyped(inputType(n.inputs()[0]))
+		u := untypedToTyped(inputType(n.inputs()[1]))
+		n.inputs()[0].setType(t)
+		n.inputs()[1].setType(u)
+		n.outputs()[0].setType(t)
 	case "==", "!=", "<", "<=", ">", ">=":
-		t := untypedToTyped(inputType(n.ins...))
-		n.ins[0].setType(t)
-		n.ins[1].setType(t)
+		t := untypedToTyped(inputType(n.inputs()...))
+		n.inputs()[0].setType(t)
+		n.inputs()[1].setType(t)
 		if t != nil {
-			n.outs[0].setType(types.Typ[types.UntypedBool])
+			n.outputs()[0].setType(types.Typ[types.UntypedBool])
 		} else {
-			n.outs[0].setType(nil)
+			n.outputs()[0].setType(nil)
 		}
 	}
 }
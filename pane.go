@@ -0,0 +1,421 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"math"
+	"time"
+)
+
+// A pane is a region of a fluxWindow showing the browser or, in its place, an editor for a func or type.
+// Each pane pans and zooms independently, and remembers the key focus it had when another pane took it.
+type pane struct {
+	*ViewBase
+	*Panner
+	w       *fluxWindow
+	tile    *tile
+	browser *browser
+	minimap *minimap
+	editor  View         // the funcNode or typeView shown in place of the browser, or nil
+	obj     types.Object // the func or type being edited
+	title   string
+	focus   View // the key focus when the pane was last left
+
+	target chan Point
+	pause  chan bool
+	closed chan bool
+}
+
+func newPane(w *fluxWindow) *pane {
+	p := &pane{w: w, title: "Flux"}
+	p.ViewBase = NewView(p)
+	p.Panner = NewPanner(p)
+	p.browser = newBrowser(browserOptions{objFilter: isFluxObj, acceptTypes: true, enterTypes: true, mutable: true}, nil)
+	p.Add(p.browser)
+	p.browser.accepted = p.open
+	p.browser.canceled = func() {}
+	p.minimap = newMinimap(p)
+	p.Add(p.minimap)
+	p.focus = p.browser
+
+	p.target = make(chan Point)
+	p.pause = make(chan bool)
+	p.closed = make(chan bool)
+	go p.animate()
+	return p
+}
+
+// paneOf returns the pane containing v, or nil if there is none.
+func paneOf(v View) *pane {
+	for ; v != nil; v = Parent(v) {
+		if p, ok := v.(*pane); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// open hides the browser and shows an editor for obj.
+func (p *pane) open(obj types.Object) {
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		p.setTitle(obj.Pkg.Path + "." + obj.Name)
+		typ := obj.Type.(*types.Named)
+		Hide(p.browser)
+		p.obj = obj
+		v := p.openType(obj)
+		if typ.UnderlyingT == nil {
+			v.edit(func() {
+				if typ.UnderlyingT == nil {
+					delete(obj.Pkg.Scope().Objects, obj.Name)
+				} else {
+					saveType(typ)
+				}
+				p.closeEditor()
+			})
+		} else {
+			SetKeyFocus(v)
+		}
+	case *types.Func:
+		prefix := obj.Pkg.Path + "."
+		if recv := obj.Type.(*types.Signature).Recv; recv != nil {
+			t, _ := indirect(recv.Type)
+			prefix += t.(*types.Named).Obj.Name + "."
+		}
+		p.setTitle(prefix + obj.Name)
+		Hide(p.browser)
+		p.obj = obj
+		f := loadFunc(obj)
+		f.history = &history{}
+		p.editor = f
+		p.Add(f)
+		go animate(f.animate, f.stop)
+		f.Move(Center(p))
+		p.minimap.setFunc(f)
+		f.done = p.closeEditor
+		SetKeyFocus(f.inputsNode)
+	}
+}
+
+// openType shows a typeView of obj in place of the current one, if any.
+func (p *pane) openType(obj *types.TypeName) *typeView {
+	typ := obj.Type.(*types.Named)
+	if p.editor != nil {
+		p.Remove(p.editor)
+	}
+	v := newTypeView(&typ.UnderlyingT, obj.Pkg)
+	v.setTypeParams(&typ.TypeParams)
+	p.editor = v
+	p.Add(v)
+	MoveCenter(v, Center(p))
+	if typ.UnderlyingT != nil {
+		v.done = func() {
+			saveType(typ)
+			p.closeEditor()
+		}
+	}
+	return v
+}
+
+// closeEditor removes the editor, shows the browser in its place, and refreshes the editors in the other panes, which may depend on what was saved.
+func (p *pane) closeEditor() {
+	if v, ok := p.editor.(*typeView); ok {
+		p.Remove(v)
+	}
+	p.editor, p.obj = nil, nil
+	p.minimap.setFunc(nil)
+	Show(p.browser)
+	p.browser.clearText()
+	SetKeyFocus(p.browser)
+	p.setTitle("Flux")
+	p.w.refresh(p)
+}
+
+func (p *pane) setTitle(title string) {
+	p.title = title
+	p.w.SetTitle(title)
+}
+
+// replaceFunc replaces the funcNode f shown in p with f2, which has restored the views of f, among them focus.
+func (p *pane) replaceFunc(f, f2 *funcNode, focus View) {
+	if p.editor != f {
+		return
+	}
+	p.editor = f2
+	p.minimap.setFunc(f2)
+	if contains(f, p.focus) {
+		p.focus = focus
+	}
+}
+
+// refresh reloads the editor in p to reflect changes saved elsewhere, unless it is in the middle of an edit.
+func (p *pane) refresh() {
+	switch v := p.focus.(type) {
+	case *Text, *browser:
+		return
+	case *connection:
+		if v.editing {
+			return
+		}
+	}
+	switch e := p.editor.(type) {
+	case *funcNode:
+		e.restore(saveState(e))
+	case *typeView:
+		if e.done != nil {
+			p.focus = p.openType(p.obj.(*types.TypeName))
+		}
+	}
+}
+
+func (p *pane) SetRect(r Rectangle) {
+	p.ViewBase.SetRect(r)
+	p.browser.Move(Center(p))
+	p.minimap.Move(Pt(r.Max.X-minimapSize-minimapMargin, r.Min.Y+minimapMargin))
+}
+
+func (p *pane) KeyPress(k KeyEvent) {
+	switch {
+	case k.Command && k.Key == KeyK:
+		openPalette(KeyFocus(p))
+	case k.Command && k.Key == KeyD:
+		p.w.split(p, k.Shift)
+	case k.Command && k.Key == KeyRightBracket:
+		p.w.cyclePanes(p, 1)
+	case k.Command && k.Key == KeyLeftBracket:
+		p.w.cyclePanes(p, -1)
+	case k.Command && k.Key == KeyW:
+		p.w.closePane(p)
+	case k.Command && k.Key == KeyEqual:
+		p.zoom(1.25, Center(p))
+	case k.Command && k.Key == KeyMinus:
+		p.zoom(1/1.25, Center(p))
+	case k.Command && k.Key == Key0 && p.minimap.f != nil:
+		p.zoom(1/Scale(p.minimap.f), Center(p))
+	case k.Command && k.Key == KeyM && p.minimap.f != nil:
+		if Hidden(p.minimap) {
+			Show(p.minimap)
+		} else {
+			Hide(p.minimap)
+		}
+	case k.Command && k.Key == KeyT:
+		typeColors = !typeColors
+		Repaint(p.w)
+	case k.Command && k.Key == KeySlash:
+		announceAgain(KeyFocus(p))
+	default:
+		p.ViewBase.KeyPress(k)
+	}
+}
+
+func (p *pane) Scroll(s ScrollEvent) {
+	if s.Command {
+		p.zoom(math.Pow(1.1, -s.Delta.Y), s.Pos)
+		return
+	}
+	select {
+	case p.pause <- true:
+	default:
+	}
+	Pan(p, Rect(p).Min.Sub(s.Delta.Mul(4)))
+}
+
+// animate pans p smoothly to keep the latest target point near its center.
+func (p *pane) animate() {
+	var target Point
+	select {
+	case target = <-p.target:
+	case <-p.closed:
+		return
+	}
+	vel := ZP
+	for {
+		next := time.After(time.Second / fps)
+		r := ZR
+		Do(p, func() {
+			Pan(p, Rect(p).Min.Add(vel.Div(fps)))
+			r = Rect(p)
+		})
+		d := target.Sub(r.Center())
+		d.X = math.Copysign(math.Max(0, math.Abs(d.X)-r.Dx()/3), d.X)
+		d.Y = math.Copysign(math.Max(0, math.Abs(d.Y)-r.Dy()/3), d.Y)
+		vel = vel.Add(d).Mul(.8)
+		if vel.Len() < .1 {
+			next = nil
+		}
+		select {
+		case <-next:
+		case target = <-p.target:
+		case <-p.pause:
+			select {
+			case target = <-p.target:
+			case <-p.closed:
+				return
+			}
+		case <-p.closed:
+			return
+		}
+	}
+}
+
+func (p *pane) Paint(cv Canvas) {
+	cv.Clip(Rect(p))
+	if len(p.w.tiles.panes()) > 1 {
+		cv.SetColor(lineColor)
+		if contains(p, KeyFocus(p)) {
+			cv.SetColor(focusColor)
+		}
+		cv.SetLineWidth(1)
+		cv.DrawRect(Rect(p).Inset(.5))
+	}
+}
+
+// contains reports whether u is v or one of its descendants.
+func contains(v, u View) bool {
+	for ; u != nil; u = Parent(u) {
+		if u == v {
+			return true
+		}
+	}
+	return false
+}
+
+// split divides the tile of p between p and a new pane showing the browser, which is placed below p if vertical or else to its right, and takes the key focus.
+func (w *fluxWindow) split(p *pane, vertical bool) {
+	q := newPane(w)
+	p.tile.split(q, vertical)
+	w.Add(q)
+	w.tiles.layOut(Rect(w))
+	w.focusPane(q)
+}
+
+// cyclePanes moves the key focus from p to the pane dir places after it, in order from left to right and top to bottom.
+func (w *fluxWindow) cyclePanes(p *pane, dir int) {
+	panes := w.tiles.panes()
+	for i, q := range panes {
+		if q == p {
+			n := len(panes)
+			w.focusPane(panes[((i+dir)%n+n)%n])
+			return
+		}
+	}
+}
+
+// focusPane gives the key focus back to the view in p that last had it.
+func (w *fluxWindow) focusPane(p *pane) {
+	if cur := paneOf(KeyFocus(w)); cur != nil {
+		cur.focus = KeyFocus(w)
+	}
+	if !contains(p, p.focus) {
+		p.focus = p.browser
+		if p.editor != nil {
+			p.focus = p.editor
+		}
+	}
+	SetKeyFocus(p.focus)
+	w.SetTitle(p.title)
+}
+
+// closePane closes p, saving its editor as Escape does, and gives its space to its neighbor.  Closing the last pane closes the window.
+func (w *fluxWindow) closePane(p *pane) {
+	panes := w.tiles.panes()
+	if len(panes) == 1 {
+		w.Close()
+		return
+	}
+	switch e := p.editor.(type) {
+	case *funcNode:
+		e.Close()
+	case *typeView:
+		if e.done != nil {
+			e.done()
+		}
+	}
+	next := p.tile.remove()
+	close(p.closed)
+	p.Close()
+	w.tiles.layOut(Rect(w))
+	w.focusPane(next.panes()[0])
+}
+
+// refresh reloads the editors in the panes other than p after p has saved a change.
+func (w *fluxWindow) refresh(p *pane) {
+	for _, q := range w.tiles.panes() {
+		if q != p {
+			q.refresh()
+		}
+	}
+}
+
+// refreshOtherPanes refreshes the editors in the panes other than the one containing v, after a change has been saved there.
+func refreshOtherPanes(v View) {
+	if p := paneOf(v); p != nil {
+		p.w.refresh(p)
+	}
+}
+
+// A tile is a node in the binary tree dividing a window between its panes:  Either it holds a pane or it is split in half between two tiles.
+type tile struct {
+	parent   *tile
+	pane     *pane
+	a, b     *tile
+	vertical bool // whether a is above b, rather than to the left of it
+}
+
+// layOut fits the panes of t to r.  Panes keep their panning when resized.
+func (t *tile) layOut(r Rectangle) {
+	if t.pane != nil {
+		t.pane.tile = t
+		t.pane.Move(r.Min)
+		min := Rect(t.pane).Min
+		t.pane.SetRect(Rectangle{min, min.Add(r.Size())})
+		return
+	}
+	ra, rb := r, r
+	if t.vertical {
+		y := math.Floor((r.Min.Y + r.Max.Y) / 2)
+		ra.Min.Y, rb.Max.Y = y, y
+	} else {
+		x := math.Floor((r.Min.X + r.Max.X) / 2)
+		ra.Max.X, rb.Min.X = x, x
+	}
+	t.a.layOut(ra)
+	t.b.layOut(rb)
+}
+
+// panes returns the panes of t from left to right and top to bottom.
+func (t *tile) panes() []*pane {
+	if t.pane != nil {
+		return []*pane{t.pane}
+	}
+	return append(t.a.panes(), t.b.panes()...)
+}
+
+// split turns t, which holds a pane, into a split between that pane and p.
+func (t *tile) split(p *pane, vertical bool) {
+	t.a = &tile{parent: t, pane: t.pane}
+	t.b = &tile{parent: t, pane: p}
+	t.a.pane.tile, p.tile = t.a, t.b
+	t.pane = nil
+	t.vertical = vertical
+}
+
+// remove removes t, which holds a pane, from the tree, giving its space to its sibling, which it returns.
+func (t *tile) remove() *tile {
+	s := t.parent.a
+	if s == t {
+		s = t.parent.b
+	}
+	parent := t.parent
+	*parent = tile{parent: parent.parent, pane: s.pane, a: s.a, b: s.b, vertical: s.vertical}
+	if parent.pane != nil {
+		parent.pane.tile = parent
+	} else {
+		parent.a.parent, parent.b.parent = parent, parent
+	}
+	return parent
+}
@@ -0,0 +1,106 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"testing"
+)
+
+// TestPanes splits a window into panes, moves the focus between them, and closes them again.
+func TestPanes(t *testing.T) {
+	w := &fluxWindow{}
+	NewHeadlessWindow(w, Pt(800, 600), w.init)
+	defer w.Close()
+
+	var first *pane
+	Do(w, func() {
+		first = w.tiles.panes()[0]
+		if KeyFocus(w) != first.browser {
+			t.Errorf("focus is %T, want the browser", KeyFocus(w))
+		}
+	})
+
+	w.PressKey(KeyEvent{Key: KeyD, Command: true})
+	w.PressKey(KeyEvent{Key: KeyD, Command: true, Shift: true})
+	Do(w, func() {
+		panes := w.tiles.panes()
+		if len(panes) != 3 {
+			t.Fatalf("%d panes, want 3", len(panes))
+		}
+		for i, r := range []Rectangle{{Pt(0, 0), Pt(400, 600)}, {Pt(400, 300), Pt(800, 600)}, {Pt(400, 0), Pt(800, 300)}} {
+			if got := RectInParent(panes[i]); got != r {
+				t.Errorf("pane %d is at %v, want %v", i, got, r)
+			}
+		}
+		if KeyFocus(w) != panes[2].browser {
+			t.Error("the newest pane's browser should have the focus")
+		}
+	})
+
+	w.PressKey(KeyEvent{Key: KeyRightBracket, Command: true})
+	Do(w, func() {
+		if KeyFocus(w) != first.browser {
+			t.Error("the focus should have cycled to the first pane")
+		}
+	})
+
+	w.PressKey(KeyEvent{Key: KeyW, Command: true})
+	Do(w, func() {
+		panes := w.tiles.panes()
+		if len(panes) != 2 || panes[0] == first {
+			t.Fatalf("the first pane should have closed")
+		}
+		for i, r := range []Rectangle{{Pt(0, 300), Pt(800, 600)}, {Pt(0, 0), Pt(800, 300)}} {
+			if got := RectInParent(panes[i]); got != r {
+				t.Errorf("pane %d is at %v, want %v", i, got, r)
+			}
+		}
+		if KeyFocus(w) != panes[0].browser {
+			t.Error("the focus should have moved to the neighboring pane")
+		}
+	})
+}
+
+// TestRefreshPanes opens a func in one pane and checks that a save in another pane reloads it there without taking the focus.
+func TestRefreshPanes(t *testing.T) {
+	pkg := audioPackage(t)
+	var obj types.Object
+	for _, o := range pkgFluxFuncs(pkg) {
+		if funcName(o) == "MultiVoice.Sing" {
+			obj = o
+		}
+	}
+
+	w := &fluxWindow{}
+	NewHeadlessWindow(w, Pt(800, 600), w.init)
+	defer w.Close()
+
+	var first, second *pane
+	var f *funcNode
+	Do(w, func() {
+		first = w.tiles.panes()[0]
+		first.open(obj)
+		f = first.editor.(*funcNode)
+		w.split(first, false)
+		second = w.tiles.panes()[1]
+		w.refresh(second)
+
+		f2, ok := first.editor.(*funcNode)
+		if !ok || f2 == f || Parent(f) != nil || Parent(f2) != first || first.minimap.f != f2 {
+			t.Error("the func in the first pane should have been replaced")
+		}
+		if KeyFocus(w) != second.browser {
+			t.Errorf("focus is %T, want the second pane's browser", KeyFocus(w))
+		}
+		w.focusPane(first)
+		if !contains(f2, KeyFocus(w)) {
+			t.Errorf("focus is %T, want a view of the reloaded func", KeyFocus(w))
+		}
+		f2.funcblk.close()
+		f2.stop.stop()
+	})
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
 	"go/ast"
 	"go/parser"
@@ -14,12 +15,13 @@ import (
 
 type port struct {
 	*ViewBase
+	model        *graph.Port
 	out          bool
 	node         node
 	obj          *types.Var
 	valView      *typeView
-	conns        []*connection
 	focused, bad bool
+	highlighted  bool // whether the connection being edited can be connected to this port
 	connsChanged func()
 
 	conntxt *Text
@@ -35,9 +37,11 @@ func newPort(out bool, n node, v *types.Var) *port {
 	}
 
 	p := &port{out: out, node: n, obj: v}
+	p.model = &graph.Port{Node: n.graphNode(), Out: out, Obj: v, Data: p}
 	p.ViewBase = NewView(p)
 	p.valView = newValueView(v, nil) // TODO: pass currentPkg here so that named types are not package qualified in the current package and so that unexported fields in unnamed literals (rare) are hidden as appropriate
 	Hide(p.valView)
+	SetMinZoom(p.valView, labelZoom)
 	p.connsChanged = func() {}
 	p.Add(p.valView)
 	p.SetRect(ZR.Inset(-portSize / 2))
@@ -47,6 +51,7 @@ func newPort(out bool, n node, v *types.Var) *port {
 	p.conntxt.SetTextColor(lineColor)
 	p.conntxt.SetBackgroundColor(noColor)
 	p.conntxt.Validate = validateID
+	SetMinZoom(p.conntxt, labelZoom)
 	p.Add(p.conntxt)
 	return p
 }
@@ -58,6 +63,9 @@ func validateID(text *string) bool {
 }
 
 func (p *port) setType(t types.Type) {
+	if *p.valView.typ != t {
+		edited(p.node)
+	}
 	p.valView.setType(t)
 	if p.out {
 		p.valView.Move(Pt(-Width(p.valView)/2, -Height(p.valView)-12))
@@ -70,36 +78,42 @@ func (p *port) setType(t types.Type) {
 		return
 	}
 
-	for i := 0; i < len(p.conns); {
-		c := p.conns[i]
-		if c.src != nil && c.dst != nil && !c.connectable(c.src, c.dst) {
-			c.blk.removeConn(c)
+	for i := 0; i < len(p.conns()); {
+		c := p.conns()[i]
+		if c.src() != nil && c.dst() != nil && !c.connectable(c.src(), c.dst()) {
+			c.block().removeConn(c)
 		} else {
-			if p.out && c.dst != nil {
-				c.dst.connsChanged()
+			if p.out && c.dst() != nil {
+				c.dst().connsChanged()
 			}
 			i++
 		}
 	}
 }
 
-func (p *port) connect(c *connection) {
-	p.conns = append(p.conns, c)
+func (p port) conns() (conns []*connection) {
+	for _, c := range p.model.Conns {
+		conns = append(conns, c.Data.(*connection))
+	}
+	return
 }
 
-func (p *port) disconnect(c *connection) {
-	for i, c2 := range p.conns {
-		if c2 == c {
-			p.conns = append(p.conns[:i], p.conns[i+1:]...)
-			return
-		}
+func (p *port) Connected(*graph.Connection)    { p.changed() }
+func (p *port) Disconnected(*graph.Connection) { p.changed() }
+
+// changed tells p's node that p's connections, highlighting, or focus changed, in case it collapses unused ports.
+func (p *port) changed() {
+	if n, ok := p.node.(interface {
+		portChanged()
+	}); ok {
+		n.portChanged()
 	}
 }
 
 func (p *port) focusMiddle() {
 	var conn *connection
 	dist := 0.0
-	for _, c := range p.conns {
+	for _, c := range p.conns() {
 		dir := c.srcPt.Sub(c.dstPt)
 		dir.Y, dir.X = dir.XY()
 		d := math.Abs(dir.Angle())
@@ -122,7 +136,7 @@ func (p *port) focusNextConn(curƟ float64, dir int) {
 	}
 	var conn *connection
 	nearestƟ := 0.0
-	for _, c := range p.conns {
+	for _, c := range p.conns() {
 		Ɵ := c.dstPt.Sub(c.srcPt).Angle()
 		if Ɵ != curƟ && less(curƟ, Ɵ) && (conn == nil || less(Ɵ, nearestƟ)) {
 			conn, nearestƟ = c, Ɵ
@@ -172,13 +186,14 @@ func (p *port) next(dir int) *port {
 
 func (p *port) Move(pt Point) {
 	p.ViewBase.Move(pt)
-	for _, c := range p.conns {
+	for _, c := range p.conns() {
 		c.reform()
 	}
 }
 
 func (p *port) TookKeyFocus() {
 	p.focused = true
+	p.changed()
 	Repaint(p)
 	Show(p.valView)
 	panTo(p, ZP)
@@ -186,6 +201,7 @@ func (p *port) TookKeyFocus() {
 
 func (p *port) LostKeyFocus() {
 	p.focused = false
+	p.changed()
 	Repaint(p)
 	Hide(p.valView)
 }
@@ -269,7 +285,7 @@ func (p *port) KeyPress(event KeyEvent) {
 			SetKeyFocus(p.node)
 		}
 	default:
-		if pn, ok := p.node.(*portsNode); ok && p.out && pn.outs[0] == p && event.Text == "*" {
+		if pn, ok := p.node.(*portsNode); ok && p.out && pn.outputs()[0] == p && event.Text == "*" {
 			if t, ok := p.obj.Type.(*types.Pointer); ok {
 				p.setType(t.Elem)
 			} else {
@@ -281,7 +297,20 @@ func (p *port) KeyPress(event KeyEvent) {
 	}
 }
 
+func (p *port) setHighlighted(h bool) {
+	p.highlighted = h
+	p.changed()
+	Repaint(p)
+}
+
 func (p *port) Mouse(m MouseEvent) {
+	if m.Button == MouseButtonRight {
+		if m.Press {
+			SetKeyFocus(p)
+			openContextMenu(p, m.Pos)
+		}
+		return
+	}
 	if m.Press {
 		SetKeyFocus(p)
 		c := newConnection()
@@ -297,17 +326,21 @@ func (p *port) Mouse(m MouseEvent) {
 	}
 }
 
-func (p *port) Paint() {
+func (p *port) Paint(cv Canvas) {
 	if p.focused {
-		SetColor(focusColor)
-		SetPointSize(portSize)
-		DrawPoint(ZP)
+		cv.SetColor(focusColor)
+		cv.SetPointSize(portSize)
+		cv.DrawPoint(ZP)
+	} else if p.highlighted {
+		cv.SetColor(highlightColor)
+		cv.SetPointSize(portSize)
+		cv.DrawPoint(ZP)
 	}
 	if p.bad {
-		SetColor(Color{1, 0, 0, 1})
-		SetLineWidth(3)
+		cv.SetColor(errorColor)
+		cv.SetLineWidth(3)
 		r := Rect(p)
-		DrawLine(r.Min, r.Max)
-		DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
+		cv.DrawLine(r.Min, r.Max)
+		cv.DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
 	}
 }
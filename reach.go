@@ -0,0 +1,848 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// A local describes a variable declared in a func that is being imported.
+type local struct {
+	decl     def  // the def made by its declaration
+	defs     int  // the number of places it is assigned, including its declaration
+	captured bool // whether a func literal refers to it
+	addr     bool // whether its address is taken, explicitly or implicitly (e.g., by assigning to one of its fields or calling a pointer method)
+}
+
+// cell reports whether the variable must live in memory allocated by new rather than flow along connections.
+// A variable that is captured by a func literal can flow along connections only if it is never reassigned.
+func (l *local) cell() bool {
+	return l.addr || l.captured && l.defs > 1
+}
+
+type collector struct {
+	info   *types.Info
+	locals map[*types.Var]*local
+	lit    *ast.FuncLit // the innermost func literal being visited, if any
+}
+
+// collectLocals finds the local variables declared in the func decl and how they are used.
+func collectLocals(info *types.Info, decl *ast.FuncDecl) map[*types.Var]*local {
+	c := &collector{info, map[*types.Var]*local{}, nil}
+	if decl.Recv != nil {
+		c.fields(decl.Type, decl.Recv)
+	}
+	ast.Walk(c, decl.Type)
+	ast.Walk(c, decl.Body)
+	return c.locals
+}
+
+func (c *collector) Visit(x ast.Node) ast.Visitor {
+	switch x := x.(type) {
+	case *ast.FuncType:
+		c.fields(x, x.Params)
+		c.fields(x, x.Results)
+		return nil
+	case *ast.FuncLit:
+		c2 := *c
+		c2.lit = x
+		ast.Walk(&c2, x.Type)
+		ast.Walk(&c2, x.Body)
+		return nil
+	case *ast.Ident:
+		if l := c.local(x); l != nil && c.lit != nil {
+			if p := l.decl.v.Pos(); p < c.lit.Pos() || p >= c.lit.End() {
+				l.captured = true
+			}
+		}
+	case *ast.AssignStmt:
+		for _, lhs := range x.Lhs {
+			c.assign(lhs, x.Tok == token.DEFINE)
+		}
+	case *ast.IncDecStmt:
+		c.assign(x.X, false)
+	case *ast.ValueSpec:
+		for _, id := range x.Names {
+			c.declare(id, id)
+		}
+	case *ast.RangeStmt:
+		for _, e := range []ast.Expr{x.Key, x.Value} {
+			if e != nil {
+				c.assign(e, x.Tok == token.DEFINE)
+			}
+		}
+	case *ast.TypeSwitchStmt:
+		for _, cc := range x.Body.List {
+			if v, ok := c.info.Implicits[cc].(*types.Var); ok {
+				c.locals[v] = &local{decl: def{cc, nil, v}, defs: 1}
+			}
+		}
+	case *ast.UnaryExpr:
+		if x.Op == token.AND {
+			c.addr(x.X)
+		}
+	case *ast.SelectorExpr:
+		if sel, ok := c.info.Selections[x]; ok && sel.Kind == types.MethodVal && !sel.Indirect {
+			if _, ptrRecv := sel.Obj.GetType().(*types.Signature).Recv.Type.(*types.Pointer); ptrRecv {
+				c.addr(x.X)
+			}
+		}
+	case *ast.SliceExpr:
+		if _, ok := underlying(c.info.Types[x.X]).(*types.Array); ok {
+			c.addr(x.X)
+		}
+	}
+	return c
+}
+
+// fields declares the parameters or results in list, whose defs are made on entry to the func of type t.
+func (c *collector) fields(t *ast.FuncType, list *ast.FieldList) {
+	if list == nil {
+		return
+	}
+	for _, f := range list.List {
+		for _, id := range f.Names {
+			c.declare(id, t)
+		}
+		if v, ok := c.info.Implicits[f].(*types.Var); ok {
+			c.locals[v] = &local{decl: def{t, nil, v}, defs: 1}
+		}
+	}
+}
+
+func (c *collector) declare(id *ast.Ident, node ast.Node) {
+	if v, ok := c.info.Objects[id].(*types.Var); ok {
+		c.locals[v] = &local{decl: def{node, nil, v}, defs: 1}
+	}
+}
+
+// assign records an assignment to x, which declares it if define is true and it is a new variable.
+func (c *collector) assign(x ast.Expr, define bool) {
+	id, ok := x.(*ast.Ident)
+	if !ok {
+		c.addr(x)
+		return
+	}
+	v, ok := c.info.Objects[id].(*types.Var)
+	if !ok {
+		return
+	}
+	if define && v.Pos() == id.Pos() {
+		c.declare(id, id)
+	} else if l := c.locals[v]; l != nil {
+		l.defs++
+	}
+}
+
+// addr records that the address of x is taken, which implicates the local variable (if any) whose memory x refers to.
+func (c *collector) addr(x ast.Expr) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if l := c.local(x); l != nil {
+			l.addr = true
+		}
+	case *ast.ParenExpr:
+		c.addr(x.X)
+	case *ast.SelectorExpr:
+		if sel, ok := c.info.Selections[x]; ok && sel.Kind == types.FieldVal && !sel.Indirect {
+			c.addr(x.X)
+		}
+	case *ast.IndexExpr:
+		if _, ok := underlying(c.info.Types[x.X]).(*types.Array); ok {
+			c.addr(x.X)
+		}
+	}
+}
+
+func (c *collector) local(id *ast.Ident) *local {
+	if v, ok := c.info.Objects[id].(*types.Var); ok {
+		return c.locals[v]
+	}
+	return nil
+}
+
+// A def is a place where a local variable is assigned.  node is the syntax that assigns it:  the declared or assigned identifier, the *ast.FuncType of a parameter or result, the *ast.CaseClause of a type switch variable, the *ast.ReturnStmt that sets a result, or the loop statement at whose header a loop-carried variable is merged (see phi).
+// at distinguishes the copies of a for statement's post statement, which is duplicated before each continue statement (at is the *ast.BranchStmt) and at the end of the body (at is the *ast.ForStmt).
+type def struct {
+	node, at ast.Node
+	v        *types.Var
+}
+
+// A use is a place where a local variable is read.
+type use struct {
+	id *ast.Ident
+	at ast.Node
+}
+
+// A reach is a set of defs that reach some point.
+type reach map[def]bool
+
+// A reachState holds the defs of each local variable that reach a point.  It is nil if the point is unreachable.
+type reachState map[*types.Var]reach
+
+func (s reachState) copy() reachState {
+	if s == nil {
+		return nil
+	}
+	c := reachState{}
+	for v, r := range s {
+		c[v] = r // reaches are replaced, never modified
+	}
+	return c
+}
+
+func mergeStates(s, t reachState) reachState {
+	if s == nil {
+		return t.copy()
+	}
+	c := s.copy()
+	for v, r := range t {
+		c[v] = mergeReaches(c[v], r)
+	}
+	return c
+}
+
+func mergeReaches(r, r2 reach) reach {
+	c := reach{}
+	for d := range r {
+		c[d] = true
+	}
+	for d := range r2 {
+		c[d] = true
+	}
+	return c
+}
+
+// A phi merges the values of a variable that is assigned in a loop at the loop's header:  pre holds the defs that reach the loop and back the defs that reach its back edge.
+// Because each iteration reads the phi instead of the defs themselves, only the connections into a phi from back need to be feedback connections.
+type phi struct {
+	pre, back reach
+}
+
+// An analyzer computes the defs that reach each use of a local variable in a func decl.  It follows the same desugaring of control flow as the normalizer.
+type analyzer struct {
+	info     *types.Info
+	locals   map[*types.Var]*local
+	uses     map[use]reach
+	live     map[def]bool // defs whose values are needed
+	phis     map[def]*phi
+	loopPhis map[ast.Stmt][]*types.Var
+	returns  map[*types.Var]reach // for each result, the defs that reach a return
+	at       ast.Node
+	targets  []*target
+	results  []*types.Var
+}
+
+// A target is a statement that may be the target of a break or continue statement.
+type target struct {
+	stmt  ast.Stmt
+	loop  bool
+	brk   reachState
+	conts []cont
+}
+
+type cont struct {
+	stmt  *ast.BranchStmt
+	state reachState
+}
+
+func analyze(info *types.Info, locals map[*types.Var]*local, decl *ast.FuncDecl) *analyzer {
+	a := &analyzer{info: info, locals: locals, uses: map[use]reach{}, live: map[def]bool{}, phis: map[def]*phi{}, loopPhis: map[ast.Stmt][]*types.Var{}, returns: map[*types.Var]reach{}}
+	a.fun(decl.Recv, decl.Type, decl.Body)
+	for changed := true; changed; {
+		changed = false
+		for d, p := range a.phis {
+			if !a.live[d] {
+				continue
+			}
+			for _, r := range []reach{p.pre, p.back} {
+				for d := range r {
+					if !a.live[d] {
+						a.live[d] = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return a
+}
+
+func (a *analyzer) fun(recv *ast.FieldList, typ *ast.FuncType, body *ast.BlockStmt) {
+	targets, results := a.targets, a.results
+	a.targets, a.results = nil, nil
+	s := reachState{}
+	for _, v := range fieldVars(a.info, recv, typ.Params) {
+		a.define(s, def{typ, nil, v})
+	}
+	for _, v := range fieldVars(a.info, typ.Results) {
+		a.define(s, def{typ, nil, v})
+		a.results = append(a.results, v)
+	}
+	a.ret(a.stmts(body.List, s))
+	a.targets, a.results = targets, results
+}
+
+// fieldVars returns the variables declared by the fields of the given parameter or result lists.
+func fieldVars(info *types.Info, lists ...*ast.FieldList) (vars []*types.Var) {
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, f := range list.List {
+			for _, id := range f.Names {
+				vars = append(vars, info.Objects[id].(*types.Var))
+			}
+			if v, ok := info.Implicits[f].(*types.Var); ok {
+				vars = append(vars, v)
+			}
+		}
+	}
+	return
+}
+
+// tracked returns the variable that x refers to if it is a local variable that flows along connections.
+func (a *analyzer) tracked(x ast.Expr) *types.Var {
+	if id, ok := x.(*ast.Ident); ok {
+		if v, ok := a.info.Objects[id].(*types.Var); ok {
+			if l := a.locals[v]; l != nil && !l.cell() {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func (a *analyzer) define(s reachState, d def) {
+	if s != nil {
+		if l := a.locals[d.v]; l != nil && !l.cell() {
+			s[d.v] = reach{d: true}
+		}
+	}
+}
+
+// ret records that s reaches the return of the func, so that the defs of its results in s are live.
+func (a *analyzer) ret(s reachState) {
+	if s == nil {
+		return
+	}
+	for _, v := range a.results {
+		if l := a.locals[v]; l != nil && !l.cell() {
+			a.returns[v] = mergeReaches(a.returns[v], s[v])
+			for d := range s[v] {
+				a.live[d] = true
+			}
+		}
+	}
+}
+
+func (a *analyzer) expr(x ast.Expr, s reachState) {
+	if x == nil {
+		return
+	}
+	ast.Inspect(x, func(x ast.Node) bool {
+		switch x := x.(type) {
+		case *ast.Ident:
+			v := a.tracked(x)
+			if v == nil || s == nil {
+				break
+			}
+			r, ok := s[v]
+			if !ok { // declared outside of the current func literal, and therefore assigned only once
+				r = reach{a.locals[v].decl: true}
+			}
+			u := use{x, a.at}
+			a.uses[u] = mergeReaches(a.uses[u], r)
+			for d := range r {
+				a.live[d] = true
+			}
+		case *ast.FuncLit:
+			a.fun(nil, x.Type, x.Body)
+			return false
+		}
+		return true
+	})
+}
+
+func (a *analyzer) stmts(list []ast.Stmt, s reachState) reachState {
+	for _, x := range list {
+		s = a.stmt(x, s)
+	}
+	return s
+}
+
+// stmt analyzes x given the state s on entry (which it may modify) and returns the state on exit.
+func (a *analyzer) stmt(x ast.Stmt, s reachState) reachState {
+	switch x := x.(type) {
+	case nil:
+	case *ast.AssignStmt:
+		for _, e := range x.Rhs {
+			a.expr(e, s)
+		}
+		for _, e := range x.Lhs {
+			if _, ok := e.(*ast.Ident); !ok || x.Tok != token.ASSIGN && x.Tok != token.DEFINE {
+				a.expr(e, s)
+			}
+		}
+		for _, e := range x.Lhs {
+			if v := a.tracked(e); v != nil {
+				a.define(s, def{e, a.at, v})
+			}
+		}
+	case *ast.IncDecStmt:
+		a.expr(x.X, s)
+		if v := a.tracked(x.X); v != nil {
+			a.define(s, def{x.X, a.at, v})
+		}
+	case *ast.DeclStmt:
+		if d, ok := x.Decl.(*ast.GenDecl); ok && d.Tok == token.VAR {
+			for _, spec := range d.Specs {
+				spec := spec.(*ast.ValueSpec)
+				for _, e := range spec.Values {
+					a.expr(e, s)
+				}
+				for _, id := range spec.Names {
+					if v := a.tracked(id); v != nil {
+						a.define(s, def{id, nil, v})
+					}
+				}
+			}
+		}
+	case *ast.ExprStmt:
+		a.expr(x.X, s)
+	case *ast.SendStmt:
+		a.expr(x.Chan, s)
+		a.expr(x.Value, s)
+	case *ast.GoStmt:
+		a.expr(x.Call, s)
+	case *ast.DeferStmt:
+		a.expr(x.Call, s)
+	case *ast.ReturnStmt:
+		for _, e := range x.Results {
+			a.expr(e, s)
+		}
+		if len(x.Results) == 0 {
+			a.ret(s)
+		} else if s != nil {
+			for _, v := range a.results {
+				d := def{x, nil, v}
+				a.returns[v] = mergeReaches(a.returns[v], reach{d: true})
+				a.live[d] = true
+			}
+		}
+		return nil
+	case *ast.BranchStmt:
+		if x.Label != nil {
+			return nil
+		}
+		for i := len(a.targets) - 1; i >= 0; i-- {
+			t := a.targets[i]
+			if x.Tok == token.BREAK {
+				t.brk = mergeStates(t.brk, s)
+				break
+			}
+			if x.Tok == token.CONTINUE && t.loop {
+				t.conts = append(t.conts, cont{x, s})
+				break
+			}
+		}
+		return nil
+	case *ast.BlockStmt:
+		return a.stmts(x.List, s)
+	case *ast.LabeledStmt:
+		return a.stmt(x.Stmt, s)
+	case *ast.IfStmt:
+		s = a.stmt(x.Init, s)
+		a.expr(x.Cond, s)
+		then := a.stmts(x.Body.List, s.copy())
+		if x.Else != nil {
+			s = a.stmt(x.Else, s)
+		}
+		return mergeStates(then, s)
+	case *ast.SwitchStmt:
+		s = a.stmt(x.Init, s)
+		a.expr(x.Tag, s)
+		for _, cc := range x.Body.List {
+			for _, e := range cc.(*ast.CaseClause).List {
+				a.expr(e, s)
+			}
+		}
+		t := a.push(x, false)
+		hasDefault := false
+		var out reachState
+		for _, cc := range x.Body.List {
+			cc := cc.(*ast.CaseClause)
+			hasDefault = hasDefault || cc.List == nil
+			out = mergeStates(out, a.stmts(cc.Body, s.copy()))
+		}
+		if !hasDefault {
+			out = mergeStates(out, s)
+		}
+		a.pop()
+		return mergeStates(out, t.brk)
+	case *ast.TypeSwitchStmt:
+		s = a.stmt(x.Init, s)
+		a.expr(typeSwitchExpr(x), s)
+		t := a.push(x, false)
+		hasDefault := false
+		var out reachState
+		for _, cc := range x.Body.List {
+			cc := cc.(*ast.CaseClause)
+			hasDefault = hasDefault || cc.List == nil
+			s2 := s.copy()
+			if v, ok := a.info.Implicits[cc].(*types.Var); ok {
+				a.define(s2, def{cc, nil, v})
+			}
+			out = mergeStates(out, a.stmts(cc.Body, s2))
+		}
+		if !hasDefault {
+			out = mergeStates(out, s)
+		}
+		a.pop()
+		return mergeStates(out, t.brk)
+	case *ast.SelectStmt:
+		for _, cc := range x.Body.List {
+			switch c := cc.(*ast.CommClause).Comm.(type) {
+			case *ast.SendStmt:
+				a.stmt(c, s)
+			case *ast.ExprStmt:
+				a.stmt(c, s)
+			case *ast.AssignStmt:
+				a.expr(c.Rhs[0], s)
+			}
+		}
+		t := a.push(x, false)
+		var out reachState
+		for _, cc := range x.Body.List {
+			cc := cc.(*ast.CommClause)
+			s2 := s.copy()
+			if c, ok := cc.Comm.(*ast.AssignStmt); ok {
+				for _, e := range c.Lhs {
+					if _, ok := e.(*ast.Ident); !ok {
+						a.expr(e, s2)
+					}
+				}
+				for _, e := range c.Lhs {
+					if v := a.tracked(e); v != nil {
+						a.define(s2, def{e, nil, v})
+					}
+				}
+			}
+			out = mergeStates(out, a.stmts(cc.Body, s2))
+		}
+		a.pop()
+		return mergeStates(out, t.brk)
+	case *ast.ForStmt:
+		if i, n, ok := countLoop(a.info, a.locals, x); ok {
+			a.expr(n, s)
+			return a.loop(x, s, func(h reachState, t *target) (back, exit reachState) {
+				exit = h.copy()
+				a.define(h, def{i, nil, a.info.Objects[i].(*types.Var)})
+				back = a.stmts(x.Body.List, h)
+				for _, c := range t.conts {
+					back = mergeStates(back, c.state)
+				}
+				exit = a.exitHeader(x, s, exit, back)
+				return
+			})
+		}
+		s = a.stmt(x.Init, s)
+		return a.loop(x, s, func(h reachState, t *target) (back, exit reachState) {
+			if x.Cond != nil {
+				a.expr(x.Cond, h)
+				exit = h.copy()
+			}
+			end := a.stmts(x.Body.List, h)
+			if x.Post == nil {
+				back = end
+				for _, c := range t.conts {
+					back = mergeStates(back, c.state)
+				}
+				return
+			}
+			at := a.at
+			for _, c := range t.conts {
+				a.at = c.stmt
+				back = mergeStates(back, a.stmt(x.Post, c.state))
+			}
+			a.at = x
+			back = mergeStates(back, a.stmt(x.Post, end))
+			a.at = at
+			return
+		})
+	case *ast.RangeStmt:
+		a.expr(x.X, s)
+		return a.loop(x, s, func(h reachState, t *target) (back, exit reachState) {
+			exit = h.copy()
+			for _, e := range []ast.Expr{x.Key, x.Value} {
+				if e == nil {
+					continue
+				}
+				if _, ok := e.(*ast.Ident); !ok {
+					a.expr(e, h)
+				} else if v := a.tracked(e); v != nil {
+					a.define(h, def{e, nil, v})
+				}
+			}
+			back = a.stmts(x.Body.List, h)
+			for _, c := range t.conts {
+				back = mergeStates(back, c.state)
+			}
+			exit = a.exitHeader(x, s, exit, back)
+			return
+		})
+	}
+	return s
+}
+
+// loop analyzes a loop that is entered with state s.  Each variable that is assigned in the loop gets a phi at the loop header.
+// iter analyzes an iteration starting with the given header state, returning the state at the back edge and the state on exit from the header.
+func (a *analyzer) loop(x ast.Stmt, s reachState, iter func(h reachState, t *target) (back, exit reachState)) reachState {
+	if s == nil {
+		s = reachState{} // the loop is unreachable, but its body must still be analyzed
+	}
+	t := a.push(x, true)
+	h := s.copy()
+	assigned := assignedIn(a.info, x)
+	for v := range s {
+		if assigned[v] {
+			a.loopPhis[x] = append(a.loopPhis[x], v)
+		}
+	}
+	sort.Sort(varsByPos(a.loopPhis[x]))
+	for _, v := range a.loopPhis[x] {
+		a.define(h, def{x, nil, v})
+	}
+	back, exit := iter(h, t)
+	for _, v := range a.loopPhis[x] {
+		d := def{x, nil, v}
+		p := &phi{s[v], reach{}}
+		for d2 := range back[v] {
+			if d2 != d {
+				p.back[d2] = true
+			}
+		}
+		a.phis[d] = p
+	}
+	a.pop()
+	return mergeStates(exit, t.brk)
+}
+
+// exitHeader returns the state on leaving the loop x from its header, given the state s on entry to the loop, h at its header, and back at its back edge.
+// A range or counting loop stops without starting another iteration, so its phis are not evaluated again:  each variable that they merge holds the value it had on entry, if there were no iterations, or at the end of the last iteration.
+func (a *analyzer) exitHeader(x ast.Stmt, s, h, back reachState) reachState {
+	for _, v := range a.loopPhis[x] {
+		h[v] = mergeReaches(s[v], back[v])
+	}
+	return h
+}
+
+func (a *analyzer) push(x ast.Stmt, loop bool) *target {
+	t := &target{stmt: x, loop: loop}
+	a.targets = append(a.targets, t)
+	return t
+}
+
+func (a *analyzer) pop() {
+	a.targets = a.targets[:len(a.targets)-1]
+}
+
+// assignedIn returns the variables that are assigned in each iteration of the loop x.
+func assignedIn(info *types.Info, x ast.Stmt) map[*types.Var]bool {
+	assigned := map[*types.Var]bool{}
+	assign := func(e ast.Expr) {
+		if id, ok := e.(*ast.Ident); ok {
+			if v, ok := info.Objects[id].(*types.Var); ok {
+				assigned[v] = true
+			}
+		}
+	}
+	var parts []ast.Node
+	switch x := x.(type) {
+	case *ast.ForStmt:
+		parts = []ast.Node{x.Body}
+		if x.Cond != nil {
+			parts = append(parts, x.Cond)
+		}
+		if x.Post != nil {
+			parts = append(parts, x.Post)
+		}
+	case *ast.RangeStmt:
+		if x.Tok == token.ASSIGN {
+			assign(x.Key)
+			assign(x.Value)
+		}
+		parts = []ast.Node{x.Body}
+	}
+	for _, p := range parts {
+		ast.Inspect(p, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.AssignStmt:
+				for _, e := range n.Lhs {
+					assign(e)
+				}
+			case *ast.IncDecStmt:
+				assign(n.X)
+			case *ast.RangeStmt:
+				assign(n.Key)
+				assign(n.Value)
+			}
+			return true
+		})
+	}
+	return assigned
+}
+
+type varsByPos []*types.Var
+
+func (v varsByPos) Len() int           { return len(v) }
+func (v varsByPos) Less(i, j int) bool { return v[i].Pos() < v[j].Pos() }
+func (v varsByPos) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+
+func typeSwitchExpr(x *ast.TypeSwitchStmt) ast.Expr {
+	switch s := x.Assign.(type) {
+	case *ast.ExprStmt:
+		return s.X.(*ast.TypeAssertExpr).X
+	case *ast.AssignStmt:
+		return s.Rhs[0].(*ast.TypeAssertExpr).X
+	}
+	panic("unreachable")
+}
+
+// countLoop reports whether x has the form "for i := 0; i < n; i++ {...}" where i is assigned nowhere else and n is invariant in the loop, so that it can be a loop node counting to n.
+func countLoop(info *types.Info, locals map[*types.Var]*local, x *ast.ForStmt) (i *ast.Ident, n ast.Expr, ok bool) {
+	init, ok1 := x.Init.(*ast.AssignStmt)
+	cond, ok2 := x.Cond.(*ast.BinaryExpr)
+	post, ok3 := x.Post.(*ast.IncDecStmt)
+	if !ok1 || !ok2 || !ok3 || init.Tok != token.DEFINE || len(init.Lhs) != 1 || cond.Op != token.LSS || post.Tok != token.INC {
+		return
+	}
+	i, _ = init.Lhs[0].(*ast.Ident)
+	if i == nil || !sameVar(info, i, cond.X) || !sameVar(info, i, post.X) {
+		return
+	}
+	if v := info.Values[init.Rhs[0]]; v == nil || v.String() != "0" {
+		return
+	}
+	v := info.Objects[i].(*types.Var)
+	if l := locals[v]; l == nil || l.addr || l.captured || l.defs != 2 {
+		return
+	}
+	b, ok := underlying(v.Type).(*types.Basic)
+	if !ok || b.Info&types.IsInteger == 0 || !types.IsIdentical(v.Type, info.Types[cond.Y]) {
+		return
+	}
+	if !invariant(info, locals, cond.Y, x.Body) {
+		return
+	}
+	return i, cond.Y, true
+}
+
+// invariant reports whether x is free of side effects and its value can't change during the execution of body.
+func invariant(info *types.Info, locals map[*types.Var]*local, x ast.Expr, body *ast.BlockStmt) bool {
+	if !pure(info, x) {
+		return false
+	}
+	assigned := map[*types.Var]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		var lhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			lhs = n.Lhs
+		case *ast.IncDecStmt:
+			lhs = []ast.Expr{n.X}
+		case *ast.RangeStmt:
+			lhs = []ast.Expr{n.Key, n.Value}
+		}
+		for _, e := range lhs {
+			if id, ok := e.(*ast.Ident); ok {
+				if v, ok := info.Objects[id].(*types.Var); ok {
+					assigned[v] = true
+				}
+			}
+		}
+		return true
+	})
+	ok := true
+	ast.Inspect(x, func(n ast.Node) bool {
+		if id, ok2 := n.(*ast.Ident); ok2 {
+			if v, ok2 := info.Objects[id].(*types.Var); ok2 {
+				if l := locals[v]; l == nil || l.cell() || assigned[v] {
+					ok = false
+				}
+			}
+		}
+		return ok
+	})
+	return ok
+}
+
+// pure reports whether evaluating x has no side effects and can't panic, so that it may be evaluated early or not at all.
+// It is conservative:  only constants, local variables, and some operators, conversions, and builtins are considered pure.
+func pure(info *types.Info, x ast.Expr) bool {
+	if info.Values[x] != nil {
+		return true
+	}
+	switch x := x.(type) {
+	case *ast.Ident:
+		_, ok := info.Objects[x].(*types.Var)
+		return ok || x.Name == "nil"
+	case *ast.ParenExpr:
+		return pure(info, x.X)
+	case *ast.UnaryExpr:
+		return x.Op != token.AND && x.Op != token.ARROW && pure(info, x.X)
+	case *ast.BinaryExpr:
+		switch x.Op {
+		case token.QUO, token.REM, token.SHL, token.SHR:
+			return false
+		}
+		return pure(info, x.X) && pure(info, x.Y)
+	case *ast.CallExpr:
+		if len(x.Args) != 1 || !pure(info, x.Args[0]) {
+			return false
+		}
+		if isTypeExpr(info, x.Fun) {
+			_, basic := underlying(info.Types[x]).(*types.Basic)
+			return basic
+		}
+		if id, ok := x.Fun.(*ast.Ident); ok && (id.Name == "len" || id.Name == "cap") {
+			if _, ok := info.Objects[id].(*types.Builtin); ok {
+				switch underlying(info.Types[x.Args[0]]).(type) {
+				case *types.Basic, *types.Slice, *types.Map, *types.Chan:
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isTypeExpr(info *types.Info, x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.Ident:
+		_, ok := info.Objects[x].(*types.TypeName)
+		return ok
+	case *ast.SelectorExpr:
+		_, ok := info.Objects[x.Sel].(*types.TypeName)
+		return ok
+	case *ast.ParenExpr:
+		return isTypeExpr(info, x.X)
+	case *ast.StarExpr:
+		return isTypeExpr(info, x.X)
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+		return true
+	}
+	return false
+}
+
+func sameVar(info *types.Info, id *ast.Ident, x ast.Expr) bool {
+	id2, ok := x.(*ast.Ident)
+	return ok && info.Objects[id2] != nil && info.Objects[id2] == info.Objects[id]
+}
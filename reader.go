@@ -6,6 +6,7 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -20,29 +21,7 @@ import (
 
 func loadFunc(obj types.Object) *funcNode {
 	f := newFuncNode(obj, nil)
-	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, fluxPath(obj), nil, parser.ParseComments)
-	if err == nil {
-		r := &reader{fset, obj.GetPkg(), types.NewScope(obj.GetPkg().Scope()), map[string]*port{}, map[string][]*connection{}, ast.NewCommentMap(fset, file, file.Comments), map[int]node{}}
-		for _, i := range file.Imports {
-			path, _ := strconv.Unquote(i.Path.Value)
-			pkg, err := getPackage(path)
-			if err != nil {
-				fmt.Printf("error importing %s: %s\n", i.Path.Value, err)
-				continue
-			}
-			name := pkg.Name
-			if i.Name != nil {
-				name = i.Name.Name
-			}
-			r.scope.Insert(types.NewPkgName(0, pkg, name))
-		}
-		decl := file.Decls[len(file.Decls)-1].(*ast.FuncDecl) // get param and result var names from the source, as the obj names might not match
-		if decl.Recv != nil {
-			r.out(decl.Recv.List[0].Names[0], f.inputsNode.newOutput(obj.GetType().(*types.Signature).Recv))
-		}
-		r.fun(f, decl.Type, decl.Body)
-	} else {
+	if err := readFunc(f, nil); err != nil {
 		// this is a new func; save it
 		if isMethod(obj) {
 			f.inputsNode.newOutput(obj.GetType().(*types.Signature).Recv)
@@ -52,6 +31,84 @@ func loadFunc(obj types.Object) *funcNode {
 	return f
 }
 
+// readFunc reads the body of f from src (or, if src is nil, from f's file), as for parser.ParseFile.
+func readFunc(f *funcNode, src interface{}) error {
+	obj := f.obj
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fluxPath(obj), src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	r := &reader{fset, obj.GetPkg(), types.NewScope(obj.GetPkg().Scope()), map[string]*port{}, map[string][]*connection{}, ast.NewCommentMap(fset, file, file.Comments), map[int]node{}, map[*funcNode][]string{}}
+	for _, i := range file.Imports {
+		path, _ := strconv.Unquote(i.Path.Value)
+		pkg, err := getPackage(path)
+		if err != nil {
+			fmt.Printf("error importing %s: %s\n", i.Path.Value, err)
+			continue
+		}
+		name := pkg.Name
+		if i.Name != nil {
+			name = i.Name.Name
+		}
+		r.scope.Insert(types.NewPkgName(0, pkg, name))
+	}
+	for _, tn := range typeParams(obj.GetType().(*types.Signature)) {
+		r.scope.Insert(tn)
+	}
+	decl := file.Decls[len(file.Decls)-1].(*ast.FuncDecl) // get param and result var names from the source, as the obj names might not match
+	if decl.Recv != nil {
+		r.out(decl.Recv.List[0].Names[0], f.inputsNode.newOutput(obj.GetType().(*types.Signature).Recv))
+	}
+	r.fun(f, decl.Type, decl.Body)
+	for _, conns := range r.conns {
+		for _, c := range conns {
+			if !c.connected() { // assigned to a var that is never used, e.g. because its reader was dead code and wasn't written
+				c.block().removeConn(c)
+			}
+		}
+	}
+	for _, g := range file.Comments {
+		if g.List[0].Text == "// "+layoutComment {
+			readLayout(f, g.List[1:])
+		}
+	}
+	return nil
+}
+
+// readLayout moves the nodes of f to the positions written by writer.layout and fixes them there.
+// Each position is matched to the next node in walkInOrder order with the same description, so that a missing or extra node doesn't displace the others.
+func readLayout(f *funcNode, cmts []*ast.Comment) {
+	positions := map[string][]Point{}
+	for _, c := range cmts {
+		s := strings.SplitN(strings.TrimPrefix(c.Text, "// "), " ", 3)
+		if len(s) < 3 {
+			fmt.Printf("error reading layout: %q\n", c.Text)
+			continue
+		}
+		x, err1 := strconv.ParseFloat(s[0], 64)
+		y, err2 := strconv.ParseFloat(s[1], 64)
+		if err1 != nil || err2 != nil {
+			fmt.Printf("error reading layout: %q\n", c.Text)
+			continue
+		}
+		positions[s[2]] = append(positions[s[2]], Pt(x, y))
+	}
+	blocks := map[*block]bool{}
+	f.funcblk.walkInOrder(func(n node) {
+		desc := describeNode(n)
+		if p := positions[desc]; len(p) > 0 {
+			positions[desc] = p[1:]
+			n.Move(p[0])
+			n.block().fixed[n] = true
+			blocks[n.block()] = true
+		}
+	})
+	for b := range blocks {
+		rearrange(b)
+	}
+}
+
 type reader struct {
 	fset     *token.FileSet
 	pkg      *types.Package
@@ -60,6 +117,7 @@ type reader struct {
 	conns    map[string][]*connection
 	cmap     ast.CommentMap
 	seqNodes map[int]node
+	results  map[*funcNode][]string // the names of the results of each func read
 }
 
 func (r *reader) fun(n *funcNode, typ *ast.FuncType, body *ast.BlockStmt) {
@@ -67,7 +125,7 @@ func (r *reader) fun(n *funcNode, typ *ast.FuncType, body *ast.BlockStmt) {
 	f := n
 	if obj == nil {
 		obj = n.output.obj
-		f = n.blk.func_()
+		f = n.block().func_()
 	}
 	sig := obj.GetType().(*types.Signature)
 
@@ -77,7 +135,7 @@ func (r *reader) fun(n *funcNode, typ *ast.FuncType, body *ast.BlockStmt) {
 		f.addPkgRef(v.Type)
 	}
 	if sig.IsVariadic {
-		n.inputsNode.outs[len(n.inputsNode.outs)-1].valView.setEllipsis()
+		n.inputsNode.outputs()[len(n.inputsNode.outputs())-1].valView.setEllipsis()
 	}
 	var results []*ast.Field
 	if r := typ.Results; r != nil {
@@ -88,6 +146,7 @@ func (r *reader) fun(n *funcNode, typ *ast.FuncType, body *ast.BlockStmt) {
 		t := sig.Results[i].Type
 		r.scope.Insert(newVar(name, t)) //make the type available for r.in's handling of unknown objects
 		r.conns[name] = []*connection{}
+		r.results[n] = append(r.results[n], name)
 		f.addPkgRef(t)
 	}
 	stmts := body.List
@@ -111,16 +170,16 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 				case *ast.BinaryExpr:
 					n := newOperatorNode(types.NewFunc(0, nil, x.Op.String(), nil))
 					b.addNode(n)
-					r.in(x.X, n.ins[0])
-					r.in(x.Y, n.ins[1])
-					r.out(s.Lhs[0], n.outs[0])
+					r.in(x.X, n.inputs()[0])
+					r.in(x.Y, n.inputs()[1])
+					r.out(s.Lhs[0], n.outputs()[0])
 				case *ast.CallExpr:
 					if p, ok := x.Fun.(*ast.ParenExpr); ok { // writer puts conversions in parens for easy recognition
 						n := newConvertNode(r.pkg)
 						b.addNode(n)
 						n.setType(r.typ(p.X))
-						r.in(x.Args[0], n.ins[0])
-						r.out(s.Lhs[0], n.outs[0])
+						r.in(x.Args[0], n.inputs()[0])
+						r.out(s.Lhs[0], n.outputs()[0])
 					} else {
 						n := r.call(b, x, "", s)
 						for i, res := range s.Lhs {
@@ -140,7 +199,13 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 					r.out(s.Lhs[0], n.output)
 					r.fun(n, x.Type, x.Body)
 				case *ast.Ident, *ast.SelectorExpr, *ast.StarExpr:
-					r.value(b, x, s.Lhs[0], false, s)
+					if sel, ok := x.(*ast.SelectorExpr); ok && isParen(sel.X) { // writer puts unpacked structs in parens for easy recognition
+						r.structUnpack(b, s)
+					} else {
+						r.value(b, x, s.Lhs[0], false, s)
+					}
+				case *ast.ParenExpr: // writer puts packed structs in parens for easy recognition
+					r.structPack(b, x.X, s)
 				case *ast.IndexExpr:
 					r.index(b, x, s.Lhs[0], false, s)
 				case *ast.SliceExpr:
@@ -163,9 +228,9 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 					n := newTypeAssertNode(r.pkg)
 					b.addNode(n)
 					n.setType(r.typ(x.Type))
-					r.in(x.X, n.ins[0])
-					r.out(s.Lhs[0], n.outs[0])
-					r.out(s.Lhs[1], n.outs[1])
+					r.in(x.X, n.inputs()[0])
+					r.out(s.Lhs[0], n.outputs()[0])
+					r.out(s.Lhs[1], n.outputs()[1])
 				case *ast.UnaryExpr:
 					switch x.Op {
 					case token.AND:
@@ -180,8 +245,8 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 					case token.NOT:
 						n := newOperatorNode(types.NewFunc(0, nil, x.Op.String(), nil))
 						b.addNode(n)
-						r.in(x.X, n.ins[0])
-						r.out(s.Lhs[0], n.outs[0])
+						r.in(x.X, n.inputs()[0])
+						r.out(s.Lhs[0], n.outputs()[0])
 					case token.ARROW:
 						n := r.sendrecv(b, x.X, nil, s)
 						r.out(s.Lhs[0], n.elem)
@@ -193,6 +258,8 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 				rh := s.Rhs[0]
 				if x, ok := lh.(*ast.IndexExpr); ok {
 					r.index(b, x, rh, true, s)
+				} else if sel, ok := rh.(*ast.SelectorExpr); ok && name(lh) == "_" && isParen(sel.X) { // writer reads a field of a sequenced unpack whose fields are unused into the blank identifier
+					r.structUnpack(b, s)
 				} else if id, ok := lh.(*ast.Ident); !ok || r.conns[id.Name] == nil {
 					r.value(b, lh, rh, true, s)
 				} else {
@@ -201,11 +268,11 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 					rh := name(rh)
 					c.setSrc(r.ports[rh])
 					if cmt, ok := r.cmap[s]; ok {
-						c.src.conntxt.SetText(cmt[0].List[0].Text[2:])
+						c.src().conntxt.SetText(cmt[0].List[0].Text[2:])
 						c.toggleHidden()
 					}
 					if p, ok := r.ports[lh]; ok {
-						c.feedback = true
+						c.model.Feedback = true
 						c.setDst(p)
 					} else {
 						r.conns[lh] = append(r.conns[lh], c)
@@ -226,23 +293,28 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 					r.scope.Insert(newVar(name, r.typ(v.Type))) // local var has nil Pkg
 					r.conns[name] = []*connection{}
 				} else {
-					r.out(v.Names[0], b.node.(*loopNode).inputsNode.outs[1])
+					r.out(v.Names[0], b.node.(*loopNode).inputsNode.outputs()[1])
 				}
 			case token.CONST:
+				sign := ""
+				if u, ok := v.Values[0].(*ast.UnaryExpr); ok && u.Op == token.SUB {
+					sign = "-"
+					v.Values[0] = u.X
+				}
 				switch x := v.Values[0].(type) {
 				case *ast.BasicLit:
 					n := newBasicLiteralNode(x.Kind)
 					b.addNode(n)
 					switch x.Kind {
 					case token.INT, token.FLOAT:
-						n.text.SetText(x.Value)
+						n.text.SetText(sign + x.Value)
 					case token.IMAG:
 						// TODO
 					case token.STRING, token.CHAR:
 						text, _ := strconv.Unquote(x.Value)
 						n.text.SetText(text)
 					}
-					r.out(v.Names[0], n.outs[0])
+					r.out(v.Names[0], n.outputs()[0])
 					r.seq(n, s)
 				case *ast.Ident, *ast.SelectorExpr:
 					r.value(b, x, v.Names[0], false, s)
@@ -264,7 +336,7 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 				r.in(s.Cond.(*ast.BinaryExpr).Y, n.input)
 			}
 			if s.Init != nil {
-				r.out(s.Init.(*ast.AssignStmt).Lhs[0], n.inputsNode.outs[0])
+				r.out(s.Init.(*ast.AssignStmt).Lhs[0], n.inputsNode.outputs()[0])
 			}
 			r.block(n.loopblk, s.Body.List)
 			r.seq(n, s)
@@ -290,15 +362,25 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 			n := newLoopNode(b.childArranged)
 			b.addNode(n)
 			r.in(s.X, n.input)
-			r.out(s.Key, n.inputsNode.outs[0])
+			r.out(s.Key, n.inputsNode.outputs()[0])
 			if s.Value != nil {
-				r.out(s.Value, n.inputsNode.outs[1])
+				r.out(s.Value, n.inputsNode.outputs()[1])
 			}
 			r.block(n.loopblk, s.Body.List)
 			r.seq(n, s)
 		case *ast.ReturnStmt:
-			n := newBranchNode("return")
+			n := newReturnNode()
 			b.addNode(n)
+			names := r.results[enclosingFunc(b)]
+			for i, x := range s.Results {
+				if i+1 >= len(n.inputs()) {
+					break
+				}
+				if id, ok := x.(*ast.Ident); ok && i < len(names) && id.Name == names[i] {
+					continue // the result variable, as set through the outputsNode
+				}
+				r.in(x, n.inputs()[i+1])
+			}
 			r.seq(n, s)
 		case *ast.SelectStmt:
 			n := newSelectNode(b.childArranged)
@@ -310,8 +392,8 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 				case *ast.AssignStmt:
 					c.send = false
 					r.in(s.Rhs[0].(*ast.UnaryExpr).X, c.ch)
-					r.out(s.Lhs[0], c.elemOk.outs[0])
-					r.out(s.Lhs[1], c.elemOk.outs[1])
+					r.out(s.Lhs[0], c.elemOk.outputs()[0])
+					r.out(s.Lhs[1], c.elemOk.outputs()[1])
 				case *ast.ExprStmt:
 					c.send = false
 					r.in(s.X.(*ast.UnaryExpr).X, c.ch)
@@ -324,6 +406,51 @@ func (r *reader) block(b *block, s []ast.Stmt) {
 				r.block(c.blk, s.Body)
 			}
 			r.seq(n, s)
+		case *ast.SwitchStmt:
+			n := newSwitchNode(b.childArranged)
+			b.addNode(n)
+			if s.Tag != nil {
+				r.in(s.Tag, n.tag)
+			}
+			for _, s := range s.Body.List {
+				s := s.(*ast.CaseClause)
+				c := n.newCase()
+				for i, x := range s.List {
+					if i > 0 {
+						n.newValue(c)
+					}
+					r.in(x, c.vals[i])
+				}
+				if s.List == nil {
+					n.removeValue(c, c.vals[0])
+				}
+				r.block(c.blk, s.Body)
+			}
+			r.seq(n, s)
+		case *ast.TypeSwitchStmt:
+			n := newTypeSwitchNode(r.pkg, b.childArranged)
+			b.addNode(n)
+			var v ast.Expr
+			switch s := s.Assign.(type) {
+			case *ast.AssignStmt:
+				v = s.Lhs[0]
+				r.in(s.Rhs[0].(*ast.TypeAssertExpr).X, n.x)
+			case *ast.ExprStmt:
+				r.in(s.X.(*ast.TypeAssertExpr).X, n.x)
+			}
+			for _, s := range s.Body.List {
+				s := s.(*ast.CaseClause)
+				var t types.Type
+				if s.List != nil {
+					t = r.typ(s.List[0])
+				}
+				c := n.newCase(t)
+				if v != nil && c.val != nil {
+					r.out(v, c.val.outputs()[0])
+				}
+				r.block(c.blk, s.Body)
+			}
+			r.seq(n, s)
 		case *ast.SendStmt:
 			r.sendrecv(b, s.Chan, s.Value, s)
 		}
@@ -381,7 +508,8 @@ func (r *reader) value(b *block, x, y ast.Expr, set bool, s ast.Stmt) {
 }
 
 func (r *reader) call(b *block, x *ast.CallExpr, godefer string, s ast.Stmt) node {
-	obj := r.obj(x.Fun)
+	fun, _ := indexArgs(x.Fun) // type arguments are inferred from the inputs
+	obj := r.obj(fun)
 	if u, ok := obj.(unknownObject); ok {
 		sig := &types.Signature{}
 		if u.recv != nil {
@@ -402,10 +530,10 @@ func (r *reader) call(b *block, x *ast.CallExpr, godefer string, s ast.Stmt) nod
 	args := x.Args
 	switch {
 	case isMethod(obj):
-		recv := x.Fun.(*ast.SelectorExpr).X
+		recv := fun.(*ast.SelectorExpr).X
 		args = append([]ast.Expr{recv}, args...)
 	case obj == nil: // func value call
-		args = append([]ast.Expr{x.Fun}, args...)
+		args = append([]ast.Expr{fun}, args...)
 	}
 	if n, ok := n.(interface {
 		setType(types.Type)
@@ -427,7 +555,7 @@ func (r *reader) call(b *block, x *ast.CallExpr, godefer string, s ast.Stmt) nod
 			}
 			if v != nil {
 				if x.Ellipsis == 0 {
-					v = newVar(v.Name, v.Type.(*types.Slice).Elem)
+					v = newVar(v.Name, underlying(v.Type).(*types.Slice).Elem)
 				}
 				in := newInput(v)
 				if x.Ellipsis != 0 {
@@ -455,7 +583,7 @@ func (r *reader) compositeLit(b *block, x *ast.CompositeLit, ptr bool, s *ast.As
 	for _, elt := range x.Elts {
 		elt := elt.(*ast.KeyValueExpr)
 		var in *port
-		for _, p := range n.ins {
+		for _, p := range n.inputs() {
 			if p.obj.GetName() == name(elt.Key) {
 				in = p
 				break
@@ -467,10 +595,91 @@ func (r *reader) compositeLit(b *block, x *ast.CompositeLit, ptr bool, s *ast.As
 		}
 		r.in(elt.Value, in)
 	}
-	r.out(s.Lhs[0], n.outs[0])
+	r.out(s.Lhs[0], n.outputs()[0])
 	r.seq(n, s)
 }
 
+func (r *reader) structUnpack(b *block, s *ast.AssignStmt) {
+	n := newStructUnpackNode()
+	b.addNode(n)
+	r.in(s.Rhs[0].(*ast.SelectorExpr).X.(*ast.ParenExpr).X, n.x)
+	for i, x := range s.Rhs {
+		name := x.(*ast.SelectorExpr).Sel.Name
+		out := n.field(name)
+		if out == nil {
+			out = n.newOutput(newVar(name, nil))
+			out.bad = true
+		}
+		r.out(s.Lhs[i], out)
+	}
+	r.seq(n, s)
+}
+
+func (r *reader) structPack(b *block, x ast.Expr, s *ast.AssignStmt) {
+	ptr := false
+	if u, ok := x.(*ast.UnaryExpr); ok {
+		x, ptr = u.X, true
+	}
+	lit := x.(*ast.CompositeLit)
+	t := r.typ(lit.Type)
+	if ptr {
+		t = &types.Pointer{Elem: t}
+	}
+	n := newStructPackNode(r.pkg)
+	b.addNode(n)
+	n.setType(t)
+	r.structPackFields(n, lit, t, nil)
+	r.out(s.Lhs[0], n.outputs()[0])
+	r.seq(n, s)
+}
+
+// structPackFields connects the values of the fields set in lit, a literal of the struct type t (or a pointer to one) at the path prefix in the struct of n, to the inputs of n.
+func (r *reader) structPackFields(n *structPackNode, lit *ast.CompositeLit, t types.Type, prefix []int) {
+	t, _ = indirect(t)
+	st, _ := underlying(t).(*types.Struct)
+	for _, elt := range lit.Elts {
+		elt := elt.(*ast.KeyValueExpr)
+		var in *port
+		if i := structFieldIndex(st, name(elt.Key)); i >= 0 {
+			index := append(prefix[:len(prefix):len(prefix)], i)
+			x := elt.Value
+			if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.AND {
+				x = u.X
+			}
+			if lit, ok := x.(*ast.CompositeLit); ok { // promoted fields
+				r.structPackFields(n, lit, st.Fields[i].Type, index)
+				continue
+			}
+			in = n.field(index)
+		}
+		if in == nil {
+			if _, ok := elt.Value.(*ast.Ident); !ok {
+				continue
+			}
+			in = n.newInput(newVar(name(elt.Key), r.scope.Lookup(name(elt.Value)).(*types.Var).Type))
+			in.bad = true
+		}
+		r.in(elt.Value, in)
+	}
+}
+
+// structFieldIndex returns the index of the field named name in st, or -1 if there is none or st is nil.
+func structFieldIndex(st *types.Struct, name string) int {
+	if st != nil {
+		for i, f := range st.Fields {
+			if f.Name == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isParen(x ast.Expr) bool {
+	_, ok := x.(*ast.ParenExpr)
+	return ok
+}
+
 func (r *reader) index(b *block, x *ast.IndexExpr, y ast.Expr, set bool, s *ast.AssignStmt) {
 	n := newIndexNode(set)
 	b.addNode(n)
@@ -554,13 +763,20 @@ func (r *reader) typ(x ast.Expr) types.Type {
 			return t.Type
 		}
 		return types.NewNamed(types.NewTypeName(0, r.pkg, x.Sel.Name, nil), types.Typ[types.Invalid], nil) //unknown(t.Obj) == true
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		x, indices := indexArgs(x)
+		targs := []types.Type{}
+		for _, t := range indices {
+			targs = append(targs, r.typ(t))
+		}
+		return types.Instantiate(r.typ(x), targs)
 	case *ast.StarExpr:
 		return types.NewPointer(r.typ(x.X))
 	case *ast.ArrayType:
 		elem := r.typ(x.Elt)
 		if x.Len != nil {
-			// TODO: x.Len
-			return types.NewArray(elem, 0)
+			n, _ := strconv.ParseInt(x.Len.(*ast.BasicLit).Value, 0, 64)
+			return types.NewArray(elem, n)
 		}
 		return types.NewSlice(elem)
 	case *ast.Ellipsis:
@@ -578,6 +794,7 @@ func (r *reader) typ(x ast.Expr) types.Type {
 		return types.NewChan(dir, r.typ(x.Value))
 	case *ast.FuncType:
 		var params, results []*types.Var
+		variadic := false
 		for _, f := range x.Params.List {
 			t := r.typ(f.Type)
 			if f.Names == nil {
@@ -586,8 +803,8 @@ func (r *reader) typ(x ast.Expr) types.Type {
 			for _, n := range f.Names {
 				params = append(params, types.NewParam(0, r.pkg, n.Name, t))
 			}
+			_, variadic = f.Type.(*ast.Ellipsis)
 		}
-		variadic := false
 		if x.Results != nil {
 			for _, f := range x.Results.List {
 				t := r.typ(f.Type)
@@ -597,7 +814,6 @@ func (r *reader) typ(x ast.Expr) types.Type {
 				for _, n := range f.Names {
 					results = append(results, types.NewParam(0, r.pkg, n.Name, t))
 				}
-				_, variadic = f.Type.(*ast.Ellipsis)
 			}
 		}
 		return types.NewSignature(nil, nil, params, results, variadic)
@@ -607,7 +823,15 @@ func (r *reader) typ(x ast.Expr) types.Type {
 			for _, f := range x.Fields.List {
 				t := r.typ(f.Type)
 				if f.Names == nil {
-					fields = append(fields, types.NewField(0, r.pkg, "", t, true))
+					// an embedded field is named by its type
+					name := ""
+					switch et, _ := indirect(t); et := et.(type) {
+					case *types.Named:
+						name = et.Obj.Name
+					case *types.Basic:
+						name = et.Name
+					}
+					fields = append(fields, types.NewField(0, r.pkg, name, t, true))
 				}
 				for _, n := range f.Names {
 					fields = append(fields, types.NewField(0, r.pkg, n.Name, t, false))
@@ -633,6 +857,17 @@ func (r *reader) typ(x ast.Expr) types.Type {
 	panic("unreachable")
 }
 
+// indexArgs splits an instantiation x[indices] into x and its indices.  Any other x has no indices.
+func indexArgs(x ast.Expr) (ast.Expr, []ast.Expr) {
+	switch y := x.(type) {
+	case *ast.IndexExpr:
+		return y.X, []ast.Expr{y.Index}
+	case *ast.IndexListExpr:
+		return y.X, y.Indices
+	}
+	return x, nil
+}
+
 func (r *reader) out(x ast.Expr, out *port) {
 	r.ports[name(x)] = out
 }
@@ -640,10 +875,10 @@ func (r *reader) out(x ast.Expr, out *port) {
 func (r *reader) in(x ast.Expr, in *port) {
 	name := name(x)
 	for _, c := range r.conns[name] {
-		if c.src.obj.Type == nil { //unknown objects have nil-typed outputs; give them types so connections succeed
-			c.src.setType(r.scope.Lookup(name).(*types.Var).Type)
+		if c.src().obj.Type == nil { //unknown objects have nil-typed outputs; give them types so connections succeed
+			c.src().setType(r.scope.Lookup(name).(*types.Var).Type)
 		}
-		if !c.connectable(c.src, in) {
+		if !c.connectable(c.src(), in) {
 			c.bad = true
 		}
 		c.setDst(in)
@@ -0,0 +1,82 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+)
+
+// A returnNode returns from the innermost func enclosing it, which may be a func literal.  It has an input for each of the func's results; a connected input sets its result, while an unconnected one leaves it as set through the func's outputsNode.
+type returnNode struct {
+	*nodeBase
+	results []*types.Var // the results of the func, in the order of the inputs following the sequencing input
+}
+
+func newReturnNode() *returnNode {
+	n := &returnNode{}
+	n.nodeBase = newNodeBase(n)
+	n.text.SetText("return")
+	n.addSeqPorts()
+	return n
+}
+
+// syncResults updates the inputs of n to match the results of the func it returns from.  An input keeps its connections if its result is still present or, if n was moved to another func, if the result at its index is of a connectable type.
+func (n *returnNode) syncResults() {
+	edited(n)
+	var results []*types.Var
+	if f := enclosingFunc(n.block()); f != nil {
+		results = f.sig().Results
+	}
+	old := map[*types.Var]*port{}
+	sameFunc := false
+	for i, v := range n.results {
+		old[v] = n.inputs()[i+1]
+		for _, r := range results {
+			sameFunc = sameFunc || r == v
+		}
+	}
+
+	ins := []*port{n.inputs()[0]}
+	kept := map[*port]bool{}
+	for i, r := range results {
+		p, ok := old[r]
+		if !sameFunc && i < len(n.results) {
+			p, ok = n.inputs()[i+1], true
+		}
+		if ok {
+			p.obj.Name = r.Name
+		} else {
+			p = newInput(n, newVar(r.Name, nil))
+			n.Add(p)
+		}
+		p.setType(r.Type)
+		kept[p] = true
+		ins = append(ins, p)
+	}
+	for _, p := range n.inputs()[1:] {
+		if !kept[p] {
+			for _, c := range append([]*connection{}, p.conns()...) {
+				c.block().removeConn(c)
+			}
+			n.model.RemovePort(p.model)
+			n.Remove(p)
+		}
+	}
+	for i, p := range ins {
+		n.model.InsertPort(p.model, i)
+	}
+	n.results = append([]*types.Var{}, results...)
+	n.reform()
+}
+
+// enclosingFunc returns the innermost func (possibly a literal) whose block contains b, or nil if there is none.
+func enclosingFunc(b *block) *funcNode {
+	for ; b != nil; b = b.outer() {
+		if f, ok := b.node.(*funcNode); ok {
+			return f
+		}
+	}
+	return nil
+}
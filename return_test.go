@@ -0,0 +1,73 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"testing"
+)
+
+// addReturn adds an if node, conditioned on ok, with a return node of x as the second result.
+func addReturn(t *testing.T, f *funcNode) *returnNode {
+	addResults(f)
+	i := newIfNode(f.funcblk.childArranged)
+	f.funcblk.addNode(i)
+	blk, cond := i.newBlock()
+	connect(f.inputsNode.outputs()[1], cond)
+	ret := newReturnNode()
+	blk.addNode(ret)
+	if len(ret.inputs()) != 3 {
+		t.Fatalf("got %d inputs, want a sequencing input and one per result", len(ret.inputs()))
+	}
+	connect(f.inputsNode.outputs()[0], ret.inputs()[2])
+	return ret
+}
+
+var returnFunc = testFunc("returnExample", []*types.Var{newVar("x", intType), newVar("ok", boolType)}, []*types.Var{newVar("y", intType), newVar("z", intType)})
+
+const returnSrc = srcHeader + `func returnExample(x int, ok bool) {
+	return
+}
+`
+
+// TestReturnInNestedBlock adds a return node inside an if node, checking that it has an input for each result.
+func TestReturnInNestedBlock(t *testing.T) {
+	testEdit(t, editTest{
+		fn:   returnFunc,
+		src:  returnSrc,
+		edit: func(t *testing.T, f *funcNode) { addReturn(t, f) },
+		want: []string{
+			"block of if: node return (seq, int, int) (seq)",
+			"func block: node if (seq, bool) (seq)",
+			"func block: node inputs () (int, bool)",
+			"func block: node outputs (int, int) ()",
+			"func block: ok of inputs -> #1 of if",
+			"func block: x of inputs -> z of return",
+		},
+	})
+}
+
+// TestReturnRemovedResult checks that a return node loses the input of a removed result and keeps the connections of the others.
+func TestReturnRemovedResult(t *testing.T) {
+	testEdit(t, editTest{
+		fn:  returnFunc,
+		src: returnSrc,
+		edit: func(t *testing.T, f *funcNode) {
+			ret := addReturn(t, f)
+			f.outputsNode.removePort(f.outputsNode.inputs()[0])
+			if len(ret.inputs()) != 2 || len(ret.inputs()[1].conns()) != 1 {
+				t.Errorf("got %d inputs, want 2 with the second still connected", len(ret.inputs()))
+			}
+		},
+		want: []string{
+			"block of if: node return (seq, int) (seq)",
+			"func block: node if (seq, bool) (seq)",
+			"func block: node inputs () (int, bool)",
+			"func block: node outputs (int) ()",
+			"func block: ok of inputs -> #1 of if",
+			"func block: x of inputs -> z of return",
+		},
+	})
+}
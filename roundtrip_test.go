@@ -0,0 +1,555 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestRoundTrip reads each Flux func in the audio package, writes it back out, and checks that the output is identical to the original, that it type-checks, and that it reads back in as the same graph.
+func TestRoundTrip(t *testing.T) {
+	for _, obj := range audioFuncs(t) {
+		roundTrip(t, obj)
+	}
+}
+
+// TestLayoutRoundTrip places the nodes of each Flux func in the audio package, writes it out, and checks that the positions read back in are the same and are written back out identically.
+func TestLayoutRoundTrip(t *testing.T) {
+	for _, obj := range audioFuncs(t) {
+		layoutRoundTrip(t, obj)
+	}
+}
+
+// TestMain points GOROOT at the stubs in testdata/goroot, which declare just what the audio package uses of the standard library.  The type checker in go/types predates much of the language used by the standard library installed with Go, and can't load it.
+func TestMain(m *testing.M) {
+	if dir, err := filepath.Abs(filepath.Join("testdata", "goroot")); err == nil {
+		build.Default.GOROOT = dir
+	}
+	os.Exit(m.Run())
+}
+
+// audioPackage loads the audio package, skipping the test if the package is not in GOPATH.
+func audioPackage(t *testing.T) *types.Package {
+	paths, err := matchPackages([]string{"./audio"})
+	if err != nil || len(paths) == 0 {
+		t.Skip("skipping: ./audio is not in GOPATH")
+	}
+	pkg, err := getPackage(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func audioFuncs(t *testing.T) []types.Object {
+	pkg := audioPackage(t)
+	objs := pkgFluxFuncs(pkg)
+	if len(objs) == 0 {
+		t.Fatal("no Flux funcs found in ./audio")
+	}
+	return objs
+}
+
+func roundTrip(t *testing.T, obj types.Object) {
+	path := fluxPath(obj)
+	name := filepath.Base(path)
+	defer func() {
+		if err := recover(); err != nil {
+			t.Errorf("%s: panic: %v", name, err)
+		}
+	}()
+
+	orig, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("%s: %s", name, err)
+		return
+	}
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, orig); err != nil {
+		t.Errorf("%s: %s", name, err)
+		return
+	}
+	want := graphLines(f)
+	buf := &bytes.Buffer{}
+	writeFunc(buf, f)
+	out := buf.Bytes()
+
+	if !bytes.Equal(out, orig) {
+		t.Errorf("%s: output differs from the original (- original, + output):\n%s", name, diffLines(strings.Split(string(orig), "\n"), strings.Split(string(out), "\n")))
+	}
+
+	if err := typeCheck(obj.GetPkg().Path, map[string][]byte{path: out}); err != nil {
+		t.Errorf("%s: output does not type-check: %s", name, err)
+	}
+
+	f2 := newFuncNode(obj, nil)
+	defer f2.funcblk.close()
+	if err := readFunc(f2, out); err != nil {
+		t.Errorf("%s: output is unreadable: %s\n%s", name, err, out)
+		return
+	}
+	if got := graphLines(f2); !equalLines(got, want) {
+		t.Errorf("%s: graph changed by writing and reading (- read, + reread):\n%s", name, diffLines(want, got))
+	}
+}
+
+func layoutRoundTrip(t *testing.T, obj types.Object) {
+	name := filepath.Base(fluxPath(obj))
+	defer func() {
+		if err := recover(); err != nil {
+			t.Errorf("%s: panic: %v", name, err)
+		}
+	}()
+
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, nil); err != nil {
+		t.Errorf("%s: %s", name, err)
+		return
+	}
+	want := []Point{}
+	f.funcblk.walkInOrder(func(n node) {
+		p := Pt(float64(len(want))*12.25, -float64(len(want))/3)
+		n.Move(p)
+		n.block().fixed[n] = true
+		want = append(want, Pt(p.X, math.Floor(p.Y*100+.5)/100))
+	})
+	buf := &bytes.Buffer{}
+	writeFunc(buf, f)
+	out := buf.Bytes()
+	if !bytes.Contains(out, []byte("\n// "+layoutComment+"\n")) {
+		t.Errorf("%s: output has no layout", name)
+	}
+
+	f2 := newFuncNode(obj, nil)
+	defer f2.funcblk.close()
+	if err := readFunc(f2, out); err != nil {
+		t.Errorf("%s: output is unreadable: %s\n%s", name, err, out)
+		return
+	}
+	i := 0
+	f2.funcblk.walkInOrder(func(n node) {
+		if i < len(want) && (Pos(n) != want[i] || !n.block().fixed[n]) {
+			t.Errorf("%s: %s is at %v (fixed %v), want %v (fixed)", name, describeNode(n), Pos(n), n.block().fixed[n], want[i])
+		}
+		i++
+	})
+	if i != len(want) {
+		t.Errorf("%s: read %d nodes, want %d", name, i, len(want))
+	}
+
+	buf.Reset()
+	writeFunc(buf, f2)
+	if out2 := buf.Bytes(); !bytes.Equal(out2, out) {
+		t.Errorf("%s: layout changed by reading and writing (- written, + rewritten):\n%s", name, diffLines(strings.Split(string(out), "\n"), strings.Split(string(out2), "\n")))
+	}
+}
+
+// TestSwitchRoundTrip reads a Flux func containing switch and type switch nodes, writes it back out, and checks that the output is identical to the original and that it type-checks.
+func TestSwitchRoundTrip(t *testing.T) {
+	pkg := audioPackage(t)
+	params := []*types.Var{newVar("x", types.NewInterface(nil, nil)), newVar("i", types.Typ[types.Int])}
+	results := []*types.Var{newVar("s", types.Typ[types.String])}
+	obj := types.NewFunc(0, pkg, "switchExample", types.NewSignature(nil, nil, params, results, false))
+	src := []byte(`// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func switchExample(x interface{}, i int) (s string) {
+	var v int
+	var v2 int
+	var v3 int
+	var v4 interface{}
+	v = i
+	v3 = i
+	v4 = x
+	const x2 = 1
+	v2 = x2
+	switch v {
+	case v2:
+		const x3 = "a"
+		s = x3
+	default:
+		const x4 = "b"
+		s = x4
+	case v3:
+	}
+	switch v5 := v4.(type) {
+	case string:
+		s = v5
+	case int:
+	default:
+		return
+	}
+	return
+}
+`)
+
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, src); err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if problems := append(checkBlock(f.funcblk), writeFunc(buf, f)...); len(problems) > 0 {
+		t.Error(strings.Join(problems, "\n"))
+	}
+	if out := buf.Bytes(); !bytes.Equal(out, src) {
+		t.Errorf("output differs from the original (- original, + output):\n%s", diffLines(strings.Split(string(src), "\n"), strings.Split(string(out), "\n")))
+	}
+	if err := typeCheck(pkg.Path, map[string][]byte{fluxPath(obj): src}); err != nil {
+		t.Errorf("does not type-check: %s", err)
+	}
+}
+
+// typeCheck type-checks (including func bodies) the package at importPath with the contents of each file in srcs replaced by (or, for a new file, given by) its source.
+func typeCheck(importPath string, srcs map[string][]byte) error {
+	buildPkg, err := importPkg(importPath, 0)
+	if err != nil {
+		return err
+	}
+	fileNames := []string{}
+	for _, fileName := range append(buildPkg.GoFiles, buildPkg.CgoFiles...) {
+		fileNames = append(fileNames, filepath.Join(buildPkg.Dir, fileName))
+	}
+	for fileName := range srcs {
+		if _, err := os.Stat(fileName); err != nil {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+	for _, fileName := range fileNames {
+		var fileSrc interface{}
+		if src, ok := srcs[fileName]; ok {
+			fileSrc = src
+		}
+		file, err := parser.ParseFile(fset, fileName, fileSrc, 0)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	var errs []string
+	cfg := types.Config{FakeImportC: true, Import: srcImport, Error: func(err error) {
+		for path := range srcs {
+			if strings.HasPrefix(err.Error(), path+":") && (len(errs) == 0 || errs[len(errs)-1] != err.Error()) {
+				errs = append(errs, err.Error())
+			}
+		}
+	}}
+	cfg.Check(importPath, fset, files, nil)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// graphLines describes the graph of f, one sorted line per node and connection, independently of variable names and statement order.
+func graphLines(f *funcNode) (lines []string) {
+	f.funcblk.walk(nil, func(n node) {
+		lines = append(lines, fmt.Sprintf("%s: node %s (%s) (%s)", describeBlock(n.block()), describeNode(n), portTypes(n.inputs()), portTypes(n.outputs())))
+	}, func(c *connection) {
+		s := fmt.Sprintf("%s: %s of %s -> %s of %s", describeBlock(c.block()), describePort(c.src()), describeNode(c.src().node), describePort(c.dst()), describeNode(c.dst().node))
+		if c.model.Feedback {
+			s += " (feedback)"
+		}
+		if c.hidden {
+			s += " (hidden " + c.src().conntxt.Text() + ")"
+		}
+		lines = append(lines, s)
+	})
+	sort.Strings(lines)
+	return
+}
+
+func portTypes(ports []*port) string {
+	s := []string{}
+	for _, p := range ports {
+		if p.obj.Type == seqType {
+			s = append(s, "seq")
+		} else {
+			s = append(s, fmt.Sprint(p.obj.Type))
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffLines returns a minimal line diff from a to b, showing only the lines that differ.
+func diffLines(a, b []string) string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			fmt.Fprintf(buf, "%4d - %s\n", i+1, a[i])
+			i++
+		default:
+			fmt.Fprintf(buf, "%4d + %s\n", j+1, b[j])
+			j++
+		}
+	}
+	return buf.String()
+}
+
+func TestDiffLines(t *testing.T) {
+	for _, x := range []struct {
+		a, b, diff string
+	}{
+		{"a b c", "a b c", ""},
+		{"a b c", "a c", "   2 - b\n"},
+		{"a c", "a b c", "   2 + b\n"},
+		{"a b c", "a x c", "   2 - b\n   2 + x\n"},
+	} {
+		if diff := diffLines(strings.Fields(x.a), strings.Fields(x.b)); diff != x.diff {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", x.a, x.b, diff, x.diff)
+		}
+	}
+}
+
+// TestReadUnusedVar reads a func that assigns to a var that is never used, as happens when the node reading it was dead code and wasn't written, and checks that no connection is left dangling for the writer to trip over.
+func TestReadUnusedVar(t *testing.T) {
+	pkg := audioPackage(t)
+	params := []*types.Var{newVar("a", types.Typ[types.Int])}
+	obj := types.NewFunc(0, pkg, "unusedExample", types.NewSignature(nil, nil, params, nil, false))
+	src := []byte(`// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func unusedExample(a int) {
+	var v int
+	var v2 int
+	v = a
+	v2 = a
+	x := v + v
+	return
+}
+`)
+	f := newFuncNode(obj, nil)
+	defer f.funcblk.close()
+	if err := readFunc(f, src); err != nil {
+		t.Fatal(err)
+	}
+	f.funcblk.walk(nil, nil, func(c *connection) {
+		if !c.connected() {
+			t.Errorf("dangling connection from %s of %s", describePort(c.src()), describeNode(c.src().node))
+		}
+	})
+	writeFunc(&bytes.Buffer{}, f)
+}
+
+// TestWriteCyclic checks that the writer reports a cyclic block as a problem.
+func TestWriteCyclic(t *testing.T) {
+	pkg := audioPackage(t)
+	src := `// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func cyclicExample(a int, b int) (c int) {
+	var v int
+	var v2 int
+	var v3 int
+	var v4 int
+	v = a
+	v2 = b
+	x := v + v2
+	v3 = x
+	const x2 = 2
+	v4 = x2
+	x3 := v3 * v4
+	c = x3
+	return
+}
+`
+	f := readTestFunc(t, pkg, "cyclicExample", []*types.Var{newVar("a", types.Typ[types.Int]), newVar("b", types.Typ[types.Int])}, []*types.Var{newVar("c", types.Typ[types.Int])}, src)
+	defer f.funcblk.close()
+	ops := map[string]*operatorNode{}
+	for _, n := range f.funcblk.allNodes() {
+		if n, ok := n.(*operatorNode); ok {
+			ops[n.op] = n
+		}
+	}
+	// feed the product back into the sum
+	ins(ops["+"])[1].conns()[0].setSrc(outs(ops["*"])[0])
+	problems := writeFunc(&bytes.Buffer{}, f)
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "cyclic ") {
+		t.Errorf("got problems %q, want one cyclic block", problems)
+	}
+}
+
+// An editTest reads a func, edits it, and checks the graph that results (see testEdit).
+type editTest struct {
+	fn        func(pkg *types.Package) *types.Func
+	recursive bool // whether to put fn in the package scope, so that it can call itself
+	src       string
+	edit      func(t *testing.T, f *funcNode) // nil means no edit
+	want      []string                        // the graphLines of the edited func; nil means the same as before the edit
+}
+
+// testEdit reads the func of x, makes its edit, and checks that the resulting graph is as expected, that it has no problems, and that it is written out as a func that type-checks and reads back in as the same graph.
+func testEdit(t *testing.T, x editTest) {
+	pkg := audioPackage(t)
+	defer func() {
+		if err := recover(); err != nil {
+			t.Errorf("panic: %v", err)
+		}
+	}()
+	obj := x.fn(pkg)
+	if x.recursive {
+		pkg.Scope().Insert(obj)
+		defer delete(pkg.Scope().Objects, obj.Name)
+	}
+	f := newFuncNode(obj, nil)
+	defer func() { f.funcblk.close() }()
+	if err := readFunc(f, []byte(x.src)); err != nil {
+		t.Error(err)
+		return
+	}
+	want := graphLines(f)
+	if x.edit != nil {
+		x.edit(t, f)
+	}
+	if x.want != nil {
+		want = x.want
+	}
+	got := graphLines(f)
+	if !equalLines(got, want) {
+		t.Errorf("graph (- want, + got):\n%s", diffLines(want, got))
+	}
+	buf := &bytes.Buffer{}
+	if problems := append(checkBlock(f.funcblk), writeFunc(buf, f)...); len(problems) > 0 {
+		t.Error(strings.Join(problems, "\n"))
+	}
+
+	out := buf.String()
+	if err := typeCheck(pkg.Path, map[string][]byte{fluxPath(f.obj): []byte(out)}); err != nil {
+		t.Errorf("output does not type-check: %s\n%s", err, out)
+	}
+	g := newFuncNode(f.obj, nil)
+	defer g.funcblk.close()
+	if err := readFunc(g, []byte(out)); err != nil {
+		t.Errorf("output is unreadable: %s\n%s", err, out)
+		return
+	}
+	if reread := graphLines(g); !equalLines(reread, got) {
+		t.Errorf("graph changed by writing and reading (- edited, + reread):\n%s", diffLines(got, reread))
+	}
+}
+
+// testFunc returns a func that makes a func with the given name, params, and results.
+func testFunc(name string, params, results []*types.Var) func(pkg *types.Package) *types.Func {
+	return func(pkg *types.Package) *types.Func {
+		return types.NewFunc(0, pkg, name, types.NewSignature(nil, nil, params, results, false))
+	}
+}
+
+func readTestFunc(t *testing.T, pkg *types.Package, name string, params, results []*types.Var, src string) *funcNode {
+	f := newFuncNode(testFunc(name, params, results)(pkg), nil)
+	if err := readFunc(f, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func writeTestFunc(f *funcNode) string {
+	buf := &bytes.Buffer{}
+	writeFunc(buf, f)
+	return buf.String()
+}
+
+var (
+	intType  = types.Typ[types.Int]
+	boolType = types.Typ[types.Bool]
+	strType  = types.Typ[types.String]
+)
+
+const srcHeader = "// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.\n\npackage audio\n\n"
+
+const opsSrc = srcHeader + `func %s(a int, b int) (c int) {
+	var v int
+	var v2 int
+	var v3 int
+	var v4 int
+	v = a
+	v2 = b
+	x := v + v2
+	v3 = x
+	const x2 = 2
+	v4 = x2
+	x3 := v3 * v4
+	c = x3
+	return
+}
+`
+
+// opsFunc returns the func named name of opsSrc, which computes (a + b) * 2.
+func opsFunc(name string) (func(pkg *types.Package) *types.Func, string) {
+	return testFunc(name, []*types.Var{newVar("a", intType), newVar("b", intType)}, []*types.Var{newVar("c", intType)}), strings.Replace(opsSrc, "%s", name, 1)
+}
+
+// addResults adds an input to the outputs node of f for each result of its signature.
+func addResults(f *funcNode) {
+	for _, v := range f.obj.GetType().(*types.Signature).Results {
+		f.outputsNode.newInput(v)
+	}
+}
+
+// operator returns the operator node of f for the operator name.
+func operator(t *testing.T, f *funcNode, name string) *operatorNode {
+	for _, n := range f.funcblk.allNodes() {
+		if n, ok := n.(*operatorNode); ok && n.op == name {
+			return n
+		}
+	}
+	t.Fatalf("no %s node", name)
+	return nil
+}
@@ -6,15 +6,16 @@ package main
 
 import (
 	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
 	. "github.com/gordonklaus/flux/gui"
 )
 
 type selectNode struct {
 	*ViewBase
 	AggregateMouser
-	name *Text
+	model *graph.Node
+	name  *Text
 
-	blk           *block
 	seqIn, seqOut *port
 
 	cases   []*selectCase
@@ -26,7 +27,8 @@ type selectNode struct {
 func newSelectNode(arranged blockchan) *selectNode {
 	n := &selectNode{focused: -2, arranged: arranged}
 	n.ViewBase = NewView(n)
-	n.AggregateMouser = AggregateMouser{NewClickFocuser(n), NewMover(n)}
+	n.AggregateMouser = nodeMouser(n)
+	n.model = newGraphNode(n)
 	n.name = NewText("select")
 	n.name.SetBackgroundColor(noColor)
 	n.name.SetTextColor(color(special{}, true, false))
@@ -35,9 +37,9 @@ func newSelectNode(arranged blockchan) *selectNode {
 	n.Add(n.name)
 
 	n.seqIn = newInput(n, newVar("seq", seqType))
-	n.Add(n.seqIn)
+	addPort(n.seqIn, nil)
 	n.seqOut = newOutput(n, newVar("seq", seqType))
-	n.Add(n.seqOut)
+	addPort(n.seqOut, nil)
 
 	return n
 }
@@ -46,12 +48,12 @@ func (n *selectNode) newCase() *selectCase {
 	c := &selectCase{n: n, send: true}
 	c.ch = newInput(n, nil)
 	c.ch.connsChanged = c.connsChanged
-	n.Add(c.ch)
+	addPort(c.ch, nil)
 	c.elem = newInput(n, nil)
-	n.Add(c.elem)
+	addPort(c.elem, nil)
 	c.blk = newBlock(n, n.arranged)
 	n.cases = append(n.cases, c)
-	rearrange(n.blk)
+	rearrange(n.block())
 	return c
 }
 
@@ -82,43 +84,14 @@ func (n *selectNode) connectable(t types.Type, dst *port) bool {
 	panic("unreachable")
 }
 
-func (n selectNode) block() *block      { return n.blk }
-func (n *selectNode) setBlock(b *block) { n.blk = b }
-func (n selectNode) inputs() []*port {
-	p := []*port{n.seqIn}
-	for _, c := range n.cases {
-		if c.ch != nil {
-			p = append(p, c.ch)
-			if c.elem != nil {
-				p = append(p, c.elem)
-			}
-		}
-	}
-	return p
-}
-func (n selectNode) outputs() []*port { return []*port{n.seqOut} }
+func (n selectNode) block() *block          { return blockView(n.model.Block) }
+func (n selectNode) inputs() []*port        { return portViews(n.model.Ins) }
+func (n selectNode) outputs() []*port       { return portViews(n.model.Outs) }
+func (n selectNode) graphNode() *graph.Node { return n.model }
 
-func (n selectNode) inConns() []*connection {
-	conns := n.seqIn.conns
-	for _, c := range n.cases {
-		if c.ch != nil {
-			conns = append(conns, c.ch.conns...)
-			if c.elem != nil {
-				conns = append(conns, c.elem.conns...)
-			}
-		}
-		conns = append(conns, c.blk.inConns()...)
-	}
-	return conns
-}
+func (n selectNode) inConns() []*connection { return connViews(n.model.InConns()) }
 
-func (n selectNode) outConns() []*connection {
-	conns := n.seqOut.conns
-	for _, c := range n.cases {
-		conns = append(conns, c.blk.outConns()...)
-	}
-	return conns
-}
+func (n selectNode) outConns() []*connection { return connViews(n.model.OutConns()) }
 
 func (n *selectNode) focus(i int) {
 	n.focused = i
@@ -222,11 +195,13 @@ func (n *selectNode) KeyPress(event KeyEvent) {
 	case KeyEqual:
 		if i >= 0 && c.ch != nil {
 			if t := c.ch.obj.Type; t == nil || underlying(t).(*types.Chan).Dir == types.SendRecv {
+				edited(n)
 				c.send = !c.send
 				c.connsChanged()
 			}
 		}
 	case KeyComma:
+		edited(n)
 		n.newCase()
 		n.focus(len(n.cases) - 1)
 	case KeyBackspace, KeyDelete:
@@ -234,18 +209,13 @@ func (n *selectNode) KeyPress(event KeyEvent) {
 			n.ViewBase.KeyPress(event)
 			return
 		}
+		edited(n)
 		c := n.cases[i]
 		c.blk.close()
 		if c.ch != nil {
-			for _, c := range c.ch.conns {
-				c.blk.removeConn(c)
-			}
-			n.Remove(c.ch)
+			deletePort(c.ch)
 			if c.elem != nil {
-				for _, c := range c.elem.conns {
-					c.blk.removeConn(c)
-				}
-				n.Remove(c.elem)
+				deletePort(c.elem)
 			}
 		}
 		n.Remove(c.blk)
@@ -254,13 +224,13 @@ func (n *selectNode) KeyPress(event KeyEvent) {
 			i--
 		}
 		n.focus(i)
-		rearrange(n.blk)
+		rearrange(n.block())
 	default:
 		n.ViewBase.KeyPress(event)
 	}
 }
 
-func (n selectNode) Paint() {
+func (n selectNode) Paint(cv Canvas) {
 	for i, c := range n.cases {
 		r := RectInParent(c.blk)
 		left := r.Min.X - portSize/2
@@ -273,22 +243,22 @@ func (n selectNode) Paint() {
 			right = center
 		}
 		origin := Pt(center, 0)
-		SetColor(lineColor)
-		SetLineWidth(3)
-		DrawLine(Pt(left, 0), Pt(right, 0))
-		DrawLine(origin, Pt(center, -portSize))
+		cv.SetColor(lineColor)
+		cv.SetLineWidth(3)
+		cv.DrawLine(Pt(left, 0), Pt(right, 0))
+		cv.DrawLine(origin, Pt(center, -portSize))
 		if c.ch != nil {
 			p := CenterInParent(c.ch)
-			DrawBezier(origin, Pt(center, p.Y/2), Pt(p.X, p.Y/2), p)
+			cv.DrawBezier(origin, Pt(center, p.Y/2), Pt(p.X, p.Y/2), p)
 			if c.elem != nil {
 				p := CenterInParent(c.elem)
-				DrawBezier(origin, Pt(center, p.Y/2), Pt(p.X, p.Y/2), p)
+				cv.DrawBezier(origin, Pt(center, p.Y/2), Pt(p.X, p.Y/2), p)
 			}
 		}
 		if i == n.focused {
-			SetPointSize(2 * portSize)
-			SetColor(focusColor)
-			DrawPoint(origin)
+			cv.SetPointSize(2 * portSize)
+			cv.SetColor(focusColor)
+			cv.DrawPoint(origin)
 		}
 	}
 }
@@ -302,43 +272,34 @@ type selectCase struct {
 }
 
 func (c *selectCase) setDefault() {
-	for _, c := range c.ch.conns {
-		c.blk.removeConn(c)
-	}
-	c.n.Remove(c.ch)
+	deletePort(c.ch)
 	c.ch = nil
 	if c.elem != nil {
-		for _, c := range c.elem.conns {
-			c.blk.removeConn(c)
-		}
-		c.n.Remove(c.elem)
+		deletePort(c.elem)
 		c.elem = nil
 	} else {
 		c.blk.removeNode(c.elemOk)
 		c.elemOk = nil
 	}
-	rearrange(c.n.blk)
+	rearrange(c.n.block())
 }
 
 func (c *selectCase) connsChanged() {
 	if c.send && c.elem == nil {
 		c.elem = newInput(c.n, nil)
-		c.n.Add(c.elem)
+		addPort(c.elem, c.ch)
 		c.blk.removeNode(c.elemOk)
 		c.elemOk = nil
-		rearrange(c.n.blk)
+		rearrange(c.n.block())
 	}
 	if !c.send && c.elem != nil {
-		for _, c := range c.elem.conns {
-			c.blk.removeConn(c)
-		}
-		c.n.Remove(c.elem)
+		deletePort(c.elem)
 		c.elem = nil
 		c.elemOk = newInputsNode()
 		c.blk.addNode(c.elemOk)
 		c.elemOk.newOutput(nil)
 		c.elemOk.newOutput(newVar("ok", nil))
-		rearrange(c.n.blk)
+		rearrange(c.n.block())
 	}
 
 	t := inputType(c.ch)
@@ -351,7 +312,7 @@ func (c *selectCase) connsChanged() {
 	if c.send {
 		c.elem.setType(elem)
 	} else {
-		c.elemOk.outs[0].setType(elem)
-		c.elemOk.outs[1].setType(ok)
+		c.elemOk.outputs()[0].setType(elem)
+		c.elemOk.outputs()[1].setType(ok)
 	}
 }
@@ -0,0 +1,340 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"fmt"
+)
+
+// The nodes selected in a func all belong to one block.  Selecting a node in another block clears the selection.
+
+func (b *block) selectNode(n node) {
+	if _, ok := n.(*portsNode); ok {
+		return
+	}
+	f := b.func_()
+	for m := range f.selected {
+		if m.block() != b {
+			f.clearSelection()
+		}
+		break
+	}
+	f.selected[n] = true
+	Repaint(b)
+}
+
+func (f *funcNode) clearSelection() {
+	for n := range f.selected {
+		Repaint(n.block())
+	}
+	f.selected = map[node]bool{}
+}
+
+// selection returns the selected nodes of b in walkInOrder order.
+func (b *block) selection() (nodes []node) {
+	f := b.func_()
+	b.walkInOrder(func(n node) {
+		if f.selected[n] && n.block() == b {
+			nodes = append(nodes, n)
+		}
+	})
+	return
+}
+
+// selectionOrFocus returns the selected nodes of b or, if there are none, the node holding the key focus.
+func (b *block) selectionOrFocus() []node {
+	if nodes := b.selection(); len(nodes) > 0 {
+		return nodes
+	}
+	if n, ok := KeyFocus(b).(node); ok && n.block() == b {
+		if _, ok := n.(*portsNode); !ok {
+			return []node{n}
+		}
+	}
+	return nil
+}
+
+// A clip holds copied nodes as the source of a func whose params and results stand in for the connections that crossed the selection boundary.
+// A connection entering the selection becomes a param, which is connected to the original source when pasted if that source is still present and can be connected from there.
+// The connections leaving the selection from an output become a result, which is connected in the same way to the original destinations, but only after a cut; otherwise, the original destinations keep their connections.
+// An unconnected output also becomes a result (with no destinations) so that the node producing it isn't lost as dead code.
+type clip struct {
+	obj  types.Object
+	src  []byte
+	ins  []*port   // the original sources of the params
+	outs [][]*port // the original destinations of the results
+	cut  bool
+}
+
+var clipboard *clip
+
+// copyNodes copies nodes, which must all belong to one block, to a clip.
+func copyNodes(nodes []node) *clip {
+	if len(nodes) == 0 {
+		return nil
+	}
+	b := nodes[0].block()
+	f := b.func_()
+	h := f.history
+	f.history = nil // copying doesn't change f; the temporary results added below are removed before returning
+	defer func() { f.history = h }()
+	if focus := KeyFocus(f); focus != nil {
+		defer SetKeyFocus(focus) // removing the temporary results moves the focus
+	}
+
+	// give each unconnected output a temporary result so that it is written
+	sig := copySig(f.sig())
+	temp := map[*port]bool{}
+	for _, n := range nodes {
+		for _, p := range outs(n) {
+			if len(p.conns()) == 0 && p.obj.Type != nil {
+				t := untypedToTyped(p.obj.Type)
+				f.addPkgRef(t)
+				v := newVar("", t)
+				sig.Results = append(sig.Results, v)
+				q := f.outputsNode.newInput(v)
+				c := newConnection()
+				c.setSrc(p)
+				c.setDst(q)
+				temp[q] = true
+			}
+		}
+	}
+	laidOut := f.laidOut
+	f.laidOut = true // write all positions, by which the nodes are matched below
+	buf := &bytes.Buffer{}
+	writeFunc(buf, f)
+	f.laidOut = laidOut
+	for q := range temp {
+		f.subPkgRef(q.obj.Type)
+		f.outputsNode.removePortBase(q)
+	}
+
+	obj := types.NewFunc(0, f.pkg(), f.obj.GetName(), &sig)
+	g := newFuncNode(obj, nil)
+	defer g.funcblk.close()
+	if err := readFunc(g, buf.Bytes()); err != nil {
+		fmt.Printf("error copying nodes: %s\n", err)
+		return nil
+	}
+
+	// match the nodes of g to those of f by position and description
+	key := func(n node) string {
+		p := Pos(n)
+		return fmt.Sprintf("%s %s %s", formatCoord(p.X), formatCoord(p.Y), describeNode(n))
+	}
+	fNodes := map[string][]node{}
+	f.funcblk.walkInOrder(func(n node) {
+		fNodes[key(n)] = append(fNodes[key(n)], n)
+	})
+	orig := map[node]node{g.inputsNode: f.inputsNode, g.outputsNode: f.outputsNode}
+	g.funcblk.walkInOrder(func(n node) {
+		if m := fNodes[key(n)]; len(m) > 0 {
+			orig[n], fNodes[key(n)] = m[0], m[1:]
+		}
+	})
+	selected := map[node]bool{}
+	for n, m := range orig {
+		for _, m2 := range nodes {
+			if m == m2 {
+				selected[n] = true
+			}
+		}
+	}
+	origPort := func(p *port) *port {
+		m := orig[p.node]
+		if m == nil {
+			return nil
+		}
+		ports, mports := p.node.inputs(), m.inputs()
+		if p.out {
+			ports, mports = p.node.outputs(), m.outputs()
+		}
+		for i, q := range ports {
+			if q == p && i < len(mports) {
+				return mports[i]
+			}
+		}
+		return nil
+	}
+	inside := func(n node) bool {
+		for !selected[n] {
+			b := n.block()
+			if b == nil {
+				return false
+			}
+			n = b.node
+		}
+		return true
+	}
+
+	oldParams, oldResults := append([]*port{}, g.inputsNode.outputs()...), append([]*port{}, g.outputsNode.inputs()...)
+	c := &clip{obj: obj}
+	params, results := []*types.Var{}, []*types.Var{}
+	srcs, dsts := map[*port]*port{}, map[*port]int{}
+	var sel []node
+	g.funcblk.walkInOrder(func(n node) {
+		if selected[n] {
+			sel = append(sel, n)
+		}
+		if !inside(n) {
+			return
+		}
+		for _, in := range n.inputs() {
+			for _, conn := range append([]*connection{}, in.conns()...) {
+				if inside(conn.src().node) {
+					continue
+				}
+				if in.obj.Type == seqType {
+					conn.block().removeConn(conn)
+					continue
+				}
+				t := conn.src().obj.Type
+				p, ok := srcs[conn.src()]
+				if b, isBasic := t.(*types.Basic); isBasic && b.Info&types.IsUntyped != 0 {
+					t, ok = untypedToTyped(in.obj.Type), false // a distinct param for each destination, each having the destination's type
+				}
+				if !ok {
+					v := newVar(conn.src().obj.Name, t)
+					params = append(params, v)
+					g.addPkgRef(t)
+					p = g.inputsNode.newOutput(v)
+					srcs[conn.src()] = p
+					c.ins = append(c.ins, origPort(conn.src()))
+				}
+				conn.setSrc(p)
+			}
+		}
+		for _, out := range n.outputs() {
+			for _, conn := range append([]*connection{}, out.conns()...) {
+				if inside(conn.dst().node) {
+					continue
+				}
+				if out.obj.Type == seqType {
+					conn.block().removeConn(conn)
+					continue
+				}
+				t := out.obj.Type
+				i, ok := dsts[out]
+				if b, isBasic := t.(*types.Basic); isBasic && b.Info&types.IsUntyped != 0 {
+					t, ok = untypedToTyped(conn.dst().obj.Type), false // a distinct result for each destination, each having the destination's type
+				}
+				dst := origPort(conn.dst())
+				if !ok {
+					v := newVar(out.obj.Name, t)
+					results = append(results, v)
+					g.addPkgRef(t)
+					i = len(c.outs)
+					dsts[out] = i
+					c.outs = append(c.outs, nil)
+					conn.setDst(g.outputsNode.newInput(v))
+				} else {
+					conn.block().removeConn(conn)
+				}
+				if dst != nil && !temp[dst] {
+					c.outs[i] = append(c.outs[i], dst)
+				}
+			}
+		}
+	})
+	if len(sel) == 0 {
+		return nil
+	}
+	g.funcblk.moveNodes(sel)
+	for _, n := range g.funcblk.nodes() {
+		if !selected[n] && n != g.inputsNode && n != g.outputsNode {
+			g.funcblk.removeNode(n)
+		}
+	}
+	for _, p := range oldParams {
+		g.subPkgRef(p.obj.Type)
+		g.inputsNode.removePortBase(p)
+	}
+	for _, p := range oldResults {
+		g.subPkgRef(p.obj.Type)
+		g.outputsNode.removePortBase(p)
+	}
+
+	c.obj = types.NewFunc(0, f.pkg(), "clip", types.NewSignature(nil, nil, params, results, false))
+	g.obj = c.obj
+	g.laidOut = true
+	buf.Reset()
+	writeFunc(buf, g)
+	c.src = buf.Bytes()
+	return c
+}
+
+// paste adds the nodes of c to b and returns them.
+func (b *block) paste(c *clip) []node {
+	g := newFuncNode(c.obj, nil)
+	defer g.funcblk.close()
+	if err := readFunc(g, c.src); err != nil {
+		fmt.Printf("error pasting nodes: %s\n", err)
+		return nil
+	}
+	f := b.func_()
+	var nodes []node
+	g.funcblk.walkInOrder(func(n node) {
+		if n.block() == g.funcblk {
+			nodes = append(nodes, n)
+		}
+	})
+	b.moveNodes(nodes)
+	for i, p := range g.inputsNode.outputs() {
+		for _, conn := range append([]*connection{}, p.conns()...) {
+			if src := c.ins[i]; src != nil && attached(src, f) && conn.connectable(src, conn.dst()) {
+				conn.setSrc(src)
+			} else {
+				conn.block().removeConn(conn)
+			}
+		}
+	}
+	for i, p := range g.outputsNode.inputs() {
+		for _, conn := range append([]*connection{}, p.conns()...) {
+			if c.cut {
+				for _, dst := range c.outs[i] {
+					if attached(dst, f) && conn.connectable(conn.src(), dst) {
+						conn2 := newConnection()
+						conn2.setSrc(conn.src())
+						conn2.setDst(dst)
+					}
+				}
+			}
+			conn.block().removeConn(conn)
+		}
+	}
+	c.cut = false
+	b.removeNode(g.inputsNode)
+	b.removeNode(g.outputsNode)
+	pasted := []node{}
+	for _, n := range nodes {
+		if n != g.inputsNode && n != g.outputsNode {
+			pasted = append(pasted, n)
+		}
+	}
+	return pasted
+}
+
+// attached reports whether p still belongs to f.
+func attached(p *port, f *funcNode) bool {
+	found := false
+	for _, q := range append(p.node.inputs(), p.node.outputs()...) {
+		found = found || q == p
+	}
+	if !found {
+		return false
+	}
+	for n := p.node; n != f; {
+		b := n.block()
+		if b == nil {
+			return false
+		}
+		n = b.node
+	}
+	return true
+}
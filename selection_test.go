@@ -0,0 +1,72 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"testing"
+)
+
+// TestCutCopyPaste copies an operator node and pastes it into its func and into another, checking which of its connections are restored, and cuts and pastes a node back into its func.
+func TestCutCopyPaste(t *testing.T) {
+	fn, src := opsFunc("pasteExample")
+	testEdit(t, editTest{
+		fn:  fn,
+		src: src,
+		edit: func(t *testing.T, f *funcNode) {
+			orig := graphLines(f)
+			unchanged := func(what string) {
+				if got := graphLines(f); !equalLines(got, orig) {
+					t.Errorf("%s changed the func (- original, + got):\n%s", what, diffLines(orig, got))
+				}
+			}
+
+			// copy and paste within f:  the inputs are reconnected, the output is not
+			c := copyNodes([]node{operator(t, f, "+")})
+			if c == nil {
+				t.Fatal("copy failed")
+			}
+			if len(c.ins) != 2 || len(c.outs) != 1 {
+				t.Fatalf("copied %d ins and %d outs, want 2 and 1", len(c.ins), len(c.outs))
+			}
+			unchanged("copying")
+			nodes := f.funcblk.paste(c)
+			if len(nodes) != 1 {
+				t.Fatalf("pasted %d nodes, want 1", len(nodes))
+			}
+			for i, in := range ins(nodes[0]) {
+				if len(in.conns()) != 1 || in.conns()[0].src() != f.inputsNode.outputs()[i] {
+					t.Errorf("pasted input %d is not connected to param %d", i, i)
+				}
+			}
+			if n := len(outs(nodes[0])[0].conns()); n != 0 {
+				t.Errorf("pasted output has %d connections, want 0", n)
+			}
+			f.funcblk.removeNode(nodes[0])
+
+			// paste into another func:  there is nothing to reconnect
+			g := readTestFunc(t, f.obj.GetPkg(), "pasteExample2", []*types.Var{newVar("a", intType)}, nil, srcHeader+"func pasteExample2(a int) () {\n\treturn\n}\n")
+			defer g.funcblk.close()
+			nodes = g.funcblk.paste(c)
+			if len(nodes) != 1 {
+				t.Fatalf("pasted %d nodes into another func, want 1", len(nodes))
+			}
+			for i, in := range ins(nodes[0]) {
+				if len(in.conns()) != 0 {
+					t.Errorf("input %d pasted into another func is connected", i)
+				}
+			}
+
+			// cut and paste within f:  the func is restored
+			mul := operator(t, f, "*")
+			c = copyNodes([]node{mul})
+			f.funcblk.removeNode(mul)
+			c.cut = true
+			if nodes := f.funcblk.paste(c); len(nodes) != 1 {
+				t.Fatalf("pasted %d nodes, want 1", len(nodes))
+			}
+		},
+	})
+}
@@ -0,0 +1,236 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"sort"
+)
+
+// A structUnpackNode reads all of the fields of a struct, or of the struct pointed to, including the fields promoted from embedded structs.  It has an output for each field, of which the unconnected ones are collapsed.
+type structUnpackNode struct {
+	*nodeBase
+	x      *port
+	fields []*types.Selection // in the order of the outputs following the sequencing output
+}
+
+func newStructUnpackNode() *structUnpackNode {
+	n := &structUnpackNode{}
+	n.nodeBase = newNodeBase(n)
+	n.text.SetText("unpack")
+	n.x = n.newInput(nil)
+	n.x.connsChanged = n.connsChanged
+	n.addSeqPorts()
+	n.collapse = true
+	return n
+}
+
+func (n *structUnpackNode) connectable(t types.Type, dst *port) bool {
+	return isStructOrPtr(t)
+}
+
+// connsChanged gives n an output for each field of its input's type.  An output keeps its connections if the new type has a field of the same name and type.
+func (n *structUnpackNode) connsChanged() {
+	t := inputType(n.x)
+	n.x.setType(t)
+	var fields []*types.Selection
+	if t != nil {
+		fields = structFields(t, n.block().func_().pkg(), false)
+	}
+
+	old := map[string]*port{}
+	for _, p := range n.outputs()[1:] {
+		old[p.obj.Name] = p
+	}
+	outs := []*port{n.outputs()[0]}
+	for _, f := range fields {
+		v := f.Obj.(*types.Var)
+		p, ok := old[v.Name]
+		if ok && types.IsIdentical(p.obj.Type, v.Type) {
+			delete(old, v.Name)
+		} else {
+			p = newOutput(n, newVar(v.Name, v.Type))
+			n.Add(p)
+		}
+		outs = append(outs, p)
+	}
+	for _, p := range old {
+		for _, c := range append([]*connection{}, p.conns()...) {
+			c.block().removeConn(c)
+		}
+		n.model.RemovePort(p.model)
+		n.Remove(p)
+	}
+	for i, p := range outs {
+		n.model.InsertPort(p.model, i)
+	}
+	n.fields = fields
+	n.reform()
+}
+
+// field returns the output of n for the field named name, or nil if there is none.
+func (n *structUnpackNode) field(name string) *port {
+	for i, f := range n.fields {
+		if f.Obj.GetName() == name {
+			return n.outputs()[i+1]
+		}
+	}
+	return nil
+}
+
+// A structPackNode makes a struct, or a pointer to a new one, from values for its fields, including the fields promoted from embedded structs.  It is a composite literal in which the promoted fields are set through nested composite literals for their embedded structs.  It has an input for each field, of which the unconnected ones are collapsed.
+type structPackNode struct {
+	*nodeBase
+	fields []*types.Selection // in the order of the inputs following the sequencing input
+}
+
+func newStructPackNode(currentPkg *types.Package) *structPackNode {
+	n := &structPackNode{}
+	n.nodeBase = newNodeBase(n)
+	n.text.SetText("pack")
+	out := n.newOutput(nil)
+	n.addSeqPorts()
+	n.typ = newTypeView(&out.obj.Type, currentPkg)
+	n.typ.mode = structOrPtrType
+	n.Add(n.typ)
+	n.collapse = true
+	return n
+}
+
+func (n *structPackNode) editType() {
+	n.typ.editType(func() {
+		if t := *n.typ.typ; t != nil {
+			n.setType(t)
+		} else {
+			n.block().removeNode(n)
+			SetKeyFocus(n.block())
+		}
+	})
+}
+
+func (n *structPackNode) setType(t types.Type) {
+	n.typ.setType(t)
+	n.outputs()[0].setType(t)
+	n.block().func_().addPkgRef(t)
+	n.fields = structFields(t, n.block().func_().pkg(), true)
+	for _, f := range n.fields {
+		v := f.Obj.(*types.Var)
+		n.newInput(newVar(v.Name, v.Type))
+	}
+	n.reform()
+	SetKeyFocus(n)
+}
+
+// connectable reports whether a value of type t can be connected to the input for a field, which may not be set both directly and through a promoted field of its own.
+func (n *structPackNode) connectable(t types.Type, dst *port) bool {
+	i := n.fieldIndex(dst)
+	if i < 0 || i >= len(n.fields) {
+		return false
+	}
+	for j, f := range n.fields {
+		if j != i && len(n.inputs()[j+1].conns()) > 0 && (isPrefix(f.Index, n.fields[i].Index) || isPrefix(n.fields[i].Index, f.Index)) {
+			return false
+		}
+	}
+	return assignable(t, dst.obj.Type)
+}
+
+// fieldIndex returns the index in n.fields of the field of input p, or -1 if p is not one.
+func (n *structPackNode) fieldIndex(p *port) int {
+	for i, q := range n.inputs()[1:] {
+		if q == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// field returns the input of n for the field at the path index, or nil if there is none.
+func (n *structPackNode) field(index []int) *port {
+	for i, f := range n.fields {
+		if isPrefix(f.Index, index) && len(f.Index) == len(index) {
+			return n.inputs()[i+1]
+		}
+	}
+	return nil
+}
+
+// setBelow reports whether any field promoted through the embedded field at the path index is connected.
+func (n *structPackNode) setBelow(index []int) bool {
+	for i, f := range n.fields {
+		if len(f.Index) > len(index) && isPrefix(index, f.Index) && len(n.inputs()[i+1].conns()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *structPackNode) removePort(p *port) {
+	if p.bad {
+		n.removePortBase(p)
+	}
+}
+
+// structFields returns the fields of the struct t, or of the struct t points to, that are visible from pkg, including the fields promoted from embedded structs, in the order of their declaration.
+// If settable, it omits the promoted fields that can't be set in a composite literal because an embedded field on the way to them is not visible.
+func structFields(t types.Type, pkg *types.Package, settable bool) (fields []*types.Selection) {
+	fset := types.NewFieldSet(t)
+	for i := 0; i < fset.Len(); i++ {
+		f := fset.At(i)
+		if invisible(f.Obj, pkg) || settable && !embeddingVisible(t, f.Index, pkg) {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	sort.Sort(byFieldIndex(fields))
+	return
+}
+
+// embeddingVisible reports whether the embedded fields along the path index through the struct t (or the struct t points to), all but the last of index, are visible from pkg.
+func embeddingVisible(t types.Type, index []int, pkg *types.Package) bool {
+	for _, i := range index[:len(index)-1] {
+		t, _ = indirect(underlying(t))
+		f := underlying(t).(*types.Struct).Fields[i]
+		if invisible(f, pkg) {
+			return false
+		}
+		t = f.Type
+	}
+	return true
+}
+
+func isStructOrPtr(t types.Type) bool {
+	t, _ = indirect(underlying(t))
+	_, ok := underlying(t).(*types.Struct)
+	return ok
+}
+
+func isPrefix(prefix, index []int) bool {
+	if len(prefix) > len(index) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != index[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// byFieldIndex sorts fields by their paths, which puts them in the order of their declaration with each embedded field followed by the fields promoted from it.
+type byFieldIndex []*types.Selection
+
+func (f byFieldIndex) Len() int      { return len(f) }
+func (f byFieldIndex) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f byFieldIndex) Less(i, j int) bool {
+	a, b := f[i].Index, f[j].Index
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,110 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"strings"
+	"testing"
+)
+
+// noteStruct returns struct{Note; Name string}.
+func noteStruct(pkg *types.Package) *types.Struct {
+	note := pkg.Scope().Lookup("Note").GetType()
+	return types.NewStruct([]*types.Var{types.NewField(0, pkg, "Note", note, true), types.NewField(0, pkg, "Name", strType, false)}, nil)
+}
+
+func structFunc(results bool) func(pkg *types.Package) *types.Func {
+	return func(pkg *types.Package) *types.Func {
+		s := noteStruct(pkg)
+		var res []*types.Var
+		if results {
+			res = []*types.Var{newVar("y", s)}
+		}
+		return testFunc("structExample", []*types.Var{newVar("x", &types.Pointer{Elem: s})}, res)(pkg)
+	}
+}
+
+const structSrc = srcHeader + `func structExample(x *struct{Note; Name string}) {
+	return
+}
+`
+
+// TestStructPackUnpack unpacks a struct param, checking that only connected fields are shown, and packs some of its fields, including a promoted one, into a struct result.
+func TestStructPackUnpack(t *testing.T) {
+	testEdit(t, editTest{
+		fn:  structFunc(true),
+		src: structSrc,
+		edit: func(t *testing.T, f *funcNode) {
+			pkg := f.obj.GetPkg()
+			addResults(f)
+			unpack := newStructUnpackNode()
+			f.funcblk.addNode(unpack)
+			connect(f.inputsNode.outputs()[0], unpack.x)
+			var names []string
+			for _, p := range outs(unpack) {
+				names = append(names, p.obj.Name)
+			}
+			if got, want := strings.Join(names, " "), "Note Start Attributes Name"; got != want {
+				t.Fatalf("got outputs %s, want %s", got, want)
+			}
+			if !Hidden(unpack.field("Note")) {
+				t.Error("unconnected output is not collapsed")
+			}
+
+			pack := newStructPackNode(pkg)
+			f.funcblk.addNode(pack)
+			pack.setType(noteStruct(pkg))
+			if len(ins(pack)) != 4 {
+				t.Fatalf("got %d pack inputs, want 4", len(ins(pack)))
+			}
+			connect(unpack.field("Start"), pack.field([]int{0, 0}))
+			connect(unpack.field("Name"), pack.field([]int{1}))
+			connect(pack.outputs()[0], f.outputsNode.inputs()[0])
+			if Hidden(unpack.field("Start")) {
+				t.Error("connected output is collapsed")
+			}
+			if pack.connectable(pkg.Scope().Lookup("Note").GetType(), pack.field([]int{0})) {
+				t.Error("an embedded field can be set along with a field promoted from it")
+			}
+		},
+		want: []string{
+			"func block: #1 of pack -> y of outputs",
+			"func block: Name of unpack -> Name of pack",
+			"func block: Start of unpack -> Start of pack",
+			"func block: node inputs () (*struct{github.com/gordonklaus/flux/audio.Note; Name string})",
+			"func block: node outputs (struct{github.com/gordonklaus/flux/audio.Note; Name string}) ()",
+			"func block: node pack (seq, github.com/gordonklaus/flux/audio.Note, float64, map[string][]github.com/gordonklaus/flux/audio.ControlPeriod, string) (struct{github.com/gordonklaus/flux/audio.Note; Name string}, seq)",
+			"func block: node unpack (*struct{github.com/gordonklaus/flux/audio.Note; Name string}, seq) (seq, github.com/gordonklaus/flux/audio.Note, float64, map[string][]github.com/gordonklaus/flux/audio.ControlPeriod, string)",
+			"func block: x of inputs -> #1 of unpack",
+		},
+	})
+}
+
+// TestStructUnpackSequenced checks that a sequenced struct unpack is kept even though none of its fields are used.
+func TestStructUnpackSequenced(t *testing.T) {
+	testEdit(t, editTest{
+		fn:  structFunc(false),
+		src: structSrc,
+		edit: func(t *testing.T, f *funcNode) {
+			u1, u2 := newStructUnpackNode(), newStructUnpackNode()
+			f.funcblk.addNode(u1)
+			f.funcblk.addNode(u2)
+			connect(f.inputsNode.outputs()[0], u1.x)
+			connect(f.inputsNode.outputs()[0], u2.x)
+			connect(seqOut(u1), seqIn(u2))
+		},
+		want: []string{
+			"func block: node inputs () (*struct{github.com/gordonklaus/flux/audio.Note; Name string})",
+			"func block: node outputs () ()",
+			"func block: node unpack (*struct{github.com/gordonklaus/flux/audio.Note; Name string}, seq) (seq, github.com/gordonklaus/flux/audio.Note, float64, map[string][]github.com/gordonklaus/flux/audio.ControlPeriod, string)",
+			"func block: node unpack (*struct{github.com/gordonklaus/flux/audio.Note; Name string}, seq) (seq, github.com/gordonklaus/flux/audio.Note, float64, map[string][]github.com/gordonklaus/flux/audio.ControlPeriod, string)",
+			"func block: seq of unpack -> seq of unpack",
+			"func block: x of inputs -> #1 of unpack",
+			"func block: x of inputs -> #1 of unpack",
+		},
+	})
+}
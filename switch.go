@@ -0,0 +1,319 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
+	. "github.com/gordonklaus/flux/gui"
+)
+
+type switchNode struct {
+	*ViewBase
+	AggregateMouser
+	model *graph.Node
+	name  *Text
+
+	seqIn, seqOut *port
+	tag           *port
+
+	cases   []*switchCase
+	focused int
+
+	arranged blockchan
+}
+
+type switchCase struct {
+	vals []*port // a default case has no values
+	blk  *block
+}
+
+func newSwitchNode(arranged blockchan) *switchNode {
+	n := &switchNode{focused: -2, arranged: arranged}
+	n.ViewBase = NewView(n)
+	n.AggregateMouser = nodeMouser(n)
+	n.model = newGraphNode(n)
+	n.name = NewText("switch")
+	n.name.SetBackgroundColor(noColor)
+	n.name.SetTextColor(color(special{}, true, false))
+	n.name.SetFrameSize(3)
+	n.name.Move(Pt(-Width(n.name)-portSize/2, -portSize))
+	n.Add(n.name)
+
+	n.seqIn = newInput(n, newVar("seq", seqType))
+	addPort(n.seqIn, nil)
+	n.tag = newInput(n, nil)
+	n.tag.connsChanged = n.connsChanged
+	addPort(n.tag, nil)
+	n.seqOut = newOutput(n, newVar("seq", seqType))
+	addPort(n.seqOut, nil)
+
+	return n
+}
+
+func (n *switchNode) newCase() *switchCase {
+	c := &switchCase{}
+	c.blk = newBlock(n, n.arranged)
+	n.cases = append(n.cases, c)
+	n.newValue(c)
+	return c
+}
+
+func (n *switchNode) newValue(c *switchCase) *port {
+	v := newInput(n, nil)
+	if t := n.tag.obj.Type; t != nil {
+		v.setType(t)
+	} else {
+		v.setType(types.Typ[types.Bool])
+	}
+	after := n.tag
+	for _, c2 := range n.cases {
+		if len(c2.vals) > 0 {
+			after = c2.vals[len(c2.vals)-1]
+		}
+		if c2 == c {
+			break
+		}
+	}
+	c.vals = append(c.vals, v)
+	addPort(v, after)
+	rearrange(n.block())
+	return v
+}
+
+// connsChanged updates the types of the tag and case values.  Without a tag, the values are boolean conditions.
+func (n *switchNode) connsChanged() {
+	t := untypedToTyped(inputType(n.tag))
+	n.tag.setType(t)
+	if t == nil {
+		t = types.Typ[types.Bool]
+	}
+	for _, c := range n.cases {
+		for _, v := range c.vals {
+			v.setType(t)
+		}
+	}
+}
+
+func (n *switchNode) connectable(t types.Type, dst *port) bool {
+	if !types.Comparable(t) {
+		return false
+	}
+	if dst == n.tag {
+		vals := []*port{}
+		for _, c := range n.cases {
+			vals = append(vals, c.vals...)
+		}
+		return assignableToAll(t, vals...)
+	}
+	if inputType(n.tag) == nil {
+		return assignable(t, types.Typ[types.Bool])
+	}
+	return assignableToAll(t, n.tag)
+}
+
+func (n switchNode) block() *block          { return blockView(n.model.Block) }
+func (n switchNode) inputs() []*port        { return portViews(n.model.Ins) }
+func (n switchNode) outputs() []*port       { return portViews(n.model.Outs) }
+func (n switchNode) graphNode() *graph.Node { return n.model }
+
+func (n switchNode) inConns() []*connection { return connViews(n.model.InConns()) }
+
+func (n switchNode) outConns() []*connection { return connViews(n.model.OutConns()) }
+
+func (n *switchNode) focus(i int) {
+	n.focused = i
+	if i == -1 {
+		n.name.SetFrameColor(focusColor)
+		panTo(n.name, Center(n.name))
+	} else {
+		n.name.SetFrameColor(noColor)
+		panTo(n, Pt(CenterInParent(n.cases[i].blk).X, 0))
+	}
+	SetKeyFocus(n)
+	Repaint(n)
+}
+
+func (n *switchNode) focusFrom(v View) {
+	if v == n.tag {
+		n.focus(-1)
+		return
+	}
+	for i, c := range n.cases {
+		if v == c.blk {
+			n.focus(i)
+			return
+		}
+		for _, p := range c.vals {
+			if v == p {
+				n.focus(i)
+				return
+			}
+		}
+	}
+}
+
+// removePort removes the case value p.  A case without values becomes the default case, but only one default case is allowed.
+func (n *switchNode) removePort(p *port) {
+	for i, c := range n.cases {
+		for _, v := range c.vals {
+			if v != p {
+				continue
+			}
+			if len(c.vals) == 1 && n.hasDefault() {
+				return
+			}
+			n.removeValue(c, v)
+			if len(c.vals) > 0 {
+				SetKeyFocus(c.vals[(len(c.vals)-1)/2])
+			} else {
+				n.focus(i)
+			}
+			return
+		}
+	}
+}
+
+func (n *switchNode) removeValue(c *switchCase, v *port) {
+	deletePort(v)
+	for i, v2 := range c.vals {
+		if v2 == v {
+			c.vals = append(c.vals[:i], c.vals[i+1:]...)
+			break
+		}
+	}
+	rearrange(n.block())
+}
+
+func (n *switchNode) hasDefault() bool {
+	for _, c := range n.cases {
+		if len(c.vals) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *switchNode) Move(p Point) {
+	n.ViewBase.Move(p)
+	nodeMoved(n)
+}
+
+func (n *switchNode) TookKeyFocus() {
+	if n.focused < -1 {
+		n.focused = -1
+	}
+	Repaint(n)
+	if n.focused == -1 {
+		n.name.SetFrameColor(focusColor)
+		panTo(n.name, Center(n.name))
+	} else {
+		n.name.SetFrameColor(noColor)
+		panTo(n, Pt(CenterInParent(n.cases[n.focused].blk).X, 0))
+	}
+}
+func (n *switchNode) LostKeyFocus() {
+	n.focused = -2
+	Repaint(n)
+	n.name.SetFrameColor(noColor)
+}
+
+func (n *switchNode) KeyPress(event KeyEvent) {
+	switch event.Key {
+	case KeyUp, KeyDown, KeyLeft, KeyRight:
+		if event.Alt {
+			n.ViewBase.KeyPress(event)
+			return
+		}
+	}
+
+	i := n.focused
+	var c *switchCase
+	if i >= 0 {
+		c = n.cases[i]
+	}
+	switch event.Key {
+	case KeyUp:
+		if i == -1 {
+			SetKeyFocus(n.tag)
+		} else if len(c.vals) > 0 {
+			SetKeyFocus(c.vals[(len(c.vals)-1)/2])
+		}
+	case KeyDown:
+		if i >= 0 {
+			c.blk.focus()
+		}
+	case KeyLeft:
+		if i > -1 {
+			n.focus(i - 1)
+		}
+	case KeyRight:
+		if i < len(n.cases)-1 {
+			n.focus(i + 1)
+		}
+	case KeyComma:
+		edited(n)
+		if event.Shift {
+			if i >= 0 {
+				SetKeyFocus(n.newValue(c))
+			}
+			break
+		}
+		n.newCase()
+		n.focus(len(n.cases) - 1)
+	case KeyBackspace, KeyDelete:
+		if i == -1 {
+			n.ViewBase.KeyPress(event)
+			return
+		}
+		edited(n)
+		c.blk.close()
+		for _, v := range c.vals {
+			deletePort(v)
+		}
+		n.Remove(c.blk)
+		n.cases = append(n.cases[:i], n.cases[i+1:]...)
+		if event.Key == KeyBackspace || i == len(n.cases) {
+			i--
+		}
+		n.focus(i)
+		rearrange(n.block())
+	default:
+		n.ViewBase.KeyPress(event)
+	}
+}
+
+func (n switchNode) Paint(cv Canvas) {
+	tag := CenterInParent(n.tag)
+	cv.SetColor(lineColor)
+	cv.SetLineWidth(3)
+	cv.DrawLine(Pt(tag.X, 0), tag)
+	for i, c := range n.cases {
+		r := RectInParent(c.blk)
+		left := r.Min.X - portSize/2
+		right := r.Max.X + portSize/2
+		center := r.Center().X
+		if i == 0 {
+			left = tag.X
+		}
+		if i == len(n.cases)-1 {
+			right = center
+		}
+		origin := Pt(center, 0)
+		cv.SetColor(lineColor)
+		cv.SetLineWidth(3)
+		cv.DrawLine(Pt(left, 0), Pt(right, 0))
+		cv.DrawLine(origin, Pt(center, -portSize))
+		for _, v := range c.vals {
+			p := CenterInParent(v)
+			cv.DrawBezier(origin, Pt(center, p.Y/2), Pt(p.X, p.Y/2), p)
+		}
+		if i == n.focused {
+			cv.SetPointSize(2 * portSize)
+			cv.SetColor(focusColor)
+			cv.DrawPoint(origin)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"testing"
+)
+
+const switchTestSrc = `// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.
+
+package audio
+
+func switchTest(ok bool, i int) {
+	return
+}
+`
+
+func readSwitchTestFunc(t *testing.T) *funcNode {
+	pkg := audioPackage(t)
+	params := []*types.Var{newVar("ok", types.Typ[types.Bool]), newVar("i", types.Typ[types.Int])}
+	return readTestFunc(t, pkg, "switchTest", params, nil, switchTestSrc)
+}
+
+// TestSwitchConnectable checks that the case values of a switch without a tag accept booleans, and that they take the tag's type once it is connected.
+func TestSwitchConnectable(t *testing.T) {
+	f := readSwitchTestFunc(t)
+	defer f.funcblk.close()
+	n := newSwitchNode(nil)
+	f.funcblk.addNode(n)
+	v := n.newCase().vals[0]
+
+	for _, x := range []struct {
+		typ  types.Type
+		want bool
+	}{{types.Typ[types.Bool], true}, {types.Typ[types.Int], false}} {
+		if got := n.connectable(x.typ, v); got != x.want {
+			t.Errorf("without a tag: connectable(%s) = %v, want %v", x.typ, got, x.want)
+		}
+	}
+
+	c := newConnection()
+	c.setSrc(f.inputsNode.outputs()[1])
+	c.setDst(n.tag)
+	if got := v.obj.Type; got != types.Type(types.Typ[types.Int]) {
+		t.Errorf("with an int tag: value has type %s, want int", got)
+	}
+	for _, x := range []struct {
+		typ  types.Type
+		want bool
+	}{{types.Typ[types.Bool], false}, {types.Typ[types.Int], true}} {
+		if got := n.connectable(x.typ, v); got != x.want {
+			t.Errorf("with an int tag: connectable(%s) = %v, want %v", x.typ, got, x.want)
+		}
+	}
+
+	c.disconnect()
+	if got := v.obj.Type; got != types.Type(types.Typ[types.Bool]) {
+		t.Errorf("after disconnecting the tag: value has type %s, want bool", got)
+	}
+}
+
+// TestSwitchUnconnectedValues checks that unconnected case values are not written, nor are cases all of whose values are unconnected, and that the result type-checks and reads back in as a switch with only the connected value.
+func TestSwitchUnconnectedValues(t *testing.T) {
+	f := readSwitchTestFunc(t)
+	defer f.funcblk.close()
+	n := newSwitchNode(nil)
+	f.funcblk.addNode(n)
+	c := n.newCase()
+	n.newValue(c)
+	n.newCase()
+	conn := newConnection()
+	conn.setSrc(f.inputsNode.outputs()[0])
+	conn.setDst(c.vals[1])
+
+	out := writeTestFunc(f)
+	if err := typeCheck(f.obj.GetPkg().Path, map[string][]byte{fluxPath(f.obj): []byte(out)}); err != nil {
+		t.Errorf("does not type-check: %s\n%s", err, out)
+	}
+
+	f2 := readTestFunc(t, f.obj.GetPkg(), "switchTest", f.obj.GetType().(*types.Signature).Params, nil, out)
+	defer f2.funcblk.close()
+	var n2 *switchNode
+	for _, n := range f2.funcblk.nodes() {
+		if n, ok := n.(*switchNode); ok {
+			n2 = n
+		}
+	}
+	if n2 == nil {
+		t.Fatalf("no switch read from\n%s", out)
+	}
+	if len(n2.cases) != 1 || len(n2.cases[0].vals) != 1 {
+		t.Fatalf("read %d cases, want 1 with 1 value, from\n%s", len(n2.cases), out)
+	}
+	if conns := n2.cases[0].vals[0].conns(); len(conns) != 1 || conns[0].src() != f2.inputsNode.outputs()[0] {
+		t.Errorf("case value is not connected to ok in\n%s", out)
+	}
+	if len(n2.tag.conns()) != 0 {
+		t.Errorf("tag is connected in\n%s", out)
+	}
+}
@@ -0,0 +1,9 @@
+package math
+
+const Pi = 3.14159265358979323846264338327950288419716939937510582097494459
+
+func Exp(x float64) float64    { return x }
+func Log(x float64) float64    { return x }
+func Pow(x, y float64) float64 { return x }
+func Sin(x float64) float64    { return x }
+func Tanh(x float64) float64   { return x }
@@ -0,0 +1,26 @@
+package reflect
+
+type Kind uint
+
+const (
+	Invalid Kind = iota
+	Interface
+	Ptr
+	Slice
+	Struct
+)
+
+type Value struct{ p *int }
+
+func ValueOf(i interface{}) Value          { return Value{} }
+func Indirect(v Value) Value               { return v }
+func (v Value) CanAddr() bool              { return false }
+func (v Value) CanInterface() bool         { return false }
+func (v Value) Kind() Kind                 { return 0 }
+func (v Value) Addr() Value                { return v }
+func (v Value) Interface() (i interface{}) { return nil }
+func (v Value) Set(x Value)                {}
+func (v Value) NumField() int              { return 0 }
+func (v Value) Field(i int) Value          { return v }
+func (v Value) Len() int                   { return 0 }
+func (v Value) Index(i int) Value          { return v }
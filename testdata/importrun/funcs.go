@@ -0,0 +1,71 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package importrun holds funcs that TestImportRun imports and runs, comparing their results with those of the originals.  Each takes a slice of ints and returns an int.
+package importrun
+
+func Sum(xs []int) (s int) {
+	for _, x := range xs {
+		s += x * 2
+	}
+	return
+}
+
+func Total(xs []int) int {
+	t := 0
+	for _, x := range xs {
+		t += x
+	}
+	return t
+}
+
+func Last(xs []int) int {
+	last := -1
+	for _, x := range xs {
+		last = x
+	}
+	return last
+}
+
+func Count(xs []int) int {
+	c := 0
+	for i := 0; i < len(xs); i++ {
+		c += i * xs[i]
+	}
+	return c
+}
+
+func Positives(xs []int) int {
+	n := 0
+	for _, x := range xs {
+		if x <= 0 {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func FirstNegative(xs []int) int {
+	i := 0
+	for i < len(xs) {
+		if xs[i] < 0 {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+func MaxPrefix(xs []int) int {
+	max := 0
+	sum := 0
+	for _, x := range xs {
+		sum += x
+		if sum > max {
+			max = sum
+		}
+	}
+	return max - sum
+}
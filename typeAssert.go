@@ -41,8 +41,8 @@ func (n *typeAssertNode) editType() {
 		if t := *n.typ.typ; t != nil {
 			n.setType(t)
 		} else {
-			n.blk.removeNode(n)
-			SetKeyFocus(n.blk)
+			n.block().removeNode(n)
+			SetKeyFocus(n.block())
 		}
 	})
 }
@@ -50,7 +50,7 @@ func (n *typeAssertNode) editType() {
 func (n *typeAssertNode) setType(t types.Type) {
 	n.typ.setType(t)
 	if t != nil {
-		n.blk.func_().addPkgRef(t)
+		n.block().func_().addPkgRef(t)
 		n.reform()
 		SetKeyFocus(n)
 	}
@@ -0,0 +1,184 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
+	"math"
+)
+
+// A typeParamsView shows and edits the type parameters of a generic func or type, each as its name and constraint.
+// The type parameters are changed in place, so that the types already referring to them stay valid.
+type typeParamsView struct {
+	*ViewBase
+	tparams    *[]*types.TypeName
+	currentPkg *types.Package
+	done       func() // called when editing is finished
+	reformed   func() // called when the size changes, so that the owner can place v
+
+	open, close *Text
+	elems       []*typeView
+	focused     bool
+}
+
+func newTypeParamsView(tparams *[]*types.TypeName, currentPkg *types.Package) *typeParamsView {
+	v := &typeParamsView{tparams: tparams, currentPkg: currentPkg, done: func() {}, reformed: func() {}}
+	v.ViewBase = NewView(v)
+	v.open = NewText("[")
+	v.close = NewText("]")
+	for _, t := range []*Text{v.open, v.close} {
+		t.SetTextColor(color(&types.TypeName{}, true, false))
+		t.SetBackgroundColor(noColor)
+		v.Add(t)
+	}
+	v.refresh()
+	return v
+}
+
+func (v *typeParamsView) refresh() {
+	for _, e := range v.elems {
+		v.Remove(e)
+	}
+	v.elems = nil
+	for i, tn := range *v.tparams {
+		tn.Type.(*types.TypeParam).Index = i
+		e := newValueView(tn, v.currentPkg)
+		v.elems = append(v.elems, e)
+		v.Add(e)
+	}
+	v.reform()
+}
+
+// reform lays out the type parameters in a row between brackets, which are hidden if there are none and v isn't being edited.
+func (v *typeParamsView) reform() {
+	const spacing = 4
+	empty := len(v.elems) == 0 && !v.focused
+	h := 0.0
+	for _, e := range v.elems {
+		h = math.Max(h, Height(e))
+	}
+	x := 0.0
+	if !empty {
+		h = math.Max(h, Height(v.open))
+		v.open.Move(Pt(x, (h-Height(v.open))/2))
+		x += Width(v.open)
+	}
+	for i, e := range v.elems {
+		if i > 0 {
+			x += spacing
+		}
+		e.Move(Pt(x, (h-Height(e))/2))
+		x += Width(e)
+	}
+	if !empty {
+		v.close.Move(Pt(x, (h-Height(v.close))/2))
+		x += Width(v.close)
+	}
+	Show(v.open)
+	Show(v.close)
+	if empty {
+		Hide(v.open)
+		Hide(v.close)
+	}
+	v.SetRect(Rectangle{Max: Pt(x, h)})
+	v.reformed()
+}
+
+// edit focuses the first type parameter or, if there are none, adds one.
+func (v *typeParamsView) edit() {
+	if len(v.elems) > 0 {
+		SetKeyFocus(v.elems[0])
+		return
+	}
+	SetKeyFocus(v)
+	v.insert(0)
+}
+
+// insert adds a type parameter at index i and edits its name and constraint; it is removed again if either is left empty.
+func (v *typeParamsView) insert(i int) {
+	tn := types.NewTypeName(0, v.currentPkg, "", nil)
+	types.NewTypeParam(tn, i, nil)
+	*v.tparams = append((*v.tparams)[:i], append([]*types.TypeName{tn}, (*v.tparams)[i:]...)...)
+	v.refresh()
+	e := v.elems[i]
+	e.edit(func() {
+		if *e.typ == nil || tn.Name == "" {
+			v.remove(i)
+			v.focus(i - 1)
+		} else {
+			SetKeyFocus(e)
+		}
+	})
+}
+
+// focus focuses the i'th type parameter, or the last one if there are fewer, or v if there are none.
+func (v *typeParamsView) focus(i int) {
+	if i >= len(v.elems) {
+		i = len(v.elems) - 1
+	}
+	if i < 0 {
+		SetKeyFocus(v)
+	} else {
+		SetKeyFocus(v.elems[i])
+	}
+}
+
+func (v *typeParamsView) remove(i int) {
+	*v.tparams = append((*v.tparams)[:i], (*v.tparams)[i+1:]...)
+	if len(*v.tparams) == 0 {
+		*v.tparams = nil
+	}
+	v.refresh()
+}
+
+func (v *typeParamsView) TookKeyFocus() { v.focused = true; v.reform(); Repaint(v) }
+func (v *typeParamsView) LostKeyFocus() { v.focused = false; v.reform(); Repaint(v) }
+
+func (v *typeParamsView) KeyPress(event KeyEvent) {
+	i := -1
+	for j, e := range v.elems {
+		if e == KeyFocus(v) {
+			i = j
+		}
+	}
+	switch event.Key {
+	case KeyEnter:
+		if i < 0 {
+			v.edit()
+		}
+	case KeyLeft:
+		if i > 0 {
+			SetKeyFocus(v.elems[i-1])
+		}
+	case KeyRight:
+		if i >= 0 && i < len(v.elems)-1 {
+			SetKeyFocus(v.elems[i+1])
+		}
+	case KeyComma:
+		if i < 0 {
+			i = len(v.elems)
+		} else if !event.Shift {
+			i++
+		}
+		v.insert(i)
+	case KeyDelete:
+		if i >= 0 {
+			v.remove(i)
+			v.focus(i)
+		}
+	case KeyEscape:
+		v.done()
+	default:
+		v.ViewBase.KeyPress(event)
+	}
+}
+
+func (v *typeParamsView) Paint(cv Canvas) {
+	if v.focused {
+		cv.SetColor(typeFocusColor)
+		cv.FillRect(Rect(v))
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/gordonklaus/flux/go/types"
+	"github.com/gordonklaus/flux/graph"
+	. "github.com/gordonklaus/flux/gui"
+)
+
+type typeSwitchNode struct {
+	*ViewBase
+	AggregateMouser
+	model      *graph.Node
+	name       *Text
+	currentPkg *types.Package
+
+	seqIn, seqOut *port
+	x             *port
+
+	cases   []*typeSwitchCase
+	focused int
+
+	arranged blockchan
+}
+
+type typeSwitchCase struct {
+	typ *typeView // nil for the default case
+	blk *block
+	val *portsNode // holds the asserted value; nil for the default case
+}
+
+func newTypeSwitchNode(currentPkg *types.Package, arranged blockchan) *typeSwitchNode {
+	n := &typeSwitchNode{currentPkg: currentPkg, focused: -2, arranged: arranged}
+	n.ViewBase = NewView(n)
+	n.AggregateMouser = nodeMouser(n)
+	n.model = newGraphNode(n)
+	n.name = NewText("typeSwitch")
+	n.name.SetBackgroundColor(noColor)
+	n.name.SetTextColor(color(special{}, true, false))
+	n.name.SetFrameSize(3)
+	n.name.Move(Pt(-Width(n.name)-portSize/2, -portSize))
+	n.Add(n.name)
+
+	n.seqIn = newInput(n, newVar("seq", seqType))
+	addPort(n.seqIn, nil)
+	n.x = newInput(n, nil)
+	n.x.connsChanged = n.connsChanged
+	addPort(n.x, nil)
+	n.seqOut = newOutput(n, newVar("seq", seqType))
+	addPort(n.seqOut, nil)
+
+	return n
+}
+
+// newCase adds a case of type t, or a default case if t is nil.
+func (n *typeSwitchNode) newCase(t types.Type) *typeSwitchCase {
+	c := &typeSwitchCase{}
+	c.blk = newBlock(n, n.arranged)
+	n.cases = append(n.cases, c)
+	if t != nil {
+		n.setCaseType(c, t)
+	}
+	rearrange(n.block())
+	return c
+}
+
+// setCaseType sets the type of c to t, turning c into the default case if t is nil.
+func (n *typeSwitchNode) setCaseType(c *typeSwitchCase, t types.Type) {
+	if c.typ != nil {
+		if t := *c.typ.typ; t != nil {
+			n.block().func_().subPkgRef(t)
+		}
+	}
+	if t == nil {
+		if c.typ != nil {
+			n.Remove(c.typ)
+			c.typ = nil
+			c.blk.removeNode(c.val)
+			c.val = nil
+		}
+		rearrange(n.block())
+		return
+	}
+	if c.typ == nil {
+		c.typ = newTypeView(new(types.Type), n.currentPkg)
+		c.typ.mode = anyType
+		n.Add(c.typ)
+		c.val = newInputsNode()
+		c.blk.addNode(c.val)
+		c.val.newOutput(nil)
+	}
+	c.typ.setType(t)
+	n.block().func_().addPkgRef(t)
+	n.connsChanged()
+	rearrange(n.block())
+}
+
+// editCase edits the type of c.  If no type is chosen, c keeps its old type or, if it has none, remains the default case (or is removed if there already is one).
+func (n *typeSwitchNode) editCase(c *typeSwitchCase) {
+	var old types.Type
+	if c.typ != nil {
+		old = *c.typ.typ
+		c.typ.setType(nil)
+	} else {
+		c.typ = newTypeView(new(types.Type), n.currentPkg)
+		c.typ.mode = anyType
+		n.Add(c.typ)
+	}
+	rearrange(n.block())
+	c.typ.editType(func() {
+		t := *c.typ.typ
+		if t == nil {
+			t = old
+		}
+		c.typ.setType(old)
+		if old == nil {
+			n.Remove(c.typ)
+			c.typ = nil
+		}
+		if t == nil {
+			for _, c2 := range n.cases {
+				if c2 != c && c2.typ == nil {
+					i := n.removeCase(c)
+					if i == len(n.cases) {
+						i--
+					}
+					n.focus(i)
+					return
+				}
+			}
+		}
+		n.setCaseType(c, t)
+		for i, c2 := range n.cases {
+			if c2 == c {
+				n.focus(i)
+			}
+		}
+	})
+}
+
+// removeCase removes c, returning its former index.
+func (n *typeSwitchNode) removeCase(c *typeSwitchCase) int {
+	for i, c2 := range n.cases {
+		if c2 != c {
+			continue
+		}
+		if c.typ != nil {
+			if t := *c.typ.typ; t != nil {
+				n.block().func_().subPkgRef(t)
+			}
+			n.Remove(c.typ)
+		}
+		c.blk.close()
+		n.Remove(c.blk)
+		n.cases = append(n.cases[:i], n.cases[i+1:]...)
+		rearrange(n.block())
+		return i
+	}
+	return -1
+}
+
+func (n *typeSwitchNode) connsChanged() {
+	t := inputType(n.x)
+	n.x.setType(t)
+	for _, c := range n.cases {
+		if c.val != nil {
+			var u types.Type
+			if t != nil {
+				u = *c.typ.typ
+			}
+			c.val.outputs()[0].setType(u)
+		}
+	}
+}
+
+func (n *typeSwitchNode) connectable(t types.Type, dst *port) bool {
+	i, ok := underlying(t).(*types.Interface)
+	if !ok {
+		return false
+	}
+	for _, c := range n.cases {
+		if c.typ != nil && *c.typ.typ != nil && !types.AssertableTo(i, *c.typ.typ) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n typeSwitchNode) block() *block          { return blockView(n.model.Block) }
+func (n typeSwitchNode) inputs() []*port        { return portViews(n.model.Ins) }
+func (n typeSwitchNode) outputs() []*port       { return portViews(n.model.Outs) }
+func (n typeSwitchNode) graphNode() *graph.Node { return n.model }
+
+func (n typeSwitchNode) inConns() []*connection { return connViews(n.model.InConns()) }
+
+func (n typeSwitchNode) outConns() []*connection { return connViews(n.model.OutConns()) }
+
+func (n *typeSwitchNode) focus(i int) {
+	n.focused = i
+	if i == -1 {
+		n.name.SetFrameColor(focusColor)
+		panTo(n.name, Center(n.name))
+	} else {
+		n.name.SetFrameColor(noColor)
+		panTo(n, Pt(CenterInParent(n.cases[i].blk).X, 0))
+	}
+	SetKeyFocus(n)
+	Repaint(n)
+}
+
+func (n *typeSwitchNode) focusFrom(v View) {
+	if v == n.x {
+		n.focus(-1)
+		return
+	}
+	for i, c := range n.cases {
+		if v == c.blk || c.val != nil && v == c.val {
+			n.focus(i)
+			return
+		}
+	}
+}
+
+func (n *typeSwitchNode) Move(p Point) {
+	n.ViewBase.Move(p)
+	nodeMoved(n)
+}
+
+func (n *typeSwitchNode) TookKeyFocus() {
+	if n.focused < -1 {
+		n.focused = -1
+	}
+	Repaint(n)
+	if n.focused == -1 {
+		n.name.SetFrameColor(focusColor)
+		panTo(n.name, Center(n.name))
+	} else {
+		n.name.SetFrameColor(noColor)
+		panTo(n, Pt(CenterInParent(n.cases[n.focused].blk).X, 0))
+	}
+}
+func (n *typeSwitchNode) LostKeyFocus() {
+	n.focused = -2
+	Repaint(n)
+	n.name.SetFrameColor(noColor)
+}
+
+func (n *typeSwitchNode) KeyPress(event KeyEvent) {
+	switch event.Key {
+	case KeyUp, KeyDown, KeyLeft, KeyRight:
+		if event.Alt {
+			n.ViewBase.KeyPress(event)
+			return
+		}
+	}
+
+	i := n.focused
+	switch event.Key {
+	case KeyUp:
+		SetKeyFocus(n.x)
+	case KeyDown:
+		if i >= 0 {
+			if c := n.cases[i]; c.val != nil {
+				SetKeyFocus(c.val)
+			} else {
+				c.blk.focus()
+			}
+		}
+	case KeyLeft:
+		if i > -1 {
+			n.focus(i - 1)
+		}
+	case KeyRight:
+		if i < len(n.cases)-1 {
+			n.focus(i + 1)
+		}
+	case KeyEnter:
+		if i >= 0 {
+			edited(n)
+			n.editCase(n.cases[i])
+		}
+	case KeyComma:
+		edited(n)
+		n.editCase(n.newCase(nil))
+	case KeyBackspace, KeyDelete:
+		if i == -1 {
+			n.ViewBase.KeyPress(event)
+			return
+		}
+		edited(n)
+		n.removeCase(n.cases[i])
+		if event.Key == KeyBackspace || i == len(n.cases) {
+			i--
+		}
+		n.focus(i)
+	default:
+		n.ViewBase.KeyPress(event)
+	}
+}
+
+// caseTypeRect returns the rect in which the type of a case is displayed, above its block b.
+func caseTypeRect(b Rectangle, t *typeView) Rectangle {
+	x := b.Center().X
+	return Rectangle{Pt(x-Width(t)/2, portSize/2), Pt(x+Width(t)/2, portSize/2+Height(t))}
+}
+
+func (n typeSwitchNode) Paint(cv Canvas) {
+	x := CenterInParent(n.x)
+	cv.SetColor(lineColor)
+	cv.SetLineWidth(3)
+	cv.DrawLine(Pt(x.X, 0), x)
+	for i, c := range n.cases {
+		r := RectInParent(c.blk)
+		left := r.Min.X - portSize/2
+		right := r.Max.X + portSize/2
+		center := r.Center().X
+		if i == 0 {
+			left = x.X
+		}
+		if i == len(n.cases)-1 {
+			right = center
+		}
+		origin := Pt(center, 0)
+		cv.SetColor(lineColor)
+		cv.SetLineWidth(3)
+		cv.DrawLine(Pt(left, 0), Pt(right, 0))
+		cv.DrawLine(origin, Pt(center, -portSize))
+		if i == n.focused {
+			cv.SetPointSize(2 * portSize)
+			cv.SetColor(focusColor)
+			cv.DrawPoint(origin)
+		}
+	}
+}
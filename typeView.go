@@ -26,6 +26,7 @@ type typeView struct {
 	unexported *Text
 	ellipsis   bool
 	focused    bool
+	tparams    *typeParamsView // non-nil if this is the view of a type declaration
 }
 
 type typeViewMode int
@@ -35,6 +36,8 @@ const (
 	comparableType
 	compositeOrPtrType
 	compositeType
+	structOrPtrType
+	structType
 	makeableType
 )
 
@@ -65,6 +68,8 @@ func newValueView(val types.Object, currentPkg *types.Package) *typeView {
 		if !val.Anonymous {
 			name = &val.Name
 		}
+	case *types.TypeName: // a type parameter and its constraint
+		t, name = &val.Type.(*types.TypeParam).Constraint, &val.Name
 	}
 	v := newTypeView(t, currentPkg)
 	v.val = val
@@ -94,6 +99,9 @@ func (v *typeView) setType(t types.Type) {
 	if v.name != nil {
 		v.Add(v.name)
 	}
+	if v.tparams != nil {
+		v.Add(v.tparams)
+	}
 
 	s := ""
 	switch t := t.(type) {
@@ -106,11 +114,25 @@ func (v *typeView) setType(t types.Type) {
 			v.pkg.setPkg(p)
 			v.Add(v.pkg)
 		}
+		// the type arguments are shown but not edited in place, as an instance is shared by all its uses
+		args := append([]types.Type{}, t.TypeArgs...)
+		if t.Orig == nil {
+			for _, tn := range t.TypeParams {
+				args = append(args, tn.Type)
+			}
+		}
+		for i := range args {
+			v.elems.right = append(v.elems.right, newTypeView(&args[i], v.currentPkg))
+		}
+	case *types.TypeParam:
+		s = t.Obj.Name
 	case *types.Pointer:
 		s = "*"
 		elem := newTypeView(&t.Elem, v.currentPkg)
 		if v.mode == compositeOrPtrType {
 			elem.mode = compositeType
+		} else if v.mode == structOrPtrType {
+			elem.mode = structType
 		}
 		v.elems.right = []*typeView{elem}
 	case *types.Array:
@@ -153,6 +175,12 @@ func (v *typeView) setType(t types.Type) {
 		}
 	case *types.Interface:
 		s = "interface"
+		if isAny(t) {
+			s = "any"
+		}
+		if len(t.Terms) > 0 && len(t.Methods) == 0 {
+			s = types.TypeString(v.currentPkg, t)
+		}
 		for _, m := range t.Methods {
 			if invisible(m, v.currentPkg) {
 				v.unexported = NewText("contains unexported methods")
@@ -164,8 +192,8 @@ func (v *typeView) setType(t types.Type) {
 	v.text.SetText(s)
 	if v.unexported != nil {
 		// TODO: small font
-		v.unexported.SetTextColor(Color{.3, .3, .3, 1})
-		v.unexported.SetBackgroundColor(Color{0, 0, 0, .3})
+		v.unexported.SetTextColor(unexportedColor)
+		v.unexported.SetBackgroundColor(unexportedBackgroundColor)
 		v.Add(v.unexported)
 	}
 	for _, c := range append(v.elems.left, v.elems.right...) {
@@ -214,6 +242,10 @@ func (v *typeView) reform() {
 		v.name.Move(Pt(0, (math.Max(h1, h2)-Height(v.name))/2))
 		x += Width(v.name) + spacing
 	}
+	if v.tparams != nil && Width(v.tparams) > 0 {
+		v.tparams.Move(Pt(x, (math.Max(h1, h2)-Height(v.tparams))/2))
+		x += Width(v.tparams) + spacing
+	}
 	y := math.Max(0, h2-h1) / 2
 	for i := len(v.elems.left) - 1; i >= 0; i-- {
 		c := v.elems.left[i]
@@ -275,8 +307,8 @@ func (v *typeView) unique(name string) bool {
 	if p, ok := Parent(v).(*port); ok {
 		ports := append(ins(p.node), outs(p.node)...)
 		if n, ok := p.node.(*portsNode); ok {
-			f := n.blk.node.(*funcNode)
-			ports = append(f.inputsNode.outs, f.outputsNode.ins...)
+			f := n.block().node.(*funcNode)
+			ports = append(f.inputsNode.outputs(), f.outputsNode.inputs()...)
 		}
 		for _, p2 := range ports {
 			if p2 != p && p2.obj.Name == name {
@@ -285,6 +317,17 @@ func (v *typeView) unique(name string) bool {
 		}
 		return true
 	}
+	if p, ok := Parent(v).(*typeParamsView); ok {
+		if name == "" {
+			return false
+		}
+		for _, tn := range *p.tparams {
+			if tn != v.val && tn.Name == name {
+				return false
+			}
+		}
+		return true
+	}
 	switch t := (*Parent(v).(*typeView).typ).(type) {
 	case *types.Struct:
 		if name == "" {
@@ -327,6 +370,8 @@ func (v *typeView) editType(done func()) {
 			comparableType:     isComparableType,
 			compositeOrPtrType: isCompositeOrPtrType,
 			compositeType:      isCompositeType,
+			structOrPtrType:    isStructOrPtrType,
+			structType:         isStructType,
 			makeableType:       isMakeableType,
 		}[v.mode]
 		b := newBrowser(opts, v)
@@ -347,7 +392,9 @@ func (v *typeView) editType(done func()) {
 			done()
 		}
 		SetKeyFocus(b)
-	case *types.Basic, *types.Named:
+	case *types.Named:
+		v.editTypeArgs(t, done)
+	case *types.Basic, *types.TypeParam:
 		done()
 	case *types.Pointer, *types.Array, *types.Slice, *types.Chan:
 		if elt := v.elems.right[0]; *elt.typ == nil {
@@ -388,10 +435,51 @@ func (v *typeView) editType(done func()) {
 			v.addVars(&t.Results, &v.elems.right, done)
 		})
 	case *types.Interface:
+		if isAny(t) || t.Terms != nil {
+			done()
+			return
+		}
 		v.addMethods(&t.Methods, &v.elems.right, done)
 	}
 }
 
+// editTypeArgs edits the type arguments of an instance of a generic type, starting from the generic type itself.
+// Once they are all chosen and satisfy their constraints, the instance replaces the partial one being edited.
+func (v *typeView) editTypeArgs(t *types.Named, done func()) {
+	if t.Orig == nil {
+		if len(t.TypeParams) == 0 {
+			done()
+			return
+		}
+		v.setType(&types.Named{Obj: t.Obj, TypeArgs: make([]types.Type, len(t.TypeParams)), Orig: t})
+		v.editType(done)
+		return
+	}
+	for i, targ := range t.TypeArgs {
+		if targ != nil {
+			continue
+		}
+		arg := v.elems.right[i]
+		arg.editType(func() {
+			if *arg.typ == nil {
+				v.setType(nil)
+			} else if t.TypeArgs[i] = *arg.typ; i == len(t.TypeArgs)-1 {
+				if j := types.Verify(t.Orig.TypeParams, t.TypeArgs); j >= 0 {
+					t.TypeArgs[j] = nil
+					v.refresh()
+				} else {
+					v.setType(types.Instantiate(t.Orig, t.TypeArgs))
+				}
+			} else {
+				v.refresh()
+			}
+			v.editType(done)
+		})
+		return
+	}
+	done()
+}
+
 func (v *typeView) insertVar(vs *[]*types.Var, elems *[]*typeView, before bool, i int, success, fail func()) {
 	if !before {
 		i++
@@ -465,6 +553,14 @@ func (v *typeView) addMethods(m *[]*types.Func, elems *[]*typeView, done func())
 
 func (v *typeView) refresh() { v.setType(*v.typ) }
 
+// setTypeParams shows and edits tparams, the type parameters of the declared type shown by v.
+func (v *typeView) setTypeParams(tparams *[]*types.TypeName) {
+	v.tparams = newTypeParamsView(tparams, v.currentPkg)
+	v.tparams.done = func() { SetKeyFocus(v) }
+	v.tparams.reformed = v.reform
+	v.refresh()
+}
+
 func (v *typeView) setEllipsis() { v.ellipsis = true; v.refresh() }
 
 func (v *typeView) TookKeyFocus() { v.focused = true; Repaint(v) }
@@ -611,6 +707,8 @@ func (v *typeView) KeyPress(event KeyEvent) {
 					}
 				}
 			}
+		} else {
+			v.ViewBase.KeyPress(event)
 		}
 	case KeyDelete:
 		if p, ok := Parent(v).(*typeView); ok {
@@ -679,34 +777,45 @@ func (v *typeView) KeyPress(event KeyEvent) {
 					}
 				}
 			}
+		} else {
+			v.ViewBase.KeyPress(event)
 		}
 	default:
+		if event.Text == "[" && v.tparams != nil {
+			v.tparams.edit()
+			return
+		}
 		v.ViewBase.KeyPress(event)
 	}
 }
 
-func (v *typeView) Paint() {
+func (v *typeView) Paint(cv Canvas) {
 	if v.focused {
-		SetColor(Color{.25, .25, .25, 1})
-		FillRect(Rect(v))
+		cv.SetColor(typeFocusColor)
+		cv.FillRect(Rect(v))
 	}
 	if _, ok := Parent(v).(*typeView); ok {
-		SetColor(lineColor)
-		SetLineWidth(1)
-		DrawRect(Rect(v))
+		cv.SetColor(lineColor)
+		cv.SetLineWidth(1)
+		cv.DrawRect(Rect(v))
 	}
 	if t, ok := (*v.typ).(*types.Named); ok && unknown(t.Obj) {
-		SetColor(Color{1, 0, 0, 1})
-		SetLineWidth(3)
+		cv.SetColor(errorColor)
+		cv.SetLineWidth(3)
 		r := Rect(v)
-		DrawLine(r.Min, r.Max)
-		DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
+		cv.DrawLine(r.Min, r.Max)
+		cv.DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
 	}
 }
 
+// isAny reports whether t is the predeclared any, which must not be edited in place.
+func isAny(t types.Type) bool {
+	return t == types.Universe.Lookup("any").GetType()
+}
+
 func underlying(t types.Type) types.Type {
 	if nt, ok := t.(*types.Named); ok {
-		return nt.UnderlyingT
+		return nt.Underlying()
 	}
 	return t
 }
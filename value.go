@@ -107,6 +107,7 @@ func (n *valueNode) connsChanged() {
 
 func (n *valueNode) KeyPress(event KeyEvent) {
 	if event.Text == "=" && n.addressable {
+		edited(n)
 		n.set = !n.set
 		n.connsChanged()
 		SetKeyFocus(n)
@@ -115,13 +116,13 @@ func (n *valueNode) KeyPress(event KeyEvent) {
 	}
 }
 
-func (n *valueNode) Paint() {
-	n.nodeBase.Paint()
+func (n *valueNode) Paint(cv Canvas) {
+	n.nodeBase.Paint(cv)
 	if n.obj != nil && unknown(n.obj) {
-		SetColor(Color{1, 0, 0, 1})
-		SetLineWidth(3)
+		cv.SetColor(errorColor)
+		cv.SetLineWidth(3)
 		r := RectInParent(n.text)
-		DrawLine(r.Min, r.Max)
-		DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
+		cv.DrawLine(r.Min, r.Max)
+		cv.DrawLine(Pt(r.Min.X, r.Max.Y), Pt(r.Max.X, r.Min.Y))
 	}
 }
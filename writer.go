@@ -7,20 +7,23 @@ package main
 import (
 	"bytes"
 	"github.com/gordonklaus/flux/go/types"
+	. "github.com/gordonklaus/flux/gui"
 	"fmt"
 	"go/build"
 	"go/parser"
 	"go/token"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 func savePackageName(importPath, name string) {
-	p, _ := build.Import(importPath, "", 0)
+	p, _ := importPkg(importPath, 0)
 	files := append(append(append(p.GoFiles, p.IgnoredGoFiles...), p.CgoFiles...), p.TestGoFiles...)
 	for _, file := range files {
 		path := filepath.Join(p.Dir, file)
@@ -52,9 +55,10 @@ func savePackageName(importPath, name string) {
 		}
 	}
 
-	if pkg, ok := pkgs[p.ImportPath]; ok {
+	if pkg := loadedPackage(p.ImportPath); pkg != nil {
 		pkg.Name = name
 	}
+	packageWritten(p.ImportPath)
 
 	// TODO: update all uses
 }
@@ -68,9 +72,12 @@ func saveType(t *types.Named) {
 
 	u := t.UnderlyingT
 	w.collectPkgs(u)
+	for _, tn := range t.TypeParams {
+		w.collectPkgs(tn.Type.(*types.TypeParam).Constraint)
+	}
 	w.imports()
 
-	w.write("type %s %s", t.Obj.Name, w.typ(u))
+	w.write("type %s%s %s", t.Obj.Name, w.typeParams(t.TypeParams), w.typ(u))
 }
 
 func saveFunc(f *funcNode) {
@@ -79,10 +86,26 @@ func saveFunc(f *funcNode) {
 		return
 	}
 	defer w.close()
+	w.funcFile(f)
+	for _, p := range w.problems {
+		fmt.Printf("error writing %s: %s\n", fluxPath(f.obj), p)
+	}
+}
+
+// writeFunc writes f to src as saveFunc would write it to its file, returning any problems found while writing.
+func writeFunc(src io.Writer, f *funcNode) (problems []string) {
+	w := newWriterTo(nopCloser{src}, f.obj)
+	w.funcFile(f)
+	return w.problems
+}
 
-	for p := range f.pkgRefs {
+func (w *writer) funcFile(f *funcNode) {
+	imports := f.imports()
+	sort.Sort(pkgsByPath(imports))
+	for _, p := range imports {
 		w.pkgNames[p] = w.name(p.Name)
 	}
+	w.numberPorts(f.funcblk)
 
 	// some package names are collected during w.fun, so delay w.imports
 	buf := bytes.Buffer{}
@@ -96,6 +119,34 @@ func saveFunc(f *funcNode) {
 
 	w.imports()
 	w.src.Write(buf.Bytes())
+	w.layout(f)
+}
+
+// layout writes the positions of f's nodes in a trailing "flux:layout" comment, one "x y description" line per node in walkInOrder order.
+// Only the positions of nodes placed by the user or by the arranger are written; the rest are left to the arranger when the func is next opened.
+// Block sizes follow from the positions of their nodes and so aren't written.
+func (w *writer) layout(f *funcNode) {
+	lines := []string{}
+	f.funcblk.walkInOrder(func(n node) {
+		if f.laidOut || n.block().fixed[n] {
+			p := Pos(n)
+			lines = append(lines, fmt.Sprintf("%s %s %s", formatCoord(p.X), formatCoord(p.Y), describeNode(n)))
+		}
+	})
+	if len(lines) == 0 {
+		return
+	}
+	w.write("\n// %s\n", layoutComment)
+	for _, l := range lines {
+		w.write("// %s\n", l)
+	}
+}
+
+const layoutComment = "flux:layout"
+
+// formatCoord formats x rounded to hundredths, so that a position is written the same way after it is read back.
+func formatCoord(x float64) string {
+	return strconv.FormatFloat(math.Floor(x*100+.5)/100, 'f', -1, 64)
 }
 
 type writer struct {
@@ -105,7 +156,9 @@ type writer struct {
 	names    map[string]int
 	seqID    int
 	seqIDs   map[node]int
+	portIDs  map[*port]int
 	nindent  int
+	problems []string // found while writing, such as cyclic blocks
 }
 
 func newWriter(obj types.Object) *writer {
@@ -114,8 +167,24 @@ func newWriter(obj types.Object) *writer {
 		fmt.Printf("error creating %s: %s\n", fluxPath(obj), err)
 		return nil
 	}
-	w := &writer{src, obj.GetPkg(), map[*types.Package]string{}, map[string]int{}, 0, map[node]int{}, 0}
-	fluxObjs[obj] = true
+	setFluxObj(obj, true)
+	return newWriterTo(fluxFile{src, obj.GetPkg().Path}, obj)
+}
+
+// A fluxFile is a Flux file being saved.  Closing it notes that its package was written, so that the package isn't loaded anew.
+type fluxFile struct {
+	*os.File
+	pkgPath string
+}
+
+func (f fluxFile) Close() error {
+	err := f.File.Close()
+	packageWritten(f.pkgPath)
+	return err
+}
+
+func newWriterTo(src io.WriteCloser, obj types.Object) *writer {
+	w := &writer{src, obj.GetPkg(), map[*types.Package]string{}, map[string]int{}, 0, map[node]int{}, map[*port]int{}, 0, nil}
 
 	w.write("// Generated by Flux, not meant for human consumption.  Editing may make it unreadable by Flux.\n\n")
 	w.write("package %s\n\n", w.pkg.Name)
@@ -137,6 +206,10 @@ func (w *writer) close() {
 	w.src.Close()
 }
 
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
 func (w *writer) collectPkgs(t types.Type) {
 	walkType(t, func(n *types.Named) {
 		if p := n.Obj.Pkg; p != nil && p != w.pkg {
@@ -151,13 +224,19 @@ func (w *writer) imports() {
 	if len(w.pkgNames) == 0 {
 		return
 	}
+	pkgs := pkgsByPath{}
+	for p := range w.pkgNames {
+		pkgs = append(pkgs, p)
+	}
+	sort.Sort(pkgs)
 	w.write("import (\n")
-	for p, id := range w.pkgNames {
+	for _, p := range pkgs {
+		id := w.pkgNames[p]
 		w.write("\t")
 		if id != p.Name {
-			w.write(id + " ")
+			w.write("%s ", id)
 		}
-		w.write(strconv.Quote(p.Path) + "\n")
+		w.write("%q\n", p.Path)
 	}
 	w.write(")\n\n")
 }
@@ -175,7 +254,7 @@ func (w *writer) fun(f *funcNode, vars map[*port]string) {
 		obj = f.output.obj
 	}
 
-	params := f.inputsNode.outs
+	params := f.inputsNode.outputs()
 	if isMethod(obj) {
 		p := params[0]
 		params = params[1:]
@@ -183,7 +262,10 @@ func (w *writer) fun(f *funcNode, vars map[*port]string) {
 		vars[p] = name
 		w.write("(%s %s) ", name, w.typ(p.obj.Type))
 	}
-	w.write("%s(", obj.GetName())
+	for _, tn := range f.sig().TypeParams {
+		w.collectPkgs(tn.Type.(*types.TypeParam).Constraint)
+	}
+	w.write("%s%s(", obj.GetName(), w.typeParams(f.sig().TypeParams))
 	for i, p := range params {
 		if i > 0 {
 			w.write(", ")
@@ -198,7 +280,7 @@ func (w *writer) fun(f *funcNode, vars map[*port]string) {
 	}
 	w.write(") (")
 	existing := map[string]string{} // support for connections from outer blocks to func literal results
-	for i, p := range f.outputsNode.ins {
+	for i, p := range f.outputsNode.inputs() {
 		if i > 0 {
 			w.write(", ")
 		}
@@ -220,7 +302,10 @@ func (w *writer) fun(f *funcNode, vars map[*port]string) {
 
 // vars maps inputs to variable names.  additionally, it stores the ouputs corresponding to func args and loops vars for special handling.
 func (w *writer) block(b *block, vars map[*port]string) {
-	order := b.nodeOrder()
+	order, cyclic := b.nodeOrder()
+	if cyclic {
+		w.problems = append(w.problems, fmt.Sprintf("cyclic %s", describeBlock(b)))
+	}
 
 	vars, varsCopy := map[*port]string{}, vars
 	for k, v := range varsCopy {
@@ -229,15 +314,15 @@ func (w *writer) block(b *block, vars map[*port]string) {
 
 	w.nindent++
 
-	for c := range b.conns {
-		if _, ok := vars[c.dst]; ok {
+	for _, c := range w.conns(b) {
+		if _, ok := vars[c.dst()]; ok {
 			continue
 		}
-		if t := c.dst.obj.Type; t != seqType {
+		if t := c.dst().obj.Type; t != seqType {
 			w.collectPkgs(t)
 			name := w.name("v")
 			w.indent("var %s %s\n", name, w.typ(t))
-			vars[c.dst] = name
+			vars[c.dst()] = name
 		}
 	}
 	for _, n := range order {
@@ -272,6 +357,22 @@ func (w *writer) block(b *block, vars map[*port]string) {
 			}
 			results, existing := w.results(n, vars)
 			switch n := n.(type) {
+			case *structUnpackNode:
+				if len(args) > 0 && len(results) > 0 {
+					lhs, rhs := []string{}, []string{}
+					for i, r := range results {
+						if r != "_" {
+							lhs = append(lhs, r)
+							rhs = append(rhs, fmt.Sprintf("(%s).%s", args[0], n.fields[i].Obj.GetName())) // parenthesize for easy recognition in reader
+						}
+					}
+					w.indent("%s := %s", strings.Join(lhs, ", "), strings.Join(rhs, ", "))
+					w.seq(n)
+				} else if len(args) > 0 && len(n.fields) > 0 && (len(seqIn(n).conns()) > 0 || len(seqOut(n).conns()) > 0) {
+					// no field is used, but the unpack is sequenced, so keep it by reading one field into the blank identifier
+					w.indent("_ = (%s).%s", args[0], n.fields[0].Obj.GetName())
+					w.seq(n)
+				}
 			case *appendNode:
 				if len(args) > 0 && len(results) > 0 {
 					if n.ellipsis() {
@@ -310,7 +411,7 @@ func (w *writer) block(b *block, vars map[*port]string) {
 					}
 					w.indent("")
 					if len(results) > 0 {
-						w.write(strings.Join(results, ", ") + " := ")
+						w.write("%s := ", strings.Join(results, ", "))
 					}
 					w.write("%s%s(%s)", n.godefer, f, strings.Join(args, ", "))
 					w.seq(n)
@@ -322,7 +423,7 @@ func (w *writer) block(b *block, vars map[*port]string) {
 					} else {
 						w.indent("")
 						if len(results) > 0 {
-							w.write(strings.Join(results, ", ") + " := ")
+							w.write("%s := ", strings.Join(results, ", "))
 						}
 						w.write("<-%s", args[0])
 					}
@@ -388,7 +489,7 @@ func (w *writer) block(b *block, vars map[*port]string) {
 			case *operatorNode:
 				c := 0
 				for _, p := range ins {
-					c += len(p.conns)
+					c += len(p.conns())
 				}
 				if c > 0 && len(results) > 0 {
 					// TODO: handle constant expressions
@@ -465,6 +566,31 @@ func (w *writer) block(b *block, vars map[*port]string) {
 		case *branchNode:
 			w.indent(n.text.Text())
 			w.seq(n)
+		case *returnNode:
+			// an unconnected input returns its result variable, which is omitted if all are unconnected
+			results, any := []string{}, false
+			for i, p := range enclosingFunc(n.block()).outputsNode.inputs() {
+				name := vars[p]
+				if i+1 < len(n.inputs()) {
+					if v, ok := vars[n.inputs()[i+1]]; ok {
+						name, any = v, true
+					}
+				}
+				results = append(results, name)
+			}
+			if any {
+				w.indent("return %s", strings.Join(results, ", "))
+			} else {
+				w.indent("return")
+			}
+			w.seq(n)
+		case *structPackNode:
+			results, existing := w.results(n, vars)
+			if len(results) > 0 {
+				w.indent("%s := (%s)", results[0], w.structLit(*n.typ.typ, n, nil, vars)) // parenthesize for easy recognition in reader
+				w.seq(n)
+				w.assignExisting(existing)
+			}
 		case *compositeLiteralNode:
 			results, existing := w.results(n, vars)
 			if len(results) > 0 {
@@ -476,7 +602,7 @@ func (w *writer) block(b *block, vars map[*port]string) {
 				w.write("%s{", w.typ(t))
 				first := true
 				for _, in := range ins(n) {
-					if len(in.conns) > 0 {
+					if len(in.conns()) > 0 {
 						if !first {
 							w.write(", ")
 						}
@@ -495,10 +621,10 @@ func (w *writer) block(b *block, vars map[*port]string) {
 					w.write(" else ")
 				}
 				cond := n.cond[i]
-				if i == 0 || i < len(n.blocks)-1 || len(cond.conns) > 0 {
+				if i == 0 || i < len(n.blocks)-1 || len(cond.conns()) > 0 {
 					w.write("if ")
-					if len(cond.conns) > 0 {
-						w.write(vars[cond])
+					if len(cond.conns()) > 0 {
+						w.write("%s", vars[cond])
 					} else {
 						w.write("false")
 					}
@@ -511,11 +637,11 @@ func (w *writer) block(b *block, vars map[*port]string) {
 		case *loopNode:
 			w.indent("for ")
 			key, val := "_", "_"
-			kv := n.inputsNode.outs
-			if len(kv[0].conns) > 0 {
+			kv := n.inputsNode.outputs()
+			if len(kv[0].conns()) > 0 {
 				key = w.name("k")
 			}
-			if len(kv) == 2 && len(kv[1].conns) > 0 {
+			if len(kv) == 2 && len(kv[1].conns()) > 0 {
 				val = w.name("v")
 			}
 			switch t := underlying(n.input.obj.Type).(type) {
@@ -529,7 +655,7 @@ func (w *writer) block(b *block, vars map[*port]string) {
 				if val != "_" && key == "_" {
 					key = w.name("i")
 				}
-				w.write(key)
+				w.write("%s", key)
 				if key == "_" {
 					w.write(" =")
 				} else {
@@ -544,9 +670,9 @@ func (w *writer) block(b *block, vars map[*port]string) {
 					w.indent("\tvar %s = %s%s[%s]\n", val, amp, vars[n.input], key)
 				}
 			case *types.Map, *types.Chan:
-				w.write(key)
+				w.write("%s", key)
 				if val != "_" {
-					w.write(", " + val)
+					w.write(", %s", val)
 				}
 				if key == "_" && val == "_" {
 					w.write(" =")
@@ -592,13 +718,13 @@ func (w *writer) block(b *block, vars map[*port]string) {
 					if c.send {
 						w.write("%s <- %s", ch, vars[c.elem])
 					} else {
-						elemOk := c.elemOk.outs
+						elemOk := c.elemOk.outputs()
 						elem, ok := "_", "_"
-						if len(elemOk[0].conns) > 0 {
+						if len(elemOk[0].conns()) > 0 {
 							elem = w.name("v")
 							vars[elemOk[0]] = elem
 						}
-						if len(elemOk[1].conns) > 0 {
+						if len(elemOk[1].conns()) > 0 {
 							ok = w.name("ok")
 							vars[elemOk[1]] = ok
 						}
@@ -613,33 +739,132 @@ func (w *writer) block(b *block, vars map[*port]string) {
 			}
 			w.indent("}")
 			w.seq(n)
+		case *switchNode:
+			if tag := vars[n.tag]; tag != "" {
+				w.indent("switch %s {\n", tag)
+			} else {
+				w.indent("switch {\n")
+			}
+			for _, c := range n.cases {
+				if len(c.vals) == 0 {
+					w.indent("default:\n")
+				} else {
+					vals := []string{}
+					for _, v := range c.vals {
+						if name := vars[v]; name != "" {
+							vals = append(vals, name)
+						}
+					}
+					if len(vals) == 0 {
+						// a case whose values are all unconnected can never be chosen
+						continue
+					}
+					w.indent("case %s:\n", strings.Join(vals, ", "))
+				}
+				w.block(c.blk, vars)
+			}
+			w.indent("}")
+			w.seq(n)
+		case *typeSwitchNode:
+			x := vars[n.x]
+			if x == "" {
+				x = w.name("v")
+				w.indent("var %s interface{}\n", x)
+			}
+			val := ""
+			for _, c := range n.cases {
+				if c.val != nil && len(c.val.outputs()[0].conns()) > 0 {
+					val = w.name("v")
+					break
+				}
+			}
+			if val != "" {
+				w.indent("switch %s := %s.(type) {\n", val, x)
+			} else {
+				w.indent("switch %s.(type) {\n", x)
+			}
+			for _, c := range n.cases {
+				if c.typ == nil {
+					w.indent("default:\n")
+				} else {
+					t := *c.typ.typ
+					w.collectPkgs(t)
+					w.indent("case %s:\n", w.typ(t))
+					if out := c.val.outputs()[0]; len(out.conns()) > 0 {
+						vars[out] = val
+					}
+				}
+				w.block(c.blk, vars)
+			}
+			w.indent("}")
+			w.seq(n)
 		}
 	}
 
 	w.nindent--
 }
 
+// numberPorts numbers the ports in b and its nested blocks in the order in which their nodes are written.
+func (w *writer) numberPorts(b *block) {
+	b.walkInOrder(func(n node) {
+		for _, p := range append(n.inputs(), n.outputs()...) {
+			w.portIDs[p] = len(w.portIDs)
+		}
+	})
+}
+
+// conns returns the connections in b ordered by their destination and source ports.
+func (w *writer) conns(b *block) []*connection {
+	c := connsByPort{ids: w.portIDs}
+	for _, m := range b.model.Conns {
+		c.conns = append(c.conns, m.Data.(*connection))
+	}
+	sort.Sort(c)
+	return c.conns
+}
+
+type connsByPort struct {
+	conns []*connection
+	ids   map[*port]int
+}
+
+func (c connsByPort) Len() int { return len(c.conns) }
+func (c connsByPort) Less(i, j int) bool {
+	c1, c2 := c.conns[i], c.conns[j]
+	if c1.dst() != c2.dst() {
+		return c.ids[c1.dst()] < c.ids[c2.dst()]
+	}
+	return c.ids[c1.src()] < c.ids[c2.src()]
+}
+func (c connsByPort) Swap(i, j int) { c.conns[i], c.conns[j] = c.conns[j], c.conns[i] }
+
+type pkgsByPath []*types.Package
+
+func (p pkgsByPath) Len() int           { return len(p) }
+func (p pkgsByPath) Less(i, j int) bool { return p[i].Path < p[j].Path }
+func (p pkgsByPath) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
 func (w *writer) results(n node, vars map[*port]string) (results []string, existing map[string]string) {
 	existing = map[string]string{}
 	any := false
 	for _, p := range outs(n) {
 		name := "_"
-		if len(p.conns) > 0 {
+		if len(p.conns()) > 0 {
 			any = true
 			if n, ok := vars[p]; ok { // inputsNodes' outputs are already named (func args, loops vars)
 				name = n
 			} else {
 				name = w.name(p.obj.GetName())
 			}
-			for _, c := range p.conns {
+			for _, c := range p.conns() {
 				v := name
-				if !assignable(c.src.obj.Type, c.dst.obj.Type) {
+				if !assignable(c.src().obj.Type, c.dst().obj.Type) {
 					v = "*" + v
 				}
 				if c.hidden {
-					v += "//" + c.src.conntxt.Text()
+					v += "//" + c.src().conntxt.Text()
 				}
-				existing[vars[c.dst]] = v
+				existing[vars[c.dst()]] = v
 			}
 		}
 		results = append(results, name)
@@ -650,18 +875,52 @@ func (w *writer) results(n node, vars map[*port]string) (results []string, exist
 	return
 }
 
+// structLit returns a composite literal of the struct type t, or a pointer to one, setting the fields of n below the path prefix from their connected inputs.  A promoted field is set through a nested literal of its embedded struct.
+func (w *writer) structLit(t types.Type, n *structPackNode, prefix []int, vars map[*port]string) string {
+	w.collectPkgs(t)
+	s := ""
+	t, isPtr := indirect(t)
+	if isPtr {
+		s = "&"
+	}
+	s += w.typ(t) + "{"
+	first := true
+	for i, f := range underlying(t).(*types.Struct).Fields {
+		index := append(prefix[:len(prefix):len(prefix)], i)
+		val := ""
+		if p := n.field(index); p != nil && len(p.conns()) > 0 {
+			val = vars[p]
+		} else if n.setBelow(index) {
+			val = w.structLit(f.Type, n, index, vars)
+		} else {
+			continue
+		}
+		if !first {
+			s += ", "
+		}
+		first = false
+		s += f.Name + ": " + val
+	}
+	return s + "}"
+}
+
 func (w *writer) seq(n node) {
 	seqIn, seqOut := seqIn(n), seqOut(n)
-	in := seqIn != nil && len(seqIn.conns) > 0
-	out := seqOut != nil && len(seqOut.conns) > 0
+	in := seqIn != nil && len(seqIn.conns()) > 0
+	out := seqOut != nil && len(seqOut.conns()) > 0
 	if in || out {
 		w.write("//")
 		if in {
-			for i, c := range seqIn.conns {
+			ids := []int{}
+			for _, c := range seqIn.conns() {
+				ids = append(ids, w.seqIDs[c.src().node])
+			}
+			sort.Ints(ids)
+			for i, id := range ids {
 				if i > 0 {
 					w.write(",")
 				}
-				w.write(strconv.Itoa(w.seqIDs[c.src.node]))
+				w.write("%d", id)
 			}
 		}
 		w.write(";")
@@ -669,15 +928,20 @@ func (w *writer) seq(n node) {
 			seqID := w.seqID
 			w.seqID++
 			w.seqIDs[n] = seqID
-			w.write(strconv.Itoa(seqID))
+			w.write("%d", seqID)
 		}
 	}
 	w.write("\n")
 }
 
 func (w *writer) assignExisting(m map[string]string) {
-	for v1, v2 := range m {
-		w.indent("%s = %s\n", v1, v2)
+	vars := []string{}
+	for v := range m {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+	for _, v := range vars {
+		w.indent("%s = %s\n", v, m[v])
 	}
 }
 
@@ -706,7 +970,15 @@ func (w writer) typ(t types.Type) string {
 	case *types.Basic:
 		return t.Name
 	case *types.Named:
-		return w.qualifiedName(t.Obj)
+		args := t.TypeArgs
+		if t.Orig == nil {
+			for _, tn := range t.TypeParams { // a generic type within its own declaration or methods stands for its own instance
+				args = append(args, tn.Type)
+			}
+		}
+		return w.qualifiedName(t.Obj) + w.typeArgs(args)
+	case *types.TypeParam:
+		return t.Obj.Name
 	case *types.Pointer:
 		return "*" + w.typ(t.Elem)
 	case *types.Array:
@@ -736,6 +1008,12 @@ func (w writer) typ(t types.Type) string {
 			}
 			s += m.Name + w.signature(m.Type.(*types.Signature))
 		}
+		if t.Terms != nil {
+			if len(t.Methods) > 0 {
+				s += "; "
+			}
+			s += w.terms(t.Terms)
+		}
 		return s + "}"
 	case *types.Struct:
 		s := "struct{"
@@ -753,6 +1031,54 @@ func (w writer) typ(t types.Type) string {
 	panic("unreachable")
 }
 
+// typeParams returns the type parameter list for tparams, or "" if there are none.
+func (w writer) typeParams(tparams []*types.TypeName) string {
+	if len(tparams) == 0 {
+		return ""
+	}
+	s := []string{}
+	for _, tn := range tparams {
+		c := "any"
+		switch t := tn.Type.(*types.TypeParam).Constraint.(type) {
+		case nil:
+		case *types.Interface:
+			if len(t.Methods) == 0 && len(t.Embeddeds) == 0 && t.Terms != nil {
+				c = w.terms(t.Terms)
+			} else if !t.Empty() {
+				c = w.typ(t)
+			}
+		default:
+			c = w.typ(t)
+		}
+		s = append(s, tn.Name+" "+c)
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+// typeArgs returns the type argument list for targs, or "" if there are none.
+func (w writer) typeArgs(targs []types.Type) string {
+	if len(targs) == 0 {
+		return ""
+	}
+	s := []string{}
+	for _, t := range targs {
+		s = append(s, w.typ(t))
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+func (w writer) terms(terms []*types.Term) string {
+	s := []string{}
+	for _, t := range terms {
+		if t.Tilde {
+			s = append(s, "~"+w.typ(t.Type))
+		} else {
+			s = append(s, w.typ(t.Type))
+		}
+	}
+	return strings.Join(s, " | ")
+}
+
 func (w writer) signature(f *types.Signature) string {
 	s := w.vars(f.Params, f.IsVariadic)
 	if len(f.Results) > 0 {
@@ -789,6 +1115,9 @@ func walkType(t types.Type, op func(*types.Named)) {
 	switch t := t.(type) {
 	case *types.Named:
 		op(t)
+		for _, t := range t.TypeArgs {
+			walkType(t, op)
+		}
 	case *types.Pointer:
 		walkType(t.Elem, op)
 	case *types.Array:
@@ -808,6 +1137,9 @@ func walkType(t types.Type, op func(*types.Named)) {
 		for _, m := range t.Methods {
 			walkType(m.Type, op)
 		}
+		for _, t := range t.Terms {
+			walkType(t.Type, op)
+		}
 	case *types.Struct:
 		for _, v := range t.Fields {
 			walkType(v.Type, op)
@@ -816,9 +1148,9 @@ func walkType(t types.Type, op func(*types.Named)) {
 }
 
 func fluxPath(obj types.Object) string {
-	pkg, err := build.Import(obj.GetPkg().Path, "", build.FindOnly)
+	pkg, err := importPkg(obj.GetPkg().Path, build.FindOnly)
 	if err != nil {
-		fmt.Println("error importing \"%s\": %s\n", obj.GetPkg().Path, err)
+		fmt.Printf("error importing \"%s\": %s\n", obj.GetPkg().Path, err)
 		return ""
 	}
 
@@ -0,0 +1,135 @@
+// Copyright 2014 Gordon Klaus. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	. "github.com/gordonklaus/flux/gui"
+	"math"
+)
+
+const (
+	minZoom, maxZoom = .05, 4.0
+	labelZoom        = .6 // below this zoom, port labels and connection names are not painted
+	minimapSize      = 200
+	minimapMargin    = 16
+)
+
+// zoom scales the func shown in p by factor, keeping pt (in p's coordinates) in place.
+func (p *pane) zoom(factor float64, pt Point) {
+	f := p.minimap.f
+	if f == nil {
+		return
+	}
+	s := math.Max(minZoom, math.Min(maxZoom, Scale(f)*factor))
+	q := MapFromParent(pt, f)
+	SetScale(f, s)
+	f.Move(pt.Sub(q.Sub(Rect(f).Min).Mul(s)))
+}
+
+// panCenter pans p so that pt (in p's coordinates) is at its center, interrupting any animated panning.
+func (p *pane) panCenter(pt Point) {
+	select {
+	case p.pause <- true:
+	default:
+	}
+	Pan(p, pt.Sub(Size(p).Div(2)))
+}
+
+// A minimap shows the whole of the func being edited, however it is zoomed or panned, along with the part of it that is visible in its pane and the focused node.  Clicking or dragging in it pans the pane.
+type minimap struct {
+	*ViewBase
+	p *pane
+	f *funcNode
+}
+
+func newMinimap(p *pane) *minimap {
+	m := &minimap{p: p}
+	m.ViewBase = NewView(m)
+	m.SetRect(Rectangle{ZP, Pt(minimapSize, minimapSize)})
+	Hide(m)
+	return m
+}
+
+// setFunc shows f in m, or hides m if f is nil.
+func (m *minimap) setFunc(f *funcNode) {
+	m.f = f
+	if f == nil {
+		Hide(m)
+	} else {
+		Show(m)
+		Raise(m)
+	}
+}
+
+// scale returns the scale at which m draws its func and the offset of the func's rect within m.
+func (m *minimap) scale() (float64, Point) {
+	size := Size(m.f)
+	inner := float64(minimapSize - minimapMargin)
+	k := math.Min(1, math.Min(inner/math.Max(1, size.X), inner/math.Max(1, size.Y)))
+	return k, Size(m).Sub(size.Mul(k)).Div(2)
+}
+
+// toFunc maps p in m's coordinates to the func's coordinates.
+func (m *minimap) toFunc(p Point) Point {
+	k, off := m.scale()
+	return p.Sub(off).Div(k).Add(Rect(m.f).Min)
+}
+
+// fromFunc maps r in the func's coordinates to m's coordinates.
+func (m *minimap) fromFunc(r Rectangle) Rectangle {
+	k, off := m.scale()
+	return r.Sub(Rect(m.f).Min).Mul(k).Add(off)
+}
+
+func (m *minimap) Mouse(e MouseEvent) {
+	if m.f != nil && (e.Press || e.Drag) {
+		m.p.panCenter(Map(m.toFunc(e.Pos), m.f, m.p))
+	}
+}
+
+func (m *minimap) Paint(cv Canvas) {
+	cv.SetColor(panelColor)
+	cv.FillRect(Rect(m))
+	cv.SetColor(lineColor)
+	cv.SetLineWidth(1)
+	cv.DrawRect(Rect(m))
+	if m.f == nil {
+		return
+	}
+
+	k, off := m.scale()
+	cv.Push()
+	cv.Clip(Rect(m))
+	cv.Translate(off)
+	cv.Scale(k / Scale(m.f))
+	PaintTo(m.f, cv)
+	cv.Pop()
+
+	cv.Push()
+	cv.Clip(Rect(m))
+	if n := focusedNode(m.f); n != nil {
+		cv.SetColor(focusColor)
+		cv.FillRect(m.fromFunc(rectIn(n, m.f)).Inset(-1))
+	}
+	cv.SetColor(viewportColor)
+	cv.DrawRect(m.fromFunc(rectIn(m.p, m.f)))
+	cv.Pop()
+}
+
+// focusedNode returns the innermost node within f containing the key focus, or nil if there is none.
+func focusedNode(f *funcNode) node {
+	for v := KeyFocus(f); v != nil && v != View(f); v = Parent(v) {
+		if n, ok := v.(node); ok {
+			return n
+		}
+	}
+	return nil
+}
+
+// rectIn returns the rect of v in the coordinates of u.
+func rectIn(v, u View) Rectangle {
+	r := Rect(v)
+	return Rectangle{Map(r.Min, v, u), Map(r.Max, v, u)}
+}